@@ -7,7 +7,6 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"sigs.k8s.io/yaml"
 
 	"github.com/sirupsen/logrus"
 
@@ -43,6 +42,15 @@ type Config struct {
 	Components map[string]ComponentAttributes `json:"components" yaml:"components"`
 	// ResourcePatches contains json patches for kubernetes resources to be applied on certain phases of the setup process.
 	ResourcePatches []patch.ResourcePatch `json:"resource_patches" yaml:"resource_patches"`
+	// ResourceManifests contains kubernetes resources to be created or updated on certain phases of the setup process.
+	// +optional
+	ResourceManifests []patch.ResourceManifest `json:"resource_manifests,omitempty" yaml:"resource_manifests,omitempty"`
+	// Pipeline disables or reorders built-in stages of the setup and adds custom steps at hook points.
+	// +optional
+	Pipeline Pipeline `json:"pipeline,omitempty" yaml:"pipeline,omitempty"`
+	// Preflight configures the checks of the cluster which are performed before the setup creates any resource.
+	// +optional
+	Preflight Preflight `json:"preflight,omitempty" yaml:"preflight,omitempty"`
 }
 
 // ReadConfigFromCluster reads the setup config from the cluster state
@@ -54,7 +62,7 @@ func ReadConfigFromCluster(ctx context.Context, client kubernetes.Interface, nam
 
 	config := &Config{}
 	stringData := configMap.Data["k8s-ces-setup.yaml"]
-	err = yaml.Unmarshal([]byte(stringData), config)
+	err = unmarshalYamlStrict([]byte(stringData), config, LegacyConfigKeys)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal configuration from configmap: %w", err)
 	}
@@ -74,7 +82,7 @@ func ReadConfigFromFile(path string) (*Config, error) {
 		return config, fmt.Errorf("failed to read configuration %s: %w", path, err)
 	}
 
-	err = yaml.Unmarshal(data, config)
+	err = unmarshalYamlStrict(data, config, LegacyConfigKeys)
 	if err != nil {
 		return config, fmt.Errorf("failed to unmarshal configuration %s: %w", path, err)
 	}
@@ -48,7 +48,7 @@ func TestReadConfig(t *testing.T) {
 
 		// then
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "error unmarshaling")
+		assert.Contains(t, err.Error(), "failed to unmarshal configuration testdata/invalidConfig.yaml")
 	})
 }
 
@@ -93,4 +93,110 @@ func TestReadConfigFromCluster(t *testing.T) {
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "failed to unmarshal configuration from configmap")
 	})
+	t.Run("should fail for unknown keys", func(t *testing.T) {
+		// given
+		myFileMap := map[string]string{"k8s-ces-setup.yaml": "component_operator_chart: k8s/k8s-component-operator:1.0.0\n" +
+			"components:\n  k8s-longhorn:\n    version: 1.0.0\n    helmRepositoryNamspace: k8s\n" +
+			"preflight:\n  warnOnly: true\n  WarnOnly: false\n"}
+		mockedConfig := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SetupConfigConfigmap,
+				Namespace: testNamespace,
+			},
+			Data: myFileMap,
+		}
+		client := fake.NewSimpleClientset(mockedConfig)
+
+		// when
+		_, err := ReadConfigFromCluster(testCtx, client, testNamespace)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to unmarshal configuration from configmap")
+		assert.ErrorContains(t, err, `unknown field "components.k8s-longhorn.helmRepositoryNamspace"`)
+		assert.ErrorContains(t, err, `unknown field "preflight.WarnOnly"`)
+	})
+	t.Run("should ignore deprecated keys", func(t *testing.T) {
+		// given
+		myFileMap := map[string]string{"k8s-ces-setup.yaml": "component_operator_chart: k8s/k8s-component-operator:1.0.0\n" +
+			"dogu_operator_url: https://dogu-operator.yaml\n" +
+			"helm_registry_secret:\n  host: https://registry.cloudogu.com\n"}
+		mockedConfig := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SetupConfigConfigmap,
+				Namespace: testNamespace,
+			},
+			Data: myFileMap,
+		}
+		client := fake.NewSimpleClientset(mockedConfig)
+
+		// when
+		config, err := ReadConfigFromCluster(testCtx, client, testNamespace)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "k8s/k8s-component-operator:1.0.0", config.ComponentOperatorChart)
+	})
+	t.Run("should keep an explicit null value of a json patch", func(t *testing.T) {
+		// given
+		myFileMap := map[string]string{"k8s-ces-setup.yaml": "resource_patches:\n" +
+			"  - phase: loadbalancer\n    resource:\n      apiVersion: v1\n      kind: Service\n      name: ces-loadbalancer\n" +
+			"    patches:\n      - op: test\n        path: /spec/loadBalancerIP\n        value: null\n"}
+		mockedConfig := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SetupConfigConfigmap,
+				Namespace: testNamespace,
+			},
+			Data: myFileMap,
+		}
+		client := fake.NewSimpleClientset(mockedConfig)
+
+		// when
+		config, err := ReadConfigFromCluster(testCtx, client, testNamespace)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, config.ResourcePatches, 1)
+		assert.NoError(t, config.ResourcePatches[0].Validate())
+	})
+	t.Run("should fail for unknown keys of a json patch", func(t *testing.T) {
+		// given
+		myFileMap := map[string]string{"k8s-ces-setup.yaml": "resource_patches:\n" +
+			"  - phase: loadbalancer\n    resource:\n      apiVersion: v1\n      kind: Service\n      name: ces-loadbalancer\n" +
+			"    patches:\n      - op: test\n        path: /spec/type\n        vaule: LoadBalancer\n"}
+		mockedConfig := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SetupConfigConfigmap,
+				Namespace: testNamespace,
+			},
+			Data: myFileMap,
+		}
+		client := fake.NewSimpleClientset(mockedConfig)
+
+		// when
+		_, err := ReadConfigFromCluster(testCtx, client, testNamespace)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, `invalid json patch: unknown field "vaule"`)
+	})
+	t.Run("should fail for duplicate yaml keys", func(t *testing.T) {
+		// given
+		myFileMap := map[string]string{"k8s-ces-setup.yaml": "log_level: INFO\nlog_level: DEBUG\n"}
+		mockedConfig := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SetupConfigConfigmap,
+				Namespace: testNamespace,
+			},
+			Data: myFileMap,
+		}
+		client := fake.NewSimpleClientset(mockedConfig)
+
+		// when
+		_, err := ReadConfigFromCluster(testCtx, client, testNamespace)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, `key "log_level" already set in map`)
+	})
 }
@@ -0,0 +1,68 @@
+package context
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	sigsjson "sigs.k8s.io/json"
+	"sigs.k8s.io/yaml"
+)
+
+// LegacySetupJsonKeys contains keys of the setup.json which former versions accepted but which are not evaluated
+// anymore. They are ignored with a deprecation warning instead of being rejected so that existing setup.json files keep
+// working. The JSON schemas describe them as deprecated properties.
+var LegacySetupJsonKeys = []string{"token", "naming.hostname"}
+
+// LegacyConfigKeys contains keys of the k8s-ces-setup.yaml which former versions accepted or documented but which are
+// not evaluated anymore. They are ignored with a deprecation warning like LegacySetupJsonKeys.
+var LegacyConfigKeys = []string{
+	"dogu_operator_url",
+	"service_discovery_url",
+	"docker_registry_secret",
+	"dogu_registry_secret",
+	"helm_registry_secret",
+}
+
+// unmarshalJsonStrict decodes the JSON data into v. Keys must match the json tags case-sensitively. Unknown and
+// duplicate keys are rejected with the path to the offending key, f. i. `unknown field "naming.certficateType"`.
+// Unknown keys contained in legacyKeys are only logged as deprecated.
+func unmarshalJsonStrict(data []byte, v any, legacyKeys []string) error {
+	strictErrs, err := sigsjson.UnmarshalStrict(data, v)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, strictErr := range strictErrs {
+		if key, ok := legacyKey(strictErr, legacyKeys); ok {
+			logrus.Warnf("Ignoring deprecated key %q which is not evaluated anymore; remove it because it will be rejected in a future version", key)
+			continue
+		}
+		errs = append(errs, strictErr)
+	}
+
+	return errors.Join(errs...)
+}
+
+// unmarshalYamlStrict decodes the YAML data into v like unmarshalJsonStrict.
+func unmarshalYamlStrict(data []byte, v any, legacyKeys []string) error {
+	jsonData, err := yaml.YAMLToJSONStrict(data)
+	if err != nil {
+		return err
+	}
+
+	return unmarshalJsonStrict(jsonData, v, legacyKeys)
+}
+
+// legacyKey returns the path of the key if the strict decoding error reports an unknown key which is contained in
+// legacyKeys.
+func legacyKey(strictErr error, legacyKeys []string) (string, bool) {
+	var fieldErr sigsjson.FieldError
+	if !errors.As(strictErr, &fieldErr) || !strings.HasPrefix(strictErr.Error(), "unknown field") {
+		return "", false
+	}
+
+	return fieldErr.FieldPath(), slices.Contains(legacyKeys, fieldErr.FieldPath())
+}
@@ -0,0 +1,294 @@
+package context
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+)
+
+// PipelineStage is a group of built-in setup steps which can be disabled or reordered as a whole.
+type PipelineStage string
+
+const (
+	// PreflightStage checks that the cluster is able to host the ecosystem before any resource is created. It is always
+	// performed first and cannot be reordered.
+	PreflightStage PipelineStage = "preflight"
+	// DefaultSAAutomountStage disables the automount of the default service account token in the ecosystem namespace.
+	DefaultSAAutomountStage PipelineStage = "default-sa-automount"
+	// LoadBalancerStage creates the main loadbalancer service and retrieves the FQDN from its IP if necessary.
+	LoadBalancerStage PipelineStage = "loadbalancer"
+	// SSLGenerationStage generates a self-signed certificate if the certificate type is "selfsigned".
+	SSLGenerationStage PipelineStage = "ssl-generation"
+	// ValidationStage validates the setup configuration.
+	ValidationStage PipelineStage = "validation"
+	// DataStage writes the registry configuration and the certificate of the ecosystem.
+	DataStage PipelineStage = "data"
+	// ComponentStage installs the component operator and all configured components.
+	ComponentStage PipelineStage = "components"
+	// DoguStage installs all configured dogus.
+	DoguStage PipelineStage = "dogus"
+)
+
+// DefaultPipelineStages contains all built-in stages in the order they are performed by default.
+var DefaultPipelineStages = []PipelineStage{
+	PreflightStage,
+	DefaultSAAutomountStage,
+	LoadBalancerStage,
+	SSLGenerationStage,
+	ValidationStage,
+	DataStage,
+	ComponentStage,
+	DoguStage,
+}
+
+// Pipeline customizes the steps of the setup. The zero value performs all built-in stages in their default order.
+type Pipeline struct {
+	// Disable contains the built-in stages which are not performed.
+	// +optional
+	Disable []PipelineStage `json:"disable,omitempty" yaml:"disable,omitempty"`
+	// Order contains built-in stages in the order they should be performed. Stages which are not listed are performed
+	// afterward in their default order. The PreflightStage is always performed first and must not be listed.
+	// +optional
+	Order []PipelineStage `json:"order,omitempty" yaml:"order,omitempty"`
+	// Hooks contains custom steps which are performed before or after a built-in stage.
+	// +optional
+	Hooks []PipelineHook `json:"hooks,omitempty" yaml:"hooks,omitempty"`
+}
+
+// PipelineHook is a custom step which is performed before or after a built-in stage. Hooks of a disabled stage are
+// still performed at the position of the stage. Hooks at the same position are performed in the order of their
+// definition.
+type PipelineHook struct {
+	// Name uniquely identifies the hook. It is used to record the progress of the setup.
+	Name string `json:"name" yaml:"name"`
+	// Before contains the stage the hook is performed before. Either Before or After must be set.
+	// +optional
+	Before PipelineStage `json:"before,omitempty" yaml:"before,omitempty"`
+	// After contains the stage the hook is performed after. Either Before or After must be set.
+	// +optional
+	After PipelineStage `json:"after,omitempty" yaml:"after,omitempty"`
+	// Patch patches a kubernetes resource. Exactly one of Patch, Wait or Manifest must be set.
+	// +optional
+	Patch *PatchHook `json:"patch,omitempty" yaml:"patch,omitempty"`
+	// Wait waits for a component or dogu to be ready. Exactly one of Patch, Wait or Manifest must be set.
+	// +optional
+	Wait *WaitHook `json:"wait,omitempty" yaml:"wait,omitempty"`
+	// Manifest applies kubernetes resources with server-side apply. Exactly one of Patch, Wait or Manifest must be set.
+	// +optional
+	Manifest *ManifestHook `json:"manifest,omitempty" yaml:"manifest,omitempty"`
+}
+
+// PatchHook contains json patches for kubernetes resources.
+type PatchHook struct {
+	// Resource identifies the kubernetes resources that should be patched.
+	Resource patch.ResourceReference `json:"resource" yaml:"resource"`
+	// Patches contains a series of operations to be applied on the specified kubernetes resource.
+	Patches []patch.JsonPatch `json:"patches" yaml:"patches"`
+}
+
+// WaitHook waits for either a component or a dogu to be ready.
+type WaitHook struct {
+	// Component contains the name of the component to wait for.
+	// +optional
+	Component string `json:"component,omitempty" yaml:"component,omitempty"`
+	// Dogu contains the simple name of the dogu to wait for.
+	// +optional
+	Dogu string `json:"dogu,omitempty" yaml:"dogu,omitempty"`
+	// TimeoutSeconds overrides the default timeout of the wait.
+	// +optional
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty" yaml:"timeoutSeconds,omitempty"`
+}
+
+// ManifestHook contains kubernetes resources either inline or referenced by an URL.
+type ManifestHook struct {
+	// Manifest contains one or more YAML or JSON documents of kubernetes resources. Either Manifest or URL must be set.
+	// +optional
+	Manifest string `json:"manifest,omitempty" yaml:"manifest,omitempty"`
+	// URL references a file with one or more YAML or JSON documents of kubernetes resources. Either Manifest or URL
+	// must be set.
+	// +optional
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+// Stages returns all built-in stages in the order they should be performed. Disabled stages are included so that their
+// hooks can be performed at their position. The PreflightStage always comes first so that the cluster is checked before
+// any resource is created.
+func (p Pipeline) Stages() []PipelineStage {
+	stages := []PipelineStage{PreflightStage}
+	for _, stage := range p.Order {
+		if stage != PreflightStage {
+			stages = append(stages, stage)
+		}
+	}
+	for _, stage := range DefaultPipelineStages {
+		if !slices.Contains(stages, stage) {
+			stages = append(stages, stage)
+		}
+	}
+
+	return stages
+}
+
+// IsDisabled checks whether the given built-in stage should not be performed.
+func (p Pipeline) IsDisabled(stage PipelineStage) bool {
+	return slices.Contains(p.Disable, stage)
+}
+
+// HooksBefore returns the hooks which are performed before the given stage.
+func (p Pipeline) HooksBefore(stage PipelineStage) []PipelineHook {
+	var hooks []PipelineHook
+	for _, hook := range p.Hooks {
+		if hook.Before == stage {
+			hooks = append(hooks, hook)
+		}
+	}
+
+	return hooks
+}
+
+// HooksAfter returns the hooks which are performed after the given stage.
+func (p Pipeline) HooksAfter(stage PipelineStage) []PipelineHook {
+	var hooks []PipelineHook
+	for _, hook := range p.Hooks {
+		if hook.After == stage {
+			hooks = append(hooks, hook)
+		}
+	}
+
+	return hooks
+}
+
+// Validate checks the pipeline for unknown stages, duplicate entries and misconfigured hooks.
+func (p Pipeline) Validate() error {
+	var errs []error
+
+	for _, stage := range p.Disable {
+		if !existsPipelineStage(stage) {
+			errs = append(errs, fmt.Errorf("disabled stage '%s' does not exist", stage))
+		}
+	}
+
+	for i, stage := range p.Order {
+		if !existsPipelineStage(stage) {
+			errs = append(errs, fmt.Errorf("ordered stage '%s' does not exist", stage))
+		}
+		if stage == PreflightStage {
+			errs = append(errs, fmt.Errorf("stage '%s' is always performed first and must not be ordered", stage))
+		}
+		if slices.Contains(p.Order[:i], stage) {
+			errs = append(errs, fmt.Errorf("stage '%s' must not be ordered more than once", stage))
+		}
+	}
+
+	hookNames := map[string]bool{}
+	for _, hook := range p.Hooks {
+		if hookNames[hook.Name] {
+			errs = append(errs, fmt.Errorf("hook name '%s' must be unique", hook.Name))
+		}
+		hookNames[hook.Name] = true
+
+		err := hook.Validate()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate checks the hook for errors.
+func (h PipelineHook) Validate() error {
+	if h.Name == "" {
+		return fmt.Errorf("hook name must not be empty")
+	}
+
+	var errs []error
+
+	if (h.Before == "") == (h.After == "") {
+		errs = append(errs, fmt.Errorf("hook '%s' must be performed either before or after a stage", h.Name))
+	}
+	for _, stage := range []PipelineStage{h.Before, h.After} {
+		if stage != "" && !existsPipelineStage(stage) {
+			errs = append(errs, fmt.Errorf("stage '%s' of hook '%s' does not exist", stage, h.Name))
+		}
+	}
+
+	if h.countActions() != 1 {
+		errs = append(errs, fmt.Errorf("hook '%s' must contain exactly one of patch, wait or manifest", h.Name))
+	}
+	if h.Patch != nil {
+		errs = append(errs, h.Patch.validate(h.Name))
+	}
+	if h.Wait != nil {
+		errs = append(errs, h.Wait.validate(h.Name))
+	}
+	if h.Manifest != nil {
+		errs = append(errs, h.Manifest.validate(h.Name))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h PipelineHook) countActions() int {
+	count := 0
+	for _, isSet := range []bool{h.Patch != nil, h.Wait != nil, h.Manifest != nil} {
+		if isSet {
+			count++
+		}
+	}
+
+	return count
+}
+
+// ResourceManifest returns the manifest of the hook attached to the phase of the hook.
+func (mh *ManifestHook) ResourceManifest(hookName string) patch.ResourceManifest {
+	return patch.ResourceManifest{Phase: patch.Phase(hookName), Manifest: mh.Manifest, URL: mh.URL}
+}
+
+func (mh *ManifestHook) validate(hookName string) error {
+	err := mh.ResourceManifest(hookName).ValidateSource()
+	if err != nil {
+		return fmt.Errorf("manifest of hook '%s' is invalid: %w", hookName, err)
+	}
+
+	return nil
+}
+
+func (ph *PatchHook) validate(hookName string) error {
+	var errs []error
+
+	err := ph.Resource.Validate()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("resource of hook '%s' is invalid: %w", hookName, err))
+	}
+
+	if len(ph.Patches) == 0 {
+		errs = append(errs, fmt.Errorf("no patches found for hook '%s' which is a sign of a misconfiguration", hookName))
+	}
+
+	for _, singlePatch := range ph.Patches {
+		errs = append(errs, singlePatch.Validate())
+	}
+
+	return errors.Join(errs...)
+}
+
+func (wh *WaitHook) validate(hookName string) error {
+	var errs []error
+
+	if (wh.Component == "") == (wh.Dogu == "") {
+		errs = append(errs, fmt.Errorf("hook '%s' must wait for either a component or a dogu", hookName))
+	}
+
+	if wh.TimeoutSeconds < 0 {
+		errs = append(errs, fmt.Errorf("timeout of hook '%s' must not be negative", hookName))
+	}
+
+	return errors.Join(errs...)
+}
+
+func existsPipelineStage(stage PipelineStage) bool {
+	return slices.Contains(DefaultPipelineStages, stage)
+}
@@ -0,0 +1,186 @@
+package context
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+)
+
+func TestPipeline_Stages(t *testing.T) {
+	t.Run("should return default stages without order", func(t *testing.T) {
+		// when
+		actual := Pipeline{}.Stages()
+
+		// then
+		assert.Equal(t, DefaultPipelineStages, actual)
+	})
+
+	t.Run("should perform preflight first, then ordered stages and all other stages in their default order", func(t *testing.T) {
+		// given
+		sut := Pipeline{Order: []PipelineStage{ValidationStage, DataStage}}
+
+		// when
+		actual := sut.Stages()
+
+		// then
+		assert.Equal(t, []PipelineStage{PreflightStage, ValidationStage, DataStage, DefaultSAAutomountStage, LoadBalancerStage, SSLGenerationStage, ComponentStage, DoguStage}, actual)
+	})
+
+	t.Run("should perform preflight first even if it is ordered", func(t *testing.T) {
+		// given
+		sut := Pipeline{Order: []PipelineStage{DoguStage, PreflightStage}}
+
+		// when
+		actual := sut.Stages()
+
+		// then
+		assert.Equal(t, []PipelineStage{PreflightStage, DoguStage, DefaultSAAutomountStage, LoadBalancerStage, SSLGenerationStage, ValidationStage, DataStage, ComponentStage}, actual)
+	})
+}
+
+func TestPipeline_Hooks(t *testing.T) {
+	// given
+	first := PipelineHook{Name: "first", Before: DoguStage}
+	second := PipelineHook{Name: "second", After: ComponentStage}
+	third := PipelineHook{Name: "third", Before: DoguStage}
+	sut := Pipeline{Hooks: []PipelineHook{first, second, third}, Disable: []PipelineStage{LoadBalancerStage}}
+
+	// then
+	assert.Equal(t, []PipelineHook{first, third}, sut.HooksBefore(DoguStage))
+	assert.Equal(t, []PipelineHook{second}, sut.HooksAfter(ComponentStage))
+	assert.Empty(t, sut.HooksAfter(DoguStage))
+	assert.True(t, sut.IsDisabled(LoadBalancerStage))
+	assert.False(t, sut.IsDisabled(DoguStage))
+}
+
+func TestPipeline_Validate(t *testing.T) {
+	validPatch := &PatchHook{
+		Resource: patch.ResourceReference{ApiVersion: "v1", Kind: "Service", Name: "ces-loadbalancer"},
+		Patches:  []patch.JsonPatch{{Operation: "add", Path: "/metadata/labels/test", Value: "value"}},
+	}
+
+	t.Run("should accept empty pipeline", func(t *testing.T) {
+		assert.NoError(t, Pipeline{}.Validate())
+	})
+
+	t.Run("should accept valid pipeline", func(t *testing.T) {
+		// given
+		sut := Pipeline{
+			Disable: []PipelineStage{LoadBalancerStage, SSLGenerationStage},
+			Order:   []PipelineStage{ValidationStage},
+			Hooks: []PipelineHook{
+				{Name: "patch", After: LoadBalancerStage, Patch: validPatch},
+				{Name: "wait", Before: DoguStage, Wait: &WaitHook{Component: "k8s-longhorn", TimeoutSeconds: 60}},
+				{Name: "manifest", After: ComponentStage, Manifest: &ManifestHook{URL: "https://example.com/manifest.yaml"}},
+			},
+		}
+
+		// when
+		err := sut.Validate()
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should report unknown and duplicate stages", func(t *testing.T) {
+		// given
+		sut := Pipeline{
+			Disable: []PipelineStage{"unknown"},
+			Order:   []PipelineStage{DataStage, "other", DataStage},
+		}
+
+		// when
+		err := sut.Validate()
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "disabled stage 'unknown' does not exist")
+		assert.ErrorContains(t, err, "ordered stage 'other' does not exist")
+		assert.ErrorContains(t, err, "stage 'data' must not be ordered more than once")
+	})
+
+	t.Run("should reject ordered preflight stage", func(t *testing.T) {
+		// given
+		sut := Pipeline{Order: []PipelineStage{ValidationStage, PreflightStage}}
+
+		// when
+		err := sut.Validate()
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "stage 'preflight' is always performed first and must not be ordered")
+	})
+
+	t.Run("should report misconfigured hooks", func(t *testing.T) {
+		// given
+		sut := Pipeline{
+			Hooks: []PipelineHook{
+				{Before: DoguStage, Patch: validPatch},
+				{Name: "both", Before: DoguStage, After: DataStage, Patch: validPatch},
+				{Name: "unknown", After: "unknown", Patch: validPatch},
+				{Name: "unknown", Before: DoguStage, Patch: validPatch},
+				{Name: "nothing", Before: DoguStage},
+				{Name: "empty-patch", Before: DoguStage, Patch: &PatchHook{}},
+				{Name: "invalid-wait", Before: DoguStage, Wait: &WaitHook{Component: "a", Dogu: "b", TimeoutSeconds: -1}},
+				{Name: "invalid-manifest", Before: DoguStage, Manifest: &ManifestHook{URL: "ftp://example.com"}},
+				{Name: "patch-and-manifest", Before: DoguStage, Patch: validPatch, Manifest: &ManifestHook{Manifest: "kind: ConfigMap"}},
+			},
+		}
+
+		// when
+		err := sut.Validate()
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "hook name must not be empty")
+		assert.ErrorContains(t, err, "hook 'both' must be performed either before or after a stage")
+		assert.ErrorContains(t, err, "stage 'unknown' of hook 'unknown' does not exist")
+		assert.ErrorContains(t, err, "hook name 'unknown' must be unique")
+		assert.ErrorContains(t, err, "hook 'nothing' must contain exactly one of patch, wait or manifest")
+		assert.ErrorContains(t, err, "hook 'patch-and-manifest' must contain exactly one of patch, wait or manifest")
+		assert.ErrorContains(t, err, "resource of hook 'empty-patch' is invalid: resource kind must not be empty")
+		assert.ErrorContains(t, err, "resource name must not be empty")
+		assert.ErrorContains(t, err, "no patches found for hook 'empty-patch'")
+		assert.ErrorContains(t, err, "hook 'invalid-wait' must wait for either a component or a dogu")
+		assert.ErrorContains(t, err, "timeout of hook 'invalid-wait' must not be negative")
+		assert.ErrorContains(t, err, "manifest of hook 'invalid-manifest' is invalid: url 'ftp://example.com' of resource manifest must use the scheme http or https")
+	})
+}
+
+func TestConfig_Pipeline(t *testing.T) {
+	// given
+	rawConfig := `
+target_namespace: ecosystem
+pipeline:
+  disable:
+    - loadbalancer
+  order:
+    - validation
+  hooks:
+    - name: wait-for-longhorn
+      after: components
+      wait:
+        component: k8s-longhorn
+        timeoutSeconds: 600
+`
+
+	// when
+	config := &Config{}
+	err := yaml.Unmarshal([]byte(rawConfig), config)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, Pipeline{
+		Disable: []PipelineStage{LoadBalancerStage},
+		Order:   []PipelineStage{ValidationStage},
+		Hooks: []PipelineHook{{
+			Name:  "wait-for-longhorn",
+			After: ComponentStage,
+			Wait:  &WaitHook{Component: "k8s-longhorn", TimeoutSeconds: 600},
+		}},
+	}, config.Pipeline)
+}
@@ -0,0 +1,90 @@
+package context
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// DefaultMinKubernetesVersion is the oldest kubernetes version which is supported if no other minimum is configured.
+const DefaultMinKubernetesVersion = "1.27"
+
+// Preflight configures the checks of the cluster which are performed before the setup creates any resource.
+type Preflight struct {
+	// WarnOnly only logs failed checks instead of failing the setup.
+	// +optional
+	WarnOnly bool `json:"warnOnly,omitempty" yaml:"warnOnly,omitempty"`
+	// MinKubernetesVersion contains the oldest supported kubernetes version in the format "major.minor". It defaults to
+	// DefaultMinKubernetesVersion.
+	// +optional
+	MinKubernetesVersion string `json:"minKubernetesVersion,omitempty" yaml:"minKubernetesVersion,omitempty"`
+	// MaxKubernetesVersion contains the newest supported kubernetes version in the format "major.minor". Newer
+	// versions are not checked if it is empty.
+	// +optional
+	MaxKubernetesVersion string `json:"maxKubernetesVersion,omitempty" yaml:"maxKubernetesVersion,omitempty"`
+	// MinCPU contains the minimum of CPU that all schedulable nodes together have to provide, f. i. "4" or "3500m".
+	// The CPU is not checked if it is empty.
+	// +optional
+	MinCPU string `json:"minCpu,omitempty" yaml:"minCpu,omitempty"`
+	// MinMemory contains the minimum of memory that all schedulable nodes together have to provide, f. i. "16Gi".
+	// The memory is not checked if it is empty.
+	// +optional
+	MinMemory string `json:"minMemory,omitempty" yaml:"minMemory,omitempty"`
+}
+
+// KubernetesVersionRange returns the parsed minimum and maximum kubernetes version. The maximum is nil if no maximum
+// is configured.
+func (p Preflight) KubernetesVersionRange() (minVersion *version.Version, maxVersion *version.Version, err error) {
+	rawMinVersion := p.MinKubernetesVersion
+	if rawMinVersion == "" {
+		rawMinVersion = DefaultMinKubernetesVersion
+	}
+
+	minVersion, err = version.ParseGeneric(rawMinVersion)
+	if err != nil {
+		return nil, nil, fmt.Errorf("minimum kubernetes version '%s' is invalid: %w", rawMinVersion, err)
+	}
+
+	if p.MaxKubernetesVersion == "" {
+		return minVersion, nil, nil
+	}
+
+	maxVersion, err = version.ParseGeneric(p.MaxKubernetesVersion)
+	if err != nil {
+		return nil, nil, fmt.Errorf("maximum kubernetes version '%s' is invalid: %w", p.MaxKubernetesVersion, err)
+	}
+
+	if maxVersion.LessThan(minVersion) {
+		return nil, nil, fmt.Errorf("maximum kubernetes version '%s' must not be less than the minimum kubernetes version '%s'", p.MaxKubernetesVersion, rawMinVersion)
+	}
+
+	return minVersion, maxVersion, nil
+}
+
+// Validate checks the pre-flight configuration for unparsable versions and quantities.
+func (p Preflight) Validate() error {
+	var errs []error
+
+	_, _, err := p.KubernetesVersionRange()
+	errs = append(errs, err)
+
+	errs = append(errs, validateQuantity("minCpu", p.MinCPU))
+	errs = append(errs, validateQuantity("minMemory", p.MinMemory))
+
+	return errors.Join(errs...)
+}
+
+func validateQuantity(name string, quantity string) error {
+	if quantity == "" {
+		return nil
+	}
+
+	_, err := resource.ParseQuantity(quantity)
+	if err != nil {
+		return fmt.Errorf("%s '%s' is invalid: %w", name, quantity, err)
+	}
+
+	return nil
+}
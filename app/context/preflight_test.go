@@ -0,0 +1,76 @@
+package context
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreflight_KubernetesVersionRange(t *testing.T) {
+	t.Run("should return default minimum without maximum", func(t *testing.T) {
+		// when
+		minVersion, maxVersion, err := Preflight{}.KubernetesVersionRange()
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "1.27", minVersion.String())
+		assert.Nil(t, maxVersion)
+	})
+
+	t.Run("should return configured range", func(t *testing.T) {
+		// given
+		sut := Preflight{MinKubernetesVersion: "1.29", MaxKubernetesVersion: "1.32"}
+
+		// when
+		minVersion, maxVersion, err := sut.KubernetesVersionRange()
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "1.29", minVersion.String())
+		assert.Equal(t, "1.32", maxVersion.String())
+	})
+
+	t.Run("should fail for maximum less than minimum", func(t *testing.T) {
+		// given
+		sut := Preflight{MinKubernetesVersion: "1.29", MaxKubernetesVersion: "1.28"}
+
+		// when
+		_, _, err := sut.KubernetesVersionRange()
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "maximum kubernetes version '1.28' must not be less than the minimum kubernetes version '1.29'")
+	})
+}
+
+func TestPreflight_Validate(t *testing.T) {
+	t.Run("should accept empty configuration", func(t *testing.T) {
+		assert.NoError(t, Preflight{}.Validate())
+	})
+
+	t.Run("should accept valid configuration", func(t *testing.T) {
+		// given
+		sut := Preflight{MinKubernetesVersion: "1.28", MaxKubernetesVersion: "1.33", MinCPU: "3500m", MinMemory: "16Gi"}
+
+		// when
+		err := sut.Validate()
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should collect all errors", func(t *testing.T) {
+		// given
+		sut := Preflight{MinKubernetesVersion: "one", MinCPU: "many", MinMemory: "16GB"}
+
+		// when
+		err := sut.Validate()
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "minimum kubernetes version 'one' is invalid")
+		assert.ErrorContains(t, err, "minCpu 'many' is invalid")
+		assert.ErrorContains(t, err, "minMemory '16GB' is invalid")
+	})
+}
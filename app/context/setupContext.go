@@ -41,6 +41,13 @@ const (
 	SetupStateConfigMap = "k8s-setup-config"
 	// SetupStateKey is the key by which the setup state can be referenced.
 	SetupStateKey = "state"
+	// SetupCompletedStepsKey is the key by which the IDs of the completed setup steps can be referenced.
+	SetupCompletedStepsKey = "completed_steps"
+	// SetupRollbackStateKey is the key by which the data needed to roll back the performed setup steps can be referenced.
+	SetupRollbackStateKey = "rollback_state"
+	// SetupReconcileKey is the key by which it can be referenced whether the last setup run reconciled an installed
+	// Cloudogu EcoSystem.
+	SetupReconcileKey = "reconcile"
 	// SetupStateInstalled means the setup installed the Cloudogu EcoSystem successfully.
 	SetupStateInstalled = "installed"
 	// SetupStateInstalling means the setup is currently installing the Cloudogu EcoSystem.
@@ -113,6 +120,43 @@ func (scb *SetupContextBuilder) NewSetupContext(ctx context.Context, clientSet k
 	}, nil
 }
 
+// ReadDoguRegistrySecret reads only the dogu registry credentials of the setup. In contrast to NewSetupContext, it
+// does not require the other configurations like the setup.json.
+func (scb *SetupContextBuilder) ReadDoguRegistrySecret(ctx context.Context, clientSet kubernetes.Interface) (*DoguRegistrySecret, error) {
+	if IsDevelopmentStage(scb.stage) {
+		return ReadDoguRegistrySecretFromFile(scb.DevDoguRegistrySecretPath)
+	}
+
+	targetNamespace, err := GetEnvVar(EnvironmentVariableTargetNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("could not read current namespace: %w", err)
+	}
+
+	return ReadDoguRegistrySecretFromCluster(ctx, clientSet, targetNamespace)
+}
+
+// ReadAppConfig reads only the configuration of the setup. In contrast to NewSetupContext, it does not require the
+// other configurations like the setup.json.
+func (scb *SetupContextBuilder) ReadAppConfig(ctx context.Context, clientSet kubernetes.Interface) (*Config, error) {
+	targetNamespace, err := GetEnvVar(EnvironmentVariableTargetNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("could not read current namespace: %w", err)
+	}
+
+	var config *Config
+	if IsDevelopmentStage(scb.stage) {
+		config, err = ReadConfigFromFile(scb.DevSetupConfigPath)
+	} else {
+		config, err = ReadConfigFromCluster(ctx, clientSet, targetNamespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config.TargetNamespace = targetNamespace
+	return config, nil
+}
+
 func (scb *SetupContextBuilder) getConfigurations(ctx context.Context, clientSet kubernetes.Interface, targetNamespace string) (*Config, *SetupJsonConfiguration, *DoguRegistrySecret, *componentOpConfig.HelmRepositoryData, error) {
 	if IsDevelopmentStage(scb.stage) {
 		return scb.getDevConfig()
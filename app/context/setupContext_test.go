@@ -313,6 +313,102 @@ func TestSetupContextBuilder_NewSetupContext(t *testing.T) {
 	})
 }
 
+func TestSetupContextBuilder_ReadDoguRegistrySecret(t *testing.T) {
+	t.Run("should read dev resource", func(t *testing.T) {
+		// given
+		t.Setenv(EnvironmentVariableStage, StageDevelopment)
+		builder := NewSetupContextBuilder("1.2.3")
+		builder.DevDoguRegistrySecretPath = "testdata/testRegistrySecret.yaml"
+
+		// when
+		actual, err := builder.ReadDoguRegistrySecret(testCtx, nil)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "endpoint", actual.Endpoint)
+	})
+
+	t.Run("should read secret from cluster without setup.json", func(t *testing.T) {
+		// given
+		t.Setenv(EnvironmentVariableTargetNamespace, "myTestNamespace")
+		registrySecret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "k8s-dogu-operator-dogu-registry", Namespace: "myTestNamespace"},
+			Data:       map[string][]byte{"endpoint": []byte("endpoint"), "username": []byte("username")},
+		}
+		builder := NewSetupContextBuilder("1.2.3")
+
+		// when
+		actual, err := builder.ReadDoguRegistrySecret(testCtx, fake.NewSimpleClientset(registrySecret))
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "endpoint", actual.Endpoint)
+		assert.Equal(t, "username", actual.Username)
+	})
+
+	t.Run("should fail if secret is missing", func(t *testing.T) {
+		// given
+		t.Setenv(EnvironmentVariableTargetNamespace, "myTestNamespace")
+		builder := NewSetupContextBuilder("1.2.3")
+
+		// when
+		_, err := builder.ReadDoguRegistrySecret(testCtx, fake.NewSimpleClientset())
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "dogu registry secret k8s-dogu-operator-dogu-registry not found")
+	})
+}
+
+func TestSetupContextBuilder_ReadAppConfig(t *testing.T) {
+	t.Run("should read dev resource", func(t *testing.T) {
+		// given
+		t.Setenv(EnvironmentVariableStage, StageDevelopment)
+		t.Setenv(EnvironmentVariableTargetNamespace, "myTestNamespace")
+		builder := NewSetupContextBuilder("1.2.3")
+		builder.DevSetupConfigPath = "testdata/testConfig.yaml"
+
+		// when
+		actual, err := builder.ReadAppConfig(testCtx, nil)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "myTestNamespace", actual.TargetNamespace)
+		assert.NotEmpty(t, actual.ComponentOperatorChart)
+	})
+
+	t.Run("should read config from cluster without setup.json", func(t *testing.T) {
+		// given
+		t.Setenv(EnvironmentVariableTargetNamespace, "myTestNamespace")
+		configMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "k8s-ces-setup-config", Namespace: "myTestNamespace"},
+			Data:       map[string]string{"k8s-ces-setup.yaml": "preflight:\n  minMemory: 16Gi\n"},
+		}
+		builder := NewSetupContextBuilder("1.2.3")
+
+		// when
+		actual, err := builder.ReadAppConfig(testCtx, fake.NewSimpleClientset(configMap))
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "myTestNamespace", actual.TargetNamespace)
+		assert.Equal(t, "16Gi", actual.Preflight.MinMemory)
+	})
+
+	t.Run("should fail if config is missing", func(t *testing.T) {
+		// given
+		t.Setenv(EnvironmentVariableTargetNamespace, "myTestNamespace")
+		builder := NewSetupContextBuilder("1.2.3")
+
+		// when
+		_, err := builder.ReadAppConfig(testCtx, fake.NewSimpleClientset())
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to get setup configuration from cluster")
+	})
+}
+
 func TestGetSetupStateConfigMap(t *testing.T) {
 	t.Run("should create config map", func(t *testing.T) {
 		// given
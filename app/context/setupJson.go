@@ -2,7 +2,6 @@ package context
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -168,7 +167,7 @@ func ReadSetupConfigFromCluster(ctx context.Context, client kubernetes.Interface
 
 	config := &SetupJsonConfiguration{}
 	stringData := configMap.Data["setup.json"]
-	err = json.Unmarshal([]byte(stringData), config)
+	err = unmarshalJsonStrict([]byte(stringData), config, LegacySetupJsonKeys)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal setup configuration from configmap: %w", err)
 	}
@@ -196,7 +195,7 @@ func ReadSetupConfigFromFile(path string) (*SetupJsonConfiguration, error) {
 		return config, fmt.Errorf("failed to read setup configuration %s: %w", path, err)
 	}
 
-	err = json.Unmarshal(data, &config)
+	err = unmarshalJsonStrict(data, config, LegacySetupJsonKeys)
 	if err != nil {
 		return config, fmt.Errorf("failed to unmarshal setup configuration %s: %w", path, err)
 	}
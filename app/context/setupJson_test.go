@@ -1,11 +1,15 @@
 package context_test
 
 import (
+	ctx "context"
 	_ "embed"
 	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/cloudogu/k8s-ces-setup/v4/app/context"
 
@@ -48,6 +52,66 @@ func TestReadSetupConfig(t *testing.T) {
 	})
 }
 
+func TestReadSetupConfigFromCluster(t *testing.T) {
+	const testNamespace = "test-namespace"
+	newConfigMap := func(setupJson string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: context.SetupStartUpConfigMap, Namespace: testNamespace},
+			Data:       map[string]string{"setup.json": setupJson},
+		}
+	}
+
+	t.Run("should read setup.json", func(t *testing.T) {
+		// given
+		client := fake.NewClientset(newConfigMap(string(myTestSetupJson)))
+
+		// when
+		actual, err := context.ReadSetupConfigFromCluster(ctx.Background(), client, testNamespace)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "192.168.56.2", actual.Naming.Fqdn)
+	})
+
+	t.Run("should fail for unknown keys", func(t *testing.T) {
+		// given
+		client := fake.NewClientset(newConfigMap(`{"naming": {"fqdn": "ces.local", "certficateType": "selfsigned"}, "admin": {"Username": "admin"}}`))
+
+		// when
+		_, err := context.ReadSetupConfigFromCluster(ctx.Background(), client, testNamespace)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to unmarshal setup configuration from configmap")
+		assert.ErrorContains(t, err, `unknown field "naming.certficateType"`)
+		assert.ErrorContains(t, err, `unknown field "admin.Username"`)
+	})
+
+	t.Run("should ignore deprecated keys", func(t *testing.T) {
+		// given
+		client := fake.NewClientset(newConfigMap(`{"token": {"id": "", "secret": "", "Completed": false}, "naming": {"fqdn": "ces.local", "hostname": "ces"}}`))
+
+		// when
+		actual, err := context.ReadSetupConfigFromCluster(ctx.Background(), client, testNamespace)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "ces.local", actual.Naming.Fqdn)
+	})
+
+	t.Run("should fail for duplicate keys", func(t *testing.T) {
+		// given
+		client := fake.NewClientset(newConfigMap(`{"naming": {"fqdn": "ces.local", "fqdn": "ces.example.com"}}`))
+
+		// when
+		_, err := context.ReadSetupConfigFromCluster(ctx.Background(), client, testNamespace)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, `duplicate field "naming.fqdn"`)
+	})
+}
+
 func TestSetupConfiguration_IsCompleted(t *testing.T) {
 	// given
 	setupJSON := context.SetupJsonConfiguration{}
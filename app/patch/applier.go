@@ -3,15 +3,23 @@ package patch
 import (
 	"context"
 	"fmt"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"time"
 )
 
 type applier struct {
@@ -53,33 +61,239 @@ func createDynamicClient(config *rest.Config) (dynamic.Interface, error) {
 	return dynamic.NewForConfig(config)
 }
 
-// Patch takes the JSON patch and applies it against the Kubernetes API for the corresponding GVK identified by the given resource name.
-func (ac *applier) Patch(ctx context.Context, jsonPatch []byte, gvk schema.GroupVersionKind, resourceName string) error {
-	// 4. Map GVK to GVR
-	// a resource can be uniquely identified by GroupVersionResource, but we need the GVK to find the corresponding GVR
-	gvr, err := ac.gvrMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+// fieldManager identifies the setup as the owner of the fields it applies with server-side apply.
+const fieldManager = "k8s-ces-setup"
+
+// PatchResult contains the outcome of patching a single kubernetes resource.
+type PatchResult struct {
+	// Namespace contains the namespace of the patched resource. It is empty for cluster-wide resources.
+	Namespace string
+	// Name contains the name of the patched resource.
+	Name string
+	// Err contains the reason why the resource could not be patched.
+	Err error
+}
+
+// Patch takes the patch of the given type and applies it against the Kubernetes API for every resource identified by
+// the given reference. It returns the result for every single resource. Resources selected by labels are listed
+// first; if none match, no result is returned.
+func (ac *applier) Patch(ctx context.Context, patchType types.PatchType, patch []byte, resource ResourceReference) ([]PatchResult, error) {
+	return ac.patch(ctx, patchType, patch, resource, v1.PatchOptions{})
+}
+
+// DryRunPatch sends the patch of the given type for every existing resource identified by the given reference to the
+// Kubernetes API without persisting any change. This way, the API server reports patches which would violate the
+// schema of the resources. Unknown kinds result in an error for which meta.IsNoMatchError holds.
+func (ac *applier) DryRunPatch(ctx context.Context, patchType types.PatchType, patch []byte, resource ResourceReference) ([]PatchResult, error) {
+	return ac.patch(ctx, patchType, patch, resource, v1.PatchOptions{DryRun: []string{v1.DryRunAll}})
+}
+
+func (ac *applier) patch(ctx context.Context, patchType types.PatchType, patch []byte, resource ResourceReference, options v1.PatchOptions) ([]PatchResult, error) {
+	gvk := resource.GroupVersionKind()
+	dr, namespace, err := ac.resourceInterface(gvk, resource.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{resource.Name}
+	if resource.LabelSelector != "" {
+		names, err = listResourceNames(ctx, dr, resource.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resources of kind %s with label selector %s: %w", gvk, resource.LabelSelector, err)
+		}
+	}
+
+	results := make([]PatchResult, 0, len(names))
+	for _, name := range names {
+		result := PatchResult{Namespace: namespace, Name: name}
+		err = ac.patchResource(ctx, name, patchType, patch, dr, options)
+		if err != nil {
+			result.Err = fmt.Errorf("failed to patch resource %s of kind %s with patch '%s': %w", name, gvk, patch, err)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// Wait blocks until the referenced resource exists and, if a condition is given, its status condition of this type is
+// "True". For resources selected by labels, it waits until one matching resource fulfills this.
+func (ac *applier) Wait(ctx context.Context, resource ResourceReference, condition string, timeout time.Duration) error {
+	gvk := resource.GroupVersionKind()
+	dr, _, err := ac.resourceInterface(gvk, resource.Namespace)
 	if err != nil {
-		return fmt.Errorf("could not find GVK mapper for GroupKind=%v,Version=%s: %w", gvk.GroupKind(), gvk.Version, err)
+		return err
 	}
 
-	var dr dynamic.ResourceInterface
-	if gvr.Scope.Name() == meta.RESTScopeNameNamespace {
-		// namespaced resources should specify the namespace
-		dr = ac.dynClient.Resource(gvr.Resource).Namespace(ac.namespace)
-	} else {
-		// for cluster-wide resources
-		dr = ac.dynClient.Resource(gvr.Resource)
+	selectOptions := func(options *v1.ListOptions) {
+		options.LabelSelector = resource.LabelSelector
+		if resource.Name != "" {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", resource.Name).String()
+		}
+	}
+	listWatch := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options v1.ListOptions) (runtime.Object, error) {
+			selectOptions(&options)
+			return dr.List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options v1.ListOptions) (watch.Interface, error) {
+			selectOptions(&options)
+			return dr.Watch(ctx, options)
+		},
 	}
 
-	err = ac.patchResource(ctx, resourceName, jsonPatch, dr)
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err = watchtools.UntilWithSync(timeoutCtx, listWatch, &unstructured.Unstructured{}, nil, func(event watch.Event) (bool, error) {
+		object, ok := event.Object.(*unstructured.Unstructured)
+		if !ok || event.Type == watch.Deleted || (resource.Name != "" && object.GetName() != resource.Name) {
+			return false, nil
+		}
+
+		return condition == "" || hasTrueCondition(object, condition), nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to patch resource %s of kind %s with json patch '%s': %w", resourceName, gvk, jsonPatch, err)
+		return fmt.Errorf("failed to wait for %s: %w", resource, err)
 	}
 
 	return nil
 }
 
-func (ac *applier) patchResource(ctx context.Context, name string, jsonPatch []byte, dr dynamic.ResourceInterface) error {
-	_, err := dr.Patch(ctx, name, types.JSONPatchType, jsonPatch, v1.PatchOptions{})
+// Exists returns true if the referenced resource exists. For resources selected by labels, at least one resource has to
+// match.
+func (ac *applier) Exists(ctx context.Context, resource ResourceReference) (bool, error) {
+	gvk := resource.GroupVersionKind()
+	dr, _, err := ac.resourceInterface(gvk, resource.Namespace)
+	if err != nil {
+		return false, err
+	}
+
+	if resource.LabelSelector != "" {
+		names, err := listResourceNames(ctx, dr, resource.LabelSelector)
+		if err != nil {
+			return false, fmt.Errorf("failed to list resources of kind %s with label selector %s: %w", gvk, resource.LabelSelector, err)
+		}
+		return len(names) > 0, nil
+	}
+
+	_, err = dr.Get(ctx, resource.Name, v1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get resource %s of kind %s: %w", resource.Name, gvk, err)
+	}
+
+	return true, nil
+}
+
+// Get returns the resource referenced by its name.
+func (ac *applier) Get(ctx context.Context, resource ResourceReference) (*unstructured.Unstructured, error) {
+	gvk := resource.GroupVersionKind()
+	dr, _, err := ac.resourceInterface(gvk, resource.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := dr.Get(ctx, resource.Name, v1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource %s of kind %s: %w", resource.Name, gvk, err)
+	}
+
+	return object, nil
+}
+
+func hasTrueCondition(object *unstructured.Unstructured, conditionType string) bool {
+	conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for _, condition := range conditions {
+		conditionMap, ok := condition.(map[string]any)
+		if ok && conditionMap["type"] == conditionType && conditionMap["status"] == "True" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func listResourceNames(ctx context.Context, dr dynamic.ResourceInterface, labelSelector string) ([]string, error) {
+	list, err := dr.List(ctx, v1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		names = append(names, item.GetName())
+	}
+
+	return names, nil
+}
+
+func (ac *applier) patchResource(ctx context.Context, name string, patchType types.PatchType, patch []byte, dr dynamic.ResourceInterface, options v1.PatchOptions) error {
+	_, err := dr.Patch(ctx, name, patchType, patch, options)
 	return err
 }
+
+// Apply creates or updates the given object with server-side apply. It returns true if the object did not exist
+// before.
+func (ac *applier) Apply(ctx context.Context, object *unstructured.Unstructured) (bool, error) {
+	gvk := object.GroupVersionKind()
+	dr, _, err := ac.resourceInterface(gvk, object.GetNamespace())
+	if err != nil {
+		return false, err
+	}
+
+	_, err = dr.Get(ctx, object.GetName(), v1.GetOptions{})
+	created := apierrors.IsNotFound(err)
+	if err != nil && !created {
+		return false, fmt.Errorf("failed to get resource %s of kind %s: %w", object.GetName(), gvk, err)
+	}
+
+	_, err = dr.Apply(ctx, object.GetName(), object, v1.ApplyOptions{FieldManager: fieldManager, Force: true})
+	if err != nil {
+		return false, fmt.Errorf("failed to apply resource %s of kind %s: %w", object.GetName(), gvk, err)
+	}
+
+	return created, nil
+}
+
+// Delete removes the given object. Objects which do not exist anymore are ignored.
+func (ac *applier) Delete(ctx context.Context, object *unstructured.Unstructured) error {
+	gvk := object.GroupVersionKind()
+	dr, _, err := ac.resourceInterface(gvk, object.GetNamespace())
+	if err != nil {
+		return err
+	}
+
+	err = dr.Delete(ctx, object.GetName(), v1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete resource %s of kind %s: %w", object.GetName(), gvk, err)
+	}
+
+	return nil
+}
+
+// resourceInterface returns the dynamic client for the given GVK and the namespace it operates in. Namespaced resources
+// use the given namespace or the namespace of the setup if it is empty. The namespace is empty for cluster-wide
+// resources.
+func (ac *applier) resourceInterface(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, string, error) {
+	// 4. Map GVK to GVR
+	// a resource can be uniquely identified by GroupVersionResource, but we need the GVK to find the corresponding GVR
+	gvr, err := ac.gvrMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not find GVK mapper for GroupKind=%v,Version=%s: %w", gvk.GroupKind(), gvk.Version, err)
+	}
+
+	if gvr.Scope.Name() != meta.RESTScopeNameNamespace {
+		// for cluster-wide resources
+		return ac.dynClient.Resource(gvr.Resource), "", nil
+	}
+
+	// namespaced resources should specify the namespace
+	if namespace == "" {
+		namespace = ac.namespace
+	}
+
+	return ac.dynClient.Resource(gvr.Resource).Namespace(namespace), namespace, nil
+}
@@ -0,0 +1,293 @@
+package patch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var deploymentGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+func newTestDeployment(namespace, name string, labels map[string]string) *unstructured.Unstructured {
+	deployment := &unstructured.Unstructured{}
+	deployment.SetAPIVersion("apps/v1")
+	deployment.SetKind("Deployment")
+	deployment.SetNamespace(namespace)
+	deployment.SetName(name)
+	deployment.SetLabels(labels)
+	return deployment
+}
+
+func newTestApplier(t *testing.T, objects ...runtime.Object) (*applier, *dynamicfake.FakeDynamicClient) {
+	t.Helper()
+
+	mapperMock := newMockGvrMapper(t)
+	mapperMock.EXPECT().RESTMapping(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "v1").
+		Return(&meta.RESTMapping{Resource: deploymentGVR, Scope: meta.RESTScopeNamespace}, nil)
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{deploymentGVR: "DeploymentList"}, objects...)
+
+	return &applier{gvrMapper: mapperMock, dynClient: client, namespace: "ecosystem"}, client
+}
+
+func Test_applier_Patch(t *testing.T) {
+	labelPatch := []byte(`{"metadata":{"labels":{"patched":"true"}}}`)
+
+	t.Run("should patch resource by name in namespace of the setup", func(t *testing.T) {
+		// given
+		sut, client := newTestApplier(t, newTestDeployment("ecosystem", "nginx", nil))
+		resource := ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Name: "nginx"}
+
+		// when
+		results, err := sut.Patch(testCtx, types.MergePatchType, labelPatch, resource)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []PatchResult{{Namespace: "ecosystem", Name: "nginx"}}, results)
+		actual, err := client.Resource(deploymentGVR).Namespace("ecosystem").Get(testCtx, "nginx", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "true", actual.GetLabels()["patched"])
+	})
+
+	t.Run("should patch all resources matching the label selector in the given namespace", func(t *testing.T) {
+		// given
+		sut, client := newTestApplier(t,
+			newTestDeployment("longhorn-system", "longhorn-manager", map[string]string{"app.kubernetes.io/part-of": "ces"}),
+			newTestDeployment("longhorn-system", "longhorn-ui", map[string]string{"app.kubernetes.io/part-of": "ces"}),
+			newTestDeployment("longhorn-system", "other", map[string]string{"app.kubernetes.io/part-of": "other"}),
+			newTestDeployment("ecosystem", "nginx", map[string]string{"app.kubernetes.io/part-of": "ces"}),
+		)
+		resource := ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Namespace: "longhorn-system", LabelSelector: "app.kubernetes.io/part-of=ces"}
+
+		// when
+		results, err := sut.Patch(testCtx, types.MergePatchType, labelPatch, resource)
+
+		// then
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []PatchResult{
+			{Namespace: "longhorn-system", Name: "longhorn-manager"},
+			{Namespace: "longhorn-system", Name: "longhorn-ui"},
+		}, results)
+		other, err := client.Resource(deploymentGVR).Namespace("longhorn-system").Get(testCtx, "other", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.NotContains(t, other.GetLabels(), "patched")
+	})
+
+	t.Run("should report resources which could not be patched", func(t *testing.T) {
+		// given
+		sut, _ := newTestApplier(t)
+		resource := ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Name: "missing"}
+
+		// when
+		results, err := sut.Patch(testCtx, types.MergePatchType, labelPatch, resource)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "missing", results[0].Name)
+		assert.ErrorContains(t, results[0].Err, "failed to patch resource missing of kind apps/v1, Kind=Deployment")
+	})
+
+	t.Run("should fail if the kind is unknown", func(t *testing.T) {
+		// given
+		mapperMock := newMockGvrMapper(t)
+		mapperMock.EXPECT().RESTMapping(schema.GroupKind{Kind: "Unknown"}, "v1").Return(nil, assert.AnError)
+		sut := &applier{gvrMapper: mapperMock, namespace: "ecosystem"}
+
+		// when
+		_, err := sut.Patch(testCtx, types.MergePatchType, labelPatch, ResourceReference{ApiVersion: "v1", Kind: "Unknown", Name: "name"})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func Test_applier_DryRunPatch(t *testing.T) {
+	labelPatch := []byte(`{"metadata":{"labels":{"patched":"true"}}}`)
+
+	t.Run("should report the result of the api server for every resource", func(t *testing.T) {
+		// given
+		sut, client := newTestApplier(t, newTestDeployment("ecosystem", "nginx", nil))
+		invalidErr := apierrors.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "nginx", nil)
+		client.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, invalidErr
+		})
+		resource := ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Name: "nginx"}
+
+		// when
+		results, err := sut.DryRunPatch(testCtx, types.MergePatchType, labelPatch, resource)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.True(t, apierrors.IsInvalid(results[0].Err))
+	})
+
+	t.Run("should fail with no match error if the kind is unknown", func(t *testing.T) {
+		// given
+		gk := schema.GroupKind{Group: "apps", Kind: "Deploymnet"}
+		mapperMock := newMockGvrMapper(t)
+		mapperMock.EXPECT().RESTMapping(gk, "v1").Return(nil, &meta.NoKindMatchError{GroupKind: gk, SearchedVersions: []string{"v1"}})
+		sut := &applier{gvrMapper: mapperMock, namespace: "ecosystem"}
+
+		// when
+		_, err := sut.DryRunPatch(testCtx, types.MergePatchType, labelPatch, ResourceReference{ApiVersion: "apps/v1", Kind: "Deploymnet", Name: "nginx"})
+
+		// then
+		require.Error(t, err)
+		assert.True(t, meta.IsNoMatchError(err))
+	})
+}
+
+func Test_applier_Wait(t *testing.T) {
+	nginx := ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Name: "nginx"}
+
+	t.Run("should return if the resource already exists", func(t *testing.T) {
+		// given
+		sut, _ := newTestApplier(t, newTestDeployment("ecosystem", "nginx", nil))
+
+		// when
+		err := sut.Wait(testCtx, nginx, "", time.Second)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should wait until the resource is created and fulfills the condition", func(t *testing.T) {
+		// given
+		sut, client := newTestApplier(t, newTestDeployment("ecosystem", "other", nil))
+		go func() {
+			deployments := client.Resource(deploymentGVR).Namespace("ecosystem")
+			time.Sleep(50 * time.Millisecond)
+			deployment, _ := deployments.Create(testCtx, newTestDeployment("ecosystem", "nginx", nil), metav1.CreateOptions{})
+			time.Sleep(50 * time.Millisecond)
+			_ = unstructured.SetNestedSlice(deployment.Object, []any{map[string]any{"type": "Available", "status": "True"}}, "status", "conditions")
+			_, _ = deployments.Update(testCtx, deployment, metav1.UpdateOptions{})
+		}()
+
+		// when
+		err := sut.Wait(testCtx, nginx, "Available", 5*time.Second)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail if the condition is not fulfilled in time", func(t *testing.T) {
+		// given
+		deployment := newTestDeployment("ecosystem", "nginx", nil)
+		_ = unstructured.SetNestedSlice(deployment.Object, []any{map[string]any{"type": "Available", "status": "False"}}, "status", "conditions")
+		sut, _ := newTestApplier(t, deployment)
+
+		// when
+		err := sut.Wait(testCtx, nginx, "Available", 100*time.Millisecond)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to wait for apps/v1/Deployment nginx")
+		assert.True(t, wait.Interrupted(err))
+	})
+
+	t.Run("should wait for any resource matching the label selector", func(t *testing.T) {
+		// given
+		sut, _ := newTestApplier(t, newTestDeployment("longhorn-system", "longhorn-ui", map[string]string{"app": "longhorn"}))
+		resource := ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Namespace: "longhorn-system", LabelSelector: "app=longhorn"}
+
+		// when
+		err := sut.Wait(testCtx, resource, "", time.Second)
+
+		// then
+		require.NoError(t, err)
+	})
+}
+
+func Test_applier_Exists(t *testing.T) {
+	t.Run("should find resource by name", func(t *testing.T) {
+		// given
+		sut, _ := newTestApplier(t, newTestDeployment("ecosystem", "nginx", nil))
+
+		// when
+		exists, err := sut.Exists(testCtx, ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Name: "nginx"})
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("should not find missing resource", func(t *testing.T) {
+		// given
+		sut, _ := newTestApplier(t, newTestDeployment("ecosystem", "other", nil))
+
+		// when
+		exists, err := sut.Exists(testCtx, ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Name: "nginx"})
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("should find resources by label selector", func(t *testing.T) {
+		// given
+		sut, _ := newTestApplier(t, newTestDeployment("longhorn-system", "longhorn-ui", map[string]string{"app": "longhorn"}))
+		resource := ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Namespace: "longhorn-system", LabelSelector: "app=longhorn"}
+
+		// when
+		exists, err := sut.Exists(testCtx, resource)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("should not find resources if no labels match", func(t *testing.T) {
+		// given
+		sut, _ := newTestApplier(t, newTestDeployment("longhorn-system", "longhorn-ui", map[string]string{"app": "longhorn"}))
+		resource := ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Namespace: "longhorn-system", LabelSelector: "app=other"}
+
+		// when
+		exists, err := sut.Exists(testCtx, resource)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+func Test_applier_Get(t *testing.T) {
+	t.Run("should get resource by name", func(t *testing.T) {
+		// given
+		sut, _ := newTestApplier(t, newTestDeployment("longhorn-system", "longhorn-ui", nil))
+
+		// when
+		object, err := sut.Get(testCtx, ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Namespace: "longhorn-system", Name: "longhorn-ui"})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "longhorn-ui", object.GetName())
+	})
+
+	t.Run("should fail for missing resource", func(t *testing.T) {
+		// given
+		sut, _ := newTestApplier(t, newTestDeployment("ecosystem", "other", nil))
+
+		// when
+		_, err := sut.Get(testCtx, ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Name: "nginx"})
+
+		// then
+		require.Error(t, err)
+		assert.True(t, apierrors.IsNotFound(err))
+		assert.ErrorContains(t, err, "failed to get resource nginx of kind apps/v1, Kind=Deployment")
+	})
+}
@@ -0,0 +1,149 @@
+package patch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Condition restricts a patch to certain setups. All conditions which are set must be fulfilled; otherwise, the patch
+// is skipped.
+type Condition struct {
+	// DoguInstalled contains the simple name of a dogu which must be installed by the setup, f. i. "redmine".
+	// +optional
+	DoguInstalled string `json:"doguInstalled,omitempty" yaml:"doguInstalled,omitempty"`
+	// ComponentConfigured contains the name of a component which must be configured in the components of the setup.
+	// +optional
+	ComponentConfigured string `json:"componentConfigured,omitempty" yaml:"componentConfigured,omitempty"`
+	// ResourceExists references a kubernetes resource which must exist when the patch is applied.
+	// +optional
+	ResourceExists *ResourceReference `json:"resourceExists,omitempty" yaml:"resourceExists,omitempty"`
+	// NodeLabel contains a label selector which must match at least one node of the cluster, f. i.
+	// "topology.kubernetes.io/region=eu-central-1".
+	// +optional
+	NodeLabel string `json:"nodeLabel,omitempty" yaml:"nodeLabel,omitempty"`
+	// SetupJson compares a field of the setup.json with a value.
+	// +optional
+	SetupJson *SetupJsonCondition `json:"setupJson,omitempty" yaml:"setupJson,omitempty"`
+}
+
+// SetupJsonCondition is fulfilled if a field of the setup.json has the expected value.
+type SetupJsonCondition struct {
+	// Field contains the path of the field separated by dots, f. i. "naming.certificateType".
+	Field string `json:"field" yaml:"field"`
+	// Equals contains the expected value of the field. Values which are no strings are compared with their textual
+	// representation, f. i. "true".
+	Equals string `json:"equals" yaml:"equals"`
+}
+
+// Validate checks the Condition for errors.
+func (c *Condition) Validate() error {
+	if *c == (Condition{}) {
+		return fmt.Errorf("condition must contain at least one of doguInstalled, componentConfigured, resourceExists, nodeLabel or setupJson")
+	}
+
+	var errs []error
+
+	if c.ResourceExists != nil {
+		err := c.ResourceExists.Validate()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("resource of condition resourceExists is invalid: %w", err))
+		}
+	}
+
+	if c.NodeLabel != "" {
+		_, err := labels.Parse(c.NodeLabel)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("label selector '%s' of condition nodeLabel is invalid: %w", c.NodeLabel, err))
+		}
+	}
+
+	if c.SetupJson != nil && c.SetupJson.Field == "" {
+		errs = append(errs, fmt.Errorf("field of condition setupJson must not be empty"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// nodes references all nodes of the cluster which match the given label selector.
+func nodes(labelSelector string) ResourceReference {
+	return ResourceReference{ApiVersion: "v1", Kind: "Node", LabelSelector: labelSelector}
+}
+
+// evaluate returns an empty reason if the condition is fulfilled with the given values and the current state of the
+// cluster. Otherwise, it returns why the condition is not fulfilled.
+func (c *Condition) evaluate(ctx context.Context, checker jsonPatchApplier, values TemplateValues) (string, error) {
+	reason := c.evaluateSetup(values)
+	if reason != "" {
+		return reason, nil
+	}
+
+	if c.ResourceExists != nil {
+		exists, err := checker.Exists(ctx, *c.ResourceExists)
+		if err != nil {
+			return "", fmt.Errorf("failed to check if %s exists: %w", c.ResourceExists, err)
+		}
+		if !exists {
+			return fmt.Sprintf("resource %s does not exist", c.ResourceExists), nil
+		}
+	}
+
+	if c.NodeLabel != "" {
+		exists, err := checker.Exists(ctx, nodes(c.NodeLabel))
+		if err != nil {
+			return "", fmt.Errorf("failed to check for nodes with labels %s: %w", c.NodeLabel, err)
+		}
+		if !exists {
+			return fmt.Sprintf("no node has the labels %s", c.NodeLabel), nil
+		}
+	}
+
+	return "", nil
+}
+
+// evaluateSetup returns an empty reason if the conditions on the dogus, components and setup.json are fulfilled with
+// the given values. Conditions on the state of the cluster are not checked.
+func (c *Condition) evaluateSetup(values TemplateValues) string {
+	if c.DoguInstalled != "" {
+		if _, ok := values.DoguVersions[c.DoguInstalled]; !ok {
+			return fmt.Sprintf("dogu %s is not installed", c.DoguInstalled)
+		}
+	}
+
+	if c.ComponentConfigured != "" && !slices.Contains(values.Components, c.ComponentConfigured) {
+		return fmt.Sprintf("component %s is not configured", c.ComponentConfigured)
+	}
+
+	if c.SetupJson != nil {
+		value, ok := lookupField(values.SetupJson, c.SetupJson.Field)
+		if !ok {
+			return fmt.Sprintf("field %s of setup.json does not exist", c.SetupJson.Field)
+		}
+		if fmt.Sprint(value) != c.SetupJson.Equals {
+			return fmt.Sprintf("field %s of setup.json is '%v' instead of '%s'", c.SetupJson.Field, value, c.SetupJson.Equals)
+		}
+	}
+
+	return ""
+}
+
+// lookupField returns the value at the given path of nested maps whose keys are separated by dots.
+func lookupField(document map[string]any, path string) (any, bool) {
+	var value any = document
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		value, ok = object[key]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
@@ -0,0 +1,133 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCondition_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		condition   Condition
+		expectedErr string
+	}{
+		{name: "dogu installed", condition: Condition{DoguInstalled: "redmine"}},
+		{name: "all conditions", condition: Condition{
+			DoguInstalled:       "redmine",
+			ComponentConfigured: "k8s-longhorn",
+			ResourceExists:      &ResourceReference{ApiVersion: "v1", Kind: "Secret", Name: "my-secret"},
+			NodeLabel:           "topology.kubernetes.io/region=eu-central-1",
+			SetupJson:           &SetupJsonCondition{Field: "naming.certificateType", Equals: "external"},
+		}},
+		{name: "empty condition", condition: Condition{}, expectedErr: "condition must contain at least one of"},
+		{name: "invalid resource", condition: Condition{ResourceExists: &ResourceReference{ApiVersion: "v1", Name: "my-secret"}}, expectedErr: "resource of condition resourceExists is invalid"},
+		{name: "invalid label selector", condition: Condition{NodeLabel: "a=b=c"}, expectedErr: "label selector 'a=b=c' of condition nodeLabel is invalid"},
+		{name: "empty setup.json field", condition: Condition{SetupJson: &SetupJsonCondition{Equals: "external"}}, expectedErr: "field of condition setupJson must not be empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// when
+			err := tt.condition.Validate()
+
+			// then
+			if tt.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestCondition_evaluate(t *testing.T) {
+	secret := ResourceReference{ApiVersion: "v1", Kind: "Secret", Name: "my-secret"}
+	values := TemplateValues{
+		DoguVersions: map[string]string{"redmine": "5.1.3-1"},
+		Components:   []string{"k8s-longhorn"},
+		SetupJson:    map[string]any{"naming": map[string]any{"certificateType": "external", "useInternalIp": false}},
+	}
+
+	tests := []struct {
+		name           string
+		condition      Condition
+		setupMock      func(applier *mockJsonPatchApplier)
+		expectedReason string
+	}{
+		{name: "installed dogu", condition: Condition{DoguInstalled: "redmine"}},
+		{name: "missing dogu", condition: Condition{DoguInstalled: "jenkins"}, expectedReason: "dogu jenkins is not installed"},
+		{name: "configured component", condition: Condition{ComponentConfigured: "k8s-longhorn"}},
+		{name: "missing component", condition: Condition{ComponentConfigured: "k8s-velero"}, expectedReason: "component k8s-velero is not configured"},
+		{
+			name:      "existing resource",
+			condition: Condition{ResourceExists: &secret},
+			setupMock: func(applier *mockJsonPatchApplier) { applier.EXPECT().Exists(testCtx, secret).Return(true, nil) },
+		},
+		{
+			name:           "missing resource",
+			condition:      Condition{ResourceExists: &secret},
+			setupMock:      func(applier *mockJsonPatchApplier) { applier.EXPECT().Exists(testCtx, secret).Return(false, nil) },
+			expectedReason: "resource v1/Secret my-secret does not exist",
+		},
+		{
+			name:      "labeled node",
+			condition: Condition{NodeLabel: "gpu=true"},
+			setupMock: func(applier *mockJsonPatchApplier) {
+				applier.EXPECT().Exists(testCtx, nodes("gpu=true")).Return(true, nil)
+			},
+		},
+		{
+			name:      "missing node labels",
+			condition: Condition{NodeLabel: "gpu=true"},
+			setupMock: func(applier *mockJsonPatchApplier) {
+				applier.EXPECT().Exists(testCtx, nodes("gpu=true")).Return(false, nil)
+			},
+			expectedReason: "no node has the labels gpu=true",
+		},
+		{name: "equal setup.json field", condition: Condition{SetupJson: &SetupJsonCondition{Field: "naming.certificateType", Equals: "external"}}},
+		{name: "equal boolean setup.json field", condition: Condition{SetupJson: &SetupJsonCondition{Field: "naming.useInternalIp", Equals: "false"}}},
+		{
+			name:           "different setup.json field",
+			condition:      Condition{SetupJson: &SetupJsonCondition{Field: "naming.certificateType", Equals: "selfsigned"}},
+			expectedReason: "field naming.certificateType of setup.json is 'external' instead of 'selfsigned'",
+		},
+		{
+			name:           "missing setup.json field",
+			condition:      Condition{SetupJson: &SetupJsonCondition{Field: "naming.certificateType.value", Equals: "external"}},
+			expectedReason: "field naming.certificateType.value of setup.json does not exist",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			applierMock := newMockJsonPatchApplier(t)
+			if tt.setupMock != nil {
+				tt.setupMock(applierMock)
+			}
+
+			// when
+			reason, err := tt.condition.evaluate(testCtx, applierMock, values)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedReason, reason)
+		})
+	}
+
+	t.Run("should fail if the existence of the resource cannot be checked", func(t *testing.T) {
+		// given
+		applierMock := newMockJsonPatchApplier(t)
+		applierMock.EXPECT().Exists(testCtx, secret).Return(false, assert.AnError)
+		sut := Condition{ResourceExists: &secret}
+
+		// when
+		_, err := sut.evaluate(testCtx, applierMock, values)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to check if v1/Secret my-secret exists")
+	})
+}
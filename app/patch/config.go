@@ -1,37 +1,197 @@
 package patch
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/scheme"
+	sigsjson "sigs.k8s.io/json"
 	"strings"
+	"time"
 )
 
-// ResourcePatch contains json patches for kubernetes resources to be applied on a phase of the setup process.
-// The patch is applied at the end of its Phase.
+// ResourcePatch contains patches for kubernetes resources to be applied on a phase of the setup process.
+// The patch is applied at the end of its Phase or, if it is anchored with After, as soon as its component or dogu is
+// ready.
 // For namespaced resources, the namespace of the setup is inferred.
 type ResourcePatch struct {
-	// Phase is a sequential step in the setup process.
-	Phase Phase `json:"phase" yaml:"phase"`
+	// Phase is a sequential step in the setup process. Either Phase or After must be set.
+	// +optional
+	Phase Phase `json:"phase,omitempty" yaml:"phase,omitempty"`
+	// After anchors the patch to a single component or dogu. Either Phase or After must be set.
+	// +optional
+	After *PatchAnchor `json:"after,omitempty" yaml:"after,omitempty"`
+	// Type selects how the resource is patched. Defaults to JsonPatchType.
+	// +optional
+	Type PatchType `json:"type,omitempty" yaml:"type,omitempty"`
 	// ResourceReference uniquely identifies a kubernetes resource that should be patched.
 	Resource ResourceReference `json:"resource" yaml:"resource"`
 	// Patches contains a series of operations to be applied on the specified kubernetes resource.
-	Patches []JsonPatch `json:"patches" yaml:"patches"`
+	// It is used by the JsonPatchType.
+	// +optional
+	Patches []JsonPatch `json:"patches,omitempty" yaml:"patches,omitempty"`
+	// Patch contains a partial document of the kubernetes resource which is merged into it.
+	// It is used by the MergePatchType and the StrategicMergePatchType.
+	// +optional
+	Patch map[string]any `json:"patch,omitempty" yaml:"patch,omitempty"`
+	// WaitFor waits until the resource exists and optionally fulfills a condition before it is patched.
+	// +optional
+	WaitFor *WaitFor `json:"waitFor,omitempty" yaml:"waitFor,omitempty"`
+	// Optional turns a missing resource into a warning instead of failing the setup.
+	// +optional
+	Optional bool `json:"optional,omitempty" yaml:"optional,omitempty"`
+	// When contains conditions which must be fulfilled when the patch is applied; otherwise, it is skipped.
+	// +optional
+	When *Condition `json:"when,omitempty" yaml:"when,omitempty"`
+}
+
+// PatchAnchor identifies the component or dogu after which a patch is applied.
+type PatchAnchor struct {
+	// Component contains the name of a component of the setup configuration, f. i. "k8s-longhorn". Either Component
+	// or Dogu must be set.
+	// +optional
+	Component string `json:"component,omitempty" yaml:"component,omitempty"`
+	// Dogu contains the simple name of a dogu of the setup, f. i. "ldap". Either Component or Dogu must be set.
+	// +optional
+	Dogu string `json:"dogu,omitempty" yaml:"dogu,omitempty"`
+}
+
+// Phase returns the phase containing the patches anchored to the component or dogu.
+func (pa PatchAnchor) Phase() Phase {
+	if pa.Component != "" {
+		return AfterComponentPhase(pa.Component)
+	}
+
+	return AfterDoguPhase(pa.Dogu)
+}
+
+// AfterComponentPhase returns the phase which is performed as soon as the component with the given name is ready.
+func AfterComponentPhase(componentName string) Phase {
+	return Phase(fmt.Sprintf("after-component/%s", componentName))
+}
+
+// AfterDoguPhase returns the phase which is performed as soon as the dogu with the given simple name is ready.
+func AfterDoguPhase(doguName string) Phase {
+	return Phase(fmt.Sprintf("after-dogu/%s", doguName))
+}
+
+// defaultWaitForTimeout is used if WaitFor does not contain a timeout.
+const defaultWaitForTimeout = 5 * time.Minute
+
+// WaitFor describes how long a patch waits for its resource and which condition the resource has to fulfill.
+type WaitFor struct {
+	// TimeoutSeconds limits the time to wait for the resource. Defaults to 300 seconds.
+	// +optional
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty" yaml:"timeoutSeconds,omitempty"`
+	// Condition contains the type of a status condition, f. i. "Available", whose status has to be "True".
+	// +optional
+	Condition string `json:"condition,omitempty" yaml:"condition,omitempty"`
+}
+
+// Timeout returns the time to wait for the resource.
+func (wf *WaitFor) Timeout() time.Duration {
+	if wf.TimeoutSeconds > 0 {
+		return time.Duration(wf.TimeoutSeconds) * time.Second
+	}
+
+	return defaultWaitForTimeout
+}
+
+// PatchType describes how a kubernetes resource is patched.
+type PatchType string
+
+const (
+	// JsonPatchType patches the resource with a series of operations according to RFC 6902.
+	JsonPatchType PatchType = "json"
+	// MergePatchType merges a partial document into the resource according to RFC 7386.
+	MergePatchType PatchType = "merge"
+	// StrategicMergePatchType merges a partial document into the resource and respects the merge strategies of lists
+	// defined by kubernetes. It is only supported for built-in kubernetes resources.
+	StrategicMergePatchType PatchType = "strategic"
+)
+
+// GetType returns the type of the patch and defaults to the JsonPatchType.
+func (rp *ResourcePatch) GetType() PatchType {
+	if rp.Type == "" {
+		return JsonPatchType
+	}
+
+	return rp.Type
+}
+
+// hasTestOperation checks whether the patch is a JSON patch containing a test operation.
+func (rp *ResourcePatch) hasTestOperation() bool {
+	if rp.GetType() != JsonPatchType {
+		return false
+	}
+
+	for _, jsonPatch := range rp.Patches {
+		if jsonPatch.Operation == testOperation {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetPhase returns the phase in which the patch is applied. For anchored patches, this is the phase of the anchor.
+func (rp *ResourcePatch) GetPhase() Phase {
+	if rp.After != nil {
+		return rp.After.Phase()
+	}
+
+	return rp.Phase
 }
 
 func (rp *ResourcePatch) Validate() error {
 	var errs []error
 
-	if !existsPhase(rp.Phase) {
+	switch {
+	case rp.After != nil && rp.Phase != "":
+		errs = append(errs, fmt.Errorf("patch must contain either a phase or an after anchor but found phase '%s' and %s", rp.Phase, rp.After))
+	case rp.After != nil:
+		errs = append(errs, rp.After.Validate())
+	case !existsPhase(rp.Phase):
 		errs = append(errs, fmt.Errorf("phase '%s' does not exist", rp.Phase))
 	}
 
-	if rp.Resource.Kind == "" {
-		errs = append(errs, fmt.Errorf("resource kind must not be empty"))
+	errs = append(errs, rp.Resource.Validate())
+
+	errs = append(errs, rp.validateTemplates())
+
+	if rp.WaitFor != nil && rp.WaitFor.TimeoutSeconds < 0 {
+		errs = append(errs, fmt.Errorf("timeout of waitFor must not be negative"))
 	}
 
-	if rp.Resource.Name == "" {
-		errs = append(errs, fmt.Errorf("resource name must not be empty"))
+	if rp.When != nil {
+		errs = append(errs, rp.When.Validate())
+	}
+
+	switch rp.GetType() {
+	case JsonPatchType:
+		errs = append(errs, rp.validateJsonPatches())
+	case MergePatchType:
+		errs = append(errs, rp.validateDocumentPatch())
+	case StrategicMergePatchType:
+		errs = append(errs, rp.validateDocumentPatch())
+		if rp.Resource.Kind != "" && !scheme.Scheme.Recognizes(rp.Resource.GroupVersionKind()) {
+			errs = append(errs, fmt.Errorf("patch type '%s' is only supported for built-in resources but found kind '%s' of api version '%s'", rp.Type, rp.Resource.Kind, rp.Resource.ApiVersion))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("patch type '%s' does not exist; use one of %s, %s or %s", rp.Type, JsonPatchType, MergePatchType, StrategicMergePatchType))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (rp *ResourcePatch) validateJsonPatches() error {
+	var errs []error
+
+	if len(rp.Patch) > 0 {
+		errs = append(errs, fmt.Errorf("patch type '%s' takes a list of patches but a patch document was provided", JsonPatchType))
 	}
 
 	if len(rp.Patches) == 0 {
@@ -48,13 +208,41 @@ func (rp *ResourcePatch) Validate() error {
 	return errors.Join(errs...)
 }
 
+func (rp *ResourcePatch) validateDocumentPatch() error {
+	var errs []error
+
+	if len(rp.Patches) > 0 {
+		errs = append(errs, fmt.Errorf("patch type '%s' takes a patch document but a list of patches was provided", rp.Type))
+	}
+
+	if len(rp.Patch) == 0 {
+		errs = append(errs, fmt.Errorf("no patch document found for patch type '%s' which is a sign of a misconfiguration", rp.Type))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate checks that the PatchAnchor references either a component or a dogu.
+func (pa PatchAnchor) Validate() error {
+	if (pa.Component == "") == (pa.Dogu == "") {
+		return fmt.Errorf("after anchor must reference either a component or a dogu")
+	}
+
+	return nil
+}
+
+// String returns a short human-readable description of the anchor.
+func (pa PatchAnchor) String() string {
+	if pa.Component != "" {
+		return fmt.Sprintf("after component '%s'", pa.Component)
+	}
+
+	return fmt.Sprintf("after dogu '%s'", pa.Dogu)
+}
+
 func existsPhase(phase Phase) bool {
 	switch phase {
-	case ComponentPhase:
-		fallthrough
-	case DoguPhase:
-		fallthrough
-	case LoadbalancerPhase:
+	case PreValidationPhase, LoadbalancerPhase, DataPhase, ComponentPhase, DoguPhase, PostSetupPhase:
 		return true
 	default:
 		return false
@@ -65,22 +253,83 @@ func existsPhase(phase Phase) bool {
 type Phase string
 
 const (
+	// PreValidationPhase is the step before the configuration of the setup will be validated.
+	PreValidationPhase Phase = "pre-validation"
+	// DataPhase is the step where the configuration of the setup will be written into the registry.
+	DataPhase Phase = "data"
 	// ComponentPhase is the step where components will be installed.
 	ComponentPhase Phase = "component"
 	// DoguPhase is the step where dogus will be installed.
 	DoguPhase Phase = "dogu"
 	// LoadbalancerPhase is the step where the external loadbalancer for the Cloudogu EcoSystem will be created.
 	LoadbalancerPhase Phase = "loadbalancer"
+	// PostSetupPhase is the step after all stages and hooks of the setup.
+	PostSetupPhase Phase = "post-setup"
 )
 
-// ResourceReference uniquely identifies a kubernetes resource.
+// ResourceReference identifies either a single kubernetes resource by its name or all resources of a kind that match a
+// label selector.
 type ResourceReference struct {
 	// ApiVersion contains the group and version of the resource.
 	ApiVersion string `json:"apiVersion" yaml:"apiVersion"`
 	// Kind contains the resource type.
 	Kind string `json:"kind" yaml:"kind"`
-	// Name contains the name of the resource.
-	Name string `json:"name" yaml:"name"`
+	// Name contains the name of the resource. Either Name or LabelSelector must be set.
+	// +optional
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Namespace contains the namespace of namespaced resources. Defaults to the namespace of the setup.
+	// +optional
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// LabelSelector selects all resources of the kind whose labels match, f. i. "app.kubernetes.io/part-of=ces".
+	// Either Name or LabelSelector must be set.
+	// +optional
+	LabelSelector string `json:"labelSelector,omitempty" yaml:"labelSelector,omitempty"`
+}
+
+// Validate checks the ResourceReference for errors.
+func (r ResourceReference) Validate() error {
+	var errs []error
+
+	if r.Kind == "" {
+		errs = append(errs, fmt.Errorf("resource kind must not be empty"))
+	}
+
+	if r.Name == "" && r.LabelSelector == "" {
+		errs = append(errs, fmt.Errorf("resource name must not be empty"))
+	}
+
+	if r.Name != "" && r.LabelSelector != "" {
+		errs = append(errs, fmt.Errorf("resource must contain either a name or a label selector"))
+	}
+
+	if r.LabelSelector != "" {
+		_, err := labels.Parse(r.LabelSelector)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("label selector '%s' is invalid: %w", r.LabelSelector, err))
+		}
+	}
+
+	if r.Namespace != "" {
+		for _, msg := range validation.IsDNS1123Label(r.Namespace) {
+			errs = append(errs, fmt.Errorf("namespace '%s' is invalid: %s", r.Namespace, msg))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// String returns a short human-readable description of the referenced resources.
+func (r ResourceReference) String() string {
+	description := fmt.Sprintf("%s/%s %s", r.ApiVersion, r.Kind, r.Name)
+	if r.LabelSelector != "" {
+		description = fmt.Sprintf("%s/%s with labels %s", r.ApiVersion, r.Kind, r.LabelSelector)
+	}
+
+	if r.Namespace != "" {
+		description = fmt.Sprintf("%s in namespace %s", description, r.Namespace)
+	}
+
+	return description
 }
 
 func (r ResourceReference) GroupVersionKind() schema.GroupVersionKind {
@@ -107,27 +356,133 @@ const (
 	addOperation     JsonPatchOperation = "add"
 	removeOperation  JsonPatchOperation = "remove"
 	replaceOperation JsonPatchOperation = "replace"
+	moveOperation    JsonPatchOperation = "move"
+	copyOperation    JsonPatchOperation = "copy"
+	testOperation    JsonPatchOperation = "test"
 )
 
 // JsonPatch describes a single operation on a kubernetes resource.
 type JsonPatch struct {
 	// Operation describes how a json object should be modified.
 	Operation JsonPatchOperation `yaml:"op" json:"op"`
+	// From contains a JSON pointer to the value that should be moved or copied by the operations 'move' and 'copy'.
+	// +optional
+	From string `yaml:"from,omitempty" json:"from,omitempty"`
 	// Path contains a JSON pointer to the value that should be modified.
 	// If keys contain '/' or '~', those characters have to be replaced with '~1' and '~0' respectively.
 	Path string `yaml:"path" json:"path"`
-	// Value contains the value that should be inserted at the specified path.
+	// Value contains the value that should be inserted at the specified path or, for the operation 'test', the value
+	// that is expected at the specified path. If the test fails, the whole patch is skipped.
 	Value any `yaml:"value,omitempty" json:"value,omitempty"`
+	// nullValue is true if the value is explicitly set to null, f. i. to test that a path contains null.
+	nullValue bool
+}
+
+// UnmarshalJSON decodes the JsonPatch strictly like the configuration containing it and remembers whether the value
+// is explicitly set to null.
+func (j *JsonPatch) UnmarshalJSON(data []byte) error {
+	type plainJsonPatch JsonPatch
+	var decoded plainJsonPatch
+	strictErrs, err := sigsjson.UnmarshalStrict(data, &decoded)
+	if err != nil {
+		return err
+	}
+	if len(strictErrs) > 0 {
+		return fmt.Errorf("invalid json patch: %w", errors.Join(strictErrs...))
+	}
+
+	if decoded.Value == nil {
+		var fields map[string]json.RawMessage
+		err = json.Unmarshal(data, &fields)
+		if err != nil {
+			return err
+		}
+		_, decoded.nullValue = fields["value"]
+	}
+
+	*j = JsonPatch(decoded)
+	return nil
+}
+
+// MarshalJSON encodes the JsonPatch and keeps a value which is explicitly set to null.
+func (j JsonPatch) MarshalJSON() ([]byte, error) {
+	type plainJsonPatch JsonPatch
+	if !j.nullValue {
+		return json.Marshal(plainJsonPatch(j))
+	}
+
+	return json.Marshal(struct {
+		plainJsonPatch
+		Value any `json:"value"`
+	}{plainJsonPatch: plainJsonPatch(j)})
 }
 
 // Validate checks the JsonPatch for errors.
 func (j JsonPatch) Validate() error {
-	if j.Value == nil && (j.Operation == addOperation || j.Operation == replaceOperation) {
-		return fmt.Errorf("value must not be empty for operation '%s' on path '%s'", j.Operation, j.Path)
+	var errs []error
+
+	switch j.Operation {
+	case addOperation, replaceOperation, testOperation:
+		if j.Value == nil && !j.nullValue {
+			errs = append(errs, fmt.Errorf("value must not be empty for operation '%s' on path '%s'", j.Operation, j.Path))
+		}
+	case removeOperation, moveOperation, copyOperation:
+		if j.Value != nil {
+			errs = append(errs, fmt.Errorf("the operation '%s' on path '%s' does not take a value but it was provided: '%s'", j.Operation, j.Path, j.Value))
+		}
+	default:
+		return fmt.Errorf("operation '%s' on path '%s' does not exist; use one of add, remove, replace, move, copy or test", j.Operation, j.Path)
+	}
+
+	errs = append(errs, validateJsonPointer("path", j.Path))
+	errs = append(errs, j.validateFrom())
+
+	return errors.Join(errs...)
+}
+
+func (j JsonPatch) validateFrom() error {
+	if j.Operation != moveOperation && j.Operation != copyOperation {
+		if j.From != "" {
+			return fmt.Errorf("the operation '%s' on path '%s' does not take a from pointer but it was provided: '%s'", j.Operation, j.Path, j.From)
+		}
+		return nil
 	}
 
-	if j.Value != nil && j.Operation == removeOperation {
-		return fmt.Errorf("the operation '%s' on path '%s' does not take a value but it was provided: '%s'", j.Operation, j.Path, j.Value)
+	if j.From == "" {
+		return fmt.Errorf("from must not be empty for operation '%s' on path '%s'", j.Operation, j.Path)
+	}
+
+	err := validateJsonPointer("from", j.From)
+	if err != nil {
+		return err
+	}
+
+	// a value cannot be moved into one of its children
+	if j.Operation == moveOperation && strings.HasPrefix(j.Path, j.From+"/") {
+		return fmt.Errorf("the operation '%s' cannot move '%s' into its child '%s'", j.Operation, j.From, j.Path)
+	}
+
+	return nil
+}
+
+// validateJsonPointer checks the given pointer for the syntax of RFC 6901. The empty pointer references the whole
+// resource.
+func validateJsonPointer(field string, pointer string) error {
+	if pointer == "" {
+		return nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return fmt.Errorf("%s '%s' is not a valid JSON pointer: it must start with '/'", field, pointer)
+	}
+
+	for i := 0; i < len(pointer); i++ {
+		if pointer[i] != '~' {
+			continue
+		}
+		if i+1 == len(pointer) || (pointer[i+1] != '0' && pointer[i+1] != '1') {
+			return fmt.Errorf("%s '%s' is not a valid JSON pointer: '~' must be escaped as '~0' and '/' within keys as '~1'", field, pointer)
+		}
 	}
 
 	return nil
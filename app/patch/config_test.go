@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -85,6 +86,14 @@ func TestJsonPatch_unmarshalling(t *testing.T) {
 				Path:      "/spec/loadBalancerIP",
 			},
 		},
+		{
+			jsonRepresentation: []byte(`{"op": "test", "path": "/spec/loadBalancerIP", "value": null}`),
+			objectRepresentation: JsonPatch{
+				Operation: "test",
+				Path:      "/spec/loadBalancerIP",
+				nullValue: true,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(string(tt.jsonRepresentation), func(t *testing.T) {
@@ -96,6 +105,20 @@ func TestJsonPatch_unmarshalling(t *testing.T) {
 	}
 }
 
+func TestJsonPatch_UnmarshalJSON(t *testing.T) {
+	t.Run("should reject unknown keys", func(t *testing.T) {
+		// given
+		var actual JsonPatch
+
+		// when
+		err := json.Unmarshal([]byte(`{"op": "test", "path": "/spec/type", "vaule": "LoadBalancer"}`), &actual)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, `invalid json patch: unknown field "vaule"`)
+	})
+}
+
 func TestJsonPatch_marshalling(t *testing.T) {
 	tests := []struct {
 		jsonRepresentation   []byte
@@ -128,6 +151,22 @@ func TestJsonPatch_marshalling(t *testing.T) {
 				Path:      "/spec/loadBalancerIP",
 			},
 		},
+		{
+			jsonRepresentation: []byte(`{"op":"test","path":"/spec/loadBalancerIP","value":null}`),
+			objectRepresentation: JsonPatch{
+				Operation: "test",
+				Path:      "/spec/loadBalancerIP",
+				nullValue: true,
+			},
+		},
+		{
+			jsonRepresentation: []byte(`{"op":"move","from":"/spec/oldField","path":"/spec/newField"}`),
+			objectRepresentation: JsonPatch{
+				Operation: "move",
+				From:      "/spec/oldField",
+				Path:      "/spec/newField",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(string(tt.jsonRepresentation), func(t *testing.T) {
@@ -168,11 +207,86 @@ func TestJsonPatch_Validate(t *testing.T) {
 	}
 }
 
+func TestJsonPatch_Validate_operations(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   JsonPatch
+		wantErr string
+	}{
+		{name: "move okay", patch: JsonPatch{Operation: moveOperation, From: "/spec/old", Path: "/spec/new"}},
+		{name: "copy okay", patch: JsonPatch{Operation: copyOperation, From: "/spec/template", Path: "/spec/template/copy"}},
+		{name: "test okay", patch: JsonPatch{Operation: testOperation, Path: "/spec/type", Value: "LoadBalancer"}},
+		{name: "escaped pointer okay", patch: JsonPatch{Operation: removeOperation, Path: "/metadata/annotations/example.com~1key~0"}},
+		{name: "whole resource okay", patch: JsonPatch{Operation: testOperation, Path: "", Value: map[string]any{}}},
+		{name: "test explicit null okay", patch: JsonPatch{Operation: testOperation, Path: "/spec/loadBalancerIP", nullValue: true}},
+		{
+			name:    "unknown operation",
+			patch:   JsonPatch{Operation: "delete", Path: "/spec/type"},
+			wantErr: "operation 'delete' on path '/spec/type' does not exist; use one of add, remove, replace, move, copy or test",
+		},
+		{
+			name:    "test misses value",
+			patch:   JsonPatch{Operation: testOperation, Path: "/spec/type"},
+			wantErr: "value must not be empty for operation 'test' on path '/spec/type'",
+		},
+		{
+			name:    "move misses from",
+			patch:   JsonPatch{Operation: moveOperation, Path: "/spec/new"},
+			wantErr: "from must not be empty for operation 'move' on path '/spec/new'",
+		},
+		{
+			name:    "copy with value",
+			patch:   JsonPatch{Operation: copyOperation, From: "/spec/old", Path: "/spec/new", Value: "value"},
+			wantErr: "the operation 'copy' on path '/spec/new' does not take a value",
+		},
+		{
+			name:    "add with from",
+			patch:   JsonPatch{Operation: addOperation, From: "/spec/old", Path: "/spec/new", Value: "value"},
+			wantErr: "the operation 'add' on path '/spec/new' does not take a from pointer but it was provided: '/spec/old'",
+		},
+		{
+			name:    "move into child",
+			patch:   JsonPatch{Operation: moveOperation, From: "/spec", Path: "/spec/child"},
+			wantErr: "the operation 'move' cannot move '/spec' into its child '/spec/child'",
+		},
+		{
+			name:    "path without leading slash",
+			patch:   JsonPatch{Operation: removeOperation, Path: "spec/type"},
+			wantErr: "path 'spec/type' is not a valid JSON pointer: it must start with '/'",
+		},
+		{
+			name:    "unescaped tilde in path",
+			patch:   JsonPatch{Operation: removeOperation, Path: "/metadata/annotations/a~b"},
+			wantErr: "path '/metadata/annotations/a~b' is not a valid JSON pointer",
+		},
+		{
+			name:    "invalid from",
+			patch:   JsonPatch{Operation: copyOperation, From: "/spec~", Path: "/spec/new"},
+			wantErr: "from '/spec~' is not a valid JSON pointer",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.patch.Validate()
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
 func Test_existsPhase(t *testing.T) {
 	assert.True(t, existsPhase(DoguPhase))
 	assert.True(t, existsPhase(ComponentPhase))
 	assert.True(t, existsPhase(LoadbalancerPhase))
+	assert.True(t, existsPhase(PreValidationPhase))
+	assert.True(t, existsPhase(DataPhase))
+	assert.True(t, existsPhase(PostSetupPhase))
 	assert.False(t, existsPhase("notexisting"))
+	assert.False(t, existsPhase(AfterDoguPhase("ldap")))
 }
 
 func TestResourcePatch_Validate(t *testing.T) {
@@ -189,13 +303,13 @@ func TestResourcePatch_Validate(t *testing.T) {
 		fields  fields
 		wantErr assert.ErrorAssertionFunc
 	}{
-		{"validates", fields{DoguPhase, ResourceReference{"v1", "Pod", "my-pod"}, validPatches}, assert.NoError},
-		{"invalid phase", fields{"typohere", ResourceReference{"v1", "Pod", "my-pod"}, validPatches}, assert.Error},
-		{"invalid patch", fields{DoguPhase, ResourceReference{"v1", "Pod", "my-pod"}, invalidPatches}, assert.Error},
-		{"empty resource reference name", fields{DoguPhase, ResourceReference{"v1", "Pod", ""}, validPatches}, assert.Error},
-		{"empty kind", fields{DoguPhase, ResourceReference{"v1", "", "ignore"}, validPatches}, assert.Error},
-		{"nil patch slice", fields{DoguPhase, ResourceReference{"v1", "Pod", "ignore"}, nil}, assert.Error},
-		{"empty patch slice", fields{DoguPhase, ResourceReference{"v1", "Pod", "ignore"}, []JsonPatch{}}, assert.Error},
+		{"validates", fields{DoguPhase, ResourceReference{ApiVersion: "v1", Kind: "Pod", Name: "my-pod"}, validPatches}, assert.NoError},
+		{"invalid phase", fields{"typohere", ResourceReference{ApiVersion: "v1", Kind: "Pod", Name: "my-pod"}, validPatches}, assert.Error},
+		{"invalid patch", fields{DoguPhase, ResourceReference{ApiVersion: "v1", Kind: "Pod", Name: "my-pod"}, invalidPatches}, assert.Error},
+		{"empty resource reference name", fields{DoguPhase, ResourceReference{ApiVersion: "v1", Kind: "Pod", Name: ""}, validPatches}, assert.Error},
+		{"empty kind", fields{DoguPhase, ResourceReference{ApiVersion: "v1", Kind: "", Name: "ignore"}, validPatches}, assert.Error},
+		{"nil patch slice", fields{DoguPhase, ResourceReference{ApiVersion: "v1", Kind: "Pod", Name: "ignore"}, nil}, assert.Error},
+		{"empty patch slice", fields{DoguPhase, ResourceReference{ApiVersion: "v1", Kind: "Pod", Name: "ignore"}, []JsonPatch{}}, assert.Error},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -208,3 +322,194 @@ func TestResourcePatch_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestResourcePatch_Validate_patchTypes(t *testing.T) {
+	validPatches := []JsonPatch{{Operation: addOperation, Path: "/metadata/annotations", Value: map[string]interface{}{"service.beta.kubernetes.io/azure-load-balancer-internal": "true"}}}
+	validDocument := map[string]any{"metadata": map[string]any{"annotations": map[string]any{"service.beta.kubernetes.io/azure-load-balancer-internal": "true"}}}
+	service := ResourceReference{ApiVersion: "v1", Kind: "Service", Name: "ces-loadbalancer"}
+	dogu := ResourceReference{ApiVersion: "k8s.cloudogu.com/v2", Kind: "Dogu", Name: "nexus"}
+
+	tests := []struct {
+		name     string
+		patch    ResourcePatch
+		wantErrs []string
+	}{
+		{name: "explicit json patch", patch: ResourcePatch{Phase: DoguPhase, Type: JsonPatchType, Resource: service, Patches: validPatches}},
+		{name: "merge patch", patch: ResourcePatch{Phase: DoguPhase, Type: MergePatchType, Resource: dogu, Patch: validDocument}},
+		{name: "strategic merge patch", patch: ResourcePatch{Phase: DoguPhase, Type: StrategicMergePatchType, Resource: service, Patch: validDocument}},
+		{
+			name:     "unknown patch type",
+			patch:    ResourcePatch{Phase: DoguPhase, Type: "apply", Resource: service, Patch: validDocument},
+			wantErrs: []string{"patch type 'apply' does not exist; use one of json, merge or strategic"},
+		},
+		{
+			name:     "json patch with document",
+			patch:    ResourcePatch{Phase: DoguPhase, Resource: service, Patches: validPatches, Patch: validDocument},
+			wantErrs: []string{"patch type 'json' takes a list of patches but a patch document was provided"},
+		},
+		{
+			name:     "merge patch without document",
+			patch:    ResourcePatch{Phase: DoguPhase, Type: MergePatchType, Resource: service, Patches: validPatches},
+			wantErrs: []string{"patch type 'merge' takes a patch document but a list of patches was provided", "no patch document found for patch type 'merge'"},
+		},
+		{
+			name:     "strategic merge patch on custom resource",
+			patch:    ResourcePatch{Phase: DoguPhase, Type: StrategicMergePatchType, Resource: dogu, Patch: validDocument},
+			wantErrs: []string{"patch type 'strategic' is only supported for built-in resources but found kind 'Dogu' of api version 'k8s.cloudogu.com/v2'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.patch.Validate()
+
+			if len(tt.wantErrs) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, wantErr := range tt.wantErrs {
+				assert.ErrorContains(t, err, wantErr)
+			}
+		})
+	}
+}
+
+func TestResourcePatch_GetType(t *testing.T) {
+	assert.Equal(t, JsonPatchType, (&ResourcePatch{}).GetType())
+	assert.Equal(t, MergePatchType, (&ResourcePatch{Type: MergePatchType}).GetType())
+}
+
+func TestResourceReference_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource ResourceReference
+		wantErrs []string
+	}{
+		{name: "name", resource: ResourceReference{ApiVersion: "v1", Kind: "Service", Name: "ces-loadbalancer"}},
+		{name: "label selector in namespace", resource: ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Namespace: "longhorn-system", LabelSelector: "app.kubernetes.io/part-of=ces"}},
+		{
+			name:     "neither name nor label selector",
+			resource: ResourceReference{ApiVersion: "v1", Kind: "Service"},
+			wantErrs: []string{"resource name must not be empty"},
+		},
+		{
+			name:     "name and label selector",
+			resource: ResourceReference{ApiVersion: "v1", Kind: "Service", Name: "ces-loadbalancer", LabelSelector: "app=ces"},
+			wantErrs: []string{"resource must contain either a name or a label selector"},
+		},
+		{
+			name:     "invalid label selector",
+			resource: ResourceReference{ApiVersion: "v1", Kind: "Service", LabelSelector: "app in (ces"},
+			wantErrs: []string{"label selector 'app in (ces' is invalid"},
+		},
+		{
+			name:     "invalid namespace",
+			resource: ResourceReference{ApiVersion: "v1", Kind: "Service", Name: "ces-loadbalancer", Namespace: "Longhorn_System"},
+			wantErrs: []string{"namespace 'Longhorn_System' is invalid"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.resource.Validate()
+
+			if len(tt.wantErrs) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, wantErr := range tt.wantErrs {
+				assert.ErrorContains(t, err, wantErr)
+			}
+		})
+	}
+}
+
+func TestResourceReference_String(t *testing.T) {
+	assert.Equal(t, "v1/Service ces-loadbalancer", ResourceReference{ApiVersion: "v1", Kind: "Service", Name: "ces-loadbalancer"}.String())
+	assert.Equal(t, "apps/v1/Deployment with labels app=ces in namespace longhorn-system", ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Namespace: "longhorn-system", LabelSelector: "app=ces"}.String())
+}
+
+func TestWaitFor_Timeout(t *testing.T) {
+	assert.Equal(t, 5*time.Minute, (&WaitFor{}).Timeout())
+	assert.Equal(t, 30*time.Second, (&WaitFor{TimeoutSeconds: 30}).Timeout())
+}
+
+func TestResourcePatch_Validate_waitFor(t *testing.T) {
+	// given
+	sut := ResourcePatch{
+		Phase:    DoguPhase,
+		Resource: ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Name: "ldap"},
+		Patches:  []JsonPatch{{Operation: removeOperation, Path: "/metadata/labels/test"}},
+		WaitFor:  &WaitFor{TimeoutSeconds: -1},
+	}
+
+	// when
+	err := sut.Validate()
+
+	// then
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "timeout of waitFor must not be negative")
+}
+
+func TestResourcePatch_GetPhase(t *testing.T) {
+	assert.Equal(t, DoguPhase, (&ResourcePatch{Phase: DoguPhase}).GetPhase())
+	assert.Equal(t, Phase("after-component/k8s-longhorn"), (&ResourcePatch{After: &PatchAnchor{Component: "k8s-longhorn"}}).GetPhase())
+	assert.Equal(t, Phase("after-dogu/ldap"), (&ResourcePatch{After: &PatchAnchor{Dogu: "ldap"}}).GetPhase())
+}
+
+func TestResourcePatch_Validate_after(t *testing.T) {
+	resource := ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Name: "ldap"}
+	patches := []JsonPatch{{Operation: removeOperation, Path: "/metadata/labels/test"}}
+
+	t.Run("should accept patch anchored to a dogu", func(t *testing.T) {
+		// given
+		sut := ResourcePatch{After: &PatchAnchor{Dogu: "ldap"}, Resource: resource, Patches: patches}
+
+		// when
+		err := sut.Validate()
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail for patch with phase and anchor", func(t *testing.T) {
+		// given
+		sut := ResourcePatch{Phase: DoguPhase, After: &PatchAnchor{Dogu: "ldap"}, Resource: resource, Patches: patches}
+
+		// when
+		err := sut.Validate()
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "patch must contain either a phase or an after anchor but found phase 'dogu' and after dogu 'ldap'")
+	})
+
+	t.Run("should fail for anchor without or with both component and dogu", func(t *testing.T) {
+		for _, anchor := range []PatchAnchor{{}, {Component: "k8s-longhorn", Dogu: "ldap"}} {
+			// given
+			sut := ResourcePatch{After: &anchor, Resource: resource, Patches: patches}
+
+			// when
+			err := sut.Validate()
+
+			// then
+			require.Error(t, err)
+			assert.ErrorContains(t, err, "after anchor must reference either a component or a dogu")
+		}
+	})
+}
+
+func TestResourcePatch_Validate_when(t *testing.T) {
+	// given
+	sut := ResourcePatch{
+		Phase:    DoguPhase,
+		Resource: ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Name: "ldap"},
+		Patches:  []JsonPatch{{Operation: removeOperation, Path: "/metadata/labels/test"}},
+		When:     &Condition{},
+	}
+
+	// when
+	err := sut.Validate()
+
+	// then
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "condition must contain at least one of doguInstalled, componentConfigured, resourceExists, nodeLabel or setupJson")
+}
@@ -2,9 +2,11 @@ package patch
 
 import (
 	"context"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/meta"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 )
 
@@ -17,6 +19,30 @@ type dynClient interface {
 }
 
 type jsonPatchApplier interface {
-	// Patch applies a JSON patch to a Kubernetes resource.
-	Patch(ctx context.Context, jsonPatch []byte, gvk schema.GroupVersionKind, name string) error
+	// Patch applies a JSON patch, JSON merge patch or strategic merge patch to all referenced Kubernetes resources and
+	// returns the result for every resource.
+	Patch(ctx context.Context, patchType types.PatchType, patch []byte, resource ResourceReference) ([]PatchResult, error)
+	// DryRunPatch sends the patch for all existing referenced Kubernetes resources to the API server without
+	// persisting any change and returns the result for every resource.
+	DryRunPatch(ctx context.Context, patchType types.PatchType, patch []byte, resource ResourceReference) ([]PatchResult, error)
+	// Wait blocks until the referenced Kubernetes resource exists and fulfills the given status condition if it is
+	// not empty.
+	Wait(ctx context.Context, resource ResourceReference, condition string, timeout time.Duration) error
+	// Exists returns true if the referenced Kubernetes resource exists. For a label selector, at least one resource
+	// has to match.
+	Exists(ctx context.Context, resource ResourceReference) (bool, error)
+	// Get returns the Kubernetes resource referenced by its name.
+	Get(ctx context.Context, resource ResourceReference) (*unstructured.Unstructured, error)
+}
+
+type resourceApplier interface {
+	// Apply creates or updates the given object with server-side apply and returns true if it was created.
+	Apply(ctx context.Context, object *unstructured.Unstructured) (bool, error)
+	// Delete removes the given object.
+	Delete(ctx context.Context, object *unstructured.Unstructured) error
+}
+
+type resourceFetcher interface {
+	// GetResourceFileContent gets the bytes from the given resource url.
+	GetResourceFileContent(resourceURL string) ([]byte, error)
 }
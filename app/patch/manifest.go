@@ -0,0 +1,78 @@
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ResourceManifest contains kubernetes resources which are created or updated with server-side apply on a phase of
+// the setup process. The manifest is applied at the end of its Phase before the resource patches of the phase, so
+// that the created resources can be patched.
+// For namespaced resources without a namespace, the namespace of the setup is inferred.
+type ResourceManifest struct {
+	// Phase is a sequential step in the setup process.
+	Phase Phase `json:"phase" yaml:"phase"`
+	// Manifest contains one or more YAML or JSON documents of kubernetes resources. Either Manifest or URL must be set.
+	// +optional
+	Manifest string `json:"manifest,omitempty" yaml:"manifest,omitempty"`
+	// URL references a file with one or more YAML or JSON documents of kubernetes resources. Files from the host of
+	// the dogu registry are fetched with its credentials. Either Manifest or URL must be set.
+	// +optional
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+// Validate checks the ResourceManifest for errors.
+func (rm ResourceManifest) Validate() error {
+	var errs []error
+
+	if !existsPhase(rm.Phase) {
+		errs = append(errs, fmt.Errorf("phase '%s' does not exist", rm.Phase))
+	}
+
+	errs = append(errs, rm.ValidateSource())
+
+	return errors.Join(errs...)
+}
+
+// ValidateSource checks that the ResourceManifest contains either an inline manifest or a valid URL.
+func (rm ResourceManifest) ValidateSource() error {
+	if (rm.Manifest == "") == (rm.URL == "") {
+		return fmt.Errorf("resource manifest must contain either a manifest or an url")
+	}
+
+	if rm.URL == "" {
+		return nil
+	}
+
+	parsedURL, err := url.Parse(rm.URL)
+	if err != nil {
+		return fmt.Errorf("url '%s' of resource manifest is invalid: %w", rm.URL, err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return fmt.Errorf("url '%s' of resource manifest must use the scheme http or https", rm.URL)
+	}
+
+	return nil
+}
+
+// Source returns a short description of the origin of the manifest.
+func (rm ResourceManifest) Source() string {
+	if rm.URL != "" {
+		return rm.URL
+	}
+
+	return "inline manifest"
+}
+
+// InlineObjects decodes the kubernetes resources of the inline manifest. Manifests referenced by an URL are only fetched
+// when they are applied, so no resources are returned for them.
+func (rm ResourceManifest) InlineObjects() ([]*unstructured.Unstructured, error) {
+	if rm.Manifest == "" {
+		return nil, nil
+	}
+
+	return decodeManifest([]byte(rm.Manifest))
+}
@@ -0,0 +1,119 @@
+package patch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const manifestDecoderBufferSize = 4096
+
+type resourceManifestApplier struct {
+	applier resourceApplier
+	fetcher resourceFetcher
+}
+
+// NewResourceManifestApplier creates a new applier for resource manifests. Manifests with an URL are fetched with
+// the given fetcher.
+func NewResourceManifestApplier(applier resourceApplier, fetcher resourceFetcher) *resourceManifestApplier {
+	return &resourceManifestApplier{applier: applier, fetcher: fetcher}
+}
+
+func filterManifestsByPhase(phase Phase, manifests []ResourceManifest) []ResourceManifest {
+	var filtered []ResourceManifest
+	for _, manifest := range manifests {
+		if manifest.Phase == phase {
+			filtered = append(filtered, manifest)
+		}
+	}
+	return filtered
+}
+
+// Apply creates or updates the resources of all manifests of the given phase with server-side apply. It returns the
+// resources which did not exist before so that they can be removed again.
+func (r *resourceManifestApplier) Apply(ctx context.Context, phase Phase, manifests []ResourceManifest) ([]*unstructured.Unstructured, error) {
+	var created []*unstructured.Unstructured
+	var errs []error
+	for _, manifest := range filterManifestsByPhase(phase, manifests) {
+		objects, err := r.readObjects(manifest)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, object := range objects {
+			isNew, err := r.applier.Apply(ctx, object)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to apply resource from %s: %w", manifest.Source(), err))
+				continue
+			}
+			if isNew {
+				created = append(created, object)
+			}
+		}
+	}
+
+	return created, errors.Join(errs...)
+}
+
+// Delete removes the given resources in reverse order.
+func (r *resourceManifestApplier) Delete(ctx context.Context, objects []*unstructured.Unstructured) error {
+	var errs []error
+	for i := len(objects) - 1; i >= 0; i-- {
+		err := r.applier.Delete(ctx, objects[i])
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *resourceManifestApplier) readObjects(manifest ResourceManifest) ([]*unstructured.Unstructured, error) {
+	content := []byte(manifest.Manifest)
+	if manifest.URL != "" {
+		var err error
+		content, err = r.fetcher.GetResourceFileContent(manifest.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch resource manifest: %w", err)
+		}
+	}
+
+	objects, err := decodeManifest(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode resource manifest from %s: %w", manifest.Source(), err)
+	}
+
+	return objects, nil
+}
+
+// decodeManifest splits the given YAML or JSON documents into kubernetes objects. Empty documents are skipped.
+func decodeManifest(content []byte) ([]*unstructured.Unstructured, error) {
+	decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), manifestDecoderBufferSize)
+
+	var objects []*unstructured.Unstructured
+	for {
+		document := map[string]interface{}{}
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(document) == 0 {
+			continue
+		}
+
+		object := &unstructured.Unstructured{Object: document}
+		if object.GetAPIVersion() == "" || object.GetKind() == "" || object.GetName() == "" {
+			return nil, fmt.Errorf("resource must contain apiVersion, kind and metadata.name")
+		}
+		objects = append(objects, object)
+	}
+}
@@ -0,0 +1,146 @@
+package patch
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const testManifest = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+---
+# only a comment
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: second
+  namespace: other
+`
+
+func Test_resourceManifestApplier_Apply(t *testing.T) {
+	t.Run("should apply all resources of the phase and return the created ones", func(t *testing.T) {
+		// given
+		manifests := []ResourceManifest{
+			{Phase: ComponentPhase, Manifest: testManifest},
+			{Phase: DoguPhase, Manifest: "must not be processed"},
+		}
+		applierMock := newMockResourceApplier(t)
+		applierMock.EXPECT().Apply(testCtx, mock.MatchedBy(hasName("first"))).Return(false, nil)
+		applierMock.EXPECT().Apply(testCtx, mock.MatchedBy(hasName("second"))).Return(true, nil)
+		sut := NewResourceManifestApplier(applierMock, nil)
+
+		// when
+		created, err := sut.Apply(testCtx, ComponentPhase, manifests)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, created, 1)
+		assert.Equal(t, "NetworkPolicy", created[0].GetKind())
+		assert.Equal(t, "other", created[0].GetNamespace())
+	})
+
+	t.Run("should apply resources fetched from url", func(t *testing.T) {
+		// given
+		manifests := []ResourceManifest{{Phase: DoguPhase, URL: "https://example.com/manifest.yaml"}}
+		fetcherMock := newMockResourceFetcher(t)
+		fetcherMock.EXPECT().GetResourceFileContent("https://example.com/manifest.yaml").Return([]byte(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"first"}}`), nil)
+		applierMock := newMockResourceApplier(t)
+		applierMock.EXPECT().Apply(testCtx, mock.MatchedBy(hasName("first"))).Return(true, nil)
+		sut := NewResourceManifestApplier(applierMock, fetcherMock)
+
+		// when
+		created, err := sut.Apply(testCtx, DoguPhase, manifests)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, created, 1)
+		assert.Equal(t, "Secret", created[0].GetKind())
+	})
+
+	t.Run("should report all failures and continue with other resources", func(t *testing.T) {
+		// given
+		manifests := []ResourceManifest{
+			{Phase: ComponentPhase, URL: "https://example.com/manifest.yaml"},
+			{Phase: ComponentPhase, Manifest: "kind: ConfigMap"},
+			{Phase: ComponentPhase, Manifest: testManifest},
+		}
+		fetcherMock := newMockResourceFetcher(t)
+		fetcherMock.EXPECT().GetResourceFileContent("https://example.com/manifest.yaml").Return(nil, assert.AnError)
+		applierMock := newMockResourceApplier(t)
+		applierMock.EXPECT().Apply(testCtx, mock.MatchedBy(hasName("first"))).Return(false, errors.New("forbidden"))
+		applierMock.EXPECT().Apply(testCtx, mock.MatchedBy(hasName("second"))).Return(true, nil)
+		sut := NewResourceManifestApplier(applierMock, fetcherMock)
+
+		// when
+		created, err := sut.Apply(testCtx, ComponentPhase, manifests)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to fetch resource manifest")
+		assert.ErrorContains(t, err, "failed to decode resource manifest from inline manifest: resource must contain apiVersion, kind and metadata.name")
+		assert.ErrorContains(t, err, "failed to apply resource from inline manifest: forbidden")
+		assert.Len(t, created, 1)
+	})
+}
+
+func Test_resourceManifestApplier_Delete(t *testing.T) {
+	t.Run("should delete resources in reverse order", func(t *testing.T) {
+		// given
+		first := newTestObject("first")
+		second := newTestObject("second")
+		var deleted []string
+		applierMock := newMockResourceApplier(t)
+		applierMock.EXPECT().Delete(testCtx, mock.Anything).Run(func(_ context.Context, object *unstructured.Unstructured) {
+			deleted = append(deleted, object.GetName())
+		}).Return(nil).Times(2)
+		sut := NewResourceManifestApplier(applierMock, nil)
+
+		// when
+		err := sut.Delete(testCtx, []*unstructured.Unstructured{first, second})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"second", "first"}, deleted)
+	})
+
+	t.Run("should delete remaining resources on error", func(t *testing.T) {
+		// given
+		first := newTestObject("first")
+		second := newTestObject("second")
+		applierMock := newMockResourceApplier(t)
+		applierMock.EXPECT().Delete(testCtx, second).Return(assert.AnError)
+		applierMock.EXPECT().Delete(testCtx, first).Return(nil)
+		sut := NewResourceManifestApplier(applierMock, nil)
+
+		// when
+		err := sut.Delete(testCtx, []*unstructured.Unstructured{first, second})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func hasName(name string) func(*unstructured.Unstructured) bool {
+	return func(object *unstructured.Unstructured) bool {
+		return object.GetName() == name
+	}
+}
+
+func newTestObject(name string) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{}
+	object.SetAPIVersion("v1")
+	object.SetKind("ConfigMap")
+	object.SetName(name)
+	return object
+}
@@ -0,0 +1,56 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceManifest_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest ResourceManifest
+		wantErrs []string
+	}{
+		{name: "inline manifest", manifest: ResourceManifest{Phase: ComponentPhase, Manifest: "kind: ConfigMap"}},
+		{name: "url", manifest: ResourceManifest{Phase: DoguPhase, URL: "https://example.com/manifest.yaml"}},
+		{
+			name:     "unknown phase",
+			manifest: ResourceManifest{Phase: "unknown", Manifest: "kind: ConfigMap"},
+			wantErrs: []string{"phase 'unknown' does not exist"},
+		},
+		{
+			name:     "neither manifest nor url",
+			manifest: ResourceManifest{Phase: ComponentPhase},
+			wantErrs: []string{"resource manifest must contain either a manifest or an url"},
+		},
+		{
+			name:     "manifest and url",
+			manifest: ResourceManifest{Phase: ComponentPhase, Manifest: "kind: ConfigMap", URL: "https://example.com"},
+			wantErrs: []string{"resource manifest must contain either a manifest or an url"},
+		},
+		{
+			name:     "unsupported scheme",
+			manifest: ResourceManifest{Phase: ComponentPhase, URL: "file:///etc/passwd"},
+			wantErrs: []string{"url 'file:///etc/passwd' of resource manifest must use the scheme http or https"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.manifest.Validate()
+
+			if len(tt.wantErrs) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, wantErr := range tt.wantErrs {
+				assert.ErrorContains(t, err, wantErr)
+			}
+		})
+	}
+}
+
+func TestResourceManifest_Source(t *testing.T) {
+	assert.Equal(t, "inline manifest", ResourceManifest{Manifest: "kind: ConfigMap"}.Source())
+	assert.Equal(t, "https://example.com/manifest.yaml", ResourceManifest{URL: "https://example.com/manifest.yaml"}.Source())
+}
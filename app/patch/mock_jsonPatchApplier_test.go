@@ -4,9 +4,13 @@ package patch
 
 import (
 	context "context"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
-	schema "k8s.io/apimachinery/pkg/runtime/schema"
+
+	types "k8s.io/apimachinery/pkg/types"
+
+	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // mockJsonPatchApplier is an autogenerated mock type for the jsonPatchApplier type
@@ -22,22 +26,211 @@ func (_m *mockJsonPatchApplier) EXPECT() *mockJsonPatchApplier_Expecter {
 	return &mockJsonPatchApplier_Expecter{mock: &_m.Mock}
 }
 
-// Patch provides a mock function with given fields: ctx, jsonPatch, gvk, name
-func (_m *mockJsonPatchApplier) Patch(ctx context.Context, jsonPatch []byte, gvk schema.GroupVersionKind, name string) error {
-	ret := _m.Called(ctx, jsonPatch, gvk, name)
+// DryRunPatch provides a mock function with given fields: ctx, patchType, patch, resource
+func (_m *mockJsonPatchApplier) DryRunPatch(ctx context.Context, patchType types.PatchType, patch []byte, resource ResourceReference) ([]PatchResult, error) {
+	ret := _m.Called(ctx, patchType, patch, resource)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DryRunPatch")
+	}
+
+	var r0 []PatchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, types.PatchType, []byte, ResourceReference) ([]PatchResult, error)); ok {
+		return rf(ctx, patchType, patch, resource)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, types.PatchType, []byte, ResourceReference) []PatchResult); ok {
+		r0 = rf(ctx, patchType, patch, resource)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]PatchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, types.PatchType, []byte, ResourceReference) error); ok {
+		r1 = rf(ctx, patchType, patch, resource)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// mockJsonPatchApplier_DryRunPatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DryRunPatch'
+type mockJsonPatchApplier_DryRunPatch_Call struct {
+	*mock.Call
+}
+
+// DryRunPatch is a helper method to define mock.On call
+//   - ctx context.Context
+//   - patchType types.PatchType
+//   - patch []byte
+//   - resource ResourceReference
+func (_e *mockJsonPatchApplier_Expecter) DryRunPatch(ctx interface{}, patchType interface{}, patch interface{}, resource interface{}) *mockJsonPatchApplier_DryRunPatch_Call {
+	return &mockJsonPatchApplier_DryRunPatch_Call{Call: _e.mock.On("DryRunPatch", ctx, patchType, patch, resource)}
+}
+
+func (_c *mockJsonPatchApplier_DryRunPatch_Call) Run(run func(ctx context.Context, patchType types.PatchType, patch []byte, resource ResourceReference)) *mockJsonPatchApplier_DryRunPatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.PatchType), args[2].([]byte), args[3].(ResourceReference))
+	})
+	return _c
+}
+
+func (_c *mockJsonPatchApplier_DryRunPatch_Call) Return(_a0 []PatchResult, _a1 error) *mockJsonPatchApplier_DryRunPatch_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *mockJsonPatchApplier_DryRunPatch_Call) RunAndReturn(run func(context.Context, types.PatchType, []byte, ResourceReference) ([]PatchResult, error)) *mockJsonPatchApplier_DryRunPatch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Exists provides a mock function with given fields: ctx, resource
+func (_m *mockJsonPatchApplier) Exists(ctx context.Context, resource ResourceReference) (bool, error) {
+	ret := _m.Called(ctx, resource)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Exists")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, ResourceReference) (bool, error)); ok {
+		return rf(ctx, resource)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, ResourceReference) bool); ok {
+		r0 = rf(ctx, resource)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, ResourceReference) error); ok {
+		r1 = rf(ctx, resource)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// mockJsonPatchApplier_Exists_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Exists'
+type mockJsonPatchApplier_Exists_Call struct {
+	*mock.Call
+}
+
+// Exists is a helper method to define mock.On call
+//   - ctx context.Context
+//   - resource ResourceReference
+func (_e *mockJsonPatchApplier_Expecter) Exists(ctx interface{}, resource interface{}) *mockJsonPatchApplier_Exists_Call {
+	return &mockJsonPatchApplier_Exists_Call{Call: _e.mock.On("Exists", ctx, resource)}
+}
+
+func (_c *mockJsonPatchApplier_Exists_Call) Run(run func(ctx context.Context, resource ResourceReference)) *mockJsonPatchApplier_Exists_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(ResourceReference))
+	})
+	return _c
+}
+
+func (_c *mockJsonPatchApplier_Exists_Call) Return(_a0 bool, _a1 error) *mockJsonPatchApplier_Exists_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *mockJsonPatchApplier_Exists_Call) RunAndReturn(run func(context.Context, ResourceReference) (bool, error)) *mockJsonPatchApplier_Exists_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Get provides a mock function with given fields: ctx, resource
+func (_m *mockJsonPatchApplier) Get(ctx context.Context, resource ResourceReference) (*unstructured.Unstructured, error) {
+	ret := _m.Called(ctx, resource)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 *unstructured.Unstructured
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, ResourceReference) (*unstructured.Unstructured, error)); ok {
+		return rf(ctx, resource)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, ResourceReference) *unstructured.Unstructured); ok {
+		r0 = rf(ctx, resource)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*unstructured.Unstructured)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, ResourceReference) error); ok {
+		r1 = rf(ctx, resource)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// mockJsonPatchApplier_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type mockJsonPatchApplier_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - ctx context.Context
+//   - resource ResourceReference
+func (_e *mockJsonPatchApplier_Expecter) Get(ctx interface{}, resource interface{}) *mockJsonPatchApplier_Get_Call {
+	return &mockJsonPatchApplier_Get_Call{Call: _e.mock.On("Get", ctx, resource)}
+}
+
+func (_c *mockJsonPatchApplier_Get_Call) Run(run func(ctx context.Context, resource ResourceReference)) *mockJsonPatchApplier_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(ResourceReference))
+	})
+	return _c
+}
+
+func (_c *mockJsonPatchApplier_Get_Call) Return(_a0 *unstructured.Unstructured, _a1 error) *mockJsonPatchApplier_Get_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *mockJsonPatchApplier_Get_Call) RunAndReturn(run func(context.Context, ResourceReference) (*unstructured.Unstructured, error)) *mockJsonPatchApplier_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Patch provides a mock function with given fields: ctx, patchType, patch, resource
+func (_m *mockJsonPatchApplier) Patch(ctx context.Context, patchType types.PatchType, patch []byte, resource ResourceReference) ([]PatchResult, error) {
+	ret := _m.Called(ctx, patchType, patch, resource)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Patch")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, []byte, schema.GroupVersionKind, string) error); ok {
-		r0 = rf(ctx, jsonPatch, gvk, name)
+	var r0 []PatchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, types.PatchType, []byte, ResourceReference) ([]PatchResult, error)); ok {
+		return rf(ctx, patchType, patch, resource)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, types.PatchType, []byte, ResourceReference) []PatchResult); ok {
+		r0 = rf(ctx, patchType, patch, resource)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]PatchResult)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, types.PatchType, []byte, ResourceReference) error); ok {
+		r1 = rf(ctx, patchType, patch, resource)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // mockJsonPatchApplier_Patch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Patch'
@@ -47,26 +240,75 @@ type mockJsonPatchApplier_Patch_Call struct {
 
 // Patch is a helper method to define mock.On call
 //   - ctx context.Context
-//   - jsonPatch []byte
-//   - gvk schema.GroupVersionKind
-//   - name string
-func (_e *mockJsonPatchApplier_Expecter) Patch(ctx interface{}, jsonPatch interface{}, gvk interface{}, name interface{}) *mockJsonPatchApplier_Patch_Call {
-	return &mockJsonPatchApplier_Patch_Call{Call: _e.mock.On("Patch", ctx, jsonPatch, gvk, name)}
+//   - patchType types.PatchType
+//   - patch []byte
+//   - resource ResourceReference
+func (_e *mockJsonPatchApplier_Expecter) Patch(ctx interface{}, patchType interface{}, patch interface{}, resource interface{}) *mockJsonPatchApplier_Patch_Call {
+	return &mockJsonPatchApplier_Patch_Call{Call: _e.mock.On("Patch", ctx, patchType, patch, resource)}
+}
+
+func (_c *mockJsonPatchApplier_Patch_Call) Run(run func(ctx context.Context, patchType types.PatchType, patch []byte, resource ResourceReference)) *mockJsonPatchApplier_Patch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.PatchType), args[2].([]byte), args[3].(ResourceReference))
+	})
+	return _c
+}
+
+func (_c *mockJsonPatchApplier_Patch_Call) Return(_a0 []PatchResult, _a1 error) *mockJsonPatchApplier_Patch_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *mockJsonPatchApplier_Patch_Call) RunAndReturn(run func(context.Context, types.PatchType, []byte, ResourceReference) ([]PatchResult, error)) *mockJsonPatchApplier_Patch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Wait provides a mock function with given fields: ctx, resource, condition, timeout
+func (_m *mockJsonPatchApplier) Wait(ctx context.Context, resource ResourceReference, condition string, timeout time.Duration) error {
+	ret := _m.Called(ctx, resource, condition, timeout)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Wait")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, ResourceReference, string, time.Duration) error); ok {
+		r0 = rf(ctx, resource, condition, timeout)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// mockJsonPatchApplier_Wait_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Wait'
+type mockJsonPatchApplier_Wait_Call struct {
+	*mock.Call
+}
+
+// Wait is a helper method to define mock.On call
+//   - ctx context.Context
+//   - resource ResourceReference
+//   - condition string
+//   - timeout time.Duration
+func (_e *mockJsonPatchApplier_Expecter) Wait(ctx interface{}, resource interface{}, condition interface{}, timeout interface{}) *mockJsonPatchApplier_Wait_Call {
+	return &mockJsonPatchApplier_Wait_Call{Call: _e.mock.On("Wait", ctx, resource, condition, timeout)}
 }
 
-func (_c *mockJsonPatchApplier_Patch_Call) Run(run func(ctx context.Context, jsonPatch []byte, gvk schema.GroupVersionKind, name string)) *mockJsonPatchApplier_Patch_Call {
+func (_c *mockJsonPatchApplier_Wait_Call) Run(run func(ctx context.Context, resource ResourceReference, condition string, timeout time.Duration)) *mockJsonPatchApplier_Wait_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].([]byte), args[2].(schema.GroupVersionKind), args[3].(string))
+		run(args[0].(context.Context), args[1].(ResourceReference), args[2].(string), args[3].(time.Duration))
 	})
 	return _c
 }
 
-func (_c *mockJsonPatchApplier_Patch_Call) Return(_a0 error) *mockJsonPatchApplier_Patch_Call {
+func (_c *mockJsonPatchApplier_Wait_Call) Return(_a0 error) *mockJsonPatchApplier_Wait_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *mockJsonPatchApplier_Patch_Call) RunAndReturn(run func(context.Context, []byte, schema.GroupVersionKind, string) error) *mockJsonPatchApplier_Patch_Call {
+func (_c *mockJsonPatchApplier_Wait_Call) RunAndReturn(run func(context.Context, ResourceReference, string, time.Duration) error) *mockJsonPatchApplier_Wait_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,141 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package patch
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// mockResourceApplier is an autogenerated mock type for the resourceApplier type
+type mockResourceApplier struct {
+	mock.Mock
+}
+
+type mockResourceApplier_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockResourceApplier) EXPECT() *mockResourceApplier_Expecter {
+	return &mockResourceApplier_Expecter{mock: &_m.Mock}
+}
+
+// Apply provides a mock function with given fields: ctx, object
+func (_m *mockResourceApplier) Apply(ctx context.Context, object *unstructured.Unstructured) (bool, error) {
+	ret := _m.Called(ctx, object)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Apply")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *unstructured.Unstructured) (bool, error)); ok {
+		return rf(ctx, object)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *unstructured.Unstructured) bool); ok {
+		r0 = rf(ctx, object)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *unstructured.Unstructured) error); ok {
+		r1 = rf(ctx, object)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// mockResourceApplier_Apply_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Apply'
+type mockResourceApplier_Apply_Call struct {
+	*mock.Call
+}
+
+// Apply is a helper method to define mock.On call
+//   - ctx context.Context
+//   - object *unstructured.Unstructured
+func (_e *mockResourceApplier_Expecter) Apply(ctx interface{}, object interface{}) *mockResourceApplier_Apply_Call {
+	return &mockResourceApplier_Apply_Call{Call: _e.mock.On("Apply", ctx, object)}
+}
+
+func (_c *mockResourceApplier_Apply_Call) Run(run func(ctx context.Context, object *unstructured.Unstructured)) *mockResourceApplier_Apply_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*unstructured.Unstructured))
+	})
+	return _c
+}
+
+func (_c *mockResourceApplier_Apply_Call) Return(_a0 bool, _a1 error) *mockResourceApplier_Apply_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *mockResourceApplier_Apply_Call) RunAndReturn(run func(context.Context, *unstructured.Unstructured) (bool, error)) *mockResourceApplier_Apply_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Delete provides a mock function with given fields: ctx, object
+func (_m *mockResourceApplier) Delete(ctx context.Context, object *unstructured.Unstructured) error {
+	ret := _m.Called(ctx, object)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *unstructured.Unstructured) error); ok {
+		r0 = rf(ctx, object)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// mockResourceApplier_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type mockResourceApplier_Delete_Call struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - ctx context.Context
+//   - object *unstructured.Unstructured
+func (_e *mockResourceApplier_Expecter) Delete(ctx interface{}, object interface{}) *mockResourceApplier_Delete_Call {
+	return &mockResourceApplier_Delete_Call{Call: _e.mock.On("Delete", ctx, object)}
+}
+
+func (_c *mockResourceApplier_Delete_Call) Run(run func(ctx context.Context, object *unstructured.Unstructured)) *mockResourceApplier_Delete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*unstructured.Unstructured))
+	})
+	return _c
+}
+
+func (_c *mockResourceApplier_Delete_Call) Return(_a0 error) *mockResourceApplier_Delete_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *mockResourceApplier_Delete_Call) RunAndReturn(run func(context.Context, *unstructured.Unstructured) error) *mockResourceApplier_Delete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockResourceApplier creates a new instance of mockResourceApplier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockResourceApplier(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockResourceApplier {
+	mock := &mockResourceApplier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,90 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package patch
+
+import mock "github.com/stretchr/testify/mock"
+
+// mockResourceFetcher is an autogenerated mock type for the resourceFetcher type
+type mockResourceFetcher struct {
+	mock.Mock
+}
+
+type mockResourceFetcher_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockResourceFetcher) EXPECT() *mockResourceFetcher_Expecter {
+	return &mockResourceFetcher_Expecter{mock: &_m.Mock}
+}
+
+// GetResourceFileContent provides a mock function with given fields: resourceURL
+func (_m *mockResourceFetcher) GetResourceFileContent(resourceURL string) ([]byte, error) {
+	ret := _m.Called(resourceURL)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetResourceFileContent")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]byte, error)); ok {
+		return rf(resourceURL)
+	}
+	if rf, ok := ret.Get(0).(func(string) []byte); ok {
+		r0 = rf(resourceURL)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(resourceURL)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// mockResourceFetcher_GetResourceFileContent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetResourceFileContent'
+type mockResourceFetcher_GetResourceFileContent_Call struct {
+	*mock.Call
+}
+
+// GetResourceFileContent is a helper method to define mock.On call
+//   - resourceURL string
+func (_e *mockResourceFetcher_Expecter) GetResourceFileContent(resourceURL interface{}) *mockResourceFetcher_GetResourceFileContent_Call {
+	return &mockResourceFetcher_GetResourceFileContent_Call{Call: _e.mock.On("GetResourceFileContent", resourceURL)}
+}
+
+func (_c *mockResourceFetcher_GetResourceFileContent_Call) Run(run func(resourceURL string)) *mockResourceFetcher_GetResourceFileContent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *mockResourceFetcher_GetResourceFileContent_Call) Return(_a0 []byte, _a1 error) *mockResourceFetcher_GetResourceFileContent_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *mockResourceFetcher_GetResourceFileContent_Call) RunAndReturn(run func(string) ([]byte, error)) *mockResourceFetcher_GetResourceFileContent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockResourceFetcher creates a new instance of mockResourceFetcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockResourceFetcher(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockResourceFetcher {
+	mock := &mockResourceFetcher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -5,6 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
 )
 
 type resourcePatcher struct {
@@ -19,18 +27,20 @@ func NewResourcePatcher(applier jsonPatchApplier) *resourcePatcher {
 func filterPatchesByPhase(phase Phase, patches []ResourcePatch) []ResourcePatch {
 	var filtered []ResourcePatch
 	for _, patch := range patches {
-		if patch.Phase == phase {
+		if patch.GetPhase() == phase {
 			filtered = append(filtered, patch)
 		}
 	}
 	return filtered
 }
 
-// Patch applies a configured patch in JSON patch format to a Kubernetes resource.
-func (r *resourcePatcher) Patch(ctx context.Context, phase Phase, patches []ResourcePatch) error {
+// Patch applies a configured patch in JSON patch, JSON merge patch or strategic merge patch format to a Kubernetes resource.
+// Patches whose conditions are not fulfilled are skipped. The templates of the other patches are rendered with the
+// given values.
+func (r *resourcePatcher) Patch(ctx context.Context, phase Phase, patches []ResourcePatch, values TemplateValues) error {
 	var errs []error
 	for _, patch := range filterPatchesByPhase(phase, patches) {
-		err := r.patchSingle(ctx, patch)
+		err := r.patchSingle(ctx, patch, values)
 		if err != nil {
 			errs = append(errs, err)
 		}
@@ -39,11 +49,221 @@ func (r *resourcePatcher) Patch(ctx context.Context, phase Phase, patches []Reso
 	return errors.Join(errs...)
 }
 
-func (r *resourcePatcher) patchSingle(ctx context.Context, patch ResourcePatch) error {
-	jsonPatch, err := json.Marshal(patch.Patches)
+// DryRun checks the given patches against the cluster without changing any resource. It reports kinds which are
+// unknown to the cluster and patches which would violate the schema of existing resources. Patches which cannot be
+// applied to the current state of a resource, f. i. because of a failed test operation, are only logged as a warning.
+// Templated and conditional patches are skipped because their values and conditions are only known while the setup runs.
+func (r *resourcePatcher) DryRun(ctx context.Context, patches []ResourcePatch) error {
+	var errs []error
+	for _, patch := range patches {
+		if patch.hasTemplates() {
+			logrus.Debugf("Skipping dry-run of templated patch for %s", patch.Resource)
+			continue
+		}
+		if patch.When != nil {
+			logrus.Debugf("Skipping dry-run of conditional patch for %s", patch.Resource)
+			continue
+		}
+
+		err := r.dryRunSingle(ctx, patch)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("patch for %s is invalid: %w", patch.Resource, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *resourcePatcher) dryRunSingle(ctx context.Context, patch ResourcePatch) error {
+	patchType, patchBytes, err := marshalPatch(patch)
+	if err != nil {
+		return err
+	}
+
+	results, err := r.applier.DryRunPatch(ctx, patchType, patchBytes, patch.Resource)
+	if meta.IsNoMatchError(err) && !scheme.Scheme.IsGroupRegistered(patch.Resource.GroupVersionKind().Group) {
+		// custom resource definitions may be installed by components later in the setup
+		logrus.Warnf("Cannot check patch for %s because its kind is not known to the cluster yet", patch.Resource)
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to marshal json patch: %w", err)
+		return err
+	}
+
+	var errs []error
+	for _, result := range results {
+		switch {
+		case result.Err == nil, apierrors.IsNotFound(result.Err):
+			continue
+		case isSchemaViolation(result.Err):
+			errs = append(errs, result.Err)
+		default:
+			// the resource may still change until the patch is applied in its phase
+			logrus.Warnf("Dry-run of patch for resource %s of kind %s failed: %v", namespacedName(result), patch.Resource.Kind, result.Err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// isSchemaViolation checks whether the API server rejected the patched resource because of its schema. These errors
+// name the offending fields. JSON patches which cannot be applied, f. i. because of a failed test operation or a
+// missing path, are also rejected as invalid but without any field.
+func isSchemaViolation(err error) bool {
+	if !apierrors.IsInvalid(err) {
+		return false
+	}
+
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) || status.Status().Details == nil {
+		return false
+	}
+
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Field != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isJsonPatchApplyError checks whether the API server rejected a JSON patch because it cannot be applied to the current
+// state of the resource. The API server does not tell the reason, f. i. a failed test operation or a missing path.
+func isJsonPatchApplyError(err error) bool {
+	return apierrors.IsInvalid(err) && !isSchemaViolation(err)
+}
+
+// isMissingResourceError checks whether waiting for a resource failed because it did not appear or fulfill its
+// condition in time. Other errors, f. i. unknown kinds or missing permissions, are no sign of a missing resource.
+func isMissingResourceError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	return wait.Interrupted(err) || apierrors.IsNotFound(err)
+}
+
+func marshalPatch(patch ResourcePatch) (types.PatchType, []byte, error) {
+	var patchBody any = patch.Patch
+	patchType := types.MergePatchType
+	switch patch.GetType() {
+	case JsonPatchType:
+		patchBody = patch.Patches
+		patchType = types.JSONPatchType
+	case StrategicMergePatchType:
+		patchType = types.StrategicMergePatchType
+	}
+
+	patchBytes, err := json.Marshal(patchBody)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal %s patch: %w", patch.GetType(), err)
+	}
+
+	return patchType, patchBytes, nil
+}
+
+func (r *resourcePatcher) patchSingle(ctx context.Context, patch ResourcePatch, values TemplateValues) error {
+	if patch.When != nil {
+		reason, err := patch.When.evaluate(ctx, r.applier, values)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate condition of patch for %s: %w", patch.Resource, err)
+		}
+		if reason != "" {
+			logrus.Infof("Skipping patch for %s because %s", patch.Resource, reason)
+			return nil
+		}
+	}
+
+	rendered, err := patch.Render(values)
+	if err != nil {
+		return fmt.Errorf("failed to render patch for %s: %w", patch.Resource, err)
+	}
+	patch = rendered
+
+	patchType, patchBytes, err := marshalPatch(patch)
+	if err != nil {
+		return err
+	}
+
+	if patch.WaitFor != nil {
+		err = r.applier.Wait(ctx, patch.Resource, patch.WaitFor.Condition, patch.WaitFor.Timeout())
+		if err != nil && patch.Optional && isMissingResourceError(err) {
+			logrus.Warnf("Skipping optional patch: %v", err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	results, err := r.applier.Patch(ctx, patchType, patchBytes, patch.Resource)
+	if err != nil {
+		return err
+	}
+
+	if len(results) == 0 {
+		logrus.Warnf("No resources found to patch for %s", patch.Resource)
+		return nil
+	}
+
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil && patch.Optional && apierrors.IsNotFound(result.Err) {
+			logrus.Warnf("Skipping optional patch of missing resource %s of kind %s", namespacedName(result), patch.Resource.Kind)
+			continue
+		}
+		if result.Err != nil && patch.hasTestOperation() && isJsonPatchApplyError(result.Err) {
+			testFailed, err := r.isTestOperationFailed(ctx, patch.Resource, result, patchBytes)
+			if err != nil {
+				errs = append(errs, errors.Join(result.Err, err))
+				continue
+			}
+			if testFailed {
+				logrus.Warnf("Skipping patch of resource %s of kind %s because a test operation failed", namespacedName(result), patch.Resource.Kind)
+				continue
+			}
+		}
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+			continue
+		}
+		logrus.Infof("Patched resource %s of kind %s", namespacedName(result), patch.Resource.Kind)
+	}
+
+	return errors.Join(errs...)
+}
+
+// isTestOperationFailed checks whether a JSON patch the API server could not apply to the resource of the given result
+// failed because of one of its test operations. As the API server does not tell the reason, the patch is applied to the
+// current state of the resource locally.
+func (r *resourcePatcher) isTestOperationFailed(ctx context.Context, resource ResourceReference, result PatchResult, patchBytes []byte) (bool, error) {
+	resource.Name = result.Name
+	resource.Namespace = result.Namespace
+	resource.LabelSelector = ""
+	object, err := r.applier.Get(ctx, resource)
+	if err != nil {
+		return false, fmt.Errorf("failed to check test operations of patch: %w", err)
+	}
+
+	objectBytes, err := object.MarshalJSON()
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal resource %s of kind %s: %w", namespacedName(result), resource.Kind, err)
+	}
+
+	decoded, err := jsonpatch.DecodePatch(patchBytes)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode patch: %w", err)
+	}
+
+	_, err = decoded.Apply(objectBytes)
+	return errors.Is(err, jsonpatch.ErrTestFailed), nil
+}
+
+func namespacedName(result PatchResult) string {
+	if result.Namespace == "" {
+		return result.Name
 	}
 
-	return r.applier.Patch(ctx, jsonPatch, patch.Resource.GroupVersionKind(), patch.Resource.Name)
+	return fmt.Sprintf("%s/%s", result.Namespace, result.Name)
 }
@@ -3,10 +3,21 @@ package patch
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 var gkvLoadbalancer = ResourceReference{
@@ -36,12 +47,12 @@ func Test_resourcePatcher_Patch(t *testing.T) {
 		}}
 		mockApplier := newMockJsonPatchApplier(t)
 		patchesBytes := marshalJson(t, validPatches)
-		mockApplier.EXPECT().Patch(testCtx, patchesBytes, gkvLoadbalancer.GroupVersionKind(), gkvLoadbalancer.Name).Return(nil)
+		mockApplier.EXPECT().Patch(testCtx, types.JSONPatchType, patchesBytes, gkvLoadbalancer).Return([]PatchResult{{Namespace: "ecosystem", Name: gkvLoadbalancer.Name}}, nil)
 
 		sut := resourcePatcher{applier: mockApplier}
 
 		// when
-		err := sut.Patch(testCtx, LoadbalancerPhase, patches)
+		err := sut.Patch(testCtx, LoadbalancerPhase, patches, TemplateValues{})
 
 		// then
 		require.NoError(t, err)
@@ -65,7 +76,7 @@ func Test_resourcePatcher_Patch(t *testing.T) {
 		sut := resourcePatcher{applier: mockApplier}
 
 		// when
-		err := sut.Patch(testCtx, LoadbalancerPhase, patches)
+		err := sut.Patch(testCtx, LoadbalancerPhase, patches, TemplateValues{})
 
 		// then
 		require.Error(t, err)
@@ -73,6 +84,451 @@ func Test_resourcePatcher_Patch(t *testing.T) {
 	})
 }
 
+func Test_resourcePatcher_Patch_selectedResources(t *testing.T) {
+	validPatches := []JsonPatch{{Operation: addOperation, Path: "/metadata/labels/patched", Value: "true"}}
+	deployments := ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Namespace: "longhorn-system", LabelSelector: "app.kubernetes.io/part-of=ces"}
+	patches := []ResourcePatch{{Phase: ComponentPhase, Resource: deployments, Patches: validPatches}}
+
+	t.Run("should report all resources which could not be patched", func(t *testing.T) {
+		// given
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Patch(testCtx, types.JSONPatchType, marshalJson(t, validPatches), deployments).Return([]PatchResult{
+			{Namespace: "longhorn-system", Name: "first"},
+			{Namespace: "longhorn-system", Name: "second", Err: errors.New("failed to patch second")},
+			{Namespace: "longhorn-system", Name: "third", Err: errors.New("failed to patch third")},
+		}, nil)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, ComponentPhase, patches, TemplateValues{})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to patch second")
+		assert.ErrorContains(t, err, "failed to patch third")
+	})
+
+	t.Run("should succeed if no resource matches", func(t *testing.T) {
+		// given
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Patch(testCtx, types.JSONPatchType, marshalJson(t, validPatches), deployments).Return([]PatchResult{}, nil)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, ComponentPhase, patches, TemplateValues{})
+
+		// then
+		require.NoError(t, err)
+	})
+}
+
+func Test_resourcePatcher_Patch_testOperation(t *testing.T) {
+	testedPatches := []JsonPatch{
+		{Operation: testOperation, Path: "/spec/type", Value: "LoadBalancer"},
+		{Operation: replaceOperation, Path: "/spec/type", Value: "NodePort"},
+	}
+	// the API server does not report why a JSON patch cannot be applied
+	notApplicable := apierrors.NewGenericServerResponse(http.StatusUnprocessableEntity, "", schema.GroupResource{}, "", "", 0, false)
+	newService := func(serviceType string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]any{"name": "ces-loadbalancer", "namespace": "ecosystem"},
+			"spec":       map[string]any{"type": serviceType},
+		}}
+	}
+	patchedResource := ResourceReference{ApiVersion: "v1", Kind: "Service", Name: "ces-loadbalancer", Namespace: "ecosystem"}
+
+	t.Run("should skip a patch whose test operation failed", func(t *testing.T) {
+		// given
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Patch(testCtx, types.JSONPatchType, marshalJson(t, testedPatches), gkvLoadbalancer).Return([]PatchResult{
+			{Namespace: "ecosystem", Name: "ces-loadbalancer", Err: notApplicable},
+		}, nil)
+		mockApplier.EXPECT().Get(testCtx, patchedResource).Return(newService("ClusterIP"), nil)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, LoadbalancerPhase, []ResourcePatch{{Phase: LoadbalancerPhase, Resource: gkvLoadbalancer, Patches: testedPatches}}, TemplateValues{})
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should skip resources selected by labels whose test operation failed", func(t *testing.T) {
+		// given
+		selected := ResourceReference{ApiVersion: "v1", Kind: "Service", Namespace: "ecosystem", LabelSelector: "app=ces"}
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Patch(testCtx, types.JSONPatchType, marshalJson(t, testedPatches), selected).Return([]PatchResult{
+			{Namespace: "ecosystem", Name: "ces-loadbalancer", Err: notApplicable},
+		}, nil)
+		mockApplier.EXPECT().Get(testCtx, patchedResource).Return(newService("ClusterIP"), nil)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, LoadbalancerPhase, []ResourcePatch{{Phase: LoadbalancerPhase, Resource: selected, Patches: testedPatches}}, TemplateValues{})
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail for a patch whose test operation succeeded but another operation cannot be applied", func(t *testing.T) {
+		// given
+		badPathPatches := []JsonPatch{
+			{Operation: testOperation, Path: "/spec/type", Value: "LoadBalancer"},
+			{Operation: replaceOperation, Path: "/spec/missing/type", Value: "NodePort"},
+		}
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Patch(testCtx, types.JSONPatchType, marshalJson(t, badPathPatches), gkvLoadbalancer).Return([]PatchResult{
+			{Namespace: "ecosystem", Name: "ces-loadbalancer", Err: notApplicable},
+		}, nil)
+		mockApplier.EXPECT().Get(testCtx, patchedResource).Return(newService("LoadBalancer"), nil)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, LoadbalancerPhase, []ResourcePatch{{Phase: LoadbalancerPhase, Resource: gkvLoadbalancer, Patches: badPathPatches}}, TemplateValues{})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, notApplicable)
+	})
+
+	t.Run("should fail if the test operation cannot be checked", func(t *testing.T) {
+		// given
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Patch(testCtx, types.JSONPatchType, marshalJson(t, testedPatches), gkvLoadbalancer).Return([]PatchResult{
+			{Namespace: "ecosystem", Name: "ces-loadbalancer", Err: notApplicable},
+		}, nil)
+		mockApplier.EXPECT().Get(testCtx, patchedResource).Return(nil, assert.AnError)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, LoadbalancerPhase, []ResourcePatch{{Phase: LoadbalancerPhase, Resource: gkvLoadbalancer, Patches: testedPatches}}, TemplateValues{})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, notApplicable)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to check test operations of patch")
+	})
+
+	t.Run("should fail for schema violations of a patch with a test operation", func(t *testing.T) {
+		// given
+		invalid := apierrors.NewInvalid(schema.GroupKind{Kind: "Service"}, "ces-loadbalancer", field.ErrorList{field.NotSupported(field.NewPath("spec", "type"), "NodePrt", []string{"NodePort"})})
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Patch(testCtx, types.JSONPatchType, marshalJson(t, testedPatches), gkvLoadbalancer).Return([]PatchResult{
+			{Name: "ces-loadbalancer", Err: invalid},
+		}, nil)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, LoadbalancerPhase, []ResourcePatch{{Phase: LoadbalancerPhase, Resource: gkvLoadbalancer, Patches: testedPatches}}, TemplateValues{})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, invalid)
+	})
+
+	t.Run("should fail for a patch without test operation which cannot be applied", func(t *testing.T) {
+		// given
+		removal := []JsonPatch{{Operation: removeOperation, Path: "/metadata/labels/a"}}
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Patch(testCtx, types.JSONPatchType, marshalJson(t, removal), gkvLoadbalancer).Return([]PatchResult{
+			{Name: "ces-loadbalancer", Err: notApplicable},
+		}, nil)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, LoadbalancerPhase, []ResourcePatch{{Phase: LoadbalancerPhase, Resource: gkvLoadbalancer, Patches: removal}}, TemplateValues{})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, notApplicable)
+	})
+}
+
+func Test_resourcePatcher_Patch_anchoredPatches(t *testing.T) {
+	// given
+	validPatches := []JsonPatch{{Operation: addOperation, Path: "/metadata/labels/patched", Value: "true"}}
+	ldap := ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Name: "ldap"}
+	cas := ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Name: "cas"}
+	patches := []ResourcePatch{
+		{After: &PatchAnchor{Dogu: "ldap"}, Resource: ldap, Patches: validPatches},
+		{After: &PatchAnchor{Dogu: "cas"}, Resource: cas, Patches: validPatches},
+		{Phase: DoguPhase, Resource: cas, Patches: validPatches},
+	}
+	mockApplier := newMockJsonPatchApplier(t)
+	mockApplier.EXPECT().Patch(testCtx, types.JSONPatchType, marshalJson(t, validPatches), ldap).Return([]PatchResult{{Name: "ldap"}}, nil).Once()
+	sut := NewResourcePatcher(mockApplier)
+
+	// when
+	err := sut.Patch(testCtx, AfterDoguPhase("ldap"), patches, TemplateValues{})
+
+	// then
+	require.NoError(t, err)
+}
+
+func Test_resourcePatcher_Patch_waitFor(t *testing.T) {
+	validPatches := []JsonPatch{{Operation: addOperation, Path: "/metadata/labels/patched", Value: "true"}}
+	deployment := ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Name: "ldap"}
+	notFoundErr := apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "ldap")
+
+	t.Run("should wait for the resource before patching it", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{{Phase: DoguPhase, Resource: deployment, Patches: validPatches, WaitFor: &WaitFor{TimeoutSeconds: 60, Condition: "Available"}}}
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Wait(testCtx, deployment, "Available", time.Minute).Return(nil)
+		mockApplier.EXPECT().Patch(testCtx, types.JSONPatchType, marshalJson(t, validPatches), deployment).Return([]PatchResult{{Name: "ldap"}}, nil)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, DoguPhase, patches, TemplateValues{})
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail if the resource does not appear", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{{Phase: DoguPhase, Resource: deployment, Patches: validPatches, WaitFor: &WaitFor{}}}
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Wait(testCtx, deployment, "", 5*time.Minute).Return(assert.AnError)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, DoguPhase, patches, TemplateValues{})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("should skip optional patch if the resource does not appear", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{{Phase: DoguPhase, Resource: deployment, Patches: validPatches, WaitFor: &WaitFor{}, Optional: true}}
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Wait(testCtx, deployment, "", 5*time.Minute).Return(fmt.Errorf("failed to wait for %s: %w", deployment, wait.ErrWaitTimeout))
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, DoguPhase, patches, TemplateValues{})
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should skip optional patch if the resource is not found", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{{Phase: DoguPhase, Resource: deployment, Patches: validPatches, WaitFor: &WaitFor{}, Optional: true}}
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Wait(testCtx, deployment, "", 5*time.Minute).Return(notFoundErr)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, DoguPhase, patches, TemplateValues{})
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail for optional patch if waiting fails for other reasons", func(t *testing.T) {
+		tests := []struct {
+			name string
+			err  error
+		}{
+			{name: "unknown kind", err: &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"}}},
+			{name: "forbidden", err: apierrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"}, "ldap", assert.AnError)},
+			{name: "invalid label selector", err: apierrors.NewBadRequest("invalid label selector")},
+			{name: "canceled setup", err: fmt.Errorf("failed to wait for %s: %w", deployment, context.Canceled)},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// given
+				patches := []ResourcePatch{{Phase: DoguPhase, Resource: deployment, Patches: validPatches, WaitFor: &WaitFor{}, Optional: true}}
+				mockApplier := newMockJsonPatchApplier(t)
+				mockApplier.EXPECT().Wait(testCtx, deployment, "", 5*time.Minute).Return(tt.err)
+				sut := NewResourcePatcher(mockApplier)
+
+				// when
+				err := sut.Patch(testCtx, DoguPhase, patches, TemplateValues{})
+
+				// then
+				require.Error(t, err)
+				assert.ErrorIs(t, err, tt.err)
+			})
+		}
+	})
+
+	t.Run("should skip optional patch of missing resource", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{{Phase: DoguPhase, Resource: deployment, Patches: validPatches, Optional: true}}
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Patch(testCtx, types.JSONPatchType, marshalJson(t, validPatches), deployment).
+			Return([]PatchResult{{Name: "ldap", Err: fmt.Errorf("failed to patch resource ldap: %w", notFoundErr)}}, nil)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, DoguPhase, patches, TemplateValues{})
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail for missing resource of mandatory patch", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{{Phase: DoguPhase, Resource: deployment, Patches: validPatches}}
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Patch(testCtx, types.JSONPatchType, marshalJson(t, validPatches), deployment).
+			Return([]PatchResult{{Name: "ldap", Err: fmt.Errorf("failed to patch resource ldap: %w", notFoundErr)}}, nil)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, DoguPhase, patches, TemplateValues{})
+
+		// then
+		require.Error(t, err)
+		assert.True(t, apierrors.IsNotFound(err))
+	})
+}
+
+func Test_resourcePatcher_Patch_templates(t *testing.T) {
+	t.Run("should render the patch with the given values", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{{
+			Phase:    LoadbalancerPhase,
+			Resource: ResourceReference{ApiVersion: "v1", Kind: "Service", Name: "{{ .Namespace }}-loadbalancer"},
+			Patches:  []JsonPatch{{Operation: addOperation, Path: "/metadata/annotations/fqdn", Value: "{{ .Fqdn }}"}},
+		}}
+		expectedResource := ResourceReference{ApiVersion: "v1", Kind: "Service", Name: "ecosystem-loadbalancer"}
+		expectedPatches := []JsonPatch{{Operation: addOperation, Path: "/metadata/annotations/fqdn", Value: "192.168.56.2"}}
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Patch(testCtx, types.JSONPatchType, marshalJson(t, expectedPatches), expectedResource).Return([]PatchResult{{Name: "ecosystem-loadbalancer"}}, nil)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, LoadbalancerPhase, patches, TemplateValues{Namespace: "ecosystem", Fqdn: "192.168.56.2"})
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail for missing values", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{{
+			Phase:    DoguPhase,
+			Resource: ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "{{ .DoguVersions.unknown }}"},
+			Patches:  []JsonPatch{{Operation: removeOperation, Path: "/data/key"}},
+		}}
+		sut := NewResourcePatcher(newMockJsonPatchApplier(t))
+
+		// when
+		err := sut.Patch(testCtx, DoguPhase, patches, TemplateValues{})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to render patch for v1/ConfigMap {{ .DoguVersions.unknown }}")
+		assert.ErrorContains(t, err, "map has no entry for key \"unknown\"")
+	})
+}
+
+func Test_resourcePatcher_Patch_when(t *testing.T) {
+	validPatches := []JsonPatch{{Operation: addOperation, Path: "/metadata/labels/patched", Value: "true"}}
+	configMap := ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "redmine-config"}
+	values := TemplateValues{DoguVersions: map[string]string{"redmine": "5.1.3-1"}}
+
+	t.Run("should apply patch if the condition is fulfilled", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{{Phase: DoguPhase, Resource: configMap, Patches: validPatches, When: &Condition{DoguInstalled: "redmine"}}}
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Patch(testCtx, types.JSONPatchType, marshalJson(t, validPatches), configMap).Return([]PatchResult{{Name: "redmine-config"}}, nil)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, DoguPhase, patches, values)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should skip patch without rendering it if the condition is not fulfilled", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{{
+			Phase:    DoguPhase,
+			Resource: ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "jenkins-{{ .DoguVersions.jenkins }}"},
+			Patches:  validPatches,
+			When:     &Condition{DoguInstalled: "jenkins"},
+		}}
+		sut := NewResourcePatcher(newMockJsonPatchApplier(t))
+
+		// when
+		err := sut.Patch(testCtx, DoguPhase, patches, values)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail if the condition cannot be evaluated", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{{Phase: DoguPhase, Resource: configMap, Patches: validPatches, When: &Condition{ResourceExists: &configMap}}}
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Exists(testCtx, configMap).Return(false, assert.AnError)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, DoguPhase, patches, values)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to evaluate condition of patch for v1/ConfigMap redmine-config")
+	})
+}
+
+func Test_resourcePatcher_Patch_documentPatches(t *testing.T) {
+	document := map[string]any{"metadata": map[string]any{"annotations": map[string]any{"service.beta.kubernetes.io/azure-load-balancer-internal": "true"}}}
+	expectedBytes, err := json.Marshal(document)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		patchType         PatchType
+		expectedPatchType types.PatchType
+	}{
+		{name: "merge patch", patchType: MergePatchType, expectedPatchType: types.MergePatchType},
+		{name: "strategic merge patch", patchType: StrategicMergePatchType, expectedPatchType: types.StrategicMergePatchType},
+	}
+	for _, tt := range tests {
+		t.Run("should apply "+tt.name, func(t *testing.T) {
+			// given
+			patches := []ResourcePatch{{Phase: LoadbalancerPhase, Type: tt.patchType, Resource: gkvLoadbalancer, Patch: document}}
+			mockApplier := newMockJsonPatchApplier(t)
+			mockApplier.EXPECT().Patch(testCtx, tt.expectedPatchType, expectedBytes, gkvLoadbalancer).Return([]PatchResult{{Namespace: "ecosystem", Name: gkvLoadbalancer.Name}}, nil)
+			sut := NewResourcePatcher(mockApplier)
+
+			// when
+			err := sut.Patch(testCtx, LoadbalancerPhase, patches, TemplateValues{})
+
+			// then
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("should return error of applier", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{{Phase: LoadbalancerPhase, Type: MergePatchType, Resource: gkvLoadbalancer, Patch: document}}
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().Patch(testCtx, types.MergePatchType, expectedBytes, gkvLoadbalancer).Return(nil, assert.AnError)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.Patch(testCtx, LoadbalancerPhase, patches, TemplateValues{})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
+
 func marshalJson(t *testing.T, patches []JsonPatch) []byte {
 	t.Helper()
 
@@ -82,6 +538,110 @@ func marshalJson(t *testing.T, patches []JsonPatch) []byte {
 	return bytes
 }
 
+func Test_resourcePatcher_DryRun(t *testing.T) {
+	validPatches := []JsonPatch{{Operation: addOperation, Path: "/metadata/labels/patched", Value: "true"}}
+	deployment := ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Name: "ldap"}
+	patches := []ResourcePatch{{Phase: DoguPhase, Resource: deployment, Patches: validPatches}}
+
+	t.Run("should accept patches of existing and missing resources", func(t *testing.T) {
+		// given
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().DryRunPatch(testCtx, types.JSONPatchType, marshalJson(t, validPatches), deployment).Return([]PatchResult{
+			{Name: "ldap"},
+			{Name: "ldap", Err: apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "ldap")},
+		}, nil)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.DryRun(testCtx, patches)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should report schema violations and only warn about other errors", func(t *testing.T) {
+		// given
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().DryRunPatch(testCtx, types.JSONPatchType, marshalJson(t, validPatches), deployment).Return([]PatchResult{
+			{Name: "ldap", Err: apierrors.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "ldap", field.ErrorList{field.Invalid(field.NewPath("metadata", "labels"), "true!", "invalid label value")})},
+			{Name: "ldap", Err: apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, "ldap", assert.AnError)},
+		}, nil)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.DryRun(testCtx, patches)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "patch for apps/v1/Deployment ldap is invalid: Deployment.apps \"ldap\" is invalid")
+		assert.NotErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("should only warn about patches which cannot be applied to an already patched resource", func(t *testing.T) {
+		// given
+		loadbalancerPatches := []JsonPatch{
+			{Operation: testOperation, Path: "/spec/type", Value: "LoadBalancer"},
+			{Operation: replaceOperation, Path: "/spec/type", Value: "NodePort"},
+		}
+		testFailed := apierrors.NewGenericServerResponse(http.StatusUnprocessableEntity, "", schema.GroupResource{}, "", "testing value /spec/type failed: test failed", 0, false)
+		require.True(t, apierrors.IsInvalid(testFailed))
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().DryRunPatch(testCtx, types.JSONPatchType, marshalJson(t, loadbalancerPatches), gkvLoadbalancer).Return([]PatchResult{
+			{Name: "ces-loadbalancer", Err: testFailed},
+		}, nil)
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.DryRun(testCtx, []ResourcePatch{{Phase: DoguPhase, Resource: gkvLoadbalancer, Patches: loadbalancerPatches}})
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should report unknown built-in kinds", func(t *testing.T) {
+		// given
+		unknown := ResourceReference{ApiVersion: "apps/v1", Kind: "Deploymnet", Name: "ldap"}
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().DryRunPatch(testCtx, types.JSONPatchType, marshalJson(t, validPatches), unknown).
+			Return(nil, &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "apps", Kind: "Deploymnet"}})
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.DryRun(testCtx, []ResourcePatch{{Phase: DoguPhase, Resource: unknown, Patches: validPatches}})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "patch for apps/v1/Deploymnet ldap is invalid: no matches for kind \"Deploymnet\" in group \"apps\"")
+	})
+
+	t.Run("should only warn about unknown custom kinds", func(t *testing.T) {
+		// given
+		setting := ResourceReference{ApiVersion: "longhorn.io/v1beta2", Kind: "Setting", Name: "default-replica-count"}
+		mockApplier := newMockJsonPatchApplier(t)
+		mockApplier.EXPECT().DryRunPatch(testCtx, types.JSONPatchType, marshalJson(t, validPatches), setting).
+			Return(nil, &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "longhorn.io", Kind: "Setting"}})
+		sut := NewResourcePatcher(mockApplier)
+
+		// when
+		err := sut.DryRun(testCtx, []ResourcePatch{{Phase: ComponentPhase, Resource: setting, Patches: validPatches}})
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should skip templated patches", func(t *testing.T) {
+		// given
+		templated := []ResourcePatch{{Phase: DoguPhase, Resource: deployment, Patches: []JsonPatch{{Operation: addOperation, Path: "/metadata/labels/fqdn", Value: "{{ .Fqdn }}"}}}}
+		sut := NewResourcePatcher(newMockJsonPatchApplier(t))
+
+		// when
+		err := sut.DryRun(testCtx, templated)
+
+		// then
+		require.NoError(t, err)
+	})
+}
+
 func TestNewResourcePatcher(t *testing.T) {
 	assert.NotNil(t, NewResourcePatcher(nil))
 }
@@ -0,0 +1,217 @@
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// TemplateValues contains the values of the setup which can be used in templated resource patches, f. i.
+// "{{ .Fqdn }}" or "{{ index .DoguVersions \"nginx-ingress\" }}", and which are checked by the conditions of the
+// patches.
+type TemplateValues struct {
+	// Namespace contains the namespace of the setup.
+	Namespace string
+	// Fqdn contains the fully qualified domain name of the Cloudogu EcoSystem. It is available after the FQDN was
+	// retrieved from the loadbalancer.
+	Fqdn string
+	// Domain contains the domain of the Cloudogu EcoSystem.
+	Domain string
+	// InternalIp contains the internal IP of the Cloudogu EcoSystem.
+	InternalIp string
+	// DoguVersions maps the simple names of the dogus to install to their resolved versions.
+	DoguVersions map[string]string
+	// Components contains the names of the configured components.
+	Components []string
+	// SetupJson contains the fields of the setup.json.
+	SetupJson map[string]any
+}
+
+// Render returns a copy of the ResourcePatch whose resource name, patch values and patch document are rendered as
+// templates with the given values. Missing map keys lead to an error, also if they are looked up with the index
+// function.
+func (rp ResourcePatch) Render(values TemplateValues) (ResourcePatch, error) {
+	return rp.render(values, true)
+}
+
+// ValidateTemplates renders the templates of all given patches with the values of the setup to detect references to
+// missing values, f. i. misspelled dogu names or fields of the setup.json. Patches whose condition is not fulfilled by
+// the setup are skipped because they are not applied.
+func ValidateTemplates(patches []ResourcePatch, values TemplateValues) error {
+	var errs []error
+	for _, resourcePatch := range patches {
+		if !resourcePatch.hasTemplates() {
+			continue
+		}
+		if resourcePatch.When != nil && resourcePatch.When.evaluateSetup(values) != "" {
+			continue
+		}
+
+		_, err := resourcePatch.render(values, true)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("templates of patch for %s are invalid: %w", resourcePatch.Resource, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateTemplates checks that all templates of the ResourcePatch can be parsed and only reference existing values.
+// Missing map keys are ignored because the values are only known while the setup runs.
+func (rp ResourcePatch) validateTemplates() error {
+	_, err := rp.render(TemplateValues{}, false)
+	return err
+}
+
+// hasTemplates returns true if the resource name, a patch value or the patch document of the ResourcePatch contains a
+// template.
+func (rp ResourcePatch) hasTemplates() bool {
+	return containsTemplate(rp.Resource.Name) || slices.ContainsFunc(rp.Patches, func(jsonPatch JsonPatch) bool {
+		return containsTemplate(jsonPatch.Value)
+	}) || containsTemplate(rp.Patch)
+}
+
+func containsTemplate(value any) bool {
+	switch typedValue := value.(type) {
+	case string:
+		return strings.Contains(typedValue, "{{")
+	case map[string]any:
+		for _, child := range typedValue {
+			if containsTemplate(child) {
+				return true
+			}
+		}
+		return false
+	case []any:
+		return slices.ContainsFunc(typedValue, containsTemplate)
+	}
+
+	return false
+}
+
+func (rp ResourcePatch) render(values TemplateValues, strict bool) (ResourcePatch, error) {
+	var errs []error
+	rendered := rp
+
+	name, err := renderString(rp.Resource.Name, values, strict)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to render resource name: %w", err))
+	}
+	rendered.Resource.Name = name
+
+	if rp.Patches != nil {
+		rendered.Patches = make([]JsonPatch, len(rp.Patches))
+		for i, jsonPatch := range rp.Patches {
+			rendered.Patches[i] = jsonPatch
+			rendered.Patches[i].Value, err = renderValue(jsonPatch.Value, values, strict)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to render value of operation '%s' on path '%s': %w", jsonPatch.Operation, jsonPatch.Path, err))
+			}
+		}
+	}
+
+	if rp.Patch != nil {
+		document, err := renderValue(rp.Patch, values, strict)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to render patch document: %w", err))
+		}
+		rendered.Patch, _ = document.(map[string]any)
+	}
+
+	return rendered, errors.Join(errs...)
+}
+
+func renderValue(value any, values TemplateValues, strict bool) (any, error) {
+	switch typedValue := value.(type) {
+	case string:
+		return renderString(typedValue, values, strict)
+	case map[string]any:
+		var errs []error
+		rendered := make(map[string]any, len(typedValue))
+		for key, child := range typedValue {
+			renderedChild, err := renderValue(child, values, strict)
+			errs = append(errs, err)
+			rendered[key] = renderedChild
+		}
+		return rendered, errors.Join(errs...)
+	case []any:
+		var errs []error
+		rendered := make([]any, len(typedValue))
+		for i, child := range typedValue {
+			renderedChild, err := renderValue(child, values, strict)
+			errs = append(errs, err)
+			rendered[i] = renderedChild
+		}
+		return rendered, errors.Join(errs...)
+	default:
+		return value, nil
+	}
+}
+
+func renderString(text string, values TemplateValues, strict bool) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tpl := template.New("patch")
+	if strict {
+		tpl = tpl.Option("missingkey=error").Funcs(template.FuncMap{"index": strictIndex})
+	}
+
+	tpl, err := tpl.Parse(text)
+	if err != nil {
+		return text, fmt.Errorf("failed to parse template '%s': %w", text, err)
+	}
+
+	var rendered strings.Builder
+	err = tpl.Execute(&rendered, values)
+	if err != nil {
+		return text, fmt.Errorf("failed to execute template '%s': %w", text, err)
+	}
+
+	return rendered.String(), nil
+}
+
+// strictIndex replaces the index function of templates when they are rendered with the values of the setup. Unlike
+// the built-in function, it fails for missing map keys, f. i. the version of a dogu which is not installed.
+func strictIndex(item any, keys ...any) (any, error) {
+	value := reflect.ValueOf(item)
+	for _, key := range keys {
+		for value.Kind() == reflect.Interface || value.Kind() == reflect.Pointer {
+			value = value.Elem()
+		}
+
+		keyValue := reflect.ValueOf(key)
+		switch value.Kind() {
+		case reflect.Map:
+			if !keyValue.IsValid() || !keyValue.Type().AssignableTo(value.Type().Key()) {
+				return nil, fmt.Errorf("cannot index %s with key %v", value.Type(), key)
+			}
+			entry := value.MapIndex(keyValue)
+			if !entry.IsValid() {
+				return nil, fmt.Errorf("map has no entry for key %q", key)
+			}
+			value = entry
+		case reflect.Slice, reflect.Array, reflect.String:
+			if !keyValue.IsValid() || !keyValue.CanInt() {
+				return nil, fmt.Errorf("cannot index %s with key %v", value.Type(), key)
+			}
+			index := keyValue.Int()
+			if index < 0 || index >= int64(value.Len()) {
+				return nil, fmt.Errorf("index %d out of range of %s with length %d", index, value.Type(), value.Len())
+			}
+			value = value.Index(int(index))
+		default:
+			return nil, fmt.Errorf("cannot index %v of type %T", item, item)
+		}
+	}
+
+	if !value.IsValid() {
+		return nil, nil
+	}
+
+	return value.Interface(), nil
+}
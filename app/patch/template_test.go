@@ -0,0 +1,218 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourcePatch_Render(t *testing.T) {
+	values := TemplateValues{Namespace: "ecosystem", Fqdn: "ces.example.com", DoguVersions: map[string]string{"ldap": "2.1.0-1"}}
+
+	t.Run("should render resource name, values and patch document", func(t *testing.T) {
+		// given
+		sut := ResourcePatch{
+			Phase:    DoguPhase,
+			Resource: ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "{{ .Namespace }}-config"},
+			Patches: []JsonPatch{
+				{Operation: addOperation, Path: "/data/fqdn", Value: "https://{{ .Fqdn }}/"},
+				{Operation: addOperation, Path: "/data/versions", Value: []any{map[string]any{"ldap": `{{ index .DoguVersions "ldap" }}`}, 42}},
+			},
+			Patch: map[string]any{"metadata": map[string]any{"labels": map[string]any{"fqdn": "{{ .Fqdn }}"}}},
+		}
+
+		// when
+		actual, err := sut.Render(values)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "ecosystem-config", actual.Resource.Name)
+		assert.Equal(t, "https://ces.example.com/", actual.Patches[0].Value)
+		assert.Equal(t, []any{map[string]any{"ldap": "2.1.0-1"}, 42}, actual.Patches[1].Value)
+		assert.Equal(t, map[string]any{"metadata": map[string]any{"labels": map[string]any{"fqdn": "ces.example.com"}}}, actual.Patch)
+		// the original patch is not modified
+		assert.Equal(t, "{{ .Namespace }}-config", sut.Resource.Name)
+		assert.Equal(t, "https://{{ .Fqdn }}/", sut.Patches[0].Value)
+	})
+
+	t.Run("should fail for missing dogu version", func(t *testing.T) {
+		// given
+		sut := ResourcePatch{
+			Resource: ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "config"},
+			Patches:  []JsonPatch{{Operation: addOperation, Path: "/data/version", Value: "{{ .DoguVersions.cas }}"}},
+		}
+
+		// when
+		_, err := sut.Render(values)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to render value of operation 'add' on path '/data/version'")
+		assert.ErrorContains(t, err, `map has no entry for key "cas"`)
+	})
+
+	t.Run("should fail for missing dogu version looked up with index", func(t *testing.T) {
+		// given
+		sut := ResourcePatch{
+			Resource: ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "config"},
+			Patches:  []JsonPatch{{Operation: addOperation, Path: "/data/version", Value: `{{ index .DoguVersions "nginx-ingress" }}`}},
+		}
+
+		// when
+		_, err := sut.Render(values)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, `map has no entry for key "nginx-ingress"`)
+	})
+
+	t.Run("should index slices and nested maps", func(t *testing.T) {
+		// given
+		setupValues := TemplateValues{Components: []string{"k8s-longhorn"}, SetupJson: map[string]any{"naming": map[string]any{"fqdn": "ces.example.com"}}}
+		sut := ResourcePatch{
+			Resource: ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "config"},
+			Patches:  []JsonPatch{{Operation: addOperation, Path: "/data/value", Value: `{{ index .Components 0 }} {{ index .SetupJson "naming" "fqdn" }}`}},
+		}
+
+		// when
+		actual, err := sut.Render(setupValues)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "k8s-longhorn ces.example.com", actual.Patches[0].Value)
+	})
+
+	t.Run("should fail for index out of range", func(t *testing.T) {
+		// given
+		sut := ResourcePatch{
+			Resource: ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "config"},
+			Patches:  []JsonPatch{{Operation: addOperation, Path: "/data/value", Value: `{{ index .Components 1 }}`}},
+		}
+
+		// when
+		_, err := sut.Render(TemplateValues{Components: []string{"k8s-longhorn"}})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "index 1 out of range of []string with length 1")
+	})
+}
+
+func TestValidateTemplates(t *testing.T) {
+	values := TemplateValues{
+		Namespace:    "ecosystem",
+		DoguVersions: map[string]string{"ldap": "2.1.0-1", "nginx-ingress": "1.6.3-1"},
+		Components:   []string{"k8s-longhorn"},
+		SetupJson:    map[string]any{"naming": map[string]any{"fqdn": "ces.example.com"}},
+	}
+	resource := ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "config"}
+
+	t.Run("should accept templates referencing values of the setup", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{
+			{Resource: resource, Patches: []JsonPatch{{Operation: addOperation, Path: "/data/value", Value: `{{ .DoguVersions.ldap }} {{ index .DoguVersions "nginx-ingress" }} {{ .SetupJson.naming.fqdn }}`}}},
+			{Resource: resource, Patches: []JsonPatch{{Operation: addOperation, Path: "/data/value", Value: "without template"}}},
+		}
+
+		// when
+		err := ValidateTemplates(patches, values)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should reject misspelled keys", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{
+			{Resource: resource, Patches: []JsonPatch{{Operation: addOperation, Path: "/data/value", Value: "{{ .SetupJson.naming.fqdnn }}"}}},
+			{Resource: resource, Patches: []JsonPatch{{Operation: addOperation, Path: "/data/value", Value: "{{ .DoguVersions.ldapp }}"}}},
+			{Resource: resource, Patches: []JsonPatch{{Operation: addOperation, Path: "/data/value", Value: `{{ index .DoguVersions "nginx" }}`}}},
+		}
+
+		// when
+		err := ValidateTemplates(patches, values)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "templates of patch for v1/ConfigMap config are invalid")
+		assert.ErrorContains(t, err, `map has no entry for key "fqdnn"`)
+		assert.ErrorContains(t, err, `map has no entry for key "ldapp"`)
+		assert.ErrorContains(t, err, `map has no entry for key "nginx"`)
+	})
+
+	t.Run("should skip patches whose condition is not fulfilled by the setup", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{{
+			Resource: resource,
+			Patches:  []JsonPatch{{Operation: addOperation, Path: "/data/value", Value: "{{ .DoguVersions.redmine }}"}},
+			When:     &Condition{DoguInstalled: "redmine"},
+		}}
+
+		// when
+		err := ValidateTemplates(patches, values)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should check patches whose condition depends on the cluster", func(t *testing.T) {
+		// given
+		patches := []ResourcePatch{{
+			Resource: resource,
+			Patches:  []JsonPatch{{Operation: addOperation, Path: "/data/value", Value: "{{ .DoguVersions.redmine }}"}},
+			When:     &Condition{DoguInstalled: "ldap", NodeLabel: "region=eu"},
+		}}
+
+		// when
+		err := ValidateTemplates(patches, values)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, `map has no entry for key "redmine"`)
+	})
+}
+
+func TestResourcePatch_Validate_templates(t *testing.T) {
+	t.Run("should accept known values", func(t *testing.T) {
+		// given
+		sut := ResourcePatch{
+			Phase:    LoadbalancerPhase,
+			Resource: ResourceReference{ApiVersion: "v1", Kind: "Service", Name: "{{ .Namespace }}-loadbalancer"},
+			Patches:  []JsonPatch{{Operation: addOperation, Path: "/data/version", Value: `{{ .DoguVersions.ldap }} {{ index .DoguVersions "nginx-ingress" }} {{ .Fqdn }} {{ .Domain }} {{ .InternalIp }}`}},
+		}
+
+		// when
+		err := sut.Validate()
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should reject undefined values and invalid templates", func(t *testing.T) {
+		// given
+		sut := ResourcePatch{
+			Phase:    LoadbalancerPhase,
+			Resource: ResourceReference{ApiVersion: "v1", Kind: "Service", Name: "{{ .Fdqn }}"},
+			Patches:  []JsonPatch{{Operation: addOperation, Path: "/data/version", Value: "{{ .Fqdn "}},
+		}
+
+		// when
+		err := sut.Validate()
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to render resource name")
+		assert.ErrorContains(t, err, "can't evaluate field Fdqn")
+		assert.ErrorContains(t, err, "failed to parse template '{{ .Fqdn '")
+	})
+}
+
+func TestResourcePatch_hasTemplates(t *testing.T) {
+	resource := ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "config"}
+
+	assert.False(t, ResourcePatch{Resource: resource, Patches: []JsonPatch{{Operation: addOperation, Path: "/data/a", Value: map[string]any{"b": []any{"c", 1}}}}}.hasTemplates())
+	assert.True(t, ResourcePatch{Resource: ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "{{ .Namespace }}-config"}}.hasTemplates())
+	assert.True(t, ResourcePatch{Resource: resource, Patches: []JsonPatch{{Operation: addOperation, Path: "/data/a", Value: []any{"{{ .Fqdn }}"}}}}.hasTemplates())
+	assert.True(t, ResourcePatch{Resource: resource, Type: MergePatchType, Patch: map[string]any{"data": map[string]any{"a": "{{ .Domain }}"}}}.hasTemplates())
+}
@@ -0,0 +1,233 @@
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/kubernetes"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+)
+
+const (
+	checkKubernetesVersion     = "kubernetes-version"
+	checkDefaultStorageClass   = "default-storage-class"
+	checkAllocatableResources  = "allocatable-resources"
+	checkLoadBalancer          = "loadbalancer"
+	defaultStorageClassKey     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultStorageClassKey = "storageclass.beta.kubernetes.io/is-default-class"
+	longhornComponentName      = "k8s-longhorn"
+	// loadBalancerProbeName is the name of the service which is created in dry-run mode to check the loadbalancer.
+	loadBalancerProbeName = "ces-preflight-loadbalancer"
+)
+
+// Checker checks whether a cluster is able to host the ecosystem before the setup creates any resource.
+type Checker struct {
+	clientSet kubernetes.Interface
+	namespace string
+	config    appcontext.Preflight
+	// minKubernetesVersion and maxKubernetesVersion contain the supported range of kubernetes versions. The maximum is
+	// not checked if it is nil.
+	minKubernetesVersion *version.Version
+	maxKubernetesVersion *version.Version
+	// storageProvided is true if the setup installs longhorn which provides its own default storage class.
+	storageProvided bool
+	// loadBalancerDisabled is true if the setup does not create the main loadbalancer service.
+	loadBalancerDisabled bool
+	// configuredResources contains the kinds which the resource patches and manifests change.
+	configuredResources []configuredResource
+}
+
+// NewChecker creates a new checker for the pre-flight configuration of the given setup configuration.
+func NewChecker(clientSet kubernetes.Interface, appConfig *appcontext.Config) (*Checker, error) {
+	err := appConfig.Preflight.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid pre-flight configuration: %w", err)
+	}
+
+	minVersion, maxVersion, _ := appConfig.Preflight.KubernetesVersionRange()
+	_, storageProvided := appConfig.Components[longhornComponentName]
+
+	return &Checker{
+		clientSet:            clientSet,
+		namespace:            appConfig.TargetNamespace,
+		config:               appConfig.Preflight,
+		minKubernetesVersion: minVersion,
+		maxKubernetesVersion: maxVersion,
+		storageProvided:      storageProvided,
+		loadBalancerDisabled: appConfig.Pipeline.IsDisabled(appcontext.LoadBalancerStage),
+		configuredResources:  configuredResources(appConfig),
+	}, nil
+}
+
+// Check performs all pre-flight checks and returns their results. The checks do not change the cluster.
+func (c *Checker) Check(ctx context.Context) Report {
+	report := &Report{}
+
+	c.checkKubernetesVersion(report)
+	c.checkRequiredPermissions(ctx, report)
+	c.checkDefaultStorageClass(ctx, report)
+	c.checkAllocatableResources(ctx, report)
+	c.checkLoadBalancer(ctx, report)
+
+	return *report
+}
+
+func (c *Checker) checkKubernetesVersion(report *Report) {
+	info, err := c.clientSet.Discovery().ServerVersion()
+	if err != nil {
+		report.add(checkKubernetesVersion, StatusFailed, "failed to get kubernetes version: %s", err.Error())
+		return
+	}
+
+	serverVersion, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		report.add(checkKubernetesVersion, StatusFailed, "failed to parse kubernetes version %s: %s", info.GitVersion, err.Error())
+		return
+	}
+
+	// patch versions are not relevant for the support of an ecosystem
+	serverMinor := version.MajorMinor(serverVersion.Major(), serverVersion.Minor())
+	if serverMinor.LessThan(c.minKubernetesVersion) {
+		report.add(checkKubernetesVersion, StatusFailed, "kubernetes version %s is older than the minimum version %s", info.GitVersion, c.minKubernetesVersion)
+		return
+	}
+	if c.maxKubernetesVersion != nil && c.maxKubernetesVersion.LessThan(serverMinor) {
+		report.add(checkKubernetesVersion, StatusFailed, "kubernetes version %s is newer than the maximum version %s", info.GitVersion, c.maxKubernetesVersion)
+		return
+	}
+
+	report.add(checkKubernetesVersion, StatusPassed, "kubernetes version %s is supported", info.GitVersion)
+}
+
+func (c *Checker) checkDefaultStorageClass(ctx context.Context, report *Report) {
+	if c.storageProvided {
+		report.add(checkDefaultStorageClass, StatusSkipped, "%s is installed as storage provider", longhornComponentName)
+		return
+	}
+
+	storageClasses, err := c.clientSet.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if errors.IsForbidden(err) {
+		report.add(checkDefaultStorageClass, StatusWarning, "%s", forbiddenMessage("list", "storage classes", err))
+		return
+	}
+	if err != nil {
+		report.add(checkDefaultStorageClass, StatusFailed, "failed to list storage classes: %s", err.Error())
+		return
+	}
+
+	for _, storageClass := range storageClasses.Items {
+		if storageClass.Annotations[defaultStorageClassKey] == "true" || storageClass.Annotations[betaDefaultStorageClassKey] == "true" {
+			report.add(checkDefaultStorageClass, StatusPassed, "storage class %s is the default", storageClass.Name)
+			return
+		}
+	}
+
+	report.add(checkDefaultStorageClass, StatusFailed, "no default storage class found, install %s or mark a storage class as default", longhornComponentName)
+}
+
+func (c *Checker) checkAllocatableResources(ctx context.Context, report *Report) {
+	if c.config.MinCPU == "" && c.config.MinMemory == "" {
+		report.add(checkAllocatableResources, StatusSkipped, "no minimum of CPU or memory is configured")
+		return
+	}
+
+	nodes, err := c.clientSet.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if errors.IsForbidden(err) {
+		report.add(checkAllocatableResources, StatusWarning, "%s", forbiddenMessage("list", "nodes", err))
+		return
+	}
+	if err != nil {
+		report.add(checkAllocatableResources, StatusFailed, "failed to list nodes: %s", err.Error())
+		return
+	}
+
+	cpu, memory := resource.Quantity{}, resource.Quantity{}
+	for _, node := range nodes.Items {
+		if !isSchedulable(node) {
+			continue
+		}
+		cpu.Add(node.Status.Allocatable[corev1.ResourceCPU])
+		memory.Add(node.Status.Allocatable[corev1.ResourceMemory])
+	}
+
+	// the quantities are validated by NewChecker
+	var insufficient []string
+	if c.config.MinCPU != "" && cpu.Cmp(resource.MustParse(c.config.MinCPU)) < 0 {
+		insufficient = append(insufficient, fmt.Sprintf("allocatable CPU %s is less than the minimum %s", cpu.String(), c.config.MinCPU))
+	}
+	if c.config.MinMemory != "" && memory.Cmp(resource.MustParse(c.config.MinMemory)) < 0 {
+		insufficient = append(insufficient, fmt.Sprintf("allocatable memory %s is less than the minimum %s", memory.String(), c.config.MinMemory))
+	}
+
+	if len(insufficient) > 0 {
+		report.add(checkAllocatableResources, StatusFailed, "%s", strings.Join(insufficient, ", "))
+		return
+	}
+
+	report.add(checkAllocatableResources, StatusPassed, "schedulable nodes provide %s CPU and %s memory", cpu.String(), memory.String())
+}
+
+// isSchedulable checks whether pods of the ecosystem can be scheduled on the node.
+func isSchedulable(node corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// checkLoadBalancer creates a service of the type LoadBalancer in dry-run mode. The cluster rejects the service if
+// the type is not permitted, f. i. by an admission policy or a resource quota. Because an accepted service does not
+// prove that a loadbalancer provider exists, the check warns if no existing service of the type LoadBalancer got an
+// external IP or hostname.
+func (c *Checker) checkLoadBalancer(ctx context.Context, report *Report) {
+	if c.loadBalancerDisabled {
+		report.add(checkLoadBalancer, StatusSkipped, "stage %s is disabled", appcontext.LoadBalancerStage)
+		return
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: loadBalancerProbeName, Namespace: c.namespace},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeLoadBalancer,
+			Ports: []corev1.ServicePort{{Name: "https", Port: 443, Protocol: corev1.ProtocolTCP}},
+		},
+	}
+	_, err := c.clientSet.CoreV1().Services(c.namespace).Create(ctx, service, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	if err != nil {
+		report.add(checkLoadBalancer, StatusFailed, "cluster does not accept services of type %s: %s", corev1.ServiceTypeLoadBalancer, err.Error())
+		return
+	}
+
+	services, err := c.clientSet.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if errors.IsForbidden(err) {
+		report.add(checkLoadBalancer, StatusWarning, "cluster accepts services of type %s but %s", corev1.ServiceTypeLoadBalancer, forbiddenMessage("list", "services", err))
+		return
+	}
+	if err != nil {
+		report.add(checkLoadBalancer, StatusWarning, "cluster accepts services of type %s but failed to list services: %s", corev1.ServiceTypeLoadBalancer, err.Error())
+		return
+	}
+
+	for _, existing := range services.Items {
+		if existing.Spec.Type == corev1.ServiceTypeLoadBalancer && len(existing.Status.LoadBalancer.Ingress) > 0 {
+			report.add(checkLoadBalancer, StatusPassed, "cluster accepts services of type %s and service %s/%s has an external address", corev1.ServiceTypeLoadBalancer, existing.Namespace, existing.Name)
+			return
+		}
+	}
+
+	report.add(checkLoadBalancer, StatusWarning, "cluster accepts services of type %s but no service of this type has an external IP or hostname; make sure that a loadbalancer provider is installed", corev1.ServiceTypeLoadBalancer)
+}
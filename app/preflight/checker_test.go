@@ -0,0 +1,350 @@
+package preflight
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+)
+
+var testCtx = context.Background()
+
+func TestNewChecker(t *testing.T) {
+	t.Run("should create checker", func(t *testing.T) {
+		// given
+		appConfig := &appcontext.Config{
+			TargetNamespace: "ecosystem",
+			Components:      map[string]appcontext.ComponentAttributes{"k8s-longhorn": {}},
+			Pipeline:        appcontext.Pipeline{Disable: []appcontext.PipelineStage{appcontext.LoadBalancerStage}},
+			Preflight:       appcontext.Preflight{MaxKubernetesVersion: "1.33"},
+		}
+
+		// when
+		actual, err := NewChecker(fake.NewClientset(), appConfig)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "ecosystem", actual.namespace)
+		assert.Equal(t, "1.27", actual.minKubernetesVersion.String())
+		assert.Equal(t, "1.33", actual.maxKubernetesVersion.String())
+		assert.True(t, actual.storageProvided)
+		assert.True(t, actual.loadBalancerDisabled)
+	})
+
+	t.Run("should fail for invalid configuration", func(t *testing.T) {
+		// given
+		appConfig := &appcontext.Config{Preflight: appcontext.Preflight{MinMemory: "much"}}
+
+		// when
+		_, err := NewChecker(fake.NewClientset(), appConfig)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "invalid pre-flight configuration: minMemory 'much' is invalid")
+	})
+}
+
+func TestChecker_Check(t *testing.T) {
+	t.Run("should pass all checks", func(t *testing.T) {
+		// given
+		clientSet := newTestClientSet("v1.30.2+k3s1", defaultStorageClass(), readyNode("node-1", "2", "8Gi"), readyNode("node-2", "2", "8Gi"), exposedLoadBalancer())
+		sut := newTestChecker(t, clientSet, appcontext.Preflight{MinCPU: "4", MinMemory: "16Gi"})
+
+		// when
+		actual := sut.Check(testCtx)
+
+		// then
+		assert.Equal(t, []Result{
+			{Check: "kubernetes-version", Status: StatusPassed, Message: "kubernetes version v1.30.2+k3s1 is supported"},
+			{Check: "permissions", Status: StatusPassed, Message: "service account of the setup has all 57 required permissions"},
+			{Check: "default-storage-class", Status: StatusPassed, Message: "storage class standard is the default"},
+			{Check: "allocatable-resources", Status: StatusPassed, Message: "schedulable nodes provide 4 CPU and 16Gi memory"},
+			{Check: "loadbalancer", Status: StatusPassed, Message: "cluster accepts services of type LoadBalancer and service kube-system/traefik has an external address"},
+		}, actual.Results)
+		assert.NoError(t, actual.Err())
+	})
+
+	t.Run("should report all failed checks", func(t *testing.T) {
+		// given
+		unschedulable := readyNode("node-2", "8", "32Gi")
+		unschedulable.Spec.Unschedulable = true
+		clientSet := newTestClientSet("v1.26.9", readyNode("node-1", "2", "8Gi"), unschedulable)
+		denyPermission(clientSet, "customresourcedefinitions", "create")
+		clientSet.PrependReactor("create", "services", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.NewForbidden(schema.GroupResource{Resource: "services"}, "ces-preflight-loadbalancer", assert.AnError)
+		})
+		sut := newTestChecker(t, clientSet, appcontext.Preflight{MinCPU: "4", MinMemory: "16Gi"})
+
+		// when
+		actual := sut.Check(testCtx)
+
+		// then
+		assert.Equal(t, []Result{
+			{Check: "kubernetes-version", Status: StatusFailed, Message: "kubernetes version v1.26.9 is older than the minimum version 1.27"},
+			{Check: "permissions", Status: StatusFailed, Message: "service account of the setup is not permitted to create customresourcedefinitions.apiextensions.k8s.io"},
+			{Check: "default-storage-class", Status: StatusFailed, Message: "no default storage class found, install k8s-longhorn or mark a storage class as default"},
+			{Check: "allocatable-resources", Status: StatusFailed, Message: "allocatable CPU 2 is less than the minimum 4, allocatable memory 8Gi is less than the minimum 16Gi"},
+			{Check: "loadbalancer", Status: StatusFailed, Message: "cluster does not accept services of type LoadBalancer: services \"ces-preflight-loadbalancer\" is forbidden: " + assert.AnError.Error()},
+		}, actual.Results)
+		assert.Len(t, actual.Failed(), 5)
+		require.Error(t, actual.Err())
+		assert.ErrorContains(t, actual.Err(), "pre-flight check kubernetes-version failed: kubernetes version v1.26.9 is older than the minimum version 1.27")
+	})
+
+	t.Run("should fail for kubernetes version newer than maximum", func(t *testing.T) {
+		// given
+		clientSet := newTestClientSet("v1.34.0", defaultStorageClass())
+		sut := newTestChecker(t, clientSet, appcontext.Preflight{MaxKubernetesVersion: "1.33"})
+
+		// when
+		actual := sut.Check(testCtx)
+
+		// then
+		assert.Equal(t, Result{Check: "kubernetes-version", Status: StatusFailed, Message: "kubernetes version v1.34.0 is newer than the maximum version 1.33"}, actual.Results[0])
+	})
+
+	t.Run("should accept patch version of maximum", func(t *testing.T) {
+		// given
+		clientSet := newTestClientSet("v1.33.4", defaultStorageClass())
+		sut := newTestChecker(t, clientSet, appcontext.Preflight{MaxKubernetesVersion: "1.33"})
+
+		// when
+		actual := sut.Check(testCtx)
+
+		// then
+		assert.Equal(t, StatusPassed, actual.Results[0].Status)
+	})
+
+	t.Run("should skip checks which are not necessary for the configuration", func(t *testing.T) {
+		// given
+		clientSet := newTestClientSet("v1.30.0")
+		appConfig := &appcontext.Config{
+			TargetNamespace: "ecosystem",
+			Components:      map[string]appcontext.ComponentAttributes{"k8s-longhorn": {}},
+			Pipeline:        appcontext.Pipeline{Disable: []appcontext.PipelineStage{appcontext.LoadBalancerStage}},
+		}
+		sut, err := NewChecker(clientSet, appConfig)
+		require.NoError(t, err)
+
+		// when
+		actual := sut.Check(testCtx)
+
+		// then
+		assert.Equal(t, []Result{
+			{Check: "default-storage-class", Status: StatusSkipped, Message: "k8s-longhorn is installed as storage provider"},
+			{Check: "allocatable-resources", Status: StatusSkipped, Message: "no minimum of CPU or memory is configured"},
+			{Check: "loadbalancer", Status: StatusSkipped, Message: "stage loadbalancer is disabled"},
+		}, actual.Results[2:])
+		assert.NoError(t, actual.Err())
+	})
+
+	t.Run("should warn if no loadbalancer provider is detectable", func(t *testing.T) {
+		// given
+		withoutAddress := exposedLoadBalancer()
+		withoutAddress.Status = corev1.ServiceStatus{}
+		clusterIP := exposedLoadBalancer()
+		clusterIP.Name = "internal"
+		clusterIP.Spec.Type = corev1.ServiceTypeClusterIP
+		clientSet := newTestClientSet("v1.30.0", defaultStorageClass(), withoutAddress, clusterIP)
+		sut := newTestChecker(t, clientSet, appcontext.Preflight{})
+
+		// when
+		actual := sut.Check(testCtx)
+
+		// then
+		assert.Equal(t, Result{Check: "loadbalancer", Status: StatusWarning, Message: "cluster accepts services of type LoadBalancer but no service of this type has an external IP or hostname; make sure that a loadbalancer provider is installed"}, actual.Results[4])
+		assert.NoError(t, actual.Err())
+	})
+
+	t.Run("should warn if setup is not permitted to list services", func(t *testing.T) {
+		// given
+		clientSet := newTestClientSet("v1.30.0", defaultStorageClass())
+		clientSet.PrependReactor("list", "services", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.NewForbidden(schema.GroupResource{Resource: "services"}, "", assert.AnError)
+		})
+		sut := newTestChecker(t, clientSet, appcontext.Preflight{})
+
+		// when
+		actual := sut.Check(testCtx)
+
+		// then
+		assert.Equal(t, StatusWarning, actual.Results[4].Status)
+		assert.Contains(t, actual.Results[4].Message, "cluster accepts services of type LoadBalancer but not permitted to list services, the check is skipped")
+		assert.NoError(t, actual.Err())
+	})
+
+	t.Run("should warn if setup is not permitted to list nodes and storage classes", func(t *testing.T) {
+		// given
+		clientSet := newTestClientSet("v1.30.0", exposedLoadBalancer())
+		for _, resourceName := range []string{"nodes", "storageclasses"} {
+			clientSet.PrependReactor("list", resourceName, func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, errors.NewForbidden(schema.GroupResource{Resource: action.GetResource().Resource}, "", assert.AnError)
+			})
+		}
+		sut := newTestChecker(t, clientSet, appcontext.Preflight{MinCPU: "4"})
+
+		// when
+		actual := sut.Check(testCtx)
+
+		// then
+		warnings := actual.Warnings()
+		require.Len(t, warnings, 2)
+		assert.Equal(t, "default-storage-class", warnings[0].Check)
+		assert.Contains(t, warnings[0].Message, "not permitted to list storage classes, the check is skipped")
+		assert.Equal(t, "allocatable-resources", warnings[1].Check)
+		assert.Contains(t, warnings[1].Message, "not permitted to list nodes, the check is skipped")
+		assert.NoError(t, actual.Err())
+	})
+
+	t.Run("should warn if permissions cannot be reviewed", func(t *testing.T) {
+		// given
+		clientSet := newTestClientSet("v1.30.0", defaultStorageClass())
+		clientSet.PrependReactor("create", "selfsubjectaccessreviews", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, assert.AnError
+		})
+		sut := newTestChecker(t, clientSet, appcontext.Preflight{})
+
+		// when
+		actual := sut.Check(testCtx)
+
+		// then
+		assert.Equal(t, Result{Check: "permissions", Status: StatusWarning, Message: "failed to review permission to get configmaps: " + assert.AnError.Error()}, actual.Results[1])
+	})
+
+	t.Run("should review namespaced permissions in target namespace", func(t *testing.T) {
+		// given
+		clientSet := newTestClientSet("v1.30.0", defaultStorageClass())
+		var reviewed []authorizationv1.ResourceAttributes
+		clientSet.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			reviewed = append(reviewed, *review.Spec.ResourceAttributes)
+			review.Status.Allowed = true
+			return true, review, nil
+		})
+		sut := newTestChecker(t, clientSet, appcontext.Preflight{})
+
+		// when
+		sut.Check(testCtx)
+
+		// then
+		assert.Contains(t, reviewed, authorizationv1.ResourceAttributes{Namespace: "ecosystem", Verb: "create", Group: "k8s.cloudogu.com", Resource: "dogus"})
+		assert.Contains(t, reviewed, authorizationv1.ResourceAttributes{Verb: "create", Resource: "namespaces"})
+	})
+
+	t.Run("should review permissions for configured resource patches and manifests", func(t *testing.T) {
+		// given
+		clientSet := newTestClientSet("v1.30.0", defaultStorageClass())
+		clientSet.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+			{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "services", Kind: "Service", Namespaced: true}}},
+			{GroupVersion: "networking.k8s.io/v1", APIResources: []metav1.APIResource{{Name: "ingressclasses", Kind: "IngressClass"}}},
+		}
+		var reviewed []authorizationv1.ResourceAttributes
+		clientSet.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			reviewed = append(reviewed, *review.Spec.ResourceAttributes)
+			review.Status.Allowed = true
+			return true, review, nil
+		})
+		appConfig := &appcontext.Config{
+			TargetNamespace: "ecosystem",
+			ResourcePatches: []patch.ResourcePatch{
+				{Resource: patch.ResourceReference{ApiVersion: "v1", Kind: "Service", LabelSelector: "app=ces", Namespace: "kube-system"}},
+				{Resource: patch.ResourceReference{ApiVersion: "k8s.example.com/v1", Kind: "Unknown", Name: "unknown"}},
+			},
+			ResourceManifests: []patch.ResourceManifest{
+				{Manifest: "apiVersion: networking.k8s.io/v1\nkind: IngressClass\nmetadata:\n  name: ces\n"},
+				{URL: "https://example.com/manifest.yaml"},
+			},
+		}
+		sut, err := NewChecker(clientSet, appConfig)
+		require.NoError(t, err)
+
+		// when
+		actual := sut.Check(testCtx)
+
+		// then
+		assert.Equal(t, Result{Check: "permissions", Status: StatusPassed, Message: "service account of the setup has all 61 required permissions"}, actual.Results[1])
+		assert.Contains(t, reviewed, authorizationv1.ResourceAttributes{Namespace: "kube-system", Verb: "patch", Resource: "services"})
+		assert.Contains(t, reviewed, authorizationv1.ResourceAttributes{Namespace: "kube-system", Verb: "list", Resource: "services"})
+		assert.Contains(t, reviewed, authorizationv1.ResourceAttributes{Verb: "create", Group: "networking.k8s.io", Resource: "ingressclasses"})
+		assert.Contains(t, reviewed, authorizationv1.ResourceAttributes{Verb: "patch", Group: "networking.k8s.io", Resource: "ingressclasses"})
+		assert.Contains(t, reviewed, authorizationv1.ResourceAttributes{Verb: "delete", Group: "networking.k8s.io", Resource: "ingressclasses"})
+	})
+}
+
+func newTestChecker(t *testing.T, clientSet *fake.Clientset, config appcontext.Preflight) *Checker {
+	t.Helper()
+
+	sut, err := NewChecker(clientSet, &appcontext.Config{TargetNamespace: "ecosystem", Preflight: config})
+	require.NoError(t, err)
+
+	return sut
+}
+
+// newTestClientSet creates a fake client set of a cluster with the given version which allows every access review.
+func newTestClientSet(gitVersion string, objects ...runtime.Object) *fake.Clientset {
+	clientSet := fake.NewClientset(objects...)
+	clientSet.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: gitVersion}
+	clientSet.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = true
+		return true, review, nil
+	})
+
+	return clientSet
+}
+
+func denyPermission(clientSet *fake.Clientset, resourceName string, verb string) {
+	clientSet.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		if attributes.Resource != resourceName || attributes.Verb != verb {
+			return false, nil, nil
+		}
+
+		return true, review, nil
+	})
+}
+
+func defaultStorageClass() *storagev1.StorageClass {
+	return &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{
+		Name:        "standard",
+		Annotations: map[string]string{"storageclass.kubernetes.io/is-default-class": "true"},
+	}}
+}
+
+func exposedLoadBalancer() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "traefik", Namespace: "kube-system"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+			Ingress: []corev1.LoadBalancerIngress{{IP: "192.168.56.2"}},
+		}},
+	}
+}
+
+func readyNode(name string, cpu string, memory string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory)},
+			Conditions:  []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
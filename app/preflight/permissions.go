@@ -0,0 +1,235 @@
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+)
+
+const checkPermissions = "permissions"
+
+// permission is a single RBAC permission which the steps of the setup need.
+type permission struct {
+	group    string
+	resource string
+	verb     string
+	// namespaced permissions are checked in the target namespace of the setup.
+	namespaced bool
+	// namespace overrides the target namespace of namespaced permissions.
+	namespace string
+}
+
+func (p permission) String() string {
+	resource := p.resource
+	if p.group != "" {
+		resource += "." + p.group
+	}
+	if p.namespaced && p.namespace != "" {
+		resource += " in namespace " + p.namespace
+	}
+
+	return p.verb + " " + resource
+}
+
+// requiredPermissions contains the RBAC permissions which the setup needs to install the ecosystem and to roll it back.
+// They are built from the roles of the helm chart of the setup, so missing or restricted roles are detected before any
+// resource is created. The permissions for the resource patches and manifests depend on the configuration, see
+// configuredResources.
+var requiredPermissions = slices.Concat(namespacePermissions, clusterResourcesPermissions, roleManagementPermissions, preflightPermissions)
+
+// namespacePermissions contains the permissions which the built-in steps need in the target namespace. The role of
+// the chart (rbac-role.yaml) grants all permissions in this namespace.
+var namespacePermissions = []permission{
+	// configuration, state and progress of the setup as well as the registry and the certificate of the ecosystem
+	{resource: "configmaps", verb: "get", namespaced: true},
+	{resource: "configmaps", verb: "create", namespaced: true},
+	{resource: "configmaps", verb: "update", namespaced: true},
+	{resource: "configmaps", verb: "delete", namespaced: true},
+	{resource: "secrets", verb: "get", namespaced: true},
+	{resource: "secrets", verb: "create", namespaced: true},
+	{resource: "secrets", verb: "update", namespaced: true},
+	{resource: "secrets", verb: "delete", namespaced: true},
+	// main loadbalancer
+	{resource: "services", verb: "get", namespaced: true},
+	{resource: "services", verb: "create", namespaced: true},
+	{resource: "services", verb: "update", namespaced: true},
+	{resource: "services", verb: "delete", namespaced: true},
+	// automount of the default service account
+	{resource: "serviceaccounts", verb: "get", namespaced: true},
+	{resource: "serviceaccounts", verb: "update", namespaced: true},
+	// components and dogus which are updated by reconcile runs and deleted by the rollback
+	{group: "k8s.cloudogu.com", resource: "components", verb: "get", namespaced: true},
+	{group: "k8s.cloudogu.com", resource: "components", verb: "create", namespaced: true},
+	{group: "k8s.cloudogu.com", resource: "components", verb: "update", namespaced: true},
+	{group: "k8s.cloudogu.com", resource: "components", verb: "delete", namespaced: true},
+	{group: "k8s.cloudogu.com", resource: "dogus", verb: "get", namespaced: true},
+	{group: "k8s.cloudogu.com", resource: "dogus", verb: "create", namespaced: true},
+	{group: "k8s.cloudogu.com", resource: "dogus", verb: "update", namespaced: true},
+	{group: "k8s.cloudogu.com", resource: "dogus", verb: "delete", namespaced: true},
+}
+
+// clusterResourcesPermissions contains the permissions of the cluster role of the chart (cluster-resources-role.yaml)
+// which the helm installations of the component operator, its CRDs and cert-manager need.
+var clusterResourcesPermissions = []permission{
+	{group: "apiextensions.k8s.io", resource: "customresourcedefinitions", verb: "get"},
+	{group: "apiextensions.k8s.io", resource: "customresourcedefinitions", verb: "list"},
+	{group: "apiextensions.k8s.io", resource: "customresourcedefinitions", verb: "create"},
+	{group: "apiextensions.k8s.io", resource: "customresourcedefinitions", verb: "patch"},
+	{group: "apiextensions.k8s.io", resource: "customresourcedefinitions", verb: "update"},
+	{group: "rbac.authorization.k8s.io", resource: "clusterroles", verb: "*"},
+	{group: "rbac.authorization.k8s.io", resource: "clusterrolebindings", verb: "*"},
+	{group: "networking.k8s.io", resource: "ingressclasses", verb: "get"},
+	{group: "networking.k8s.io", resource: "ingressclasses", verb: "create"},
+	{group: "networking.k8s.io", resource: "ingressclasses", verb: "list"},
+	{group: "networking.k8s.io", resource: "ingressclasses", verb: "watch"},
+	{group: "admissionregistration.k8s.io", resource: "mutatingwebhookconfigurations", verb: "get"},
+	{group: "admissionregistration.k8s.io", resource: "mutatingwebhookconfigurations", verb: "create"},
+	{group: "admissionregistration.k8s.io", resource: "mutatingwebhookconfigurations", verb: "delete"},
+	{group: "admissionregistration.k8s.io", resource: "validatingwebhookconfigurations", verb: "get"},
+	{group: "admissionregistration.k8s.io", resource: "validatingwebhookconfigurations", verb: "create"},
+	{group: "admissionregistration.k8s.io", resource: "validatingwebhookconfigurations", verb: "delete"},
+	{group: "coordination.k8s.io", resource: "leases", verb: "create", namespaced: true},
+	{group: "coordination.k8s.io", resource: "leases", verb: "get", namespaced: true},
+	{group: "coordination.k8s.io", resource: "leases", verb: "update", namespaced: true},
+	{group: "coordination.k8s.io", resource: "leases", verb: "patch", namespaced: true},
+	{resource: "namespaces", verb: "create"},
+	{group: "cert-manager.io", resource: "clusterissuers", verb: "get"},
+	{group: "cert-manager.io", resource: "clusterissuers", verb: "create"},
+	{group: "cert-manager.io", resource: "clusterissuers", verb: "list"},
+	{group: "cert-manager.io", resource: "clusterissuers", verb: "delete"},
+}
+
+// roleManagementPermissions contains the permissions of the role of the chart in the namespace kube-system
+// (role-management-role.yaml) which the helm installations need to create their own roles.
+var roleManagementPermissions = []permission{
+	{group: "rbac.authorization.k8s.io", resource: "roles", verb: "get", namespaced: true, namespace: "kube-system"},
+	{group: "rbac.authorization.k8s.io", resource: "roles", verb: "create", namespaced: true, namespace: "kube-system"},
+	{group: "rbac.authorization.k8s.io", resource: "roles", verb: "delete", namespaced: true, namespace: "kube-system"},
+	{group: "rbac.authorization.k8s.io", resource: "rolebindings", verb: "get", namespaced: true, namespace: "kube-system"},
+	{group: "rbac.authorization.k8s.io", resource: "rolebindings", verb: "create", namespaced: true, namespace: "kube-system"},
+	{group: "rbac.authorization.k8s.io", resource: "rolebindings", verb: "delete", namespaced: true, namespace: "kube-system"},
+}
+
+// preflightPermissions contains the permissions of the cluster role of the chart for the pre-flight checks and the
+// conditions of resource patches (preflight-role.yaml).
+var preflightPermissions = []permission{
+	{resource: "nodes", verb: "list"},
+	{group: "storage.k8s.io", resource: "storageclasses", verb: "list"},
+	{resource: "services", verb: "list"},
+}
+
+// configuredResource is a kind of resources which the resource patches or manifests of the setup configuration change.
+type configuredResource struct {
+	gvk       schema.GroupVersionKind
+	namespace string
+	verbs     []string
+}
+
+// configuredResources collects the kinds which the resource patches and the inline resource manifests of the setup
+// configuration change. Patches need the verb patch and, if they select resources by labels, list. Manifests are
+// applied server-side which needs create and patch, and their resources are deleted by the rollback.
+func configuredResources(appConfig *appcontext.Config) []configuredResource {
+	var resources []configuredResource
+	for _, resourcePatch := range appConfig.ResourcePatches {
+		verbs := []string{"patch"}
+		if resourcePatch.Resource.LabelSelector != "" {
+			verbs = append(verbs, "list")
+		}
+		resources = append(resources, configuredResource{gvk: resourcePatch.Resource.GroupVersionKind(), namespace: resourcePatch.Resource.Namespace, verbs: verbs})
+	}
+
+	for _, manifest := range appConfig.ResourceManifests {
+		objects, err := manifest.InlineObjects()
+		if err != nil {
+			// invalid manifests are reported by the validation
+			continue
+		}
+		for _, object := range objects {
+			resources = append(resources, configuredResource{gvk: object.GroupVersionKind(), namespace: object.GetNamespace(), verbs: []string{"create", "patch", "delete"}})
+		}
+	}
+
+	return resources
+}
+
+// configuredPermissions maps the configuredResources to the permissions for their resource types. Kinds which are
+// unknown to the cluster are skipped because a component may install their definition during the setup.
+func (c *Checker) configuredPermissions() []permission {
+	if len(c.configuredResources) == 0 {
+		return nil
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(c.clientSet.Discovery()))
+	var permissions []permission
+	for _, configured := range c.configuredResources {
+		mapping, err := mapper.RESTMapping(configured.gvk.GroupKind(), configured.gvk.Version)
+		if err != nil {
+			continue
+		}
+
+		namespaced := mapping.Scope.Name() == meta.RESTScopeNameNamespace
+		for _, verb := range configured.verbs {
+			configuredPermission := permission{group: mapping.Resource.Group, resource: mapping.Resource.Resource, verb: verb, namespaced: namespaced}
+			if namespaced && configured.namespace != c.namespace {
+				configuredPermission.namespace = configured.namespace
+			}
+			if !slices.Contains(requiredPermissions, configuredPermission) && !slices.Contains(permissions, configuredPermission) {
+				permissions = append(permissions, configuredPermission)
+			}
+		}
+	}
+
+	return permissions
+}
+
+// checkRequiredPermissions checks with SelfSubjectAccessReviews that the service account of the setup has all
+// requiredPermissions and the permissions for the configured resource patches and manifests.
+func (c *Checker) checkRequiredPermissions(ctx context.Context, report *Report) {
+	permissions := append(slices.Clone(requiredPermissions), c.configuredPermissions()...)
+
+	var missing []string
+	for _, required := range permissions {
+		attributes := &authorizationv1.ResourceAttributes{Group: required.group, Resource: required.resource, Verb: required.verb}
+		if required.namespaced {
+			attributes.Namespace = c.namespace
+			if required.namespace != "" {
+				attributes.Namespace = required.namespace
+			}
+		}
+
+		review, err := c.clientSet.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: attributes},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			report.add(checkPermissions, StatusWarning, "failed to review permission to %s: %s", required, err.Error())
+			return
+		}
+
+		if !review.Status.Allowed {
+			missing = append(missing, required.String())
+		}
+	}
+
+	if len(missing) > 0 {
+		report.add(checkPermissions, StatusFailed, "service account of the setup is not permitted to %s", strings.Join(missing, ", "))
+		return
+	}
+
+	report.add(checkPermissions, StatusPassed, "service account of the setup has all %d required permissions", len(permissions))
+}
+
+// forbiddenMessage describes a check which cannot be performed because the setup is not permitted to read the
+// resources.
+func forbiddenMessage(verb string, resource string, err error) string {
+	return fmt.Sprintf("not permitted to %s %s, the check is skipped: %s", verb, resource, err.Error())
+}
@@ -0,0 +1,75 @@
+package preflight
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const chartTemplatesDir = "../../k8s/helm/templates"
+
+var templateLine = regexp.MustCompile(`(?m)^.*\{\{.*$`)
+
+func Test_requiredPermissions(t *testing.T) {
+	t.Run("should contain the permissions of the roles of the chart", func(t *testing.T) {
+		for _, file := range []string{"cluster-resources-role.yaml", "role-management-role.yaml", "preflight-role.yaml"} {
+			t.Run(file, func(t *testing.T) {
+				// given
+				role := readChartRole(t, file)
+
+				// then
+				for _, rule := range role.Rules {
+					for _, group := range rule.APIGroups {
+						for _, resource := range rule.Resources {
+							for _, verb := range rule.Verbs {
+								assert.Truef(t, containsPermission(group, resource, verb, role.Namespace), "%s grants %s on %s.%s which is not required", file, verb, resource, group)
+							}
+						}
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("should contain no duplicates", func(t *testing.T) {
+		seen := map[permission]bool{}
+		for _, required := range requiredPermissions {
+			assert.Falsef(t, seen[required], "duplicate permission %v", required)
+			seen[required] = true
+		}
+	})
+}
+
+// readChartRole reads a role of the chart. Lines with template actions are dropped because they contain only the
+// metadata.
+func readChartRole(t *testing.T, file string) rbacv1.Role {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join(chartTemplatesDir, file))
+	require.NoError(t, err)
+
+	var role rbacv1.Role
+	require.NoError(t, yaml.Unmarshal(templateLine.ReplaceAll(content, nil), &role))
+	require.NotEmpty(t, role.Rules)
+
+	return role
+}
+
+// containsPermission checks if a required permission matches the rule of a role. Rules for all api groups are
+// matched by a required permission of any group.
+func containsPermission(group string, resource string, verb string, namespace string) bool {
+	for _, required := range requiredPermissions {
+		if required.resource == resource && required.verb == verb && required.namespace == namespace &&
+			(required.group == group || group == "*") {
+			return true
+		}
+	}
+
+	return false
+}
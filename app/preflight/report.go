@@ -0,0 +1,71 @@
+package preflight
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Status describes the outcome of a single pre-flight check.
+type Status string
+
+const (
+	// StatusPassed means that the cluster fulfills the requirement of the check.
+	StatusPassed Status = "passed"
+	// StatusWarning means that the check could not be performed completely, f. i. because of missing permissions.
+	// Warnings never fail the setup.
+	StatusWarning Status = "warning"
+	// StatusFailed means that the cluster does not fulfill the requirement of the check.
+	StatusFailed Status = "failed"
+	// StatusSkipped means that the check is not necessary for the configuration of the setup.
+	StatusSkipped Status = "skipped"
+)
+
+// Result contains the outcome of a single pre-flight check.
+type Result struct {
+	// Check identifies the check, f. i. "kubernetes-version".
+	Check string `json:"check"`
+	// Status contains the outcome of the check.
+	Status Status `json:"status"`
+	// Message describes the outcome of the check.
+	Message string `json:"message"`
+}
+
+// Report contains the results of all pre-flight checks in the order they were performed.
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+func (r *Report) add(check string, status Status, format string, args ...any) {
+	r.Results = append(r.Results, Result{Check: check, Status: status, Message: fmt.Sprintf(format, args...)})
+}
+
+// Failed returns the results of all failed checks.
+func (r Report) Failed() []Result {
+	return r.withStatus(StatusFailed)
+}
+
+// Warnings returns the results of all checks which reported a warning.
+func (r Report) Warnings() []Result {
+	return r.withStatus(StatusWarning)
+}
+
+func (r Report) withStatus(status Status) []Result {
+	var results []Result
+	for _, result := range r.Results {
+		if result.Status == status {
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+// Err returns an error which contains all failed checks or nil if no check failed.
+func (r Report) Err() error {
+	var errs []error
+	for _, result := range r.Failed() {
+		errs = append(errs, fmt.Errorf("pre-flight check %s failed: %s", result.Check, result.Message))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,93 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/context"
+)
+
+const (
+	// Setup is the name of the schema of the setup.json.
+	Setup = "setup"
+	// Config is the name of the schema of the k8s-ces-setup.yaml.
+	Config = "config"
+)
+
+// Names contains the names of all schemas in a stable order.
+var Names = []string{Setup, Config}
+
+// Get generates the JSON schema with the given name. It returns false if no schema with the name exists.
+//
+// The schemas reject unknown keys like the strict decoding of the configurations does. Legacy keys which the decoding
+// ignores with a warning are contained as deprecated properties. No key is required because missing keys are reported
+// by the validation of the setup.
+func Get(name string) (*jsonschema.Schema, bool) {
+	switch name {
+	case Setup:
+		return generate(&context.SetupJsonConfiguration{}, "setup.json", context.LegacySetupJsonKeys), true
+	case Config:
+		return generate(&context.Config{}, "k8s-ces-setup.yaml", context.LegacyConfigKeys), true
+	default:
+		return nil, false
+	}
+}
+
+func generate(configuration any, title string, legacyKeys []string) *jsonschema.Schema {
+	reflector := &jsonschema.Reflector{
+		// the configurations are decoded by their json tags, even the YAML of the k8s-ces-setup.yaml
+		FieldNameTag:               "json",
+		RequiredFromJSONSchemaTags: true,
+		ExpandedStruct:             true,
+		Anonymous:                  true,
+		Mapper:                     mapLogLevel,
+	}
+
+	result := reflector.Reflect(configuration)
+	result.Title = title
+	for _, key := range legacyKeys {
+		addLegacyKey(result, key)
+	}
+
+	return result
+}
+
+// addLegacyKey adds the key as deprecated property of any type. Nested keys like naming.hostname are added to the
+// definition of their parent object.
+func addLegacyKey(root *jsonschema.Schema, key string) {
+	parts := strings.Split(key, ".")
+	parent := root
+	for _, part := range parts[:len(parts)-1] {
+		property, ok := parent.Properties.Get(part)
+		if !ok {
+			logrus.Warnf("Cannot add legacy key %q to schema %s because %q is no property", key, root.Title, part)
+			return
+		}
+		if property.Ref != "" {
+			property = root.Definitions[strings.TrimPrefix(property.Ref, "#/$defs/")]
+		}
+		parent = property
+	}
+
+	parent.Properties.Set(parts[len(parts)-1], &jsonschema.Schema{
+		Description: "Deprecated: the key is not evaluated anymore and will be rejected in a future version.",
+		Deprecated:  true,
+	})
+}
+
+// mapLogLevel describes the log level as the names which are accepted by logrus instead of the underlying integer.
+func mapLogLevel(t reflect.Type) *jsonschema.Schema {
+	if t != reflect.TypeOf(logrus.Level(0)) {
+		return nil
+	}
+
+	var levels []any
+	for _, level := range logrus.AllLevels {
+		levels = append(levels, strings.ToUpper(level.String()), level.String())
+	}
+
+	return &jsonschema.Schema{Type: "string", Enum: levels}
+}
@@ -0,0 +1,160 @@
+package schema
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xeipuuv/gojsonschema"
+	"sigs.k8s.io/yaml"
+)
+
+// publishedSchemas maps the names of the schemas to their published files in docs/schema. The files are updated with
+// UPDATE_SCHEMAS=true go test ./app/schema/...
+var publishedSchemas = map[string]string{
+	Setup:  "../../docs/schema/setup.schema.json",
+	Config: "../../docs/schema/k8s-ces-setup.schema.json",
+}
+
+func marshalSchema(t *testing.T, name string) []byte {
+	t.Helper()
+
+	schema, ok := Get(name)
+	require.True(t, ok)
+	data, err := json.MarshalIndent(schema, "", "  ")
+	require.NoError(t, err)
+
+	return append(data, '\n')
+}
+
+func validate(t *testing.T, name string, document []byte) *gojsonschema.Result {
+	t.Helper()
+
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(marshalSchema(t, name)), gojsonschema.NewBytesLoader(document))
+	require.NoError(t, err)
+
+	return result
+}
+
+func TestGet(t *testing.T) {
+	t.Run("should return false for unknown schema", func(t *testing.T) {
+		// when
+		_, ok := Get("blueprint")
+
+		// then
+		assert.False(t, ok)
+	})
+
+	t.Run("should describe the log level as name", func(t *testing.T) {
+		// when
+		schema, ok := Get(Config)
+
+		// then
+		require.True(t, ok)
+		logLevel, ok := schema.Properties.Get("log_level")
+		require.True(t, ok)
+		assert.Equal(t, "string", logLevel.Type)
+		assert.Contains(t, logLevel.Enum, "INFO")
+		assert.Contains(t, logLevel.Enum, "debug")
+	})
+
+	t.Run("should accept the setup.json of the development environment", func(t *testing.T) {
+		// given
+		document, err := os.ReadFile("../../k8s/dev-resources/setup.json")
+		require.NoError(t, err)
+
+		// when
+		result := validate(t, Setup, document)
+
+		// then
+		assert.True(t, result.Valid(), result.Errors())
+	})
+
+	t.Run("should accept the k8s-ces-setup.yaml of the development environment", func(t *testing.T) {
+		// given
+		document, err := os.ReadFile("../../k8s/dev-resources/k8s-ces-setup.yaml")
+		require.NoError(t, err)
+		document, err = yaml.YAMLToJSON(document)
+		require.NoError(t, err)
+
+		// when
+		result := validate(t, Config, document)
+
+		// then
+		assert.True(t, result.Valid(), result.Errors())
+	})
+
+	t.Run("should accept deprecated legacy keys of the setup.json", func(t *testing.T) {
+		// when
+		result := validate(t, Setup, []byte(`{"token": "secret", "naming": {"fqdn": "ces.local", "hostname": "ces"}}`))
+
+		// then
+		assert.True(t, result.Valid(), result.Errors())
+	})
+
+	t.Run("should accept deprecated legacy keys of the k8s-ces-setup.yaml", func(t *testing.T) {
+		// given
+		document := []byte(`{
+			"dogu_operator_url": "https://example.com/dogu-operator.yaml",
+			"service_discovery_url": "https://example.com/service-discovery.yaml",
+			"docker_registry_secret": {"url": "registry.cloudogu.com"},
+			"dogu_registry_secret": {"url": "https://dogu.cloudogu.com/api/v2/dogus"},
+			"helm_registry_secret": {"host": "registry.cloudogu.com"}
+		}`)
+
+		// when
+		result := validate(t, Config, document)
+
+		// then
+		assert.True(t, result.Valid(), result.Errors())
+	})
+
+	t.Run("should mark legacy keys as deprecated", func(t *testing.T) {
+		// when
+		schema, ok := Get(Setup)
+
+		// then
+		require.True(t, ok)
+		token, ok := schema.Properties.Get("token")
+		require.True(t, ok)
+		assert.True(t, token.Deprecated)
+		hostname, ok := schema.Definitions["Naming"].Properties.Get("hostname")
+		require.True(t, ok)
+		assert.True(t, hostname.Deprecated)
+	})
+
+	t.Run("should reject unknown keys", func(t *testing.T) {
+		// when
+		result := validate(t, Setup, []byte(`{"naming": {"fqdn": "ces.local", "certficateType": "selfsigned"}}`))
+
+		// then
+		require.False(t, result.Valid())
+		require.Len(t, result.Errors(), 1)
+		assert.Equal(t, "naming", result.Errors()[0].Field())
+		assert.Contains(t, result.Errors()[0].String(), "certficateType")
+	})
+}
+
+func TestPublishedSchemas(t *testing.T) {
+	for _, name := range Names {
+		t.Run(name, func(t *testing.T) {
+			// given
+			expected := marshalSchema(t, name)
+			path := publishedSchemas[name]
+			if os.Getenv("UPDATE_SCHEMAS") == "true" {
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				require.NoError(t, os.WriteFile(path, expected, 0644))
+			}
+
+			// when
+			actual, err := os.ReadFile(path)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(actual), "schema %s is outdated, update it with UPDATE_SCHEMAS=true go test ./app/schema/...", path)
+		})
+	}
+}
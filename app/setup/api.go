@@ -2,27 +2,97 @@ package setup
 
 import (
 	"context"
+	"fmt"
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/preflight"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/schema"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/validation"
+	"io"
 	"k8s.io/client-go/rest"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
 )
 
-const endpointPostStartSetup = "/api/v1/setup"
+const (
+	endpointPostStartSetup    = "/api/v1/setup"
+	endpointDeleteSetup       = "/api/v1/setup"
+	endpointPostValidateSetup = "/api/v1/setup/validate"
+	endpointGetSetupStatus    = "/api/v1/setup/status"
+	endpointGetPreflight      = "/api/v1/setup/preflight"
+	endpointGetSetupStatusSSE = "/api/v1/setup/status/events"
+	endpointSetupRuns         = "/api/v1/setup/runs/"
+	endpointGetSetupRun       = endpointSetupRuns + ":id"
+	endpointGetSchema         = "/api/v1/schema/:name"
+	queryParamDryRun          = "dryRun"
+	queryParamReconcile       = "reconcile"
+	sseEventStatus            = "status"
+	sseEventStepTransition    = "step"
+)
 
 type ginRoutes interface {
 	gin.IRoutes
 }
 
+// setupJsonProblemValidator collects all problems of a setup.json configuration.
+type setupJsonProblemValidator interface {
+	ValidateAll(ctx context.Context, setupJson *appcontext.SetupJsonConfiguration) validation.Problems
+}
+
 // SetupAPI setups the REST API for configuration information
 func SetupAPI(ctx context.Context, router ginRoutes, clusterConfig *rest.Config, k8sClient kubernetes.Interface, setupContextBuilder *appcontext.SetupContextBuilder) {
 	logrus.Debugf("Register endpoint [%s][%s]", http.MethodPost, endpointPostStartSetup)
 	router.POST(endpointPostStartSetup, func(ginCtx *gin.Context) {
 		startSetup(ctx, ginCtx, clusterConfig, k8sClient, setupContextBuilder)
 	})
+
+	logrus.Debugf("Register endpoint [%s][%s]", http.MethodPost, endpointPostValidateSetup)
+	router.POST(endpointPostValidateSetup, func(ginCtx *gin.Context) {
+		validator, err := newSetupJsonProblemValidator(ctx, k8sClient, setupContextBuilder)
+		if err != nil {
+			handleInternalServerError(ginCtx, err, "Failed to create setup.json validator")
+			return
+		}
+
+		validateSetupJson(ctx, ginCtx, validator)
+	})
+
+	logrus.Debugf("Register endpoint [%s][%s]", http.MethodGet, endpointGetPreflight)
+	router.GET(endpointGetPreflight, func(ginCtx *gin.Context) {
+		checker, err := newPreflightChecker(ctx, k8sClient, setupContextBuilder)
+		if err != nil {
+			handleInternalServerError(ginCtx, err, "Failed to create pre-flight checker")
+			return
+		}
+
+		checkPreflight(ctx, ginCtx, checker)
+	})
+
+	logrus.Debugf("Register endpoint [%s][%s]", http.MethodDelete, endpointDeleteSetup)
+	router.DELETE(endpointDeleteSetup, func(ginCtx *gin.Context) {
+		rollbackSetup(ctx, ginCtx, clusterConfig, k8sClient, setupContextBuilder)
+	})
+
+	logrus.Debugf("Register endpoint [%s][%s]", http.MethodGet, endpointGetSetupRun)
+	router.GET(endpointGetSetupRun, func(ginCtx *gin.Context) {
+		getSetupRun(ginCtx, setupRuns)
+	})
+
+	logrus.Debugf("Register endpoint [%s][%s]", http.MethodGet, endpointGetSchema)
+	router.GET(endpointGetSchema, getSchema)
+
+	logrus.Debugf("Register endpoint [%s][%s]", http.MethodGet, endpointGetSetupStatus)
+	router.GET(endpointGetSetupStatus, func(ginCtx *gin.Context) {
+		getSetupStatus(ginCtx, setupStatus)
+	})
+
+	logrus.Debugf("Register endpoint [%s][%s]", http.MethodGet, endpointGetSetupStatusSSE)
+	router.GET(endpointGetSetupStatusSSE, func(ginCtx *gin.Context) {
+		streamSetupStatus(ginCtx, setupStatus)
+	})
 }
 
 func handleInternalServerError(ginCtx *gin.Context, err error, causingAction string) {
@@ -34,18 +104,223 @@ func handleInternalServerError(ginCtx *gin.Context, err error, causingAction str
 	_ = ginCtx.Error(err)
 }
 
+// startSetup starts the setup in the background and responds with the newly created run. Only one setup can run at a
+// time.
 func startSetup(ctx context.Context, ginCtx *gin.Context, clusterConfig *rest.Config, k8sClient kubernetes.Interface, setupContextBuilder *appcontext.SetupContextBuilder) {
+	if IsDryRunEnabled() || ginCtx.Query(queryParamDryRun) == "true" {
+		planSetup(ctx, ginCtx, clusterConfig, k8sClient, setupContextBuilder)
+		return
+	}
+
+	if !runningSetup.TryLock() {
+		ginCtx.String(http.StatusConflict, "HTTP %d: The setup is already running", http.StatusConflict)
+		ginCtx.Abort()
+		return
+	}
+
+	starter, err := NewStarter(ctx, clusterConfig, k8sClient, setupContextBuilder)
+	if err != nil {
+		runningSetup.Unlock()
+		handleInternalServerError(ginCtx, err, "Failed to create setup starter")
+		return
+	}
+	if ginCtx.Query(queryParamReconcile) == "true" {
+		starter.Reconcile = true
+	}
+
+	run := setupRuns.start()
+	go func() {
+		defer runningSetup.Unlock()
+
+		err := starter.performSetup(ctx)
+		if err != nil {
+			logrus.Errorf("setup run [%s] failed: %s", run.ID, err.Error())
+		}
+		setupRuns.finish(run, err)
+	}()
+
+	response, _ := setupRuns.get(run.ID)
+	ginCtx.Header("Location", endpointSetupRuns+run.ID)
+	ginCtx.JSON(http.StatusAccepted, response)
+}
+
+// rollbackSetup rolls back the last setup run in the background and responds with the newly created run. If this
+// process did not run a setup, f. i. after a restart, the setup run recorded in the setup state config map is rolled
+// back. The rollback cannot be started while a setup is running.
+func rollbackSetup(ctx context.Context, ginCtx *gin.Context, clusterConfig *rest.Config, k8sClient kubernetes.Interface, setupContextBuilder *appcontext.SetupContextBuilder) {
+	if !runningSetup.TryLock() {
+		ginCtx.String(http.StatusConflict, "HTTP %d: The setup is already running", http.StatusConflict)
+		ginCtx.Abort()
+		return
+	}
+
+	starter := lastSetup
+	rollback := (*Starter).rollbackSetup
+	if starter == nil {
+		var ok bool
+		starter, ok = newRecordedSetupStarter(ctx, ginCtx, clusterConfig, k8sClient, setupContextBuilder)
+		if !ok {
+			runningSetup.Unlock()
+			return
+		}
+		rollback = (*Starter).rollbackRecordedSetup
+	}
+
+	run := setupRuns.start()
+	go func() {
+		defer runningSetup.Unlock()
+
+		err := rollback(starter, ctx)
+		if err != nil {
+			logrus.Errorf("rollback run [%s] failed: %s", run.ID, err.Error())
+		}
+		setupRuns.finish(run, err)
+	}()
+
+	response, _ := setupRuns.get(run.ID)
+	ginCtx.Header("Location", endpointSetupRuns+run.ID)
+	ginCtx.JSON(http.StatusAccepted, response)
+}
+
+// newRecordedSetupStarter creates a starter for the setup run recorded in the setup state config map. It responds with
+// an error and returns false if there is no recorded setup run or the starter cannot be created.
+func newRecordedSetupStarter(ctx context.Context, ginCtx *gin.Context, clusterConfig *rest.Config, k8sClient kubernetes.Interface, setupContextBuilder *appcontext.SetupContextBuilder) (*Starter, bool) {
+	namespace, err := appcontext.GetEnvVar(appcontext.EnvironmentVariableTargetNamespace)
+	if err != nil {
+		handleInternalServerError(ginCtx, err, "Failed to read the target namespace")
+		return nil, false
+	}
+
+	recorded, err := hasRecordedSetup(ctx, k8sClient, namespace)
+	if err != nil {
+		handleInternalServerError(ginCtx, err, "Failed to read the setup state")
+		return nil, false
+	}
+	if !recorded {
+		ginCtx.String(http.StatusConflict, "HTTP %d: There is no setup run to roll back", http.StatusConflict)
+		ginCtx.Abort()
+		return nil, false
+	}
+
+	starter, err := NewStarter(ctx, clusterConfig, k8sClient, setupContextBuilder)
+	if err != nil {
+		handleInternalServerError(ginCtx, err, "Failed to create setup starter")
+		return nil, false
+	}
+
+	return starter, true
+}
+
+// planSetup responds with the plan of the setup without performing any step.
+func planSetup(ctx context.Context, ginCtx *gin.Context, clusterConfig *rest.Config, k8sClient kubernetes.Interface, setupContextBuilder *appcontext.SetupContextBuilder) {
 	starter, err := NewStarter(ctx, clusterConfig, k8sClient, setupContextBuilder)
 	if err != nil {
 		handleInternalServerError(ginCtx, err, "Failed to create setup starter")
 		return
 	}
 
-	err = starter.StartSetup(ctx)
+	setupPlan, err := starter.PlanSetup(ctx)
 	if err != nil {
-		handleInternalServerError(ginCtx, err, "Failed to start setup")
+		handleInternalServerError(ginCtx, err, "Failed to plan setup")
 		return
 	}
 
-	ginCtx.Status(http.StatusOK)
+	ginCtx.JSON(http.StatusOK, setupPlan)
+}
+
+// newSetupJsonProblemValidator creates a validator for setup.json configurations which resolves the dogus with the dogu
+// registry of the setup.
+func newSetupJsonProblemValidator(ctx context.Context, k8sClient kubernetes.Interface, setupContextBuilder *appcontext.SetupContextBuilder) (setupJsonProblemValidator, error) {
+	doguRegistry, err := setupContextBuilder.ReadDoguRegistrySecret(ctx, k8sClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dogu registry secret: %w", err)
+	}
+
+	doguRepository, err := newRemoteDoguRepository(doguRegistry)
+	if err != nil {
+		return nil, err
+	}
+
+	return validation.NewSetupJsonConfigurationValidator(doguRepository), nil
+}
+
+// validateSetupJson responds with all problems of the setup.json in the request body. The list is empty if the
+// setup.json is valid.
+func validateSetupJson(ctx context.Context, ginCtx *gin.Context, validator setupJsonProblemValidator) {
+	setupJson := &appcontext.SetupJsonConfiguration{}
+	err := ginCtx.ShouldBindJSON(setupJson)
+	if err != nil {
+		ginCtx.String(http.StatusBadRequest, "HTTP %d: The setup.json cannot be parsed: %s", http.StatusBadRequest, err.Error())
+		ginCtx.Abort()
+		return
+	}
+
+	problems := validator.ValidateAll(ctx, setupJson)
+	if problems == nil {
+		problems = validation.Problems{}
+	}
+
+	ginCtx.JSON(http.StatusOK, problems)
+}
+
+// newPreflightChecker creates a checker for the cluster with the pre-flight configuration of the setup.
+func newPreflightChecker(ctx context.Context, k8sClient kubernetes.Interface, setupContextBuilder *appcontext.SetupContextBuilder) (preflightChecker, error) {
+	appConfig, err := setupContextBuilder.ReadAppConfig(ctx, k8sClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read setup configuration: %w", err)
+	}
+
+	return preflight.NewChecker(k8sClient, appConfig)
+}
+
+// checkPreflight responds with the report of all pre-flight checks. The checks do not change the cluster.
+func checkPreflight(ctx context.Context, ginCtx *gin.Context, checker preflightChecker) {
+	ginCtx.JSON(http.StatusOK, checker.Check(ctx))
+}
+
+func getSetupRun(ginCtx *gin.Context, runs *runRegistry) {
+	run, ok := runs.get(ginCtx.Param("id"))
+	if !ok {
+		ginCtx.String(http.StatusNotFound, "HTTP %d: Setup run %s not found", http.StatusNotFound, ginCtx.Param("id"))
+		return
+	}
+
+	ginCtx.JSON(http.StatusOK, run)
+}
+
+// getSchema responds with the JSON schema of the setup.json or the k8s-ces-setup.yaml.
+func getSchema(ginCtx *gin.Context) {
+	result, ok := schema.Get(ginCtx.Param("name"))
+	if !ok {
+		ginCtx.String(http.StatusNotFound, "HTTP %d: Schema %s not found, valid schemas are %s", http.StatusNotFound, ginCtx.Param("name"), strings.Join(schema.Names, ", "))
+		return
+	}
+
+	ginCtx.JSON(http.StatusOK, result)
+}
+
+func getSetupStatus(ginCtx *gin.Context, statusTracker *StatusTracker) {
+	ginCtx.JSON(http.StatusOK, statusTracker.GetStatus())
+}
+
+// streamSetupStatus sends the current status of all steps as server-sent event and afterward every step transition
+// until the client closes the connection.
+func streamSetupStatus(ginCtx *gin.Context, statusTracker *StatusTracker) {
+	transitions, unsubscribe := statusTracker.Subscribe()
+	defer unsubscribe()
+
+	ginCtx.Header("Cache-Control", "no-cache")
+	ginCtx.Header("Connection", "keep-alive")
+	ginCtx.SSEvent(sseEventStatus, statusTracker.GetStatus())
+	ginCtx.Writer.Flush()
+
+	ginCtx.Stream(func(_ io.Writer) bool {
+		select {
+		case <-ginCtx.Request.Context().Done():
+			return false
+		case transition := <-transitions:
+			ginCtx.SSEvent(sseEventStepTransition, transition)
+			return true
+		}
+	})
 }
@@ -1,8 +1,12 @@
 package setup
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
 	"github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/preflight"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/validation"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -13,11 +17,13 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"strings"
 	"testing"
 )
 
@@ -36,6 +42,9 @@ func TestSetupAPI(t *testing.T) {
 
 			return routesMock
 		})
+		routesMock.EXPECT().GET(mock.Anything, mock.AnythingOfType("gin.HandlerFunc")).Return(routesMock)
+		routesMock.EXPECT().POST("/api/v1/setup/validate", mock.AnythingOfType("gin.HandlerFunc")).Return(routesMock)
+		routesMock.EXPECT().DELETE("/api/v1/setup", mock.AnythingOfType("gin.HandlerFunc")).Return(routesMock)
 		restConfig := &rest.Config{}
 		clientSet := fake.NewClientset()
 
@@ -63,15 +72,19 @@ func TestSetupAPI(t *testing.T) {
 			return &rest.Config{}
 		}
 
+		recorder := httptest.NewRecorder()
 		routesMock := newMockGinRoutes(t)
 		routesMock.EXPECT().POST("/api/v1/setup", mock.AnythingOfType("gin.HandlerFunc")).RunAndReturn(func(_ string, handlerFunc ...gin.HandlerFunc) gin.IRoutes {
 			for _, f := range handlerFunc {
-				c, _ := gin.CreateTestContext(httptest.NewRecorder())
+				c, _ := gin.CreateTestContext(recorder)
 				f(c)
 			}
 
 			return routesMock
 		})
+		routesMock.EXPECT().GET(mock.Anything, mock.AnythingOfType("gin.HandlerFunc")).Return(routesMock)
+		routesMock.EXPECT().POST("/api/v1/setup/validate", mock.AnythingOfType("gin.HandlerFunc")).Return(routesMock)
+		routesMock.EXPECT().DELETE("/api/v1/setup", mock.AnythingOfType("gin.HandlerFunc")).Return(routesMock)
 		restConfig := &rest.Config{}
 		defaultSA := &corev1.ServiceAccount{
 			ObjectMeta: v1.ObjectMeta{
@@ -101,16 +114,379 @@ func TestSetupAPI(t *testing.T) {
 		_, err = io.Copy(&buf, r)
 
 		// when
+		var run Run
 		logs, err := captureLogs(func() {
 			SetupAPI(testCtx, routesMock, restConfig, clientSet, setupCtxBuilder)
+			run = waitForRun(t, recorder)
 		})
 
 		// then
 		require.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, recorder.Code)
+		assert.Equal(t, RunStateFailed, run.State)
+		assert.Contains(t, run.Error, "failed to get dogu [official/ldap]")
 		assert.Contains(t, logs, "failed to register dogu installation steps: failed to generate dogu step generator: failed to get dogu [official/ldap]")
 	})
 }
 
+func waitForRun(t *testing.T, recorder *httptest.ResponseRecorder) Run {
+	t.Helper()
+
+	location := recorder.Header().Get("Location")
+	require.True(t, strings.HasPrefix(location, endpointSetupRuns))
+	run, ok := setupRuns.get(strings.TrimPrefix(location, endpointSetupRuns))
+	require.True(t, ok)
+	<-run.done
+
+	run, _ = setupRuns.get(run.ID)
+	return run
+}
+
+func Test_startSetup(t *testing.T) {
+	t.Run("should return conflict if a setup is already running", func(t *testing.T) {
+		// given
+		runningSetup.Lock()
+		defer runningSetup.Unlock()
+
+		recorder := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(recorder)
+
+		// when
+		startSetup(testCtx, ginCtx, &rest.Config{}, fake.NewClientset(), context.NewSetupContextBuilder("development"))
+
+		// then
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "The setup is already running")
+	})
+}
+
+func Test_rollbackSetup(t *testing.T) {
+	t.Run("should return conflict if a setup is running", func(t *testing.T) {
+		// given
+		runningSetup.Lock()
+		defer runningSetup.Unlock()
+
+		recorder := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(recorder)
+
+		// when
+		rollbackSetup(testCtx, ginCtx, nil, nil, nil)
+
+		// then
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "The setup is already running")
+	})
+
+	t.Run("should return conflict if no setup was run", func(t *testing.T) {
+		// given
+		t.Setenv("POD_NAMESPACE", "test")
+		oldLastSetup := lastSetup
+		defer func() { lastSetup = oldLastSetup }()
+		lastSetup = nil
+
+		recorder := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(recorder)
+
+		// when
+		rollbackSetup(testCtx, ginCtx, &rest.Config{}, fake.NewClientset(), context.NewSetupContextBuilder("development"))
+
+		// then
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "There is no setup run to roll back")
+		assert.True(t, runningSetup.TryLock())
+		runningSetup.Unlock()
+	})
+
+	t.Run("should fail to create the starter of a setup run recorded before a restart", func(t *testing.T) {
+		// given
+		t.Setenv("POD_NAMESPACE", "test")
+		t.Setenv("STAGE", "development")
+		oldLastSetup := lastSetup
+		defer func() { lastSetup = oldLastSetup }()
+		lastSetup = nil
+		stateCM := &corev1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: context.SetupStateConfigMap, Namespace: "test"},
+			Data:       map[string]string{context.SetupRollbackStateKey: `{"create-loadbalancer":true}`},
+		}
+		setupCtxBuilder := context.NewSetupContextBuilder("development")
+		setupCtxBuilder.DevSetupConfigPath = "invalid"
+
+		recorder := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(recorder)
+
+		// when
+		rollbackSetup(testCtx, ginCtx, &rest.Config{}, fake.NewClientset(stateCM), setupCtxBuilder)
+
+		// then
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "Failed to create setup starter")
+		assert.True(t, runningSetup.TryLock())
+		runningSetup.Unlock()
+	})
+
+	t.Run("should roll back the last setup run in the background", func(t *testing.T) {
+		// given
+		executorMock := NewMockSetupExecutor(t)
+		executorMock.EXPECT().RollbackSetup(testCtx).Return(nil)
+		stateCM := &corev1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: context.SetupStateConfigMap, Namespace: "test"},
+			Data:       map[string]string{context.SetupStateKey: context.SetupStateInstalling},
+		}
+		clientSet := fake.NewClientset(stateCM)
+
+		oldLastSetup := lastSetup
+		defer func() { lastSetup = oldLastSetup }()
+		lastSetup = &Starter{Namespace: "test", SetupExecutor: executorMock, ClientSet: clientSet}
+
+		recorder := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(recorder)
+
+		// when
+		rollbackSetup(testCtx, ginCtx, nil, nil, nil)
+		run := waitForRun(t, recorder)
+
+		// then
+		assert.Equal(t, http.StatusAccepted, recorder.Code)
+		assert.Equal(t, RunStateSucceeded, run.State)
+		actualCM, err := clientSet.CoreV1().ConfigMaps("test").Get(testCtx, context.SetupStateConfigMap, v1.GetOptions{})
+		require.NoError(t, err)
+		assert.NotContains(t, actualCM.Data, context.SetupStateKey)
+	})
+}
+
+func Test_startSetup_dryRun(t *testing.T) {
+	t.Run("should plan setup even if a setup is running", func(t *testing.T) {
+		// given
+		t.Setenv("POD_NAMESPACE", "ecosystem")
+		t.Setenv("STAGE", "development")
+		runningSetup.Lock()
+		defer runningSetup.Unlock()
+
+		recorder := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(recorder)
+		ginCtx.Request = httptest.NewRequest(http.MethodPost, "/api/v1/setup?dryRun=true", nil)
+		setupCtxBuilder := context.NewSetupContextBuilder("development")
+		setupCtxBuilder.DevSetupConfigPath = "invalid"
+
+		// when
+		startSetup(testCtx, ginCtx, &rest.Config{}, fake.NewClientset(), setupCtxBuilder)
+
+		// then
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "Failed to create setup starter")
+	})
+}
+
+func Test_validateSetupJson(t *testing.T) {
+	t.Run("should return all problems of the setup.json", func(t *testing.T) {
+		// given
+		problems := validation.Problems{
+			{Path: "naming.fqdn", Code: validation.CodeRequired, Message: "no fqdn set", Severity: validation.SeverityError},
+			{Path: "admin.mail", Code: validation.CodeInvalidFormat, Message: "invalid admin mail", Severity: validation.SeverityError},
+		}
+		validatorMock := newMockSetupJsonProblemValidator(t)
+		validatorMock.EXPECT().ValidateAll(testCtx, &context.SetupJsonConfiguration{Admin: context.User{Mail: "invalid"}}).Return(problems)
+
+		recorder := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(recorder)
+		ginCtx.Request = httptest.NewRequest(http.MethodPost, "/api/v1/setup/validate", strings.NewReader(`{"admin":{"mail":"invalid"}}`))
+
+		// when
+		validateSetupJson(testCtx, ginCtx, validatorMock)
+
+		// then
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `[
+			{"path":"naming.fqdn","code":"required","message":"no fqdn set","severity":"error"},
+			{"path":"admin.mail","code":"invalid_format","message":"invalid admin mail","severity":"error"}
+		]`, recorder.Body.String())
+	})
+
+	t.Run("should return an empty list for a valid setup.json", func(t *testing.T) {
+		// given
+		validatorMock := newMockSetupJsonProblemValidator(t)
+		validatorMock.EXPECT().ValidateAll(testCtx, &context.SetupJsonConfiguration{}).Return(nil)
+
+		recorder := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(recorder)
+		ginCtx.Request = httptest.NewRequest(http.MethodPost, "/api/v1/setup/validate", strings.NewReader(`{}`))
+
+		// when
+		validateSetupJson(testCtx, ginCtx, validatorMock)
+
+		// then
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `[]`, recorder.Body.String())
+	})
+
+	t.Run("should return bad request for an invalid body", func(t *testing.T) {
+		// given
+		recorder := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(recorder)
+		ginCtx.Request = httptest.NewRequest(http.MethodPost, "/api/v1/setup/validate", strings.NewReader(`{"naming":`))
+
+		// when
+		validateSetupJson(testCtx, ginCtx, newMockSetupJsonProblemValidator(t))
+
+		// then
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "The setup.json cannot be parsed")
+	})
+}
+
+func Test_newSetupJsonProblemValidator(t *testing.T) {
+	t.Run("should fail if the dogu registry secret is missing", func(t *testing.T) {
+		// given
+		t.Setenv("POD_NAMESPACE", "ecosystem")
+		setupCtxBuilder := context.NewSetupContextBuilder("production")
+
+		// when
+		_, err := newSetupJsonProblemValidator(testCtx, fake.NewClientset(), setupCtxBuilder)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to read dogu registry secret")
+	})
+
+	t.Run("should create validator with the dogu registry of the setup", func(t *testing.T) {
+		// given
+		t.Setenv("POD_NAMESPACE", "ecosystem")
+		secret := &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: "k8s-dogu-operator-dogu-registry", Namespace: "ecosystem"},
+			Data:       map[string][]byte{"endpoint": []byte("https://dogu.cloudogu.com/api/v2/dogus"), "username": []byte("user"), "password": []byte("pass")},
+		}
+		setupCtxBuilder := context.NewSetupContextBuilder("production")
+
+		// when
+		actual, err := newSetupJsonProblemValidator(testCtx, fake.NewClientset(secret), setupCtxBuilder)
+
+		// then
+		require.NoError(t, err)
+		assert.NotNil(t, actual)
+	})
+}
+
+func Test_newPreflightChecker(t *testing.T) {
+	t.Run("should fail if the setup configuration is missing", func(t *testing.T) {
+		// given
+		t.Setenv("POD_NAMESPACE", "ecosystem")
+		setupCtxBuilder := context.NewSetupContextBuilder("production")
+
+		// when
+		_, err := newPreflightChecker(testCtx, fake.NewClientset(), setupCtxBuilder)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to read setup configuration")
+	})
+
+	t.Run("should create checker with the configuration of the setup", func(t *testing.T) {
+		// given
+		t.Setenv("POD_NAMESPACE", "ecosystem")
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: "k8s-ces-setup-config", Namespace: "ecosystem"},
+			Data:       map[string]string{"k8s-ces-setup.yaml": "preflight:\n  warnOnly: true\n"},
+		}
+		setupCtxBuilder := context.NewSetupContextBuilder("production")
+
+		// when
+		actual, err := newPreflightChecker(testCtx, fake.NewClientset(configMap), setupCtxBuilder)
+
+		// then
+		require.NoError(t, err)
+		assert.NotNil(t, actual)
+	})
+}
+
+func Test_checkPreflight(t *testing.T) {
+	// given
+	checkerMock := newMockPreflightChecker(t)
+	checkerMock.EXPECT().Check(testCtx).Return(preflight.Report{Results: []preflight.Result{
+		{Check: "kubernetes-version", Status: preflight.StatusPassed, Message: "kubernetes version v1.30.0 is supported"},
+	}})
+
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+
+	// when
+	checkPreflight(testCtx, ginCtx, checkerMock)
+
+	// then
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"results":[{"check":"kubernetes-version","status":"passed","message":"kubernetes version v1.30.0 is supported"}]}`, recorder.Body.String())
+}
+
+func Test_getSetupRun(t *testing.T) {
+	t.Run("should return the run", func(t *testing.T) {
+		// given
+		runs := newRunRegistry()
+		run := runs.start()
+		runs.finish(run, assert.AnError)
+
+		recorder := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(recorder)
+		ginCtx.Params = gin.Params{{Key: "id", Value: run.ID}}
+
+		// when
+		getSetupRun(ginCtx, runs)
+
+		// then
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var actual Run
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &actual))
+		assert.Equal(t, run.ID, actual.ID)
+		assert.Equal(t, RunStateFailed, actual.State)
+		assert.Equal(t, assert.AnError.Error(), actual.Error)
+		assert.NotNil(t, actual.EndTime)
+	})
+
+	t.Run("should return not found for unknown run", func(t *testing.T) {
+		// given
+		recorder := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(recorder)
+		ginCtx.Params = gin.Params{{Key: "id", Value: "unknown"}}
+
+		// when
+		getSetupRun(ginCtx, newRunRegistry())
+
+		// then
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
+
+func Test_getSchema(t *testing.T) {
+	t.Run("should return the schema", func(t *testing.T) {
+		// given
+		recorder := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(recorder)
+		ginCtx.Params = gin.Params{{Key: "name", Value: "setup"}}
+
+		// when
+		getSchema(ginCtx)
+
+		// then
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var actual map[string]any
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &actual))
+		assert.Equal(t, "setup.json", actual["title"])
+		assert.Equal(t, false, actual["additionalProperties"])
+	})
+
+	t.Run("should return not found for unknown schema", func(t *testing.T) {
+		// given
+		recorder := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(recorder)
+		ginCtx.Params = gin.Params{{Key: "name", Value: "blueprint"}}
+
+		// when
+		getSchema(ginCtx)
+
+		// then
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, "HTTP 404: Schema blueprint not found, valid schemas are setup, config", recorder.Body.String())
+	})
+}
+
 func captureLogs(f func()) (string, error) {
 	realOut := logrus.StandardLogger().Out
 	defer logrus.SetOutput(realOut)
@@ -167,3 +543,75 @@ func readOutput(fakeReaderPipe, fakeWriterPipe *os.File) (string, error) {
 		return "", err
 	}
 }
+
+func Test_getSetupStatus(t *testing.T) {
+	t.Run("should return the status of all steps", func(t *testing.T) {
+		// given
+		statusTracker := NewStatusTracker()
+		statusTracker.Reset([]ExecutorStep{newSimpleSetupStep("Step1", false), newSimpleSetupStep("Step2", false)})
+		statusTracker.StepStarted(0)
+
+		recorder := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(recorder)
+
+		// when
+		getSetupStatus(ginCtx, statusTracker)
+
+		// then
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var status SetupStatus
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+		require.Len(t, status.Steps, 2)
+		assert.Equal(t, "Step1", status.Steps[0].ID)
+		assert.Equal(t, StepStateRunning, status.Steps[0].State)
+		assert.Equal(t, StepStatePending, status.Steps[1].State)
+	})
+}
+
+func Test_streamSetupStatus(t *testing.T) {
+	t.Run("should stream the current status and every step transition", func(t *testing.T) {
+		// given
+		statusTracker := NewStatusTracker()
+		statusTracker.Reset([]ExecutorStep{newSimpleSetupStep("Step1", false)})
+
+		router := gin.New()
+		router.GET(endpointGetSetupStatusSSE, func(ginCtx *gin.Context) {
+			streamSetupStatus(ginCtx, statusTracker)
+		})
+		server := httptest.NewServer(router)
+		defer server.Close()
+
+		// when
+		response, err := http.Get(server.URL + endpointGetSetupStatusSSE)
+		require.NoError(t, err)
+		defer response.Body.Close()
+		reader := bufio.NewReader(response.Body)
+
+		// then
+		assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+		assert.Equal(t, "event:status", readSSELine(t, reader))
+		assert.Contains(t, readSSELine(t, reader), `"state":"pending"`)
+
+		statusTracker.StepStarted(0)
+		assert.Equal(t, "event:step", readSSELine(t, reader))
+		assert.Contains(t, readSSELine(t, reader), `"state":"running"`)
+
+		statusTracker.StepSucceeded(0)
+		assert.Equal(t, "event:step", readSSELine(t, reader))
+		assert.Contains(t, readSSELine(t, reader), `"state":"succeeded"`)
+	})
+}
+
+// readSSELine returns the next non-empty line of a server-sent event stream.
+func readSSELine(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSpace(line)
+		if line != "" {
+			return line
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package component
+
+import (
+	"fmt"
+
+	componentOpConfig "github.com/cloudogu/k8s-component-operator/pkg/config"
+	helmclient "github.com/cloudogu/k8s-component-operator/pkg/helm/client"
+	"helm.sh/helm/v3/pkg/action"
+	"k8s.io/client-go/rest"
+)
+
+// These paths are the same as the ones of the helm client of the component operator so that the credentials of the
+// helm registry are shared.
+const (
+	helmRepositoryCache    = "/tmp/.helmcache"
+	helmRepositoryConfig   = "/tmp/.helmrepo"
+	helmRegistryConfigFile = "/tmp/.helmregistry/config.json"
+)
+
+// NewHelmChartTagResolver creates a client which lists the versions of helm charts in the configured helm repository.
+func NewHelmChartTagResolver(namespace string, helmRepoData *componentOpConfig.HelmRepositoryData, restConfig *rest.Config, debug bool, debugLog action.DebugLog) (helmclient.TagResolver, error) {
+	helmClient, err := helmclient.NewClientFromRestConf(&helmclient.RestConfClientOptions{
+		Options: &helmclient.Options{
+			Namespace:        namespace,
+			RepositoryCache:  helmRepositoryCache,
+			RepositoryConfig: helmRepositoryConfig,
+			RegistryConfig:   helmRegistryConfigFile,
+			Debug:            debug,
+			DebugLog:         debugLog,
+			PlainHttp:        helmRepoData.PlainHttp,
+			InsecureTls:      helmRepoData.InsecureTLS,
+		},
+		RestConfig: restConfig,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create helm client: %w", err)
+	}
+
+	return helmClient, nil
+}
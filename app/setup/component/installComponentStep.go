@@ -2,11 +2,18 @@ package component
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"time"
+
 	v1 "github.com/cloudogu/k8s-component-operator/pkg/api/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 )
 
 type installComponentStep struct {
@@ -17,8 +24,21 @@ type installComponentStep struct {
 	namespace           string
 	deployNamespace     string
 	valuesYamlOverwrite string
+	// created is true if the component resource was created by this step.
+	created bool
+	// previousSpec contains the spec of an existing component resource before this step updated it.
+	previousSpec *v1.ComponentSpec
+}
+
+// installComponentRollbackState contains the data needed to roll back the step.
+type installComponentRollbackState struct {
+	Created      bool              `json:"created"`
+	PreviousSpec *v1.ComponentSpec `json:"previousSpec,omitempty"`
 }
 
+// deletionPollInterval is the interval in which the removal of a deleted component resource is checked.
+const deletionPollInterval = 2 * time.Second
+
 // NewInstallComponentStep creates a new step responsible to apply a component resource to the cluster, and, thus, starting the component installation.
 func NewInstallComponentStep(client componentsClient, componentName string, attributes appcontext.ComponentAttributes, namespace string) *installComponentStep {
 	return &installComponentStep{
@@ -32,19 +52,142 @@ func NewInstallComponentStep(client componentsClient, componentName string, attr
 	}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (ics *installComponentStep) GetStepID() string {
+	return fmt.Sprintf("install-component/%s", ics.componentName)
+}
+
 // GetStepDescription return the human-readable description of the step
 func (ics *installComponentStep) GetStepDescription() string {
 	return fmt.Sprintf("Installing component '%s/%s:%s'", ics.componentNamespace, ics.componentName, ics.version)
 }
 
-// PerformSetupStep applies a component resource for the configured component to the cluster.
+// DescribeEffect returns the component resource and the version which the step creates.
+func (ics *installComponentStep) DescribeEffect() plan.Effect {
+	return plan.Effect{
+		Action:  plan.ActionCreate,
+		Kind:    "Component",
+		Targets: []string{fmt.Sprintf("%s/%s", ics.namespace, ics.componentName)},
+		Version: ics.version,
+	}
+}
+
+// PerformSetupStep applies a component resource for the configured component to the cluster. An existing component
+// resource is updated to the configured version and values.
 func (ics *installComponentStep) PerformSetupStep(ctx context.Context) error {
 	cr := ics.createComponentCr()
 	_, err := ics.client.Create(ctx, cr, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// the component resource was applied by an interrupted setup run or belongs to an installed ecosystem
+		return ics.updateComponentCr(ctx, cr)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to apply component '%s/%s:%s' : %w", ics.componentNamespace, ics.componentName, ics.version, err)
 	}
 
+	ics.created = true
+	return nil
+}
+
+func (ics *installComponentStep) updateComponentCr(ctx context.Context, cr *v1.Component) error {
+	actualCr, err := ics.client.Get(ctx, ics.componentName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get existing component '%s': %w", ics.componentName, err)
+	}
+
+	desiredSpec := cr.Spec
+	if desiredSpec.Version == "" {
+		// the version "latest" does not upgrade an installed component
+		desiredSpec.Version = actualCr.Spec.Version
+	}
+	if actualCr.Spec == desiredSpec {
+		logrus.Infof("component resource %s is already up to date", ics.componentName)
+		return nil
+	}
+
+	logrus.Infof("update component resource %s from version %s to %s", ics.componentName, actualCr.Spec.Version, desiredSpec.Version)
+	if !ics.created && ics.previousSpec == nil {
+		// keep the spec recorded by an interrupted setup run
+		previousSpec := actualCr.Spec
+		ics.previousSpec = &previousSpec
+	}
+	actualCr.Spec = desiredSpec
+	_, err = ics.client.Update(ctx, actualCr, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update component '%s/%s:%s' : %w", ics.componentNamespace, ics.componentName, ics.version, err)
+	}
+
+	return nil
+}
+
+// RollbackSetupStep deletes the component resource if it was created by this step and waits until the component
+// operator has removed the component. The spec of a component resource updated by this step is reset to the previous
+// spec.
+func (ics *installComponentStep) RollbackSetupStep(ctx context.Context) error {
+	if !ics.created {
+		return ics.restorePreviousSpec(ctx)
+	}
+
+	err := ics.client.Delete(ctx, ics.componentName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete component '%s': %w", ics.componentName, err)
+	}
+
+	err = wait.PollUntilContextTimeout(ctx, deletionPollInterval, TimeoutInSeconds(), true, func(ctx context.Context) (bool, error) {
+		_, getErr := ics.client.Get(ctx, ics.componentName, metav1.GetOptions{})
+		if errors.IsNotFound(getErr) {
+			return true, nil
+		}
+
+		return false, getErr
+	})
+	if err != nil {
+		return fmt.Errorf("failed to wait for deletion of component '%s': %w", ics.componentName, err)
+	}
+
+	ics.created = false
+	return nil
+}
+
+func (ics *installComponentStep) restorePreviousSpec(ctx context.Context) error {
+	if ics.previousSpec == nil {
+		return nil
+	}
+
+	actualCr, err := ics.client.Get(ctx, ics.componentName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get component '%s': %w", ics.componentName, err)
+	}
+
+	if actualCr.Spec != *ics.previousSpec {
+		logrus.Infof("reset component resource %s from version %s to %s", ics.componentName, actualCr.Spec.Version, ics.previousSpec.Version)
+		actualCr.Spec = *ics.previousSpec
+		_, err = ics.client.Update(ctx, actualCr, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to reset component '%s' to version %s: %w", ics.componentName, ics.previousSpec.Version, err)
+		}
+	}
+
+	ics.previousSpec = nil
+	return nil
+}
+
+// GetRollbackState returns whether the component resource was created by this step and its spec before it was
+// updated.
+func (ics *installComponentStep) GetRollbackState() (json.RawMessage, error) {
+	return json.Marshal(installComponentRollbackState{Created: ics.created, PreviousSpec: ics.previousSpec})
+}
+
+// SetRollbackState restores the rollback data of the component resource recorded by a previous setup run.
+func (ics *installComponentStep) SetRollbackState(state json.RawMessage) error {
+	var rollbackState installComponentRollbackState
+	err := json.Unmarshal(state, &rollbackState)
+	if err != nil {
+		return err
+	}
+
+	ics.created = rollbackState.Created
+	ics.previousSpec = rollbackState.PreviousSpec
 	return nil
 }
 
@@ -3,12 +3,16 @@ package component
 import (
 	"context"
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 	v1 "github.com/cloudogu/k8s-component-operator/pkg/api/v1"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestNewInstallComponentsStep(t *testing.T) {
@@ -95,6 +99,7 @@ func TestInstallComponentsStep_PerformSetupStep(t *testing.T) {
 
 		// then
 		require.NoError(t, err)
+		assert.True(t, step.created)
 	})
 	t.Run("should successfully perform setup for 'latest' version", func(t *testing.T) {
 		// given
@@ -176,4 +181,300 @@ func TestInstallComponentsStep_PerformSetupStep(t *testing.T) {
 		require.ErrorIs(t, err, assert.AnError)
 		require.ErrorContains(t, err, "failed to apply component 'testing/testComponent:4.5.6' :")
 	})
+	t.Run("should keep already existing component cr with the same spec", func(t *testing.T) {
+		// given
+		namespace := "testNS"
+		testCtx := context.TODO()
+
+		expectedComponent := &v1.Component{
+			TypeMeta: metav1.TypeMeta{},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testComponent",
+				Namespace: namespace,
+				Labels: map[string]string{
+					"app":                    "ces",
+					"app.kubernetes.io/name": "testComponent",
+				},
+			},
+			Spec: v1.ComponentSpec{
+				Name:      "testComponent",
+				Namespace: "testing",
+				Version:   "4.5.6",
+			},
+		}
+
+		componentsClientMock := newMockComponentsClient(t)
+		alreadyExistsErr := errors.NewAlreadyExists(schema.GroupResource{Resource: "components"}, "testComponent")
+		componentsClientMock.EXPECT().Create(testCtx, expectedComponent, metav1.CreateOptions{}).Return(nil, alreadyExistsErr)
+		componentsClientMock.EXPECT().Get(testCtx, "testComponent", metav1.GetOptions{}).Return(expectedComponent.DeepCopy(), nil)
+
+		step := &installComponentStep{
+			client:             componentsClientMock,
+			namespace:          namespace,
+			componentName:      "testComponent",
+			componentNamespace: "testing",
+			version:            "4.5.6",
+		}
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should update the version of an already existing component cr", func(t *testing.T) {
+		// given
+		testCtx := context.TODO()
+		existingComponent := &v1.Component{
+			ObjectMeta: metav1.ObjectMeta{Name: "testComponent", Namespace: "testNS", ResourceVersion: "42"},
+			Spec:       v1.ComponentSpec{Name: "testComponent", Namespace: "testing", Version: "4.5.5"},
+		}
+
+		componentsClientMock := newMockComponentsClient(t)
+		alreadyExistsErr := errors.NewAlreadyExists(schema.GroupResource{Resource: "components"}, "testComponent")
+		componentsClientMock.EXPECT().Create(testCtx, mock.Anything, metav1.CreateOptions{}).Return(nil, alreadyExistsErr)
+		componentsClientMock.EXPECT().Get(testCtx, "testComponent", metav1.GetOptions{}).Return(existingComponent, nil)
+		componentsClientMock.EXPECT().Update(testCtx, mock.MatchedBy(func(cr *v1.Component) bool {
+			return cr.Spec.Version == "4.5.6" && cr.Spec.ValuesYamlOverwrite == "key: value" && cr.ResourceVersion == "42"
+		}), metav1.UpdateOptions{}).Return(existingComponent, nil)
+
+		step := &installComponentStep{
+			client:              componentsClientMock,
+			namespace:           "testNS",
+			componentName:       "testComponent",
+			componentNamespace:  "testing",
+			version:             "4.5.6",
+			valuesYamlOverwrite: "key: value",
+		}
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, step.created)
+		assert.Equal(t, &v1.ComponentSpec{Name: "testComponent", Namespace: "testing", Version: "4.5.5"}, step.previousSpec)
+	})
+
+	t.Run("should not upgrade an already existing component cr for 'latest' version", func(t *testing.T) {
+		// given
+		testCtx := context.TODO()
+		existingComponent := &v1.Component{
+			ObjectMeta: metav1.ObjectMeta{Name: "testComponent", Namespace: "testNS"},
+			Spec:       v1.ComponentSpec{Name: "testComponent", Namespace: "testing", Version: "4.5.5"},
+		}
+
+		componentsClientMock := newMockComponentsClient(t)
+		alreadyExistsErr := errors.NewAlreadyExists(schema.GroupResource{Resource: "components"}, "testComponent")
+		componentsClientMock.EXPECT().Create(testCtx, mock.Anything, metav1.CreateOptions{}).Return(nil, alreadyExistsErr)
+		componentsClientMock.EXPECT().Get(testCtx, "testComponent", metav1.GetOptions{}).Return(existingComponent, nil)
+
+		step := &installComponentStep{
+			client:             componentsClientMock,
+			namespace:          "testNS",
+			componentName:      "testComponent",
+			componentNamespace: "testing",
+			version:            "latest",
+		}
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail to update an already existing component cr", func(t *testing.T) {
+		// given
+		testCtx := context.TODO()
+		existingComponent := &v1.Component{
+			ObjectMeta: metav1.ObjectMeta{Name: "testComponent", Namespace: "testNS"},
+			Spec:       v1.ComponentSpec{Name: "testComponent", Namespace: "testing", Version: "4.5.5"},
+		}
+
+		componentsClientMock := newMockComponentsClient(t)
+		alreadyExistsErr := errors.NewAlreadyExists(schema.GroupResource{Resource: "components"}, "testComponent")
+		componentsClientMock.EXPECT().Create(testCtx, mock.Anything, metav1.CreateOptions{}).Return(nil, alreadyExistsErr)
+		componentsClientMock.EXPECT().Get(testCtx, "testComponent", metav1.GetOptions{}).Return(existingComponent, nil)
+		componentsClientMock.EXPECT().Update(testCtx, mock.Anything, metav1.UpdateOptions{}).Return(nil, assert.AnError)
+
+		step := &installComponentStep{
+			client:             componentsClientMock,
+			namespace:          "testNS",
+			componentName:      "testComponent",
+			componentNamespace: "testing",
+			version:            "4.5.6",
+		}
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to update component 'testing/testComponent:4.5.6'")
+	})
+}
+
+func TestInstallComponentsStep_RollbackSetupStep(t *testing.T) {
+	testCtx := context.TODO()
+	notFound := errors.NewNotFound(schema.GroupResource{}, "testComponent")
+
+	t.Run("should delete component created by the step and wait for its removal", func(t *testing.T) {
+		// given
+		componentsClientMock := newMockComponentsClient(t)
+		componentsClientMock.EXPECT().Delete(testCtx, "testComponent", metav1.DeleteOptions{}).Return(nil)
+		componentsClientMock.EXPECT().Get(mock.Anything, "testComponent", metav1.GetOptions{}).Return(nil, notFound)
+
+		step := &installComponentStep{client: componentsClientMock, componentName: "testComponent", created: true}
+
+		// when
+		err := step.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, step.created)
+	})
+
+	t.Run("should not delete component which was not created by the step", func(t *testing.T) {
+		// given
+		step := &installComponentStep{client: newMockComponentsClient(t), componentName: "testComponent"}
+
+		// when
+		err := step.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should reset the spec of a component updated by the step", func(t *testing.T) {
+		// given
+		previousSpec := v1.ComponentSpec{Name: "testComponent", Namespace: "testing", Version: "4.5.5"}
+		existingComponent := &v1.Component{
+			ObjectMeta: metav1.ObjectMeta{Name: "testComponent", Namespace: "testNS"},
+			Spec:       v1.ComponentSpec{Name: "testComponent", Namespace: "testing", Version: "4.5.6", ValuesYamlOverwrite: "key: value"},
+		}
+		componentsClientMock := newMockComponentsClient(t)
+		componentsClientMock.EXPECT().Get(testCtx, "testComponent", metav1.GetOptions{}).Return(existingComponent, nil)
+		componentsClientMock.EXPECT().Update(testCtx, mock.MatchedBy(func(cr *v1.Component) bool {
+			return cr.Spec == previousSpec
+		}), metav1.UpdateOptions{}).Return(existingComponent, nil)
+
+		step := &installComponentStep{client: componentsClientMock, componentName: "testComponent", previousSpec: &previousSpec}
+
+		// when
+		err := step.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Nil(t, step.previousSpec)
+	})
+
+	t.Run("should fail to reset the spec of a component updated by the step", func(t *testing.T) {
+		// given
+		previousSpec := v1.ComponentSpec{Name: "testComponent", Namespace: "testing", Version: "4.5.5"}
+		componentsClientMock := newMockComponentsClient(t)
+		componentsClientMock.EXPECT().Get(testCtx, "testComponent", metav1.GetOptions{}).Return(nil, assert.AnError)
+
+		step := &installComponentStep{client: componentsClientMock, componentName: "testComponent", previousSpec: &previousSpec}
+
+		// when
+		err := step.RollbackSetupStep(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to get component 'testComponent'")
+		assert.NotNil(t, step.previousSpec)
+	})
+
+	t.Run("should ignore component which is already deleted", func(t *testing.T) {
+		// given
+		componentsClientMock := newMockComponentsClient(t)
+		componentsClientMock.EXPECT().Delete(testCtx, "testComponent", metav1.DeleteOptions{}).Return(notFound)
+		componentsClientMock.EXPECT().Get(mock.Anything, "testComponent", metav1.GetOptions{}).Return(nil, notFound)
+
+		step := &installComponentStep{client: componentsClientMock, componentName: "testComponent", created: true}
+
+		// when
+		err := step.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail to delete component", func(t *testing.T) {
+		// given
+		componentsClientMock := newMockComponentsClient(t)
+		componentsClientMock.EXPECT().Delete(testCtx, "testComponent", metav1.DeleteOptions{}).Return(assert.AnError)
+
+		step := &installComponentStep{client: componentsClientMock, componentName: "testComponent", created: true}
+
+		// when
+		err := step.RollbackSetupStep(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to delete component 'testComponent'")
+		assert.True(t, step.created)
+	})
+
+	t.Run("should fail to wait for the removal of the component", func(t *testing.T) {
+		// given
+		componentsClientMock := newMockComponentsClient(t)
+		componentsClientMock.EXPECT().Delete(testCtx, "testComponent", metav1.DeleteOptions{}).Return(nil)
+		componentsClientMock.EXPECT().Get(mock.Anything, "testComponent", metav1.GetOptions{}).Return(nil, assert.AnError)
+
+		step := &installComponentStep{client: componentsClientMock, componentName: "testComponent", created: true}
+
+		// when
+		err := step.RollbackSetupStep(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to wait for deletion of component 'testComponent'")
+	})
+}
+
+func TestInstallComponentsStep_RollbackState(t *testing.T) {
+	t.Run("should restore the rollback state of a previous setup run", func(t *testing.T) {
+		// given
+		previousSpec := &v1.ComponentSpec{Name: "testComponent", Namespace: "testing", Version: "4.5.5"}
+		performedStep := &installComponentStep{componentName: "testComponent", previousSpec: previousSpec}
+		state, err := performedStep.GetRollbackState()
+		require.NoError(t, err)
+		step := &installComponentStep{componentName: "testComponent"}
+
+		// when
+		err = step.SetRollbackState(state)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, step.created)
+		assert.Equal(t, previousSpec, step.previousSpec)
+	})
+
+	t.Run("should fail to restore an invalid rollback state", func(t *testing.T) {
+		// given
+		step := &installComponentStep{componentName: "testComponent"}
+
+		// when
+		err := step.SetRollbackState([]byte("true"))
+
+		// then
+		require.Error(t, err)
+	})
+}
+
+func TestInstallComponentsStep_DescribeEffect(t *testing.T) {
+	t.Run("should describe component resource and version", func(t *testing.T) {
+		// given
+		attributes := appcontext.ComponentAttributes{Version: "0.0.2", HelmRepositoryNamespace: "testing"}
+		step := NewInstallComponentStep(newMockComponentsClient(t), "comp", attributes, "testNS")
+
+		// when
+		actual := step.DescribeEffect()
+
+		// then
+		assert.Equal(t, plan.Effect{Action: plan.ActionCreate, Kind: "Component", Targets: []string{"testNS/comp"}, Version: "0.0.2"}, actual)
+	})
 }
@@ -2,6 +2,7 @@ package component
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -9,6 +10,9 @@ import (
 	"github.com/cloudogu/k8s-component-operator/pkg/api/v1"
 	helmclient "github.com/cloudogu/k8s-component-operator/pkg/helm/client"
 	"github.com/cloudogu/k8s-component-operator/pkg/labels"
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 )
 
 type helmClient interface {
@@ -16,12 +20,18 @@ type helmClient interface {
 	InstallOrUpgrade(ctx context.Context, chart *helmclient.ChartSpec) error
 	// GetLatestVersion tries to get the latest version identifier for the chart with the given name.
 	GetLatestVersion(chartName string) (string, error)
+	// GetDeployedReleaseVersion returns the version for the specified release (if the release exists).
+	GetDeployedReleaseVersion(ctx context.Context, name string) (string, error)
+	// Uninstall removes the helmRelease with the given name.
+	Uninstall(releaseName string) error
 }
 
 type installHelmChartStep struct {
 	namespace  string
 	chart      string
 	helmClient helmClient
+	// installedRelease contains the name of the release if it was installed by this step.
+	installedRelease string
 }
 
 // NewInstallHelmChartStep creates new instance of a k8s component chart
@@ -33,11 +43,40 @@ func NewInstallHelmChartStep(namespace string, chartUrl string, helmClient helmC
 	}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (s *installHelmChartStep) GetStepID() string {
+	return fmt.Sprintf("install-helm-chart/%s/%s", s.namespace, s.chart)
+}
+
 // GetStepDescription returns a human-readable description of the component-chart installation step.
 func (s *installHelmChartStep) GetStepDescription() string {
 	return fmt.Sprintf("Install component-chart from %s in namespace %s", s.chart, s.namespace)
 }
 
+// DescribeEffect returns the helm chart and the version which the step installs. A chart version "latest" is resolved
+// with the helm repository.
+func (s *installHelmChartStep) DescribeEffect() plan.Effect {
+	effect := plan.Effect{Action: plan.ActionInstall, Kind: "HelmChart", Targets: []string{s.chart}}
+
+	fullChartName, chartVersion, err := SplitChartString(s.chart)
+	if err != nil {
+		return effect
+	}
+
+	effect.Targets = []string{fmt.Sprintf("%s/%s", s.namespace, fullChartName)}
+	effect.Version = chartVersion
+	if chartVersion == "latest" {
+		latestVersion, err := s.helmClient.GetLatestVersion(fullChartName)
+		if err != nil {
+			logrus.Warningf("failed to resolve latest version of chart %q: %s", fullChartName, err.Error())
+			return effect
+		}
+		effect.Version = latestVersion
+	}
+
+	return effect
+}
+
 // PerformSetupStep installs the component chart.
 func (s *installHelmChartStep) PerformSetupStep(ctx context.Context) error {
 	if s.chart == "" {
@@ -65,9 +104,53 @@ func (s *installHelmChartStep) installChart(ctx context.Context, chart string) e
 		}
 	}
 
+	deployedVersion, err := s.helmClient.GetDeployedReleaseVersion(ctx, releaseName)
+	if err != nil {
+		return fmt.Errorf("error checking for deployed release %q: %w", releaseName, err)
+	}
+
+	if deployedVersion == chartVersion {
+		logrus.Infof("release %s is already deployed in version %s", releaseName, chartVersion)
+		return nil
+	}
+
 	chartSpec := s.createChartSpec(releaseName, fullChartName, chartVersion)
+	err = s.helmClient.InstallOrUpgrade(ctx, chartSpec)
+	if err != nil {
+		return err
+	}
+
+	if deployedVersion == "" {
+		s.installedRelease = releaseName
+	}
+
+	return nil
+}
+
+// RollbackSetupStep uninstalls the release if it was installed by this step. Releases which were already deployed
+// before the setup are kept.
+func (s *installHelmChartStep) RollbackSetupStep(_ context.Context) error {
+	if s.installedRelease == "" {
+		return nil
+	}
 
-	return s.helmClient.InstallOrUpgrade(ctx, chartSpec)
+	err := s.helmClient.Uninstall(s.installedRelease)
+	if err != nil {
+		return fmt.Errorf("failed to uninstall release %q: %w", s.installedRelease, err)
+	}
+
+	s.installedRelease = ""
+	return nil
+}
+
+// GetRollbackState returns the name of the release installed by this step.
+func (s *installHelmChartStep) GetRollbackState() (json.RawMessage, error) {
+	return json.Marshal(s.installedRelease)
+}
+
+// SetRollbackState restores the name of the release installed by a previous setup run.
+func (s *installHelmChartStep) SetRollbackState(state json.RawMessage) error {
+	return json.Unmarshal(state, &s.installedRelease)
 }
 
 func SplitChartString(chart string) (string, string, error) {
@@ -81,9 +164,13 @@ func SplitChartString(chart string) (string, string, error) {
 	return fullChartName, chartVersion, nil
 }
 
-func SplitHelmNamespaceFromChartString(chartString string) (string, string) {
+func SplitHelmNamespaceFromChartString(chartString string) (string, string, error) {
 	split := strings.Split(chartString, "/")
-	return split[0], split[1]
+	if len(split) != 2 || split[0] == "" || split[1] == "" {
+		return "", "", fmt.Errorf("chart name '%s' has a wrong format. Must be '<helmRepositoryNamespace>/<chartName>'; e.g.: 'foo/bar'", chartString)
+	}
+
+	return split[0], split[1], nil
 }
 
 func (s *installHelmChartStep) createChartSpec(releaseName string, fullChartName string, chartVersion string) *helmclient.ChartSpec {
@@ -2,6 +2,7 @@ package component
 
 import (
 	"context"
+	"fmt"
 	v1 "github.com/cloudogu/k8s-component-operator/pkg/api/v1"
 	"github.com/cloudogu/k8s-component-operator/pkg/labels"
 	"testing"
@@ -9,7 +10,10 @@ import (
 
 	helmclient "github.com/cloudogu/k8s-component-operator/pkg/helm/client"
 
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -65,6 +69,7 @@ func TestComponentChartInstallerStep_PerformSetupStep(t *testing.T) {
 		}
 
 		helmClientMock := newMockHelmClient(t)
+		helmClientMock.EXPECT().GetDeployedReleaseVersion(testCtx, "testChart").Return("", nil)
 		helmClientMock.EXPECT().InstallOrUpgrade(testCtx, chartSpec).Return(nil)
 
 		step := &installHelmChartStep{
@@ -98,6 +103,7 @@ func TestComponentChartInstallerStep_PerformSetupStep(t *testing.T) {
 		}
 
 		helmClientMock := newMockHelmClient(t)
+		helmClientMock.EXPECT().GetDeployedReleaseVersion(testCtx, "testChart").Return("", nil)
 		helmClientMock.EXPECT().InstallOrUpgrade(testCtx, chartSpec).Return(nil)
 		helmClientMock.EXPECT().GetLatestVersion("foo/testChart").Return("1.5.0", nil)
 
@@ -152,6 +158,7 @@ func TestComponentChartInstallerStep_PerformSetupStep(t *testing.T) {
 		}
 
 		helmClientMock := newMockHelmClient(t)
+		helmClientMock.EXPECT().GetDeployedReleaseVersion(testCtx, "testChart").Return("", nil)
 		helmClientMock.EXPECT().InstallOrUpgrade(testCtx, chartSpec).Return(assert.AnError)
 
 		step := &installHelmChartStep{
@@ -223,6 +230,7 @@ func TestComponentChartInstallerStep_PerformSetupStep(t *testing.T) {
 		}
 
 		helmClientMock := newMockHelmClient(t)
+		helmClientMock.EXPECT().GetDeployedReleaseVersion(testCtx, "testChart").Return("", nil)
 		helmClientMock.EXPECT().InstallOrUpgrade(testCtx, chartSpec).Return(assert.AnError)
 
 		step := &installHelmChartStep{
@@ -238,4 +246,193 @@ func TestComponentChartInstallerStep_PerformSetupStep(t *testing.T) {
 		require.Error(t, err)
 		require.ErrorIs(t, err, assert.AnError)
 	})
+	t.Run("should fail to check for a deployed release", func(t *testing.T) {
+		// given
+		testCtx := context.TODO()
+
+		helmClientMock := newMockHelmClient(t)
+		helmClientMock.EXPECT().GetDeployedReleaseVersion(testCtx, "testChart").Return("", assert.AnError)
+
+		step := &installHelmChartStep{
+			namespace:  "testing",
+			chart:      "foo/testChart:0.1",
+			helmClient: helmClientMock,
+		}
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		require.ErrorContains(t, err, "error checking for deployed release \"testChart\"")
+	})
+
+	t.Run("should not upgrade release which is already deployed in the configured version", func(t *testing.T) {
+		// given
+		testCtx := context.TODO()
+
+		helmClientMock := newMockHelmClient(t)
+		helmClientMock.EXPECT().GetDeployedReleaseVersion(testCtx, "testChart").Return("0.1", nil)
+
+		step := NewInstallHelmChartStep("testing", "foo/testChart:0.1", helmClientMock)
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, step.installedRelease)
+	})
+}
+
+func TestComponentChartInstallerStep_RollbackSetupStep(t *testing.T) {
+	t.Run("should uninstall release installed by the step", func(t *testing.T) {
+		// given
+		testCtx := context.TODO()
+
+		helmClientMock := newMockHelmClient(t)
+		helmClientMock.EXPECT().GetDeployedReleaseVersion(testCtx, "testChart").Return("", nil)
+		helmClientMock.EXPECT().InstallOrUpgrade(testCtx, mock.Anything).Return(nil)
+		helmClientMock.EXPECT().Uninstall("testChart").Return(nil).Once()
+
+		step := NewInstallHelmChartStep("testing", "foo/testChart:0.1", helmClientMock)
+		require.NoError(t, step.PerformSetupStep(testCtx))
+
+		// when
+		err := step.RollbackSetupStep(testCtx)
+		secondErr := step.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		require.NoError(t, secondErr)
+	})
+
+	t.Run("should keep release which was deployed before the setup", func(t *testing.T) {
+		// given
+		testCtx := context.TODO()
+
+		helmClientMock := newMockHelmClient(t)
+		helmClientMock.EXPECT().GetDeployedReleaseVersion(testCtx, "testChart").Return("0.0.9", nil)
+		helmClientMock.EXPECT().InstallOrUpgrade(testCtx, mock.Anything).Return(nil)
+
+		step := NewInstallHelmChartStep("testing", "foo/testChart:0.1", helmClientMock)
+		require.NoError(t, step.PerformSetupStep(testCtx))
+
+		// when
+		err := step.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail to uninstall release", func(t *testing.T) {
+		// given
+		helmClientMock := newMockHelmClient(t)
+		helmClientMock.EXPECT().Uninstall("testChart").Return(assert.AnError)
+
+		step := &installHelmChartStep{namespace: "testing", chart: "foo/testChart:0.1", helmClient: helmClientMock, installedRelease: "testChart"}
+
+		// when
+		err := step.RollbackSetupStep(context.TODO())
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to uninstall release \"testChart\"")
+	})
+}
+
+func TestComponentChartInstallerStep_DescribeEffect(t *testing.T) {
+	t.Run("should describe chart with configured version", func(t *testing.T) {
+		// given
+		step := NewInstallHelmChartStep("testNS", "foo/testChart:0.1", newMockHelmClient(t))
+
+		// when
+		actual := step.DescribeEffect()
+
+		// then
+		assert.Equal(t, plan.Effect{Action: plan.ActionInstall, Kind: "HelmChart", Targets: []string{"testNS/foo/testChart"}, Version: "0.1"}, actual)
+	})
+
+	t.Run("should resolve 'latest' version", func(t *testing.T) {
+		// given
+		helmClientMock := newMockHelmClient(t)
+		helmClientMock.EXPECT().GetLatestVersion("foo/testChart").Return("1.2.3", nil)
+		step := NewInstallHelmChartStep("testNS", "foo/testChart:latest", helmClientMock)
+
+		// when
+		actual := step.DescribeEffect()
+
+		// then
+		assert.Equal(t, "1.2.3", actual.Version)
+	})
+
+	t.Run("should keep 'latest' version if it cannot be resolved", func(t *testing.T) {
+		// given
+		helmClientMock := newMockHelmClient(t)
+		helmClientMock.EXPECT().GetLatestVersion("foo/testChart").Return("", assert.AnError)
+		step := NewInstallHelmChartStep("testNS", "foo/testChart:latest", helmClientMock)
+
+		// when
+		actual := step.DescribeEffect()
+
+		// then
+		assert.Equal(t, "latest", actual.Version)
+	})
+
+	t.Run("should describe chart with wrong format", func(t *testing.T) {
+		// given
+		step := NewInstallHelmChartStep("testNS", "foo/testChart", newMockHelmClient(t))
+
+		// when
+		actual := step.DescribeEffect()
+
+		// then
+		assert.Equal(t, []string{"foo/testChart"}, actual.Targets)
+		assert.Empty(t, actual.Version)
+	})
+}
+
+func TestSplitChartString(t *testing.T) {
+	t.Run("should split chart name and version", func(t *testing.T) {
+		// when
+		name, version, err := SplitChartString("k8s/k8s-component-operator:1.2.3")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "k8s/k8s-component-operator", name)
+		assert.Equal(t, "1.2.3", version)
+	})
+
+	t.Run("should fail without version", func(t *testing.T) {
+		// when
+		_, _, err := SplitChartString("k8s/k8s-component-operator")
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "componentChart 'k8s/k8s-component-operator' has a wrong format")
+	})
+}
+
+func TestSplitHelmNamespaceFromChartString(t *testing.T) {
+	t.Run("should split helm repository namespace and chart name", func(t *testing.T) {
+		// when
+		namespace, name, err := SplitHelmNamespaceFromChartString("k8s/k8s-component-operator")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "k8s", namespace)
+		assert.Equal(t, "k8s-component-operator", name)
+	})
+
+	tests := []string{"k8s-component-operator", "k8s/", "/k8s-component-operator", "k8s/sub/k8s-component-operator"}
+	for _, chart := range tests {
+		t.Run(fmt.Sprintf("should fail for %q", chart), func(t *testing.T) {
+			// when
+			_, _, err := SplitHelmNamespaceFromChartString(chart)
+
+			// then
+			require.Error(t, err)
+			assert.ErrorContains(t, err, "Must be '<helmRepositoryNamespace>/<chartName>'")
+		})
+	}
 }
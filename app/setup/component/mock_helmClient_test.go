@@ -23,6 +23,63 @@ func (_m *mockHelmClient) EXPECT() *mockHelmClient_Expecter {
 	return &mockHelmClient_Expecter{mock: &_m.Mock}
 }
 
+// GetDeployedReleaseVersion provides a mock function with given fields: ctx, name
+func (_m *mockHelmClient) GetDeployedReleaseVersion(ctx context.Context, name string) (string, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDeployedReleaseVersion")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// mockHelmClient_GetDeployedReleaseVersion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDeployedReleaseVersion'
+type mockHelmClient_GetDeployedReleaseVersion_Call struct {
+	*mock.Call
+}
+
+// GetDeployedReleaseVersion is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *mockHelmClient_Expecter) GetDeployedReleaseVersion(ctx interface{}, name interface{}) *mockHelmClient_GetDeployedReleaseVersion_Call {
+	return &mockHelmClient_GetDeployedReleaseVersion_Call{Call: _e.mock.On("GetDeployedReleaseVersion", ctx, name)}
+}
+
+func (_c *mockHelmClient_GetDeployedReleaseVersion_Call) Run(run func(ctx context.Context, name string)) *mockHelmClient_GetDeployedReleaseVersion_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *mockHelmClient_GetDeployedReleaseVersion_Call) Return(_a0 string, _a1 error) *mockHelmClient_GetDeployedReleaseVersion_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *mockHelmClient_GetDeployedReleaseVersion_Call) RunAndReturn(run func(context.Context, string) (string, error)) *mockHelmClient_GetDeployedReleaseVersion_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetLatestVersion provides a mock function with given fields: chartName
 func (_m *mockHelmClient) GetLatestVersion(chartName string) (string, error) {
 	ret := _m.Called(chartName)
@@ -126,6 +183,52 @@ func (_c *mockHelmClient_InstallOrUpgrade_Call) RunAndReturn(run func(context.Co
 	return _c
 }
 
+// Uninstall provides a mock function with given fields: releaseName
+func (_m *mockHelmClient) Uninstall(releaseName string) error {
+	ret := _m.Called(releaseName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Uninstall")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(releaseName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// mockHelmClient_Uninstall_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Uninstall'
+type mockHelmClient_Uninstall_Call struct {
+	*mock.Call
+}
+
+// Uninstall is a helper method to define mock.On call
+//   - releaseName string
+func (_e *mockHelmClient_Expecter) Uninstall(releaseName interface{}) *mockHelmClient_Uninstall_Call {
+	return &mockHelmClient_Uninstall_Call{Call: _e.mock.On("Uninstall", releaseName)}
+}
+
+func (_c *mockHelmClient_Uninstall_Call) Run(run func(releaseName string)) *mockHelmClient_Uninstall_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *mockHelmClient_Uninstall_Call) Return(_a0 error) *mockHelmClient_Uninstall_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *mockHelmClient_Uninstall_Call) RunAndReturn(run func(string) error) *mockHelmClient_Uninstall_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // newMockHelmClient creates a new instance of mockHelmClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func newMockHelmClient(t interface {
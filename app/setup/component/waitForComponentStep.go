@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/dogus"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 	v1 "github.com/cloudogu/k8s-component-operator/pkg/api/v1"
 	"github.com/cloudogu/retry-lib/retry"
 	"github.com/sirupsen/logrus"
@@ -46,11 +47,25 @@ func NewWaitForComponentStep(client componentsClient, componentName string, name
 	}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (wfcs *waitForComponentStep) GetStepID() string {
+	return fmt.Sprintf("wait-for-component/%s", wfcs.componentName)
+}
+
 // GetStepDescription return the human-readable description of the step
 func (wfcs *waitForComponentStep) GetStepDescription() string {
 	return fmt.Sprintf("Wait for component with selector %s to be ready", wfcs.labelSelector)
 }
 
+// DescribeEffect returns the component resource which the step waits for.
+func (wfcs *waitForComponentStep) DescribeEffect() plan.Effect {
+	return plan.Effect{
+		Action:  plan.ActionWait,
+		Kind:    "Component",
+		Targets: []string{fmt.Sprintf("%s/%s", wfcs.namespace, wfcs.componentName)},
+	}
+}
+
 // PerformSetupStep implements all actions in this step
 func (wfcs *waitForComponentStep) PerformSetupStep(ctx context.Context) error {
 	timeoutCtx, cancel := context.WithTimeout(ctx, wfcs.timeout)
@@ -60,12 +75,18 @@ func (wfcs *waitForComponentStep) PerformSetupStep(ctx context.Context) error {
 
 // isComponentStatusReady does a watch on a component and returns nil if the component is installed
 func (wfcs *waitForComponentStep) isComponentReady(ctx context.Context) error {
+	return waitForComponent(ctx, wfcs.client, wfcs.componentName, wfcs.labelSelector, wfcs.timeout, nil)
+}
+
+// waitForComponent does a watch on a component and returns nil if the component is installed and available.
+// Every observed state of the component is passed to observe if it is not nil.
+func waitForComponent(ctx context.Context, client componentsClient, componentName string, labelSelector string, timeout time.Duration, observe func(component *v1.Component)) error {
 	var get *v1.Component
-	err := retry.OnErrorWithLimit(wfcs.timeout, errors.IsNotFound, func() error {
+	err := retry.OnErrorWithLimit(timeout, errors.IsNotFound, func() error {
 		var getErr error
-		get, getErr = wfcs.client.Get(ctx, wfcs.componentName, metav1.GetOptions{})
+		get, getErr = client.Get(ctx, componentName, metav1.GetOptions{})
 		if getErr != nil && !errors.IsNotFound(getErr) {
-			return fmt.Errorf("failed to get initial component cr %q: %w", wfcs.componentName, getErr)
+			return fmt.Errorf("failed to get initial component cr %q: %w", componentName, getErr)
 		}
 
 		return getErr
@@ -75,14 +96,18 @@ func (wfcs *waitForComponentStep) isComponentReady(ctx context.Context) error {
 		return err
 	}
 
+	if observe != nil {
+		observe(get)
+	}
+
 	if isComponentStatusReady(get) {
 		return nil
 	}
 
-	watcher := componentReadyWatcher{client: wfcs.client, componentName: wfcs.componentName, labelSelector: wfcs.labelSelector}
+	watcher := componentReadyWatcher{client: client, componentName: componentName, labelSelector: labelSelector, observe: observe}
 	_, err = retrywatch.Until(ctx, get.ResourceVersion, watcher, watcher.checkComponentStatus)
 	if err != nil {
-		return fmt.Errorf("failed to wait for component with label %q with retry watch: %w", wfcs.labelSelector, err)
+		return fmt.Errorf("failed to wait for component with label %q with retry watch: %w", labelSelector, err)
 	}
 
 	return nil
@@ -92,6 +117,8 @@ type componentReadyWatcher struct {
 	client        componentsClient
 	componentName string
 	labelSelector string
+	// observe receives every state of the component received from the watch. It may be nil.
+	observe func(component *v1.Component)
 }
 
 // Watch creates a watch for the component defined in this step.
@@ -127,6 +154,9 @@ func (crw componentReadyWatcher) checkComponentStatus(event watch.Event) (bool,
 			logrus.Errorf("failed to cast event object to component: selector=[%s] type=[%s]; object=[%+v]", crw.labelSelector, event.Type, event.Object)
 			return false, nil
 		}
+		if crw.observe != nil {
+			crw.observe(component)
+		}
 		if isComponentStatusReady(component) {
 			return true, nil
 		}
@@ -0,0 +1,157 @@
+package component
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
+	v1 "github.com/cloudogu/k8s-component-operator/pkg/api/v1"
+)
+
+// ComponentReadiness contains the outcome of waiting for a single component.
+type ComponentReadiness struct {
+	// Name is the name of the component.
+	Name string `json:"name"`
+	// Ready is true if the component became installed and available before the deadline.
+	Ready bool `json:"ready"`
+	// Status is the last observed installation status of the component.
+	Status string `json:"status,omitempty"`
+	// Health is the last observed health of the component.
+	Health v1.HealthStatus `json:"health,omitempty"`
+	// Error contains the reason why the component did not become ready.
+	Error string `json:"error,omitempty"`
+}
+
+// ComponentsNotReadyError is returned if at least one component did not become ready before the shared deadline.
+// It reports the outcome of all components the step waited for.
+type ComponentsNotReadyError struct {
+	// Components contains the outcome of all components in the order of their names.
+	Components []ComponentReadiness
+}
+
+// Error returns the last observed state of all components which did not become ready.
+func (e *ComponentsNotReadyError) Error() string {
+	var ready, notReady []string
+	for _, component := range e.Components {
+		if component.Ready {
+			ready = append(ready, component.Name)
+			continue
+		}
+		notReady = append(notReady, fmt.Sprintf("%s (status: %q, health: %q): %s", component.Name, component.Status, component.Health, component.Error))
+	}
+
+	message := fmt.Sprintf("%d of %d components did not become ready: %s", len(notReady), len(e.Components), strings.Join(notReady, "; "))
+	if len(ready) > 0 {
+		message += fmt.Sprintf("; ready components: %s", strings.Join(ready, ", "))
+	}
+
+	return message
+}
+
+type waitForComponentsStep struct {
+	client         componentsClient
+	namespace      string
+	componentNames []string
+	timeout        time.Duration
+}
+
+// NewWaitForComponentsStep creates a new setup step which waits concurrently for all given components. All components
+// share the same deadline.
+func NewWaitForComponentsStep(client componentsClient, componentNames []string, namespace string, timeout time.Duration) *waitForComponentsStep {
+	sortedNames := slices.Clone(componentNames)
+	slices.Sort(sortedNames)
+
+	return &waitForComponentsStep{
+		client:         client,
+		namespace:      namespace,
+		componentNames: sortedNames,
+		timeout:        timeout,
+	}
+}
+
+// GetStepID returns the stable identifier of the step.
+func (wfcs *waitForComponentsStep) GetStepID() string {
+	return fmt.Sprintf("wait-for-components/%s", strings.Join(wfcs.componentNames, ","))
+}
+
+// GetStepDescription return the human-readable description of the step
+func (wfcs *waitForComponentsStep) GetStepDescription() string {
+	return fmt.Sprintf("Wait for components %s to be ready", strings.Join(wfcs.componentNames, ", "))
+}
+
+// DescribeEffect returns the component resources which the step waits for.
+func (wfcs *waitForComponentsStep) DescribeEffect() plan.Effect {
+	var targets []string
+	for _, componentName := range wfcs.componentNames {
+		targets = append(targets, fmt.Sprintf("%s/%s", wfcs.namespace, componentName))
+	}
+
+	return plan.Effect{
+		Action:  plan.ActionWait,
+		Kind:    "Component",
+		Targets: targets,
+	}
+}
+
+// PerformSetupStep waits for all components at once and returns a ComponentsNotReadyError if at least one of them
+// did not become ready before the deadline.
+func (wfcs *waitForComponentsStep) PerformSetupStep(ctx context.Context) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, wfcs.timeout)
+	defer cancel()
+
+	readiness := make([]ComponentReadiness, len(wfcs.componentNames))
+	var wg sync.WaitGroup
+	for i, componentName := range wfcs.componentNames {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			readiness[i] = wfcs.waitForComponent(timeoutCtx, componentName)
+		}()
+	}
+	wg.Wait()
+
+	allReady := true
+	for _, component := range readiness {
+		if component.Ready {
+			logrus.Infof("Component %q is ready", component.Name)
+			continue
+		}
+		allReady = false
+		logrus.Errorf("Component %q did not become ready (status: %q, health: %q): %s", component.Name, component.Status, component.Health, component.Error)
+	}
+
+	if !allReady {
+		return &ComponentsNotReadyError{Components: readiness}
+	}
+
+	return nil
+}
+
+func (wfcs *waitForComponentsStep) waitForComponent(ctx context.Context, componentName string) ComponentReadiness {
+	var mutex sync.Mutex
+	readiness := ComponentReadiness{Name: componentName}
+	observe := func(component *v1.Component) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		readiness.Status = component.Status.Status
+		readiness.Health = component.Status.Health
+	}
+
+	err := waitForComponent(ctx, wfcs.client, componentName, CreateComponentLabelSelector(componentName), wfcs.timeout, observe)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if err != nil {
+		readiness.Error = err.Error()
+		return readiness
+	}
+
+	readiness.Ready = true
+	return readiness
+}
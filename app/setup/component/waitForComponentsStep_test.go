@@ -0,0 +1,119 @@
+package component
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "github.com/cloudogu/k8s-component-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
+)
+
+func TestNewWaitForComponentsStep(t *testing.T) {
+	t.Run("should sort component names", func(t *testing.T) {
+		// when
+		step := NewWaitForComponentsStep(newMockComponentsClient(t), []string{"k8s-dogu-operator", "k8s-ces-control"}, testNamespace, TimeoutInSeconds())
+
+		// then
+		assert.Equal(t, []string{"k8s-ces-control", "k8s-dogu-operator"}, step.componentNames)
+		assert.Equal(t, "wait-for-components/k8s-ces-control,k8s-dogu-operator", step.GetStepID())
+		assert.Equal(t, "Wait for components k8s-ces-control, k8s-dogu-operator to be ready", step.GetStepDescription())
+		assert.Equal(t, plan.Effect{
+			Action:  plan.ActionWait,
+			Kind:    "Component",
+			Targets: []string{"ecosystem/k8s-ces-control", "ecosystem/k8s-dogu-operator"},
+		}, step.DescribeEffect())
+	})
+}
+
+func TestWaitForComponentsStep_PerformSetupStep(t *testing.T) {
+	testCtx := context.Background()
+
+	t.Run("should succeed if all components are ready", func(t *testing.T) {
+		// given
+		componentsClientMock := newMockComponentsClient(t)
+		componentsClientMock.EXPECT().Get(mock.Anything, "k8s-ces-control", metav1.GetOptions{}).Return(readyComponent("k8s-ces-control"), nil)
+		componentsClientMock.EXPECT().Get(mock.Anything, "k8s-dogu-operator", metav1.GetOptions{}).Return(readyComponent("k8s-dogu-operator"), nil)
+
+		step := NewWaitForComponentsStep(componentsClientMock, []string{"k8s-dogu-operator", "k8s-ces-control"}, testNamespace, TimeoutInSeconds())
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should report ready components and the last observed state of components which are not ready", func(t *testing.T) {
+		// given
+		notReady := &v1.Component{ObjectMeta: metav1.ObjectMeta{Name: "k8s-dogu-operator", Namespace: testNamespace, ResourceVersion: "1"}}
+		installing := notReady.DeepCopy()
+		installing.Status = v1.ComponentStatus{Status: v1.ComponentStatusInstalling, Health: v1.UnavailableHealthStatus}
+		watcher := watch.NewFake()
+
+		componentsClientMock := newMockComponentsClient(t)
+		componentsClientMock.EXPECT().Get(mock.Anything, "k8s-ces-control", metav1.GetOptions{}).Return(readyComponent("k8s-ces-control"), nil)
+		componentsClientMock.EXPECT().Get(mock.Anything, "k8s-dogu-operator", metav1.GetOptions{}).Return(notReady, nil)
+		componentsClientMock.EXPECT().Watch(mock.Anything, mock.Anything).Return(watcher, nil)
+
+		step := NewWaitForComponentsStep(componentsClientMock, []string{"k8s-dogu-operator", "k8s-ces-control"}, testNamespace, TimeoutInSeconds())
+
+		go func() {
+			watcher.Modify(installing)
+			watcher.Delete(installing)
+		}()
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.Error(t, err)
+		var notReadyErr *ComponentsNotReadyError
+		require.ErrorAs(t, err, &notReadyErr)
+		require.Len(t, notReadyErr.Components, 2)
+		assert.Equal(t, ComponentReadiness{Name: "k8s-ces-control", Ready: true, Status: v1.ComponentStatusInstalled, Health: v1.AvailableHealthStatus}, notReadyErr.Components[0])
+		assert.Equal(t, "k8s-dogu-operator", notReadyErr.Components[1].Name)
+		assert.False(t, notReadyErr.Components[1].Ready)
+		assert.Equal(t, v1.ComponentStatusInstalling, notReadyErr.Components[1].Status)
+		assert.Equal(t, v1.UnavailableHealthStatus, notReadyErr.Components[1].Health)
+		assert.Contains(t, notReadyErr.Components[1].Error, "abort watch because of component deletion")
+		assert.ErrorContains(t, err, "1 of 2 components did not become ready: k8s-dogu-operator (status: \"installing\", health: \"unavailable\")")
+		assert.ErrorContains(t, err, "ready components: k8s-ces-control")
+	})
+
+	t.Run("should share the deadline between all components", func(t *testing.T) {
+		// given
+		componentsClientMock := newMockComponentsClient(t)
+		for _, name := range []string{"k8s-ces-control", "k8s-dogu-operator"} {
+			notReady := &v1.Component{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, ResourceVersion: "1"}}
+			componentsClientMock.EXPECT().Get(mock.Anything, name, metav1.GetOptions{}).Return(notReady, nil)
+		}
+		componentsClientMock.EXPECT().Watch(mock.Anything, mock.Anything).RunAndReturn(func(context.Context, metav1.ListOptions) (watch.Interface, error) {
+			return watch.NewFake(), nil
+		})
+
+		step := NewWaitForComponentsStep(componentsClientMock, []string{"k8s-dogu-operator", "k8s-ces-control"}, testNamespace, 100*time.Millisecond)
+
+		// when
+		start := time.Now()
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.Less(t, time.Since(start), 5*time.Second)
+		assert.ErrorContains(t, err, "2 of 2 components did not become ready")
+	})
+}
+
+func readyComponent(name string) *v1.Component {
+	return &v1.Component{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, ResourceVersion: "1"},
+		Status:     v1.ComponentStatus{Status: v1.ComponentStatusInstalled, Health: v1.AvailableHealthStatus},
+	}
+}
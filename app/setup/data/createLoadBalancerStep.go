@@ -2,12 +2,15 @@ package data
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"maps"
 	"strings"
 
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -26,6 +29,15 @@ type createLoadBalancerStep struct {
 	config    *appcontext.SetupJsonConfiguration
 	clientSet kubernetes.Interface
 	namespace string
+	created   bool
+	// previousService contains the labels and spec of an existing loadbalancer service before this step updated it.
+	previousService *corev1.Service
+}
+
+// createLoadBalancerRollbackState contains the data needed to roll back the step.
+type createLoadBalancerRollbackState struct {
+	Created         bool            `json:"created"`
+	PreviousService *corev1.Service `json:"previousService,omitempty"`
 }
 
 // NewCreateLoadBalancerStep creates the external loadbalancer service for the Cloudogu EcoSystem
@@ -33,11 +45,25 @@ func NewCreateLoadBalancerStep(config *appcontext.SetupJsonConfiguration, client
 	return &createLoadBalancerStep{config: config, clientSet: clientSet, namespace: namespace}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (fcs *createLoadBalancerStep) GetStepID() string {
+	return "create-loadbalancer"
+}
+
 // GetStepDescription return the human-readable description of the step
 func (fcs *createLoadBalancerStep) GetStepDescription() string {
 	return "Creating the main loadbalancer service for the Cloudogu EcoSystem"
 }
 
+// DescribeEffect returns the loadbalancer service which the step creates or updates.
+func (fcs *createLoadBalancerStep) DescribeEffect() plan.Effect {
+	return plan.Effect{
+		Action:  plan.ActionCreate,
+		Kind:    "Service",
+		Targets: []string{fmt.Sprintf("%s/%s", fcs.namespace, cesLoadbalancerName)},
+	}
+}
+
 // PerformSetupStep creates a loadbalancer service and sets the loadbalancer IP as the new FQDN.
 func (fcs *createLoadBalancerStep) PerformSetupStep(ctx context.Context) error {
 	err := fcs.checkIfNginxWillBeInstalled()
@@ -48,6 +74,68 @@ func (fcs *createLoadBalancerStep) PerformSetupStep(ctx context.Context) error {
 	return fcs.upsertLoadbalancerService(ctx)
 }
 
+// RollbackSetupStep deletes the loadbalancer service if it was created by this step. The labels and spec of a
+// loadbalancer service updated by this step are reset to their previous values.
+func (fcs *createLoadBalancerStep) RollbackSetupStep(ctx context.Context) error {
+	if !fcs.created {
+		return fcs.restorePreviousService(ctx)
+	}
+
+	err := fcs.clientSet.CoreV1().Services(fcs.namespace).Delete(ctx, cesLoadbalancerName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete loadbalancer service: %w", err)
+	}
+
+	fcs.created = false
+	return nil
+}
+
+func (fcs *createLoadBalancerStep) restorePreviousService(ctx context.Context) error {
+	if fcs.previousService == nil {
+		return nil
+	}
+
+	serviceClient := fcs.clientSet.CoreV1().Services(fcs.namespace)
+	actualService, err := serviceClient.Get(ctx, cesLoadbalancerName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get loadbalancer service: %w", err)
+	}
+
+	logrus.Debug("Reset existing load balancer service")
+	actualService.Labels = fcs.previousService.Labels
+	actualService.Spec.Type = fcs.previousService.Spec.Type
+	actualService.Spec.IPFamilyPolicy = fcs.previousService.Spec.IPFamilyPolicy
+	actualService.Spec.IPFamilies = fcs.previousService.Spec.IPFamilies
+	actualService.Spec.Selector = fcs.previousService.Spec.Selector
+	actualService.Spec.Ports = fcs.previousService.Spec.Ports
+	_, err = serviceClient.Update(ctx, actualService, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to reset loadbalancer service: %w", err)
+	}
+
+	fcs.previousService = nil
+	return nil
+}
+
+// GetRollbackState returns whether the loadbalancer service was created by this step and its labels and spec before
+// it was updated.
+func (fcs *createLoadBalancerStep) GetRollbackState() (json.RawMessage, error) {
+	return json.Marshal(createLoadBalancerRollbackState{Created: fcs.created, PreviousService: fcs.previousService})
+}
+
+// SetRollbackState restores the rollback data of the loadbalancer service recorded by a previous setup run.
+func (fcs *createLoadBalancerStep) SetRollbackState(state json.RawMessage) error {
+	var rollbackState createLoadBalancerRollbackState
+	err := json.Unmarshal(state, &rollbackState)
+	if err != nil {
+		return err
+	}
+
+	fcs.created = rollbackState.Created
+	fcs.previousService = rollbackState.PreviousService
+	return nil
+}
+
 func (fcs *createLoadBalancerStep) checkIfNginxWillBeInstalled() error {
 	for _, dogu := range fcs.config.Dogus.Install {
 		if strings.Contains(dogu, nginxIngressName) {
@@ -82,6 +170,7 @@ func (fcs *createLoadBalancerStep) upsertLoadbalancerService(ctx context.Context
 	if err != nil && errors.IsNotFound(err) {
 		logrus.Debug("Create load balancer service")
 		_, err = fcs.clientSet.CoreV1().Services(fcs.namespace).Create(ctx, serviceResource, metav1.CreateOptions{})
+		fcs.created = err == nil
 	}
 
 	return err
@@ -89,6 +178,13 @@ func (fcs *createLoadBalancerStep) upsertLoadbalancerService(ctx context.Context
 
 func (fcs *createLoadBalancerStep) updateServiceResource(ctx context.Context, actualService *corev1.Service, service *corev1.Service) error {
 	logrus.Debug("Update existing load balancer service")
+	if !fcs.created && fcs.previousService == nil {
+		// keep the service recorded by an interrupted setup run
+		fcs.previousService = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Labels: maps.Clone(actualService.Labels)},
+			Spec:       *actualService.Spec.DeepCopy(),
+		}
+	}
 	// Update to ensure idempotence
 	if actualService.Labels != nil {
 		actualService.Labels["app"] = "ces"
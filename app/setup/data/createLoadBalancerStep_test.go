@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/fake"
@@ -108,3 +109,94 @@ func Test_createLoadBalancerStep_PerformSetupStep(t *testing.T) {
 		assert.Equal(t, expectedServicePorts, actual.Spec.Ports)
 	})
 }
+
+func Test_createLoadBalancerStep_RollbackSetupStep(t *testing.T) {
+	t.Run("should delete the loadbalancer created by the step", func(t *testing.T) {
+		// given
+		fakeClient := fake.NewClientset()
+		config := &appctx.SetupJsonConfiguration{Dogus: appctx.Dogus{Install: []string{nginxIngressName}}}
+		sut := NewCreateLoadBalancerStep(config, fakeClient, testNamespace)
+		require.NoError(t, sut.PerformSetupStep(testCtx))
+
+		// when
+		err := sut.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		_, err = fakeClient.CoreV1().Services(testNamespace).Get(testCtx, cesLoadbalancerName, metav1.GetOptions{})
+		assert.True(t, errors.IsNotFound(err))
+	})
+
+	t.Run("should reset a loadbalancer which existed before", func(t *testing.T) {
+		// given
+		previousPorts := []corev1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 8080, TargetPort: intstr.FromInt32(8080)}}
+		serviceResource := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: cesLoadbalancerName, Namespace: testNamespace, Labels: map[string]string{"app": "other"}},
+			Spec: corev1.ServiceSpec{
+				Type:     corev1.ServiceTypeClusterIP,
+				Selector: map[string]string{"app": "other"},
+				Ports:    previousPorts,
+			},
+		}
+		fakeClient := fake.NewClientset(serviceResource)
+		config := &appctx.SetupJsonConfiguration{Dogus: appctx.Dogus{Install: []string{nginxIngressName}}}
+		sut := NewCreateLoadBalancerStep(config, fakeClient, testNamespace)
+		require.NoError(t, sut.PerformSetupStep(testCtx))
+
+		// when
+		err := sut.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		actual, err := fakeClient.CoreV1().Services(testNamespace).Get(testCtx, cesLoadbalancerName, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"app": "other"}, actual.Labels)
+		assert.Equal(t, corev1.ServiceTypeClusterIP, actual.Spec.Type)
+		assert.Equal(t, map[string]string{"app": "other"}, actual.Spec.Selector)
+		assert.Equal(t, previousPorts, actual.Spec.Ports)
+		assert.Nil(t, actual.Spec.IPFamilyPolicy)
+		assert.Nil(t, sut.previousService)
+	})
+
+	t.Run("should reset a loadbalancer recorded by a previous setup run", func(t *testing.T) {
+		// given
+		serviceResource := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: cesLoadbalancerName, Namespace: testNamespace, Labels: map[string]string{"app": "other"}},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP},
+		}
+		fakeClient := fake.NewClientset(serviceResource)
+		config := &appctx.SetupJsonConfiguration{Dogus: appctx.Dogus{Install: []string{nginxIngressName}}}
+		performedStep := NewCreateLoadBalancerStep(config, fakeClient, testNamespace)
+		require.NoError(t, performedStep.PerformSetupStep(testCtx))
+		state, err := performedStep.GetRollbackState()
+		require.NoError(t, err)
+		sut := NewCreateLoadBalancerStep(config, fakeClient, testNamespace)
+		require.NoError(t, sut.SetRollbackState(state))
+
+		// when
+		err = sut.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		actual, err := fakeClient.CoreV1().Services(testNamespace).Get(testCtx, cesLoadbalancerName, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"app": "other"}, actual.Labels)
+		assert.Equal(t, corev1.ServiceTypeClusterIP, actual.Spec.Type)
+	})
+
+	t.Run("should fail to reset a loadbalancer which was removed", func(t *testing.T) {
+		// given
+		fakeClient := fake.NewClientset()
+		config := &appctx.SetupJsonConfiguration{Dogus: appctx.Dogus{Install: []string{nginxIngressName}}}
+		sut := NewCreateLoadBalancerStep(config, fakeClient, testNamespace)
+		sut.previousService = &corev1.Service{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP}}
+
+		// when
+		err := sut.RollbackSetupStep(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.True(t, errors.IsNotFound(err))
+		assert.ErrorContains(t, err, "failed to get loadbalancer service")
+	})
+}
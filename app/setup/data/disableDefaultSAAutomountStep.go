@@ -3,6 +3,7 @@ package data
 import (
 	"context"
 	"fmt"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
@@ -17,11 +18,25 @@ func NewDisableDefaultSAAutomountStep(clientSet kubernetes.Interface, namespace
 	return &disableDefaultSAAutomountStep{clientSet: clientSet, namespace: namespace}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (fcs *disableDefaultSAAutomountStep) GetStepID() string {
+	return "disable-default-sa-automount"
+}
+
 // GetStepDescription return the human-readable description of the step
 func (fcs *disableDefaultSAAutomountStep) GetStepDescription() string {
 	return "Disable automounting the token for the default service account in the ecosystem namespace"
 }
 
+// DescribeEffect returns the default service account which the step updates.
+func (fcs *disableDefaultSAAutomountStep) DescribeEffect() plan.Effect {
+	return plan.Effect{
+		Action:  plan.ActionUpdate,
+		Kind:    "ServiceAccount",
+		Targets: []string{fmt.Sprintf("%s/default", fcs.namespace)},
+	}
+}
+
 // PerformSetupStep disables automounting the token for the default service account in the ecoSystem namespace.
 // No pod in the ecosystem namespace should mount the default service account, with this we prevent accidental mounting.
 func (fcs *disableDefaultSAAutomountStep) PerformSetupStep(ctx context.Context) error {
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -30,11 +31,30 @@ func NewFQDNRetrieverStep(config *appcontext.SetupJsonConfiguration, clientSet k
 	return &fqdnRetrieverStep{config: config, clientSet: clientSet, namespace: namespace}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (fcs *fqdnRetrieverStep) GetStepID() string {
+	return "retrieve-fqdn"
+}
+
 // GetStepDescription return the human-readable description of the step
 func (fcs *fqdnRetrieverStep) GetStepDescription() string {
 	return "Retrieving a new FQDN from the IP of a loadbalancer service"
 }
 
+// IsRepeatable returns true because the retrieved FQDN is only kept in the setup configuration in memory.
+func (fcs *fqdnRetrieverStep) IsRepeatable() bool {
+	return true
+}
+
+// DescribeEffect returns the FQDN which the step retrieves from the loadbalancer service.
+func (fcs *fqdnRetrieverStep) DescribeEffect() plan.Effect {
+	return plan.Effect{
+		Action:  plan.ActionGenerate,
+		Kind:    "FQDN",
+		Targets: []string{fmt.Sprintf("%s/%s", fcs.namespace, cesLoadbalancerName)},
+	}
+}
+
 // PerformSetupStep creates a loadbalancer service and sets the loadbalancer IP as the new FQDN.
 func (fcs *fqdnRetrieverStep) PerformSetupStep(ctx context.Context) error {
 	return fcs.setFQDNFromLoadbalancerIP(ctx)
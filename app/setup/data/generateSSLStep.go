@@ -2,9 +2,18 @@ package data
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"time"
+
 	"github.com/cloudogu/cesapp-lib/ssl"
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -13,6 +22,7 @@ const (
 
 type generateSSLStep struct {
 	config       *appcontext.SetupJsonConfiguration
+	secretClient secretClient
 	SslGenerator SSLGenerator
 }
 
@@ -22,10 +32,16 @@ type SSLGenerator interface {
 		province string, locality string, altDNSNames []string) (string, string, error)
 }
 
-// NewGenerateSSLStep creates a new setup step which on generates ssl certificates
-func NewGenerateSSLStep(config *appcontext.SetupJsonConfiguration) *generateSSLStep {
+// NewGenerateSSLStep creates a new setup step which on generates ssl certificates. A valid self-signed certificate in
+// the certificate secret is kept instead.
+func NewGenerateSSLStep(config *appcontext.SetupJsonConfiguration, secretClient secretClient) *generateSSLStep {
 	generator := ssl.NewSSLGenerator()
-	return &generateSSLStep{config: config, SslGenerator: generator}
+	return &generateSSLStep{config: config, secretClient: secretClient, SslGenerator: generator}
+}
+
+// GetStepID returns the stable identifier of the step.
+func (gss *generateSSLStep) GetStepID() string {
+	return "generate-ssl"
 }
 
 // GetStepDescription return the human-readable description of the step
@@ -33,13 +49,36 @@ func (gss *generateSSLStep) GetStepDescription() string {
 	return "Generate SSL certificate and key"
 }
 
-// PerformSetupStep either generates a certificate if necessary and writes it to the setup configuration
-func (gss *generateSSLStep) PerformSetupStep(context.Context) error {
+// IsRepeatable returns true because the generated certificate is only kept in the setup configuration in memory.
+func (gss *generateSSLStep) IsRepeatable() bool {
+	return true
+}
+
+// DescribeEffect returns the certificate which the step generates in the setup configuration.
+func (gss *generateSSLStep) DescribeEffect() plan.Effect {
+	return plan.Effect{Action: plan.ActionGenerate, Kind: "Certificate", Targets: []string{"naming/certificate", "naming/certificateKey"}}
+}
+
+// PerformSetupStep either generates a certificate if necessary and writes it to the setup configuration. The
+// self-signed certificate of an installed ecosystem is kept as long as it is valid for the configured FQDN.
+func (gss *generateSSLStep) PerformSetupStep(ctx context.Context) error {
 	naming := &gss.config.Naming
 	// Generation not needed
 	if naming.CertificateType == "external" {
 		return nil
 	}
+
+	existingCert, existingKey, found, err := gss.getExistingCertificate(ctx)
+	if err != nil {
+		return err
+	}
+	if found && isValidSelfSignedCertificate(existingCert, existingKey, naming.Fqdn) {
+		logrus.Info("Keep the existing self-signed certificate as it is still valid for the FQDN")
+		naming.Certificate = existingCert
+		naming.CertificateKey = existingKey
+		return nil
+	}
+
 	cert, key, err := gss.SslGenerator.GenerateSelfSignedCert(naming.Fqdn, naming.Domain, expireDays, ssl.Country, ssl.Province, ssl.Locality, []string{})
 	if err != nil {
 		return fmt.Errorf("failed to generate self-signed certificate and key: %w", err)
@@ -50,3 +89,39 @@ func (gss *generateSSLStep) PerformSetupStep(context.Context) error {
 
 	return nil
 }
+
+func (gss *generateSSLStep) getExistingCertificate(ctx context.Context) (cert string, key string, found bool, err error) {
+	secret, err := gss.secretClient.Get(ctx, certificateSecretName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return "", "", false, nil
+	}
+	if err != nil {
+		return "", "", false, fmt.Errorf("failed to get existing certificate secret: %w", err)
+	}
+
+	return string(secret.Data[v1.TLSCertKey]), string(secret.Data[v1.TLSPrivateKeyKey]), true, nil
+}
+
+// isValidSelfSignedCertificate checks whether the given certificate chain was generated by the setup, matches the
+// key, is not expired and is valid for the given FQDN.
+func isValidSelfSignedCertificate(cert string, key string, fqdn string) bool {
+	keyPair, err := tls.X509KeyPair([]byte(cert), []byte(key))
+	if err != nil || len(keyPair.Certificate) != 2 {
+		return false
+	}
+
+	leaf, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return false
+	}
+	ca, err := x509.ParseCertificate(keyPair.Certificate[1])
+	if err != nil {
+		return false
+	}
+
+	if ca.CheckSignatureFrom(ca) != nil || leaf.CheckSignatureFrom(ca) != nil {
+		return false
+	}
+
+	return time.Now().Before(leaf.NotAfter) && leaf.VerifyHostname(fqdn) == nil
+}
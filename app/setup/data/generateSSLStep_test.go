@@ -2,8 +2,14 @@ package data_test
 
 import (
 	"context"
+	"github.com/cloudogu/cesapp-lib/ssl"
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
 	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/data"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -13,7 +19,7 @@ import (
 
 func TestNewGenerateSSL(t *testing.T) {
 	// when
-	step := data.NewGenerateSSLStep(nil)
+	step := data.NewGenerateSSLStep(nil, nil)
 
 	// then
 	require.NotNil(t, step)
@@ -21,7 +27,7 @@ func TestNewGenerateSSL(t *testing.T) {
 
 func Test_generateSSLStep_GetStepDescription(t *testing.T) {
 	// given
-	step := data.NewGenerateSSLStep(nil)
+	step := data.NewGenerateSSLStep(nil, nil)
 
 	// when
 	description := step.GetStepDescription()
@@ -36,7 +42,7 @@ func Test_generateSSLStep_PerformSetupStep(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		// given
 		config := &appcontext.SetupJsonConfiguration{Naming: appcontext.Naming{CertificateType: "selfsigned", Fqdn: fqdn, Domain: "myces"}}
-		step := data.NewGenerateSSLStep(config)
+		step := data.NewGenerateSSLStep(config, fake.NewClientset().CoreV1().Secrets("ecosystem"))
 		generatorMock := &data.MockSSLGenerator{}
 		generatorMock.EXPECT().GenerateSelfSignedCert(fqdn, "myces", 365, "DE",
 			"Lower Saxony", "Brunswick", []string{}).Return("cert", "key", nil)
@@ -55,7 +61,7 @@ func Test_generateSSLStep_PerformSetupStep(t *testing.T) {
 	t.Run("failed to generate certificate", func(t *testing.T) {
 		// given
 		config := &appcontext.SetupJsonConfiguration{Naming: appcontext.Naming{CertificateType: "selfsigned", Fqdn: fqdn, Domain: "myces"}}
-		step := data.NewGenerateSSLStep(config)
+		step := data.NewGenerateSSLStep(config, fake.NewClientset().CoreV1().Secrets("ecosystem"))
 		generatorMock := &data.MockSSLGenerator{}
 		generatorMock.EXPECT().GenerateSelfSignedCert(fqdn, "myces", 365, "DE",
 			"Lower Saxony", "Brunswick", []string{}).Return("cert", "key", assert.AnError)
@@ -72,7 +78,7 @@ func Test_generateSSLStep_PerformSetupStep(t *testing.T) {
 	t.Run("let external cert unchanged", func(t *testing.T) {
 		// given
 		config := &appcontext.SetupJsonConfiguration{Naming: appcontext.Naming{CertificateType: "external", Certificate: "bitte nicht", CertificateKey: "bitte nicht"}}
-		step := data.NewGenerateSSLStep(config)
+		step := data.NewGenerateSSLStep(config, fake.NewClientset().CoreV1().Secrets("ecosystem"))
 
 		// when
 		err := step.PerformSetupStep(testCtx)
@@ -82,4 +88,84 @@ func Test_generateSSLStep_PerformSetupStep(t *testing.T) {
 		assert.Equal(t, "bitte nicht", config.Naming.Certificate)
 		assert.Equal(t, "bitte nicht", config.Naming.CertificateKey)
 	})
+	t.Run("should keep existing certificate which is valid for the fqdn", func(t *testing.T) {
+		// given
+		existingCert, existingKey, err := ssl.NewSSLGenerator().GenerateSelfSignedCert(fqdn, "myces", 365, ssl.Country, ssl.Province, ssl.Locality, []string{})
+		require.NoError(t, err)
+		secretClient := fake.NewClientset(certificateSecret(existingCert, existingKey)).CoreV1().Secrets("ecosystem")
+		config := &appcontext.SetupJsonConfiguration{Naming: appcontext.Naming{CertificateType: "selfsigned", Fqdn: fqdn, Domain: "myces"}}
+		step := data.NewGenerateSSLStep(config, secretClient)
+		step.SslGenerator = data.NewMockSSLGenerator(t)
+
+		// when
+		err = step.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, existingCert, config.Naming.Certificate)
+		assert.Equal(t, existingKey, config.Naming.CertificateKey)
+	})
+
+	t.Run("should generate new certificate if the fqdn changed", func(t *testing.T) {
+		// given
+		existingCert, existingKey, err := ssl.NewSSLGenerator().GenerateSelfSignedCert("192.168.56.3", "myces", 365, ssl.Country, ssl.Province, ssl.Locality, []string{})
+		require.NoError(t, err)
+		secretClient := fake.NewClientset(certificateSecret(existingCert, existingKey)).CoreV1().Secrets("ecosystem")
+		config := &appcontext.SetupJsonConfiguration{Naming: appcontext.Naming{CertificateType: "selfsigned", Fqdn: fqdn, Domain: "myces"}}
+		step := data.NewGenerateSSLStep(config, secretClient)
+		generatorMock := data.NewMockSSLGenerator(t)
+		generatorMock.EXPECT().GenerateSelfSignedCert(fqdn, "myces", 365, "DE",
+			"Lower Saxony", "Brunswick", []string{}).Return("cert", "key", nil)
+		step.SslGenerator = generatorMock
+
+		// when
+		err = step.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "cert", config.Naming.Certificate)
+		assert.Equal(t, "key", config.Naming.CertificateKey)
+	})
+
+	t.Run("should generate new certificate if the existing certificate is external", func(t *testing.T) {
+		// given
+		secretClient := fake.NewClientset(certificateSecret("external", "key")).CoreV1().Secrets("ecosystem")
+		config := &appcontext.SetupJsonConfiguration{Naming: appcontext.Naming{CertificateType: "selfsigned", Fqdn: fqdn, Domain: "myces"}}
+		step := data.NewGenerateSSLStep(config, secretClient)
+		generatorMock := data.NewMockSSLGenerator(t)
+		generatorMock.EXPECT().GenerateSelfSignedCert(fqdn, "myces", 365, "DE",
+			"Lower Saxony", "Brunswick", []string{}).Return("cert", "key", nil)
+		step.SslGenerator = generatorMock
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "cert", config.Naming.Certificate)
+	})
+
+	t.Run("should fail to get existing certificate", func(t *testing.T) {
+		// given
+		clientSet := fake.NewClientset()
+		clientSet.PrependReactor("get", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, assert.AnError
+		})
+		config := &appcontext.SetupJsonConfiguration{Naming: appcontext.Naming{CertificateType: "selfsigned", Fqdn: fqdn, Domain: "myces"}}
+		step := data.NewGenerateSSLStep(config, clientSet.CoreV1().Secrets("ecosystem"))
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to get existing certificate secret")
+	})
+}
+
+func certificateSecret(cert string, key string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ecosystem-certificate", Namespace: "ecosystem"},
+		Data:       map[string][]byte{v1.TLSCertKey: []byte(cert), v1.TLSPrivateKeyKey: []byte(key)},
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -25,11 +26,28 @@ func NewInstanceSecretValidatorStep(clientSet kubernetes.Interface, targetNamesp
 	return v
 }
 
+// GetStepID returns the stable identifier of the step.
+func (isv *instanceSecretValidatorStep) GetStepID() string {
+	return "validate-instance-secrets"
+}
+
 // GetStepDescription returns a human-readable description of the instance secrets validation step.
 func (isv *instanceSecretValidatorStep) GetStepDescription() string {
 	return "Validate instance secrets"
 }
 
+// DescribeEffect returns the instance secrets which the step checks.
+func (isv *instanceSecretValidatorStep) DescribeEffect() plan.Effect {
+	return plan.Effect{
+		Action: plan.ActionValidate,
+		Kind:   "Secret",
+		Targets: []string{
+			fmt.Sprintf("%s/%s", isv.targetNamespace, appcontext.SecretDoguRegistry),
+			fmt.Sprintf("%s/%s", isv.targetNamespace, appcontext.SecretDockerRegistry),
+		},
+	}
+}
+
 // PerformSetupStep validates the current instance secrets.
 func (isv *instanceSecretValidatorStep) PerformSetupStep(ctx context.Context) error {
 	_, err := isv.clientSet.CoreV1().Secrets(isv.targetNamespace).Get(ctx, appcontext.SecretDoguRegistry, metav1.GetOptions{})
@@ -20,6 +20,110 @@ func (_m *MockRegistryWriter) EXPECT() *MockRegistryWriter_Expecter {
 	return &MockRegistryWriter_Expecter{mock: &_m.Mock}
 }
 
+// DeleteConfigFromRegistry provides a mock function with given fields: registryConfig
+func (_m *MockRegistryWriter) DeleteConfigFromRegistry(registryConfig context.CustomKeyValue) error {
+	ret := _m.Called(registryConfig)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteConfigFromRegistry")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.CustomKeyValue) error); ok {
+		r0 = rf(registryConfig)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRegistryWriter_DeleteConfigFromRegistry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteConfigFromRegistry'
+type MockRegistryWriter_DeleteConfigFromRegistry_Call struct {
+	*mock.Call
+}
+
+// DeleteConfigFromRegistry is a helper method to define mock.On call
+//   - registryConfig context.CustomKeyValue
+func (_e *MockRegistryWriter_Expecter) DeleteConfigFromRegistry(registryConfig interface{}) *MockRegistryWriter_DeleteConfigFromRegistry_Call {
+	return &MockRegistryWriter_DeleteConfigFromRegistry_Call{Call: _e.mock.On("DeleteConfigFromRegistry", registryConfig)}
+}
+
+func (_c *MockRegistryWriter_DeleteConfigFromRegistry_Call) Run(run func(registryConfig context.CustomKeyValue)) *MockRegistryWriter_DeleteConfigFromRegistry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.CustomKeyValue))
+	})
+	return _c
+}
+
+func (_c *MockRegistryWriter_DeleteConfigFromRegistry_Call) Return(_a0 error) *MockRegistryWriter_DeleteConfigFromRegistry_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRegistryWriter_DeleteConfigFromRegistry_Call) RunAndReturn(run func(context.CustomKeyValue) error) *MockRegistryWriter_DeleteConfigFromRegistry_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReadConfigFromRegistry provides a mock function with given fields: registryConfig
+func (_m *MockRegistryWriter) ReadConfigFromRegistry(registryConfig context.CustomKeyValue) (context.CustomKeyValue, error) {
+	ret := _m.Called(registryConfig)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReadConfigFromRegistry")
+	}
+
+	var r0 context.CustomKeyValue
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.CustomKeyValue) (context.CustomKeyValue, error)); ok {
+		return rf(registryConfig)
+	}
+	if rf, ok := ret.Get(0).(func(context.CustomKeyValue) context.CustomKeyValue); ok {
+		r0 = rf(registryConfig)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(context.CustomKeyValue)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.CustomKeyValue) error); ok {
+		r1 = rf(registryConfig)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRegistryWriter_ReadConfigFromRegistry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReadConfigFromRegistry'
+type MockRegistryWriter_ReadConfigFromRegistry_Call struct {
+	*mock.Call
+}
+
+// ReadConfigFromRegistry is a helper method to define mock.On call
+//   - registryConfig context.CustomKeyValue
+func (_e *MockRegistryWriter_Expecter) ReadConfigFromRegistry(registryConfig interface{}) *MockRegistryWriter_ReadConfigFromRegistry_Call {
+	return &MockRegistryWriter_ReadConfigFromRegistry_Call{Call: _e.mock.On("ReadConfigFromRegistry", registryConfig)}
+}
+
+func (_c *MockRegistryWriter_ReadConfigFromRegistry_Call) Run(run func(registryConfig context.CustomKeyValue)) *MockRegistryWriter_ReadConfigFromRegistry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.CustomKeyValue))
+	})
+	return _c
+}
+
+func (_c *MockRegistryWriter_ReadConfigFromRegistry_Call) Return(_a0 context.CustomKeyValue, _a1 error) *MockRegistryWriter_ReadConfigFromRegistry_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRegistryWriter_ReadConfigFromRegistry_Call) RunAndReturn(run func(context.CustomKeyValue) (context.CustomKeyValue, error)) *MockRegistryWriter_ReadConfigFromRegistry_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // WriteConfigToRegistry provides a mock function with given fields: registryConfig
 func (_m *MockRegistryWriter) WriteConfigToRegistry(registryConfig context.CustomKeyValue) error {
 	ret := _m.Called(registryConfig)
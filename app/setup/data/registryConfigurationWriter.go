@@ -2,14 +2,19 @@ package data
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	k8sdogu "github.com/cloudogu/ces-commons-lib/dogu"
 	k8serror "github.com/cloudogu/ces-commons-lib/errors"
 	k8sconf "github.com/cloudogu/k8s-registry-lib/config"
 	k8sreg "github.com/cloudogu/k8s-registry-lib/repository"
+	"maps"
 	"reflect"
+	"slices"
+	"strings"
 
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 
 	"github.com/sirupsen/logrus"
 
@@ -19,6 +24,11 @@ import (
 // RegistryWriter is responsible to write entries into the registry.
 type RegistryWriter interface {
 	WriteConfigToRegistry(registryConfig appcontext.CustomKeyValue) error
+	// DeleteConfigFromRegistry removes the keys of the given registry config from the registry.
+	DeleteConfigFromRegistry(registryConfig appcontext.CustomKeyValue) error
+	// ReadConfigFromRegistry returns the current values of the keys of the given registry config which already exist
+	// in the registry.
+	ReadConfigFromRegistry(registryConfig appcontext.CustomKeyValue) (appcontext.CustomKeyValue, error)
 }
 
 // RegistryConfigurationWriter writes a configuration into the registry.
@@ -103,6 +113,273 @@ func (gcw *RegistryConfigurationWriter) writeEntriesForConfig(entries map[string
 	return nil
 }
 
+// DeleteConfigFromRegistry removes the keys of the given registry config from the registry. Keys and configs which do
+// not exist are ignored.
+func (gcw *RegistryConfigurationWriter) DeleteConfigFromRegistry(registryConfig appcontext.CustomKeyValue) error {
+	ctx := context.TODO()
+	for _, configName := range registryConfigNames(registryConfig) {
+		keys := entryKeys(configName, registryConfig[configName])
+		for i, key := range keys {
+			keys[i] = strings.TrimPrefix(key, configName+"/")
+		}
+
+		logrus.Infof("delete written keys from %s configuration", configName)
+		var err error
+		if configName == "_global" {
+			err = gcw.deleteGlobalConfigKeys(ctx, keys)
+		} else {
+			err = gcw.deleteDoguConfigKeys(ctx, configName, keys)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadConfigFromRegistry returns the current values of the keys of the given registry config which already exist in
+// the registry. The keys of the result are the full paths of the entries inside their config, f. i.
+// "certificate/type". Keys and configs which do not exist are omitted.
+func (gcw *RegistryConfigurationWriter) ReadConfigFromRegistry(registryConfig appcontext.CustomKeyValue) (appcontext.CustomKeyValue, error) {
+	ctx := context.TODO()
+	existing := appcontext.CustomKeyValue{}
+	for _, configName := range registryConfigNames(registryConfig) {
+		config, err := gcw.getConfig(ctx, configName)
+		if k8serror.IsNotFoundError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range entryKeys(configName, registryConfig[configName]) {
+			key = strings.TrimPrefix(key, configName+"/")
+			value, ok := config.Get(k8sconf.Key(key))
+			if !ok {
+				continue
+			}
+
+			if existing[configName] == nil {
+				existing[configName] = map[string]interface{}{}
+			}
+			existing[configName][key] = value.String()
+		}
+	}
+
+	return existing, nil
+}
+
+func (gcw *RegistryConfigurationWriter) getConfig(ctx context.Context, configName string) (k8sconf.Config, error) {
+	if configName == "_global" {
+		c, err := gcw.globalConfig.Get(ctx)
+		if err != nil {
+			return k8sconf.Config{}, fmt.Errorf("failed to get global config: %w", err)
+		}
+		return c.Config, nil
+	}
+
+	c, err := gcw.doguConfig.Get(ctx, k8sdogu.SimpleName(configName))
+	if err != nil {
+		return k8sconf.Config{}, fmt.Errorf("failed to get dogu config for '%s': %w", configName, err)
+	}
+	return c.Config, nil
+}
+
+func (gcw *RegistryConfigurationWriter) deleteGlobalConfigKeys(ctx context.Context, keys []string) error {
+	c, err := gcw.globalConfig.Get(ctx)
+	if k8serror.IsNotFoundError(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get global config: %w", err)
+	}
+
+	for _, key := range keys {
+		c.Config = c.Delete(k8sconf.Key(key))
+	}
+
+	_, err = gcw.globalConfig.Update(ctx, c)
+	if err != nil {
+		return fmt.Errorf("failed to update global config: %w", err)
+	}
+
+	return nil
+}
+
+func (gcw *RegistryConfigurationWriter) deleteDoguConfigKeys(ctx context.Context, config string, keys []string) error {
+	c, err := gcw.doguConfig.Get(ctx, k8sdogu.SimpleName(config))
+	if k8serror.IsNotFoundError(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get dogu config for '%s': %w", config, err)
+	}
+
+	for _, key := range keys {
+		c.Config = c.Delete(k8sconf.Key(key))
+	}
+
+	_, err = gcw.doguConfig.Update(ctx, c)
+	if err != nil {
+		return fmt.Errorf("failed to update dogu config for '%s': %w", config, err)
+	}
+
+	return nil
+}
+
+// writtenRegistryConfig records the registry config written by a setup step so that the step can roll it back. Keys
+// which existed before, f. i. because a reconcile run overwrites the config of the installed ecosystem, are restored to
+// their previous values instead of being removed.
+type writtenRegistryConfig struct {
+	registryConfig appcontext.CustomKeyValue
+	previous       appcontext.CustomKeyValue
+}
+
+// writtenRegistryConfigState is the persisted form of a writtenRegistryConfig.
+type writtenRegistryConfigState struct {
+	RegistryConfig appcontext.CustomKeyValue `json:"registryConfig,omitempty"`
+	Previous       appcontext.CustomKeyValue `json:"previous,omitempty"`
+}
+
+// MarshalJSON encodes the recorded registry config so that it can be rolled back after a restart of the setup.
+func (wrc *writtenRegistryConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(writtenRegistryConfigState{RegistryConfig: wrc.registryConfig, Previous: wrc.previous})
+}
+
+// UnmarshalJSON decodes a registry config recorded by a previous setup run.
+func (wrc *writtenRegistryConfig) UnmarshalJSON(data []byte) error {
+	state := writtenRegistryConfigState{}
+	err := json.Unmarshal(data, &state)
+	if err != nil {
+		return err
+	}
+
+	wrc.registryConfig = state.RegistryConfig
+	wrc.previous = state.Previous
+	return nil
+}
+
+// record remembers the given registry config and the previous values of its keys. It is called before writing so
+// that partially written configs are rolled back as well. If an interrupted setup run already recorded the config,
+// its previous values are kept because the registry already contains the values written by that run.
+func (wrc *writtenRegistryConfig) record(reader RegistryWriter, registryConfig appcontext.CustomKeyValue) error {
+	if wrc.registryConfig != nil {
+		wrc.registryConfig = registryConfig
+		return nil
+	}
+
+	previous, err := reader.ReadConfigFromRegistry(registryConfig)
+	if err != nil {
+		return fmt.Errorf("failed to read previous data from registry: %w", err)
+	}
+
+	wrc.registryConfig = registryConfig
+	wrc.previous = previous
+	return nil
+}
+
+// rollback removes the recorded keys which did not exist before from the registry and restores the previous values
+// of the other keys.
+func (wrc *writtenRegistryConfig) rollback(writer RegistryWriter) error {
+	if wrc.registryConfig == nil {
+		return nil
+	}
+
+	newConfig := withoutKeys(wrc.registryConfig, wrc.previous)
+	if len(newConfig) > 0 {
+		err := writer.DeleteConfigFromRegistry(newConfig)
+		if err != nil {
+			return fmt.Errorf("failed to delete written data from registry: %w", err)
+		}
+	}
+
+	if len(wrc.previous) > 0 {
+		err := writer.WriteConfigToRegistry(wrc.previous)
+		if err != nil {
+			return fmt.Errorf("failed to restore previous data in registry: %w", err)
+		}
+	}
+
+	wrc.registryConfig = nil
+	wrc.previous = nil
+	return nil
+}
+
+// withoutKeys returns the entries of the registry config whose keys are not contained in the given keys. The keys of
+// the result are the full paths of the entries inside their config like the ones of ReadConfigFromRegistry.
+func withoutKeys(registryConfig appcontext.CustomKeyValue, keys appcontext.CustomKeyValue) appcontext.CustomKeyValue {
+	if len(keys) == 0 {
+		return registryConfig
+	}
+
+	result := appcontext.CustomKeyValue{}
+	for configName, entries := range registryConfig {
+		for _, key := range entryKeys(configName, entries) {
+			key = strings.TrimPrefix(key, configName+"/")
+			if _, ok := keys[configName][key]; ok {
+				continue
+			}
+
+			if result[configName] == nil {
+				result[configName] = map[string]interface{}{}
+			}
+			// the value is irrelevant for deleting the key
+			result[configName][key] = ""
+		}
+	}
+
+	return result
+}
+
+// registryConfigKind is the kind of the configs in the registry which are described in the setup plan.
+const registryConfigKind = "RegistryConfig"
+
+// describeRegistryWrite describes the effect of writing the given registry config into the registry.
+func describeRegistryWrite(registryConfig appcontext.CustomKeyValue) plan.Effect {
+	return plan.Effect{
+		Action:  plan.ActionWrite,
+		Kind:    registryConfigKind,
+		Targets: registryConfigNames(registryConfig),
+		Keys:    registryKeys(registryConfig),
+	}
+}
+
+// registryKeys returns the sorted keys of all entries of the given registry config prefixed with the name of their
+// config.
+func registryKeys(registryConfig appcontext.CustomKeyValue) []string {
+	var keys []string
+	for configName, entries := range registryConfig {
+		keys = append(keys, entryKeys(configName, entries)...)
+	}
+
+	slices.Sort(keys)
+	return keys
+}
+
+func entryKeys(prefix string, value any) []string {
+	var keys []string
+	switch castedValue := value.(type) {
+	case map[string]string:
+		for key, entry := range castedValue {
+			keys = append(keys, entryKeys(prefix+"/"+key, entry)...)
+		}
+	case map[string]interface{}:
+		for key, entry := range castedValue {
+			keys = append(keys, entryKeys(prefix+"/"+key, entry)...)
+		}
+	default:
+		keys = append(keys, prefix)
+	}
+
+	return keys
+}
+
+// registryConfigNames returns the sorted names of all configs of the given registry config.
+func registryConfigNames(registryConfig appcontext.CustomKeyValue) []string {
+	return slices.Sorted(maps.Keys(registryConfig))
+}
+
 // newConfigWriter returns a new object to write config with a given function.
 func (gcw *RegistryConfigurationWriter) newConfigWriter(writer configurationContextWriter) *configWriter {
 	return &configWriter{write: writer, delimiter: "/"}
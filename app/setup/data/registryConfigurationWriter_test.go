@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 	"strings"
 	"testing"
 
@@ -190,3 +191,121 @@ func TestGenericConfigurationWriter_WriteConfigToRegistry(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestGenericConfigurationWriter_DeleteConfigFromRegistry(t *testing.T) {
+	t.Run("should delete written keys and keep other keys", func(t *testing.T) {
+		// given
+		cm := data.NewMockConfigMapClient(t)
+		globalConfig := v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "global-config"},
+			Data:       map[string]string{"config.yaml": "certificate:\n    type: selfsigned\nfqdn: ces.example.com\nother: value\n"},
+		}
+		casConfig := v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "cas-config"},
+			Data:       map[string]string{"config.yaml": "ldap:\n    attribute_fullname: cn\n"},
+		}
+		cm.EXPECT().List(mock.Anything, metav1.SingleObject(metav1.ObjectMeta{Name: "global-config"})).Return(&v1.ConfigMapList{Items: []v1.ConfigMap{globalConfig}}, nil)
+		cm.EXPECT().List(mock.Anything, metav1.SingleObject(metav1.ObjectMeta{Name: "cas-config"})).Return(&v1.ConfigMapList{Items: []v1.ConfigMap{casConfig}}, nil)
+		cm.EXPECT().Update(mock.Anything, mock.MatchedBy(func(inputCm *v1.ConfigMap) bool {
+			return inputCm.Name == "global-config" && inputCm.Data["config.yaml"] == "other: value\n"
+		}), mock.Anything).Return(&globalConfig, nil)
+		cm.EXPECT().Update(mock.Anything, mock.MatchedBy(func(inputCm *v1.ConfigMap) bool {
+			return inputCm.Name == "cas-config" && inputCm.Data["config.yaml"] == "{}\n"
+		}), mock.Anything).Return(&casConfig, nil)
+
+		writer := data.NewRegistryConfigurationWriter(k8sreg.NewGlobalConfigRepository(cm), k8sreg.NewDoguConfigRepository(cm))
+
+		// when
+		err := writer.DeleteConfigFromRegistry(appcontext.CustomKeyValue{
+			"_global": map[string]interface{}{
+				"fqdn":        "ces.example.com",
+				"certificate": map[string]interface{}{"type": "selfsigned"},
+			},
+			"cas": map[string]interface{}{
+				"ldap": map[string]string{"attribute_fullname": "cn"},
+			},
+		})
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should ignore configs which do not exist", func(t *testing.T) {
+		// given
+		configMapClient := fake.NewClientset().CoreV1().ConfigMaps("ecosystem")
+		writer := data.NewRegistryConfigurationWriter(k8sreg.NewGlobalConfigRepository(configMapClient), k8sreg.NewDoguConfigRepository(configMapClient))
+
+		// when
+		err := writer.DeleteConfigFromRegistry(appcontext.CustomKeyValue{
+			"_global": map[string]interface{}{"fqdn": "ces.example.com"},
+			"cas":     map[string]interface{}{"key": "value"},
+		})
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail to get config", func(t *testing.T) {
+		// given
+		cm := data.NewMockConfigMapClient(t)
+		cm.EXPECT().List(mock.Anything, metav1.SingleObject(metav1.ObjectMeta{Name: "global-config"})).Return(nil, assert.AnError)
+		writer := data.NewRegistryConfigurationWriter(k8sreg.NewGlobalConfigRepository(cm), k8sreg.NewDoguConfigRepository(cm))
+
+		// when
+		err := writer.DeleteConfigFromRegistry(appcontext.CustomKeyValue{"_global": map[string]interface{}{"fqdn": "ces.example.com"}})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, assert.AnError.Error())
+		assert.ErrorContains(t, err, "failed to get global config")
+	})
+}
+
+func TestGenericConfigurationWriter_ReadConfigFromRegistry(t *testing.T) {
+	t.Run("should read existing keys and omit missing keys", func(t *testing.T) {
+		// given
+		cm := data.NewMockConfigMapClient(t)
+		globalConfig := v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "global-config"},
+			Data:       map[string]string{"config.yaml": "certificate:\n    type: selfsigned\nfqdn: ces.example.com\nother: value\n"},
+		}
+		cm.EXPECT().List(mock.Anything, metav1.SingleObject(metav1.ObjectMeta{Name: "global-config"})).Return(&v1.ConfigMapList{Items: []v1.ConfigMap{globalConfig}}, nil)
+		cm.EXPECT().List(mock.Anything, metav1.SingleObject(metav1.ObjectMeta{Name: "cas-config"})).Return(&v1.ConfigMapList{}, nil)
+
+		writer := data.NewRegistryConfigurationWriter(k8sreg.NewGlobalConfigRepository(cm), k8sreg.NewDoguConfigRepository(cm))
+
+		// when
+		actual, err := writer.ReadConfigFromRegistry(appcontext.CustomKeyValue{
+			"_global": map[string]interface{}{
+				"fqdn":        "ces.local",
+				"domain":      "ces.local",
+				"certificate": map[string]interface{}{"type": "external"},
+			},
+			"cas": map[string]interface{}{"ldap": map[string]string{"attribute_fullname": "cn"}},
+		})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, appcontext.CustomKeyValue{
+			"_global": map[string]interface{}{
+				"fqdn":             "ces.example.com",
+				"certificate/type": "selfsigned",
+			},
+		}, actual)
+	})
+
+	t.Run("should fail to get config", func(t *testing.T) {
+		// given
+		cm := data.NewMockConfigMapClient(t)
+		cm.EXPECT().List(mock.Anything, metav1.SingleObject(metav1.ObjectMeta{Name: "cas-config"})).Return(nil, assert.AnError)
+		writer := data.NewRegistryConfigurationWriter(k8sreg.NewGlobalConfigRepository(cm), k8sreg.NewDoguConfigRepository(cm))
+
+		// when
+		_, err := writer.ReadConfigFromRegistry(appcontext.CustomKeyValue{"cas": map[string]interface{}{"key": "value"}})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, assert.AnError.Error())
+		assert.ErrorContains(t, err, "failed to get dogu config for 'cas'")
+	})
+}
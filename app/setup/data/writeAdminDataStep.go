@@ -2,10 +2,12 @@ package data
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 
 	"github.com/cloudogu/k8s-ces-setup/v4/app/validation"
 )
@@ -13,6 +15,7 @@ import (
 type writeAdminDataStep struct {
 	Writer        RegistryWriter
 	Configuration *appcontext.SetupJsonConfiguration
+	written       writtenRegistryConfig
 }
 
 // NewWriteAdminDataStep create a new setup step which writes the admin data into the registry.
@@ -20,13 +23,53 @@ func NewWriteAdminDataStep(writer RegistryWriter, configuration *appcontext.Setu
 	return &writeAdminDataStep{Writer: writer, Configuration: configuration}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (wacs *writeAdminDataStep) GetStepID() string {
+	return "write-admin-data"
+}
+
 // GetStepDescription return the human-readable description of the step.
 func (wacs *writeAdminDataStep) GetStepDescription() string {
 	return "Write admin data to the registry"
 }
 
+// DescribeEffect returns the registry keys which the step writes.
+func (wacs *writeAdminDataStep) DescribeEffect() plan.Effect {
+	return describeRegistryWrite(wacs.createRegistryConfig())
+}
+
 // PerformSetupStep writes the configured admin data into the registry.
 func (wacs *writeAdminDataStep) PerformSetupStep(context.Context) error {
+	registryConfig := wacs.createRegistryConfig()
+	err := wacs.written.record(wacs.Writer, registryConfig)
+	if err != nil {
+		return fmt.Errorf("failed to write admin data to registry: %w", err)
+	}
+
+	err = wacs.Writer.WriteConfigToRegistry(registryConfig)
+	if err != nil {
+		return fmt.Errorf("failed to write admin data to registry: %w", err)
+	}
+
+	return nil
+}
+
+// RollbackSetupStep rolls back the admin data written by this step in the registry.
+func (wacs *writeAdminDataStep) RollbackSetupStep(context.Context) error {
+	return wacs.written.rollback(wacs.Writer)
+}
+
+// GetRollbackState returns the admin data written by this step and the previous values of its keys.
+func (wacs *writeAdminDataStep) GetRollbackState() (json.RawMessage, error) {
+	return json.Marshal(&wacs.written)
+}
+
+// SetRollbackState restores the admin data written by a previous setup run and the previous values of its keys.
+func (wacs *writeAdminDataStep) SetRollbackState(state json.RawMessage) error {
+	return json.Unmarshal(state, &wacs.written)
+}
+
+func (wacs *writeAdminDataStep) createRegistryConfig() appcontext.CustomKeyValue {
 	registryConfig := appcontext.CustomKeyValue{
 		"_global": map[string]interface{}{
 			"admin_group": wacs.Configuration.Admin.AdminGroup,
@@ -41,10 +84,5 @@ func (wacs *writeAdminDataStep) PerformSetupStep(context.Context) error {
 		}
 	}
 
-	err := wacs.Writer.WriteConfigToRegistry(registryConfig)
-	if err != nil {
-		return fmt.Errorf("failed to write admin data to registry: %w", err)
-	}
-
-	return nil
+	return registryConfig
 }
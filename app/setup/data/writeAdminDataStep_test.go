@@ -6,6 +6,7 @@ import (
 
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
 	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/data"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 	"github.com/cloudogu/k8s-ces-setup/v4/app/validation"
 
 	"github.com/stretchr/testify/assert"
@@ -54,6 +55,7 @@ func Test_writeAdminDataStep_PerformSetupStep(t *testing.T) {
 		// given
 		testConfig := &appcontext.SetupJsonConfiguration{}
 		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(mock.Anything).Return(appcontext.CustomKeyValue{}, nil)
 		mockRegistryWriter.EXPECT().WriteConfigToRegistry(mock.Anything).Return(assert.AnError)
 
 		myStep := data.NewWriteAdminDataStep(mockRegistryWriter, testConfig)
@@ -65,6 +67,21 @@ func Test_writeAdminDataStep_PerformSetupStep(t *testing.T) {
 		require.ErrorIs(t, err, assert.AnError)
 	})
 
+	t.Run("fail to read the previous data from the registry", func(t *testing.T) {
+		// given
+		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(mock.Anything).Return(nil, assert.AnError)
+
+		myStep := data.NewWriteAdminDataStep(mockRegistryWriter, &appcontext.SetupJsonConfiguration{})
+
+		// when
+		err := myStep.PerformSetupStep(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to read previous data from registry")
+	})
+
 	t.Run("successfully set values for external ldap data", func(t *testing.T) {
 		// given
 		testConfig := &appcontext.SetupJsonConfiguration{
@@ -79,6 +96,7 @@ func Test_writeAdminDataStep_PerformSetupStep(t *testing.T) {
 		}
 
 		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(registryConfig).Return(appcontext.CustomKeyValue{}, nil)
 		mockRegistryWriter.EXPECT().WriteConfigToRegistry(registryConfig).Return(nil)
 
 		myStep := data.NewWriteAdminDataStep(mockRegistryWriter, testConfig)
@@ -114,6 +132,7 @@ func Test_writeAdminDataStep_PerformSetupStep(t *testing.T) {
 		}
 
 		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(registryConfig).Return(appcontext.CustomKeyValue{}, nil)
 		mockRegistryWriter.EXPECT().WriteConfigToRegistry(registryConfig).Return(nil)
 
 		myStep := data.NewWriteAdminDataStep(mockRegistryWriter, testConfig)
@@ -125,3 +144,155 @@ func Test_writeAdminDataStep_PerformSetupStep(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func Test_writeAdminDataStep_RollbackSetupStep(t *testing.T) {
+	var testCtx = context.Background()
+
+	t.Run("should delete the written admin data", func(t *testing.T) {
+		// given
+		testConfig := &appcontext.SetupJsonConfiguration{Admin: appcontext.User{AdminGroup: "myTestAdminGroup"}}
+		registryConfig := appcontext.CustomKeyValue{
+			"_global": map[string]interface{}{
+				"admin_group": "myTestAdminGroup",
+			},
+		}
+
+		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(registryConfig).Return(appcontext.CustomKeyValue{}, nil)
+		mockRegistryWriter.EXPECT().WriteConfigToRegistry(registryConfig).Return(assert.AnError)
+		mockRegistryWriter.EXPECT().DeleteConfigFromRegistry(registryConfig).Return(nil).Once()
+
+		myStep := data.NewWriteAdminDataStep(mockRegistryWriter, testConfig)
+		require.Error(t, myStep.PerformSetupStep(testCtx))
+
+		// when
+		err := myStep.RollbackSetupStep(testCtx)
+		require.NoError(t, err)
+		err = myStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should restore existing keys and only delete new keys in a reconcile run", func(t *testing.T) {
+		// given
+		testConfig := &appcontext.SetupJsonConfiguration{
+			Admin:       appcontext.User{AdminGroup: "newAdmins", Mail: "admin@ces.local", Username: "admin"},
+			UserBackend: appcontext.UserBackend{DsType: validation.DsTypeEmbedded},
+		}
+		previous := appcontext.CustomKeyValue{
+			"_global": map[string]interface{}{"admin_group": "cesAdmins"},
+			"ldap":    map[string]interface{}{"admin_username": "admin"},
+		}
+
+		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(mock.Anything).Return(previous, nil)
+		mockRegistryWriter.EXPECT().WriteConfigToRegistry(mock.Anything).Return(nil).Once()
+		mockRegistryWriter.EXPECT().DeleteConfigFromRegistry(appcontext.CustomKeyValue{
+			"ldap": map[string]interface{}{"admin_mail": "", "admin_member": ""},
+		}).Return(nil).Once()
+		mockRegistryWriter.EXPECT().WriteConfigToRegistry(previous).Return(nil).Once()
+
+		myStep := data.NewWriteAdminDataStep(mockRegistryWriter, testConfig)
+		require.NoError(t, myStep.PerformSetupStep(testCtx))
+
+		// when
+		err := myStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail to restore the previous admin data", func(t *testing.T) {
+		// given
+		previous := appcontext.CustomKeyValue{"_global": map[string]interface{}{"admin_group": "cesAdmins"}}
+		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(mock.Anything).Return(previous, nil)
+		mockRegistryWriter.EXPECT().WriteConfigToRegistry(mock.Anything).Return(nil).Once()
+		mockRegistryWriter.EXPECT().WriteConfigToRegistry(previous).Return(assert.AnError).Once()
+
+		myStep := data.NewWriteAdminDataStep(mockRegistryWriter, &appcontext.SetupJsonConfiguration{Admin: appcontext.User{AdminGroup: "newAdmins"}})
+		require.NoError(t, myStep.PerformSetupStep(testCtx))
+
+		// when
+		err := myStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to restore previous data in registry")
+	})
+
+	t.Run("should roll back the admin data recorded by an interrupted setup run", func(t *testing.T) {
+		// given
+		testConfig := &appcontext.SetupJsonConfiguration{Admin: appcontext.User{AdminGroup: "newAdmins"}}
+		previous := appcontext.CustomKeyValue{"_global": map[string]interface{}{"admin_group": "cesAdmins"}}
+		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(mock.Anything).Return(previous, nil).Once()
+		mockRegistryWriter.EXPECT().WriteConfigToRegistry(mock.Anything).Return(nil).Times(2)
+		mockRegistryWriter.EXPECT().WriteConfigToRegistry(previous).Return(nil).Once()
+
+		interruptedStep := data.NewWriteAdminDataStep(mockRegistryWriter, testConfig)
+		require.NoError(t, interruptedStep.PerformSetupStep(testCtx))
+		state, err := interruptedStep.GetRollbackState()
+		require.NoError(t, err)
+
+		myStep := data.NewWriteAdminDataStep(mockRegistryWriter, testConfig)
+		require.NoError(t, myStep.SetRollbackState(state))
+		// the previous values of the interrupted run are kept
+		require.NoError(t, myStep.PerformSetupStep(testCtx))
+
+		// when
+		err = myStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should not delete anything if the step was not performed", func(t *testing.T) {
+		// given
+		myStep := data.NewWriteAdminDataStep(data.NewMockRegistryWriter(t), &appcontext.SetupJsonConfiguration{})
+
+		// when
+		err := myStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail to delete the written admin data", func(t *testing.T) {
+		// given
+		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(mock.Anything).Return(appcontext.CustomKeyValue{}, nil)
+		mockRegistryWriter.EXPECT().WriteConfigToRegistry(mock.Anything).Return(nil)
+		mockRegistryWriter.EXPECT().DeleteConfigFromRegistry(mock.Anything).Return(assert.AnError)
+
+		myStep := data.NewWriteAdminDataStep(mockRegistryWriter, &appcontext.SetupJsonConfiguration{})
+		require.NoError(t, myStep.PerformSetupStep(testCtx))
+
+		// when
+		err := myStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to delete written data from registry")
+	})
+}
+
+func Test_writeAdminDataStep_DescribeEffect(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should describe registry keys for embedded ldap", func(t *testing.T) {
+		// given
+		testConfig := &appcontext.SetupJsonConfiguration{UserBackend: appcontext.UserBackend{DsType: validation.DsTypeEmbedded}}
+		myStep := data.NewWriteAdminDataStep(data.NewMockRegistryWriter(t), testConfig)
+
+		// when
+		actual := myStep.DescribeEffect()
+
+		// then
+		assert.Equal(t, plan.ActionWrite, actual.Action)
+		assert.Equal(t, "RegistryConfig", actual.Kind)
+		assert.Equal(t, []string{"_global", "ldap"}, actual.Targets)
+		assert.Equal(t, []string{"_global/admin_group", "ldap/admin_mail", "ldap/admin_member", "ldap/admin_username"}, actual.Keys)
+	})
+}
@@ -2,14 +2,17 @@ package data
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 )
 
 type writeDoguDataStep struct {
 	Writer        RegistryWriter
 	Configuration *appcontext.SetupJsonConfiguration
+	written       writtenRegistryConfig
 }
 
 // NewWriteDoguDataStep create a new setup step which writes the dogu data into the registry.
@@ -17,23 +20,56 @@ func NewWriteDoguDataStep(writer RegistryWriter, configuration *appcontext.Setup
 	return &writeDoguDataStep{Writer: writer, Configuration: configuration}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (wdds *writeDoguDataStep) GetStepID() string {
+	return "write-dogu-data"
+}
+
 // GetStepDescription return the human-readable description of the step.
 func (wdds *writeDoguDataStep) GetStepDescription() string {
 	return "Write dogu data to the registry"
 }
 
+// DescribeEffect returns the registry keys which the step writes.
+func (wdds *writeDoguDataStep) DescribeEffect() plan.Effect {
+	return describeRegistryWrite(wdds.createRegistryConfig())
+}
+
 // PerformSetupStep writes the configured dogu data into the registry
 func (wdds *writeDoguDataStep) PerformSetupStep(context.Context) error {
-	registryConfig := appcontext.CustomKeyValue{
-		"_global": map[string]interface{}{
-			"default_dogu": wdds.Configuration.Dogus.DefaultDogu,
-		},
+	registryConfig := wdds.createRegistryConfig()
+	err := wdds.written.record(wdds.Writer, registryConfig)
+	if err != nil {
+		return fmt.Errorf("failed to write dogu data to registry: %w", err)
 	}
 
-	err := wdds.Writer.WriteConfigToRegistry(registryConfig)
+	err = wdds.Writer.WriteConfigToRegistry(registryConfig)
 	if err != nil {
 		return fmt.Errorf("failed to write dogu data to registry: %w", err)
 	}
 
 	return nil
 }
+
+// RollbackSetupStep rolls back the dogu data written by this step in the registry.
+func (wdds *writeDoguDataStep) RollbackSetupStep(context.Context) error {
+	return wdds.written.rollback(wdds.Writer)
+}
+
+// GetRollbackState returns the dogu data written by this step and the previous values of its keys.
+func (wdds *writeDoguDataStep) GetRollbackState() (json.RawMessage, error) {
+	return json.Marshal(&wdds.written)
+}
+
+// SetRollbackState restores the dogu data written by a previous setup run and the previous values of its keys.
+func (wdds *writeDoguDataStep) SetRollbackState(state json.RawMessage) error {
+	return json.Unmarshal(state, &wdds.written)
+}
+
+func (wdds *writeDoguDataStep) createRegistryConfig() appcontext.CustomKeyValue {
+	return appcontext.CustomKeyValue{
+		"_global": map[string]interface{}{
+			"default_dogu": wdds.Configuration.Dogus.DefaultDogu,
+		},
+	}
+}
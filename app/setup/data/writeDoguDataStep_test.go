@@ -53,6 +53,7 @@ func Test_writeDoguDataStep_PerformSetupStep(t *testing.T) {
 		// given
 		testConfig := &appcontext.SetupJsonConfiguration{}
 		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(mock.Anything).Return(appcontext.CustomKeyValue{}, nil)
 		mockRegistryWriter.EXPECT().WriteConfigToRegistry(mock.Anything).Return(assert.AnError)
 
 		myStep := data.NewWriteDoguDataStep(mockRegistryWriter, testConfig)
@@ -77,6 +78,7 @@ func Test_writeDoguDataStep_PerformSetupStep(t *testing.T) {
 		}
 
 		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(registryConfig).Return(appcontext.CustomKeyValue{}, nil)
 		mockRegistryWriter.EXPECT().WriteConfigToRegistry(registryConfig).Return(nil)
 
 		myStep := data.NewWriteDoguDataStep(mockRegistryWriter, testConfig)
@@ -2,17 +2,35 @@ package data
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-const certificateSecretName = "ecosystem-certificate"
+const (
+	certificateSecretName = "ecosystem-certificate"
+	// certificateBackupSecretName is the name of the secret which keeps the previous certificate data until the
+	// setup is rolled back or performed again. The data is not part of the rollback state because it contains the
+	// private key.
+	certificateBackupSecretName = "ecosystem-certificate-backup"
+)
 
 type writeEcosystemCertificateDataStep struct {
 	secretClient  secretClient
 	configuration *appcontext.SetupJsonConfiguration
+	created       bool
+	// backedUp is true if this step saved an existing certificate secret to the backup secret before updating it.
+	backedUp bool
+}
+
+// writeEcosystemCertificateRollbackState contains the data needed to roll back the step.
+type writeEcosystemCertificateRollbackState struct {
+	Created  bool `json:"created"`
+	BackedUp bool `json:"backedUp"`
 }
 
 func NewWriteEcosystemCertificateDataStep(secretClient secretClient, configuration *appcontext.SetupJsonConfiguration) *writeEcosystemCertificateDataStep {
@@ -22,11 +40,26 @@ func NewWriteEcosystemCertificateDataStep(secretClient secretClient, configurati
 	}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (wecs *writeEcosystemCertificateDataStep) GetStepID() string {
+	return "write-ecosystem-certificate"
+}
+
 // GetStepDescription return the human-readable description of the step.
 func (wecs *writeEcosystemCertificateDataStep) GetStepDescription() string {
 	return "Write ecosystem certificate data to a secret"
 }
 
+// DescribeEffect returns the certificate secret and the keys which the step writes.
+func (wecs *writeEcosystemCertificateDataStep) DescribeEffect() plan.Effect {
+	return plan.Effect{
+		Action:  plan.ActionWrite,
+		Kind:    "Secret",
+		Targets: []string{certificateSecretName},
+		Keys:    []string{v1.TLSCertKey, v1.TLSPrivateKeyKey},
+	}
+}
+
 // PerformSetupStep writes the configured certificate data into a secret.
 func (wecs *writeEcosystemCertificateDataStep) PerformSetupStep(ctx context.Context) error {
 	certificateSecret := &v1.Secret{
@@ -40,9 +73,116 @@ func (wecs *writeEcosystemCertificateDataStep) PerformSetupStep(ctx context.Cont
 	}
 
 	_, err := wecs.secretClient.Create(ctx, certificateSecret, metav1.CreateOptions{})
+	if err == nil {
+		wecs.created = true
+	}
+	if errors.IsAlreadyExists(err) {
+		// the secret was already written by an interrupted setup run or belongs to an installed ecosystem
+		err = wecs.updateCertificateSecret(ctx, certificateSecret)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to write certificate to secret: %w", err)
 	}
 
 	return nil
 }
+
+func (wecs *writeEcosystemCertificateDataStep) updateCertificateSecret(ctx context.Context, certificateSecret *v1.Secret) error {
+	if !wecs.created && !wecs.backedUp {
+		// keep the backup of an interrupted setup run
+		err := wecs.backupCertificateSecret(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := wecs.secretClient.Update(ctx, certificateSecret, metav1.UpdateOptions{})
+	return err
+}
+
+func (wecs *writeEcosystemCertificateDataStep) backupCertificateSecret(ctx context.Context) error {
+	actualSecret, err := wecs.secretClient.Get(ctx, certificateSecretName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get existing certificate secret: %w", err)
+	}
+
+	backupSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: certificateBackupSecretName},
+		Type:       actualSecret.Type,
+		Data:       actualSecret.Data,
+	}
+	_, err = wecs.secretClient.Create(ctx, backupSecret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// the backup is left over from a previous setup run which was not rolled back
+		_, err = wecs.secretClient.Update(ctx, backupSecret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to back up existing certificate secret: %w", err)
+	}
+
+	wecs.backedUp = true
+	return nil
+}
+
+// RollbackSetupStep deletes the certificate secret if it was created by this step. The data of a certificate secret
+// updated by this step is restored from the backup secret.
+func (wecs *writeEcosystemCertificateDataStep) RollbackSetupStep(ctx context.Context) error {
+	if !wecs.created {
+		return wecs.restoreCertificateSecret(ctx)
+	}
+
+	err := wecs.secretClient.Delete(ctx, certificateSecretName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete certificate secret: %w", err)
+	}
+
+	wecs.created = false
+	return nil
+}
+
+func (wecs *writeEcosystemCertificateDataStep) restoreCertificateSecret(ctx context.Context) error {
+	if !wecs.backedUp {
+		return nil
+	}
+
+	backupSecret, err := wecs.secretClient.Get(ctx, certificateBackupSecretName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get certificate backup secret: %w", err)
+	}
+
+	certificateSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: certificateSecretName},
+		Type:       backupSecret.Type,
+		Data:       backupSecret.Data,
+	}
+	_, err = wecs.secretClient.Update(ctx, certificateSecret, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to restore certificate secret: %w", err)
+	}
+
+	err = wecs.secretClient.Delete(ctx, certificateBackupSecretName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete certificate backup secret: %w", err)
+	}
+
+	wecs.backedUp = false
+	return nil
+}
+
+// GetRollbackState returns whether the certificate secret was created or backed up by this step.
+func (wecs *writeEcosystemCertificateDataStep) GetRollbackState() (json.RawMessage, error) {
+	return json.Marshal(writeEcosystemCertificateRollbackState{Created: wecs.created, BackedUp: wecs.backedUp})
+}
+
+// SetRollbackState restores whether the certificate secret was created or backed up by a previous setup run.
+func (wecs *writeEcosystemCertificateDataStep) SetRollbackState(state json.RawMessage) error {
+	var rollbackState writeEcosystemCertificateRollbackState
+	err := json.Unmarshal(state, &rollbackState)
+	if err != nil {
+		return err
+	}
+
+	wecs.created = rollbackState.Created
+	wecs.backedUp = rollbackState.BackedUp
+	return nil
+}
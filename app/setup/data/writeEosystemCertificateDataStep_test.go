@@ -4,9 +4,13 @@ import (
 	"context"
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
 	"testing"
 )
 
@@ -94,4 +98,160 @@ func Test_writeEcosystemCertificateDataStep_PerformSetupStep(t *testing.T) {
 		// then
 		require.NoError(t, err)
 	})
+	t.Run("should back up and update an existing secret", func(t *testing.T) {
+		certificateSecret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: certificateSecretName,
+			},
+			Data: map[string][]byte{
+				v1.TLSCertKey:       []byte("cert"),
+				v1.TLSPrivateKeyKey: []byte("key"),
+			},
+		}
+		// given
+		testConfig := &appcontext.SetupJsonConfiguration{Naming: appcontext.Naming{Certificate: "cert", CertificateKey: "key"}}
+		secretClient := newMockSecretClient(t)
+		alreadyExistsErr := errors.NewAlreadyExists(schema.GroupResource{Resource: "secrets"}, certificateSecretName)
+		secretClient.EXPECT().Create(testCtx, certificateSecret, metav1.CreateOptions{}).Return(nil, alreadyExistsErr)
+		previousSecret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: certificateSecretName},
+			Type:       v1.SecretTypeOpaque,
+			Data:       map[string][]byte{v1.TLSCertKey: []byte("previous cert"), v1.TLSPrivateKeyKey: []byte("previous key")},
+		}
+		secretClient.EXPECT().Get(testCtx, certificateSecretName, metav1.GetOptions{}).Return(previousSecret, nil)
+		backupSecret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: certificateBackupSecretName},
+			Type:       v1.SecretTypeOpaque,
+			Data:       previousSecret.Data,
+		}
+		secretClient.EXPECT().Create(testCtx, backupSecret, metav1.CreateOptions{}).Return(backupSecret, nil)
+		secretClient.EXPECT().Update(testCtx, certificateSecret, metav1.UpdateOptions{}).Return(certificateSecret, nil)
+
+		myStep := NewWriteEcosystemCertificateDataStep(secretClient, testConfig)
+
+		// when
+		err := myStep.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, myStep.backedUp)
+		assert.False(t, myStep.created)
+	})
+	t.Run("should keep the backup of an interrupted setup run", func(t *testing.T) {
+		// given
+		secretClient := newMockSecretClient(t)
+		alreadyExistsErr := errors.NewAlreadyExists(schema.GroupResource{Resource: "secrets"}, certificateSecretName)
+		secretClient.EXPECT().Create(testCtx, mock.Anything, metav1.CreateOptions{}).Return(nil, alreadyExistsErr)
+		secretClient.EXPECT().Update(testCtx, mock.Anything, metav1.UpdateOptions{}).Return(nil, nil)
+
+		myStep := NewWriteEcosystemCertificateDataStep(secretClient, &appcontext.SetupJsonConfiguration{})
+		require.NoError(t, myStep.SetRollbackState([]byte(`{"created":false,"backedUp":true}`)))
+
+		// when
+		err := myStep.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, myStep.backedUp)
+	})
+	t.Run("fail to back up an existing secret", func(t *testing.T) {
+		// given
+		secretClient := newMockSecretClient(t)
+		alreadyExistsErr := errors.NewAlreadyExists(schema.GroupResource{Resource: "secrets"}, certificateSecretName)
+		secretClient.EXPECT().Create(testCtx, mock.Anything, metav1.CreateOptions{}).Return(nil, alreadyExistsErr)
+		secretClient.EXPECT().Get(testCtx, certificateSecretName, metav1.GetOptions{}).Return(nil, assert.AnError)
+
+		myStep := NewWriteEcosystemCertificateDataStep(secretClient, &appcontext.SetupJsonConfiguration{})
+
+		// when
+		err := myStep.PerformSetupStep(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to get existing certificate secret")
+		assert.False(t, myStep.backedUp)
+	})
+}
+
+func Test_writeEcosystemCertificateDataStep_RollbackSetupStep(t *testing.T) {
+	var testCtx = context.Background()
+
+	t.Run("should delete the secret created by the step", func(t *testing.T) {
+		// given
+		secretClient := newMockSecretClient(t)
+		secretClient.EXPECT().Create(testCtx, mock.Anything, metav1.CreateOptions{}).Return(nil, nil)
+		secretClient.EXPECT().Delete(testCtx, certificateSecretName, metav1.DeleteOptions{}).Return(nil)
+
+		myStep := NewWriteEcosystemCertificateDataStep(secretClient, &appcontext.SetupJsonConfiguration{})
+		require.NoError(t, myStep.PerformSetupStep(testCtx))
+
+		// when
+		err := myStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, myStep.created)
+	})
+
+	t.Run("should restore a secret which existed before", func(t *testing.T) {
+		// given
+		previousData := map[string][]byte{v1.TLSCertKey: []byte("previous cert"), v1.TLSPrivateKeyKey: []byte("previous key")}
+		fakeClient := fake.NewClientset(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: certificateSecretName, Namespace: ecosystemNamespace},
+			Data:       previousData,
+		})
+		secretClient := fakeClient.CoreV1().Secrets(ecosystemNamespace)
+		testConfig := &appcontext.SetupJsonConfiguration{Naming: appcontext.Naming{Certificate: "cert", CertificateKey: "key"}}
+		performedStep := NewWriteEcosystemCertificateDataStep(secretClient, testConfig)
+		require.NoError(t, performedStep.PerformSetupStep(testCtx))
+		state, err := performedStep.GetRollbackState()
+		require.NoError(t, err)
+		myStep := NewWriteEcosystemCertificateDataStep(secretClient, testConfig)
+		require.NoError(t, myStep.SetRollbackState(state))
+
+		// when
+		err = myStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		actual, err := secretClient.Get(testCtx, certificateSecretName, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, previousData, actual.Data)
+		_, err = secretClient.Get(testCtx, certificateBackupSecretName, metav1.GetOptions{})
+		assert.True(t, errors.IsNotFound(err))
+		assert.False(t, myStep.backedUp)
+	})
+
+	t.Run("should fail to restore a secret without backup", func(t *testing.T) {
+		// given
+		secretClient := fake.NewClientset().CoreV1().Secrets(ecosystemNamespace)
+		myStep := NewWriteEcosystemCertificateDataStep(secretClient, &appcontext.SetupJsonConfiguration{})
+		myStep.backedUp = true
+
+		// when
+		err := myStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.True(t, errors.IsNotFound(err))
+		assert.ErrorContains(t, err, "failed to get certificate backup secret")
+		assert.True(t, myStep.backedUp)
+	})
+
+	t.Run("should fail to delete the secret", func(t *testing.T) {
+		// given
+		secretClient := newMockSecretClient(t)
+		secretClient.EXPECT().Delete(testCtx, certificateSecretName, metav1.DeleteOptions{}).Return(assert.AnError)
+
+		myStep := NewWriteEcosystemCertificateDataStep(secretClient, &appcontext.SetupJsonConfiguration{})
+		myStep.created = true
+
+		// when
+		err := myStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to delete certificate secret")
+		assert.True(t, myStep.created)
+	})
 }
@@ -2,17 +2,20 @@ package data
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/cloudogu/k8s-ces-setup/v4/app/validation"
 
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 )
 
 type writeLdapDataStep struct {
 	Writer        RegistryWriter
 	Configuration *appcontext.SetupJsonConfiguration
+	written       writtenRegistryConfig
 }
 
 // NewWriteLdapDataStep create a new setup step which writes the ldap configuration into the registry.
@@ -20,21 +23,30 @@ func NewWriteLdapDataStep(writer RegistryWriter, configuration *appcontext.Setup
 	return &writeLdapDataStep{Writer: writer, Configuration: configuration}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (wlds *writeLdapDataStep) GetStepID() string {
+	return "write-ldap-data"
+}
+
 // GetStepDescription return the human-readable description of the step.
 func (wlds *writeLdapDataStep) GetStepDescription() string {
 	return "Write ldap data to the registry"
 }
 
+// DescribeEffect returns the registry keys which the step writes.
+func (wlds *writeLdapDataStep) DescribeEffect() plan.Effect {
+	return describeRegistryWrite(wlds.createRegistryConfig())
+}
+
 // PerformSetupStep writes the configured ldap data into the registry
 func (wlds *writeLdapDataStep) PerformSetupStep(context.Context) error {
-	registryConfig := appcontext.CustomKeyValue{}
-	registryConfig["cas"] = wlds.getCasEntriesAsMap()
-
-	if isDoguInstalled(wlds.Configuration.Dogus.Install, "ldap-mapper") {
-		registryConfig["ldap-mapper"] = wlds.getLdapMapperEntriesAsMap()
+	registryConfig := wlds.createRegistryConfig()
+	err := wlds.written.record(wlds.Writer, registryConfig)
+	if err != nil {
+		return fmt.Errorf("failed to write ldap data to registry: %w", err)
 	}
 
-	err := wlds.Writer.WriteConfigToRegistry(registryConfig)
+	err = wlds.Writer.WriteConfigToRegistry(registryConfig)
 	if err != nil {
 		return fmt.Errorf("failed to write ldap data to registry: %w", err)
 	}
@@ -42,6 +54,32 @@ func (wlds *writeLdapDataStep) PerformSetupStep(context.Context) error {
 	return nil
 }
 
+// RollbackSetupStep rolls back the ldap data written by this step in the registry.
+func (wlds *writeLdapDataStep) RollbackSetupStep(context.Context) error {
+	return wlds.written.rollback(wlds.Writer)
+}
+
+// GetRollbackState returns the ldap data written by this step and the previous values of its keys.
+func (wlds *writeLdapDataStep) GetRollbackState() (json.RawMessage, error) {
+	return json.Marshal(&wlds.written)
+}
+
+// SetRollbackState restores the ldap data written by a previous setup run and the previous values of its keys.
+func (wlds *writeLdapDataStep) SetRollbackState(state json.RawMessage) error {
+	return json.Unmarshal(state, &wlds.written)
+}
+
+func (wlds *writeLdapDataStep) createRegistryConfig() appcontext.CustomKeyValue {
+	registryConfig := appcontext.CustomKeyValue{}
+	registryConfig["cas"] = wlds.getCasEntriesAsMap()
+
+	if isDoguInstalled(wlds.Configuration.Dogus.Install, "ldap-mapper") {
+		registryConfig["ldap-mapper"] = wlds.getLdapMapperEntriesAsMap()
+	}
+
+	return registryConfig
+}
+
 func (wlds *writeLdapDataStep) getCasEntriesAsMap() map[string]interface{} {
 	ldapInCasOptions := map[string]string{}
 	putIfNotEmpty(ldapInCasOptions,
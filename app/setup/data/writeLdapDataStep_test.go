@@ -56,6 +56,7 @@ func Test_writeLdapDataStep_PerformSetupStep(t *testing.T) {
 		testConfig := &context.SetupJsonConfiguration{}
 
 		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(mock.Anything).Return(context.CustomKeyValue{}, nil)
 		mockRegistryWriter.EXPECT().WriteConfigToRegistry(mock.Anything).Return(assert.AnError)
 
 		myStep := data.NewWriteLdapDataStep(mockRegistryWriter, testConfig)
@@ -119,6 +120,7 @@ func Test_writeLdapDataStep_PerformSetupStep(t *testing.T) {
 		}
 
 		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(registryConfig).Return(context.CustomKeyValue{}, nil)
 		mockRegistryWriter.EXPECT().WriteConfigToRegistry(registryConfig).Return(nil)
 
 		myStep := data.NewWriteLdapDataStep(mockRegistryWriter, testConfig)
@@ -157,6 +159,7 @@ func Test_writeLdapDataStep_PerformSetupStep(t *testing.T) {
 		}
 
 		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(registryConfig).Return(context.CustomKeyValue{}, nil)
 		mockRegistryWriter.EXPECT().WriteConfigToRegistry(registryConfig).Return(nil)
 
 		myStep := data.NewWriteLdapDataStep(mockRegistryWriter, testConfig)
@@ -196,6 +199,7 @@ func Test_writeLdapDataStep_PerformSetupStep(t *testing.T) {
 		}
 
 		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(registryConfig).Return(context.CustomKeyValue{}, nil)
 		mockRegistryWriter.EXPECT().WriteConfigToRegistry(registryConfig).Return(nil)
 
 		myStep := data.NewWriteLdapDataStep(mockRegistryWriter, testConfig)
@@ -267,6 +271,7 @@ func Test_writeLdapDataStep_PerformSetupStep(t *testing.T) {
 		}
 
 		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(registryConfig).Return(context.CustomKeyValue{}, nil)
 		mockRegistryWriter.EXPECT().WriteConfigToRegistry(registryConfig).Return(nil)
 
 		myStep := data.NewWriteLdapDataStep(mockRegistryWriter, testConfig)
@@ -315,6 +320,7 @@ func Test_writeLdapDataStep_PerformSetupStep(t *testing.T) {
 		}
 
 		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(registryConfig).Return(context.CustomKeyValue{}, nil)
 		mockRegistryWriter.EXPECT().WriteConfigToRegistry(registryConfig).Return(nil)
 
 		myStep := data.NewWriteLdapDataStep(mockRegistryWriter, testConfig)
@@ -2,11 +2,13 @@ package data
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"k8s.io/client-go/kubernetes"
 	"strconv"
 
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 )
 
 const tlsSecretName = "ecosystem-certificate"
@@ -16,6 +18,7 @@ type writeNamingDataStep struct {
 	configuration *appcontext.SetupJsonConfiguration
 	clientSet     kubernetes.Interface
 	namespace     string
+	written       writtenRegistryConfig
 }
 
 // NewWriteNamingDataStep create a new setup step which writes the naming data into the registry.
@@ -23,14 +26,54 @@ func NewWriteNamingDataStep(writer RegistryWriter, configuration *appcontext.Set
 	return &writeNamingDataStep{writer: writer, configuration: configuration, clientSet: clientSet, namespace: namespace}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (wnds *writeNamingDataStep) GetStepID() string {
+	return "write-naming-data"
+}
+
 // GetStepDescription return the human-readable description of the step.
 func (wnds *writeNamingDataStep) GetStepDescription() string {
 	return "Write naming data to the registry"
 }
 
+// DescribeEffect returns the registry keys which the step writes.
+func (wnds *writeNamingDataStep) DescribeEffect() plan.Effect {
+	return describeRegistryWrite(wnds.createRegistryConfig())
+}
+
 // PerformSetupStep writes the configured naming data into the registry
 func (wnds *writeNamingDataStep) PerformSetupStep(ctx context.Context) error {
-	registryConfig := appcontext.CustomKeyValue{
+	registryConfig := wnds.createRegistryConfig()
+	err := wnds.written.record(wnds.writer, registryConfig)
+	if err != nil {
+		return fmt.Errorf("failed to write naming data to registry: %w", err)
+	}
+
+	err = wnds.writer.WriteConfigToRegistry(registryConfig)
+	if err != nil {
+		return fmt.Errorf("failed to write naming data to registry: %w", err)
+	}
+
+	return nil
+}
+
+// RollbackSetupStep rolls back the naming data written by this step in the registry.
+func (wnds *writeNamingDataStep) RollbackSetupStep(context.Context) error {
+	return wnds.written.rollback(wnds.writer)
+}
+
+// GetRollbackState returns the naming data written by this step and the previous values of its keys.
+func (wnds *writeNamingDataStep) GetRollbackState() (json.RawMessage, error) {
+	return json.Marshal(&wnds.written)
+}
+
+// SetRollbackState restores the naming data written by a previous setup run and the previous values of its keys.
+func (wnds *writeNamingDataStep) SetRollbackState(state json.RawMessage) error {
+	return json.Unmarshal(state, &wnds.written)
+}
+
+func (wnds *writeNamingDataStep) createRegistryConfig() appcontext.CustomKeyValue {
+	return appcontext.CustomKeyValue{
 		"_global": map[string]interface{}{
 			"fqdn":                wnds.configuration.Naming.Fqdn,
 			"domain":              wnds.configuration.Naming.Domain,
@@ -43,11 +86,4 @@ func (wnds *writeNamingDataStep) PerformSetupStep(ctx context.Context) error {
 			"relayhost": wnds.configuration.Naming.RelayHost,
 		},
 	}
-
-	err := wnds.writer.WriteConfigToRegistry(registryConfig)
-	if err != nil {
-		return fmt.Errorf("failed to write naming data to registry: %w", err)
-	}
-
-	return nil
 }
@@ -58,6 +58,7 @@ func Test_writeNamingDataStep_PerformSetupStep(t *testing.T) {
 		// given
 		testConfig := &appcontext.SetupJsonConfiguration{}
 		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(mock.Anything).Return(appcontext.CustomKeyValue{}, nil)
 		mockRegistryWriter.EXPECT().WriteConfigToRegistry(mock.Anything).Return(assert.AnError)
 		fakeClient := fake.NewSimpleClientset()
 
@@ -97,6 +98,7 @@ func Test_writeNamingDataStep_PerformSetupStep(t *testing.T) {
 		}
 
 		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(registryConfig).Return(appcontext.CustomKeyValue{}, nil)
 		mockRegistryWriter.EXPECT().WriteConfigToRegistry(registryConfig).Return(nil)
 
 		fakeClient := fake.NewSimpleClientset()
@@ -2,14 +2,17 @@ package data
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 )
 
 type writeRegistryConfigDataStep struct {
 	Writer        RegistryWriter
 	Configuration *appcontext.SetupJsonConfiguration
+	written       writtenRegistryConfig
 }
 
 // NewWriteRegistryConfigDataStep create a new setup step which writes the registry config configuration into the registry.
@@ -17,17 +20,48 @@ func NewWriteRegistryConfigDataStep(writer RegistryWriter, configuration *appcon
 	return &writeRegistryConfigDataStep{Writer: writer, Configuration: configuration}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (wrcds *writeRegistryConfigDataStep) GetStepID() string {
+	return "write-registry-config-data"
+}
+
 // GetStepDescription return the human-readable description of the step.
 func (wrcds *writeRegistryConfigDataStep) GetStepDescription() string {
 	return "Write registry config data to the registry"
 }
 
+// DescribeEffect returns the registry keys which the step writes.
+func (wrcds *writeRegistryConfigDataStep) DescribeEffect() plan.Effect {
+	return describeRegistryWrite(wrcds.Configuration.RegistryConfig)
+}
+
 // PerformSetupStep writes the registry config data into the registry
 func (wrcds *writeRegistryConfigDataStep) PerformSetupStep(context.Context) error {
-	err := wrcds.Writer.WriteConfigToRegistry(wrcds.Configuration.RegistryConfig)
+	registryConfig := wrcds.Configuration.RegistryConfig
+	err := wrcds.written.record(wrcds.Writer, registryConfig)
+	if err != nil {
+		return fmt.Errorf("failed to write registry config data to registry: %w", err)
+	}
+
+	err = wrcds.Writer.WriteConfigToRegistry(registryConfig)
 	if err != nil {
 		return fmt.Errorf("failed to write registry config data to registry: %w", err)
 	}
 
 	return nil
 }
+
+// RollbackSetupStep rolls back the registry config data written by this step in the registry.
+func (wrcds *writeRegistryConfigDataStep) RollbackSetupStep(context.Context) error {
+	return wrcds.written.rollback(wrcds.Writer)
+}
+
+// GetRollbackState returns the registry config data written by this step and the previous values of its keys.
+func (wrcds *writeRegistryConfigDataStep) GetRollbackState() (json.RawMessage, error) {
+	return json.Marshal(&wrcds.written)
+}
+
+// SetRollbackState restores the registry config data written by a previous setup run and the previous values of its keys.
+func (wrcds *writeRegistryConfigDataStep) SetRollbackState(state json.RawMessage) error {
+	return json.Unmarshal(state, &wrcds.written)
+}
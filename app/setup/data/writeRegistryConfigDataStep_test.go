@@ -53,6 +53,7 @@ func Test_writeRegistryConfigDataStep_PerformSetupStep(t *testing.T) {
 		// given
 		testConfig := &appcontext.SetupJsonConfiguration{}
 		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(mock.Anything).Return(appcontext.CustomKeyValue{}, nil)
 		mockRegistryWriter.EXPECT().WriteConfigToRegistry(mock.Anything).Return(assert.AnError)
 
 		myStep := data.NewWriteRegistryConfigDataStep(mockRegistryWriter, testConfig)
@@ -82,6 +83,7 @@ func Test_writeRegistryConfigDataStep_PerformSetupStep(t *testing.T) {
 		testConfig := &appcontext.SetupJsonConfiguration{RegistryConfig: registryConfig}
 
 		mockRegistryWriter := data.NewMockRegistryWriter(t)
+		mockRegistryWriter.EXPECT().ReadConfigFromRegistry(registryConfig).Return(appcontext.CustomKeyValue{}, nil)
 		mockRegistryWriter.EXPECT().WriteConfigToRegistry(registryConfig).Return(nil)
 
 		myStep := data.NewWriteRegistryConfigDataStep(mockRegistryWriter, testConfig)
@@ -2,19 +2,28 @@ package data
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	k8sdogu "github.com/cloudogu/ces-commons-lib/dogu"
+	k8serror "github.com/cloudogu/ces-commons-lib/errors"
 	k8sconf "github.com/cloudogu/k8s-registry-lib/config"
 	k8sreg "github.com/cloudogu/k8s-registry-lib/repository"
+	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
+	"slices"
 
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 	"github.com/cloudogu/k8s-ces-setup/v4/app/validation"
 )
 
+// sensitiveDoguConfigKind is the kind of the sensitive dogu configs which are described in the setup plan.
+const sensitiveDoguConfigKind = "SensitiveDoguConfig"
+
 type writeRegistryConfigEncryptedStep struct {
 	configuration           *appcontext.SetupJsonConfiguration
 	sensitiveDoguConfigRepo *k8sreg.DoguConfigRepository
+	createdDoguConfigs      []k8sdogu.SimpleName
 }
 
 // NewWriteRegistryConfigEncryptedStep create a new setup step which writes the registry config encrypted configuration into the cluster.
@@ -25,13 +34,89 @@ func NewWriteRegistryConfigEncryptedStep(configuration *appcontext.SetupJsonConf
 	}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (wrces *writeRegistryConfigEncryptedStep) GetStepID() string {
+	return "write-registry-config-encrypted"
+}
+
 // GetStepDescription return the human-readable description of the step.
 func (wrces *writeRegistryConfigEncryptedStep) GetStepDescription() string {
 	return "Write registry config encrypted data to the registry"
 }
 
+// DescribeEffect returns the sensitive dogu configs and their keys which the step writes.
+func (wrces *writeRegistryConfigEncryptedStep) DescribeEffect() plan.Effect {
+	effect := plan.Effect{Action: plan.ActionWrite, Kind: sensitiveDoguConfigKind}
+
+	doguConfigs, err := wrces.createDoguConfigs()
+	if err != nil {
+		logrus.Warningf("failed to describe sensitive dogu configs: %s", err.Error())
+		return effect
+	}
+
+	for _, doguConfig := range doguConfigs {
+		effect.Targets = append(effect.Targets, doguConfig.DoguName.String())
+		for key := range doguConfig.GetAll() {
+			effect.Keys = append(effect.Keys, fmt.Sprintf("%s/%s", doguConfig.DoguName, key))
+		}
+	}
+	slices.Sort(effect.Targets)
+	slices.Sort(effect.Keys)
+
+	return effect
+}
+
 // PerformSetupStep writes the registry config data into the registry
 func (wrces *writeRegistryConfigEncryptedStep) PerformSetupStep(ctx context.Context) error {
+	doguConfigs, err := wrces.createDoguConfigs()
+	if err != nil {
+		return err
+	}
+
+	for _, doguConfig := range doguConfigs {
+		_, err = wrces.sensitiveDoguConfigRepo.Create(ctx, doguConfig)
+		if err == nil {
+			wrces.createdDoguConfigs = append(wrces.createdDoguConfigs, doguConfig.DoguName)
+		}
+		if k8serror.IsAlreadyExistsError(err) {
+			// the config was already written by an interrupted setup run
+			err = wrces.updateSensitiveDoguConfig(ctx, doguConfig)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create dogu config for '%s': %w", doguConfig.DoguName, err)
+		}
+	}
+
+	return nil
+}
+
+// RollbackSetupStep deletes the sensitive dogu configs created by this step. Configs which already existed before are
+// kept.
+func (wrces *writeRegistryConfigEncryptedStep) RollbackSetupStep(ctx context.Context) error {
+	for len(wrces.createdDoguConfigs) > 0 {
+		last := len(wrces.createdDoguConfigs) - 1
+		doguName := wrces.createdDoguConfigs[last]
+		err := wrces.sensitiveDoguConfigRepo.Delete(ctx, doguName)
+		if err != nil && !k8serror.IsNotFoundError(err) {
+			return fmt.Errorf("failed to delete dogu config for '%s': %w", doguName, err)
+		}
+		wrces.createdDoguConfigs = wrces.createdDoguConfigs[:last]
+	}
+
+	return nil
+}
+
+// GetRollbackState returns the names of the sensitive dogu configs created by this step.
+func (wrces *writeRegistryConfigEncryptedStep) GetRollbackState() (json.RawMessage, error) {
+	return json.Marshal(wrces.createdDoguConfigs)
+}
+
+// SetRollbackState restores the names of the sensitive dogu configs created by a previous setup run.
+func (wrces *writeRegistryConfigEncryptedStep) SetRollbackState(state json.RawMessage) error {
+	return json.Unmarshal(state, &wrces.createdDoguConfigs)
+}
+
+func (wrces *writeRegistryConfigEncryptedStep) createDoguConfigs() ([]k8sconf.DoguConfig, error) {
 	if wrces.configuration.RegistryConfigEncrypted == nil {
 		wrces.configuration.RegistryConfigEncrypted = make(appcontext.CustomKeyValue)
 	}
@@ -46,10 +131,11 @@ func (wrces *writeRegistryConfigEncryptedStep) PerformSetupStep(ctx context.Cont
 		wrces.configuration.RegistryConfigEncrypted["ldap-mapper"] = map[string]any{}
 	}
 
+	var doguConfigs []k8sconf.DoguConfig
 	for dogu, resultConfig := range wrces.configuration.RegistryConfigEncrypted {
 		entries, err := k8sconf.MapToEntries(resultConfig)
 		if err != nil {
-			return fmt.Errorf("faild to map config for dogu '%s' to entries: %w", dogu, err)
+			return nil, fmt.Errorf("faild to map config for dogu '%s' to entries: %w", dogu, err)
 		}
 		doguConfig := k8sconf.CreateDoguConfig(k8sdogu.SimpleName(dogu), entries)
 
@@ -57,27 +143,43 @@ func (wrces *writeRegistryConfigEncryptedStep) PerformSetupStep(ctx context.Cont
 		case "cas":
 			doguConfig, err = wrces.appendCasConfig(doguConfig)
 			if err != nil {
-				return fmt.Errorf("failed to append default config to cas: %w", err)
+				return nil, fmt.Errorf("failed to append default config to cas: %w", err)
 			}
 		case "ldap":
 			doguConfig, err = wrces.appendLdapConfig(doguConfig)
 			if err != nil {
-				return fmt.Errorf("failed to append default config to ldap: %w", err)
+				return nil, fmt.Errorf("failed to append default config to ldap: %w", err)
 			}
 		case "ldap-mapper":
 			doguConfig, err = wrces.appendLdapMapperConfig(doguConfig)
 			if err != nil {
-				return fmt.Errorf("failed to append default config to ldap-mapper: %w", err)
+				return nil, fmt.Errorf("failed to append default config to ldap-mapper: %w", err)
 			}
 		}
 
-		_, err = wrces.sensitiveDoguConfigRepo.Create(ctx, doguConfig)
+		doguConfigs = append(doguConfigs, doguConfig)
+	}
+
+	return doguConfigs, nil
+}
+
+// updateSensitiveDoguConfig writes the keys of the given config into the existing config. Keys which are not part of
+// the given config, f. i. keys written by an installed dogu, are kept.
+func (wrces *writeRegistryConfigEncryptedStep) updateSensitiveDoguConfig(ctx context.Context, doguConfig k8sconf.DoguConfig) error {
+	actualConfig, err := wrces.sensitiveDoguConfigRepo.Get(ctx, doguConfig.DoguName)
+	if err != nil {
+		return fmt.Errorf("failed to get existing dogu config: %w", err)
+	}
+
+	for key, value := range doguConfig.GetAll() {
+		actualConfig.Config, err = actualConfig.Set(key, value)
 		if err != nil {
-			return fmt.Errorf("failed to create dogu config for '%s': %w", dogu, err)
+			return fmt.Errorf("failed to set key '%s': %w", key, err)
 		}
 	}
 
-	return nil
+	_, err = wrces.sensitiveDoguConfigRepo.Update(ctx, actualConfig)
+	return err
 }
 
 func (wrces *writeRegistryConfigEncryptedStep) appendLdapMapperConfig(doguConfig k8sconf.DoguConfig) (k8sconf.DoguConfig, error) {
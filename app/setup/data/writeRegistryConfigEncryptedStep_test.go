@@ -6,12 +6,17 @@ import (
 
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
 	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/data"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func TestNewWriteRegistryConfigEncryptedStep(t *testing.T) {
@@ -100,6 +105,50 @@ func Test_writeRegistryConfigEncryptedStep_PerformSetupStep(t *testing.T) {
 		assert.Equal(t, "admin_password: adminPw\nfromUser: user\n", secret.StringData["config.yaml"])
 	})
 
+	t.Run("should update existing configs of an interrupted setup run", func(t *testing.T) {
+		// given
+		admin := appcontext.User{Password: "newAdminPw"}
+		embeddedUserBackend := appcontext.UserBackend{DsType: "embedded"}
+		setupConfig := &appcontext.SetupJsonConfiguration{UserBackend: embeddedUserBackend, Admin: admin}
+		existingSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ldap-config", Namespace: "test"},
+			Data:       map[string][]byte{"config.yaml": []byte("admin_password: oldAdminPw\n")},
+		}
+		fakeClient := newSecretClientset(existingSecret)
+		step := data.NewWriteRegistryConfigEncryptedStep(setupConfig, fakeClient, "test")
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		secret, err := fakeClient.CoreV1().Secrets("test").Get(testCtx, "ldap-config", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "admin_password: newAdminPw\n", secret.StringData["config.yaml"])
+	})
+
+	t.Run("should keep keys of existing configs which are not configured", func(t *testing.T) {
+		// given
+		admin := appcontext.User{Password: "newAdminPw"}
+		embeddedUserBackend := appcontext.UserBackend{DsType: "embedded"}
+		setupConfig := &appcontext.SetupJsonConfiguration{UserBackend: embeddedUserBackend, Admin: admin}
+		existingSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ldap-config", Namespace: "test"},
+			Data:       map[string][]byte{"config.yaml": []byte("admin_password: oldAdminPw\nwritten_by_dogu: value\n")},
+		}
+		fakeClient := newSecretClientset(existingSecret)
+		step := data.NewWriteRegistryConfigEncryptedStep(setupConfig, fakeClient, "test")
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		secret, err := fakeClient.CoreV1().Secrets("test").Get(testCtx, "ldap-config", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "admin_password: newAdminPw\nwritten_by_dogu: value\n", secret.StringData["config.yaml"])
+	})
+
 	t.Run("success external", func(t *testing.T) {
 		// given
 		admin := appcontext.User{Password: "adminPw"}
@@ -173,3 +222,79 @@ func Test_writeRegistryConfigEncryptedStep_PerformSetupStep(t *testing.T) {
 		assert.Equal(t, "backend:\n    connection_dn: connection\n    password: ldapPw\n", ldapMapperSecret.StringData["config.yaml"])
 	})
 }
+
+func Test_writeRegistryConfigEncryptedStep_DescribeEffect(t *testing.T) {
+	t.Run("should describe sensitive configs and keys without writing them", func(t *testing.T) {
+		// given
+		setupConfig := &appcontext.SetupJsonConfiguration{
+			UserBackend:             appcontext.UserBackend{DsType: "embedded"},
+			Admin:                   appcontext.User{Password: "adminPw"},
+			RegistryConfigEncrypted: appcontext.CustomKeyValue{"redmine": {"secret": "value"}},
+		}
+		fakeClient := fake.NewSimpleClientset()
+		step := data.NewWriteRegistryConfigEncryptedStep(setupConfig, fakeClient, "test")
+
+		// when
+		actual := step.DescribeEffect()
+
+		// then
+		assert.Equal(t, plan.ActionWrite, actual.Action)
+		assert.Equal(t, []string{"cas", "ldap", "ldap-mapper", "redmine"}, actual.Targets)
+		assert.Equal(t, []string{"ldap/admin_password", "redmine/secret"}, actual.Keys)
+		secrets, err := fakeClient.CoreV1().Secrets("test").List(context.Background(), metav1.ListOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, secrets.Items)
+	})
+}
+
+func Test_writeRegistryConfigEncryptedStep_RollbackSetupStep(t *testing.T) {
+	var testCtx = context.Background()
+
+	t.Run("should delete the configs created by the step and keep existing configs", func(t *testing.T) {
+		// given
+		setupConfig := &appcontext.SetupJsonConfiguration{UserBackend: appcontext.UserBackend{DsType: "embedded"}, Admin: appcontext.User{Password: "adminPw"}}
+		existingSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ldap-config", Namespace: "test"},
+			Data:       map[string][]byte{"config.yaml": []byte("admin_password: oldAdminPw\n")},
+		}
+		fakeClient := newSecretClientset(existingSecret)
+		step := data.NewWriteRegistryConfigEncryptedStep(setupConfig, fakeClient, "test")
+		require.NoError(t, step.PerformSetupStep(testCtx))
+
+		// when
+		err := step.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		_, err = fakeClient.CoreV1().Secrets("test").Get(testCtx, "ldap-config", metav1.GetOptions{})
+		require.NoError(t, err)
+		_, err = fakeClient.CoreV1().Secrets("test").Get(testCtx, "cas-config", metav1.GetOptions{})
+		assert.True(t, errors.IsNotFound(err))
+		_, err = fakeClient.CoreV1().Secrets("test").Get(testCtx, "ldap-mapper-config", metav1.GetOptions{})
+		assert.True(t, errors.IsNotFound(err))
+	})
+}
+
+// newSecretClientset creates a fake clientset which respects the field selector on the secret name. The registry reads
+// existing configs with such a selector which is ignored by the fake clientset otherwise.
+func newSecretClientset(objects ...runtime.Object) *fake.Clientset {
+	clientSet := fake.NewSimpleClientset(objects...)
+	clientSet.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		name, ok := action.(k8stesting.ListAction).GetListRestrictions().Fields.RequiresExactMatch("metadata.name")
+		if !ok {
+			return false, nil, nil
+		}
+
+		secret, err := clientSet.Tracker().Get(action.GetResource(), action.GetNamespace(), name)
+		if errors.IsNotFound(err) {
+			return true, &corev1.SecretList{}, nil
+		}
+		if err != nil {
+			return true, nil, err
+		}
+
+		return true, &corev1.SecretList{Items: []corev1.Secret{*secret.(*corev1.Secret)}}, nil
+	})
+
+	return clientSet
+}
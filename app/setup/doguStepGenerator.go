@@ -28,7 +28,8 @@ const (
 )
 
 // doguStepGenerator is responsible to generate the steps to install a dogu, i.e., applying the dogu cr into the cluster
-// and waiting for the dependencies before doing so.
+// and waiting for the dependencies before doing so. The generated steps declare their dependencies so that independent
+// dogus are installed concurrently.
 type doguStepGenerator struct {
 	Client          kubernetes.Interface
 	EcoSystemClient ecoSystem.EcoSystemV2Interface
@@ -37,6 +38,10 @@ type doguStepGenerator struct {
 	namespace       string
 	components      []string
 	componentClient componentEcoSystem.ComponentInterface
+	// installStepIDs maps the names of the dogus to the IDs of their already generated install steps.
+	installStepIDs map[string]string
+	// waitStepIDs maps the label selectors to the IDs of the already generated wait steps.
+	waitStepIDs map[string]string
 }
 
 // NewDoguStepGenerator creates a new generator capable of generating dogu installation steps.
@@ -60,7 +65,7 @@ func NewDoguStepGenerator(ctx context.Context, client kubernetes.Interface, clus
 		doguList = append(doguList, dogu)
 	}
 
-	return &doguStepGenerator{Client: client, EcoSystemClient: ecoSystemClient, Dogus: &doguList, Repository: repository, namespace: namespace, components: components, componentClient: componentClient.Components(namespace)}, nil
+	return &doguStepGenerator{Client: client, EcoSystemClient: ecoSystemClient, Dogus: &doguList, Repository: repository, namespace: namespace, components: components, componentClient: componentClient.Components(namespace), installStepIDs: map[string]string{}, waitStepIDs: map[string]string{}}, nil
 }
 
 // GenerateSteps generates dogu installation steps for all configured dogus.
@@ -75,15 +80,50 @@ func (dsg *doguStepGenerator) GenerateSteps() ([]ExecutorStep, error) {
 
 	for _, dogu := range installedDogus {
 		// create wait step if needing a service account from a certain dogu
-		steps = dsg.appendDoguWaitStepsIfNeeded(dogu, installedDogus, steps, waitList)
-		installStep := dogus.NewInstallDogusStep(dsg.EcoSystemClient, dogu, dsg.namespace)
+		var waitStepIDs []string
+		steps, waitStepIDs = dsg.appendDoguWaitStepsIfNeeded(dogu, installedDogus, steps, waitList)
+		// the dogu only blocks on the waits for its own service account dependencies
+		installStep := newScheduledStep(dogus.NewInstallDogusStep(dsg.EcoSystemClient, dogu, dsg.namespace), waitStepIDs...)
 		steps = append(steps, installStep)
+		dsg.installStepIDs[dogu.GetSimpleName()] = installStep.GetStepID()
 	}
 
 	return steps, nil
 }
 
-func (dsg *doguStepGenerator) appendDoguWaitStepsIfNeeded(dogu *core.Dogu, installedDogus []*core.Dogu, steps []ExecutorStep, waitList map[string]bool) []ExecutorStep {
+// DoguVersions maps the simple names of all configured dogus to their resolved versions.
+func (dsg *doguStepGenerator) DoguVersions() map[string]string {
+	versions := make(map[string]string, len(*dsg.Dogus))
+	for _, dogu := range *dsg.Dogus {
+		versions[dogu.GetSimpleName()] = dogu.Version
+	}
+
+	return versions
+}
+
+// AppendWaitStep appends a step waiting for the given dogu of this setup to be ready unless such a step was already
+// generated. It returns the ID of the wait step.
+func (dsg *doguStepGenerator) AppendWaitStep(doguName string, steps []ExecutorStep) ([]ExecutorStep, string, error) {
+	installStepID, ok := dsg.installStepIDs[doguName]
+	if !ok {
+		return nil, "", fmt.Errorf("dogu %s is not installed by the setup", doguName)
+	}
+
+	labelSelector := dogus.CreateDoguLabelSelector(doguName)
+	if waitStepID, ok := dsg.waitStepIDs[labelSelector]; ok {
+		return steps, waitStepID, nil
+	}
+
+	waitStep := newScheduledStep(dogus.NewWaitForDoguStep(dsg.EcoSystemClient.Dogus(dsg.namespace), doguName, dsg.namespace, dogus.TimeoutInSeconds()), installStepID)
+	dsg.waitStepIDs[labelSelector] = waitStep.GetStepID()
+
+	return append(steps, waitStep), waitStep.GetStepID(), nil
+}
+
+// appendDoguWaitStepsIfNeeded appends the wait steps for the service account dependencies of the given dogu and returns
+// the IDs of all wait steps the dogu has to wait for.
+func (dsg *doguStepGenerator) appendDoguWaitStepsIfNeeded(dogu *core.Dogu, installedDogus []*core.Dogu, steps []ExecutorStep, waitList map[string]bool) ([]ExecutorStep, []string) {
+	var waitStepIDs []string
 	for _, serviceAccountDependency := range dogu.ServiceAccounts {
 		switch serviceAccountDependency.Kind {
 		case "":
@@ -94,19 +134,30 @@ func (dsg *doguStepGenerator) appendDoguWaitStepsIfNeeded(dogu *core.Dogu, insta
 				continue
 			}
 			steps = dsg.createWaitStepForDogu(serviceAccountDependency, waitList, steps)
+			waitStepIDs = dsg.appendWaitStepID(waitStepIDs, dogus.CreateDoguLabelSelector(serviceAccountDependency.Type))
 		case serviceAccountKindComponent:
 			if !shouldDoguWaitForSAComponent(dogu, serviceAccountDependency, dsg.components) {
 				logrus.Infof("skipping wait step for optional component %s service account creation", serviceAccountDependency.Type)
 				continue
 			}
 			steps = dsg.createWaitStepForK8sComponent(serviceAccountDependency, waitList, steps)
+			waitStepIDs = dsg.appendWaitStepID(waitStepIDs, component.CreateComponentLabelSelector(serviceAccountDependency.Type))
 		default:
 			logrus.Errorf("unknown service account kind %s from dogu %s. skipping wait step creation for service account creation", serviceAccountDependency.Kind, dogu.GetSimpleName())
 			continue
 		}
 	}
 
-	return steps
+	return steps, waitStepIDs
+}
+
+func (dsg *doguStepGenerator) appendWaitStepID(waitStepIDs []string, labelSelector string) []string {
+	waitStepID, ok := dsg.waitStepIDs[labelSelector]
+	if !ok || slices.Contains(waitStepIDs, waitStepID) {
+		return waitStepIDs
+	}
+
+	return append(waitStepIDs, waitStepID)
 }
 
 func shouldDoguWaitForSAComponent(dogu *core.Dogu, serviceAccount core.ServiceAccount, configureComponents []string) bool {
@@ -144,9 +195,15 @@ func (dsg *doguStepGenerator) createWaitStepForDogu(serviceAccountDependency cor
 		return steps
 	}
 
-	waitForDependencyStep := dogus.NewWaitForDoguStep(dsg.EcoSystemClient.Dogus(dsg.namespace), serviceAccountDependency.Type, dsg.namespace, dogus.TimeoutInSeconds())
+	// the wait step only blocks on the installation of the dogu if it is part of this setup
+	var dependencies []string
+	if installStepID, ok := dsg.installStepIDs[serviceAccountDependency.Type]; ok {
+		dependencies = append(dependencies, installStepID)
+	}
+	waitForDependencyStep := newScheduledStep(dogus.NewWaitForDoguStep(dsg.EcoSystemClient.Dogus(dsg.namespace), serviceAccountDependency.Type, dsg.namespace, dogus.TimeoutInSeconds()), dependencies...)
 	steps = append(steps, waitForDependencyStep)
 	waitList[labelSelector] = true
+	dsg.waitStepIDs[labelSelector] = waitForDependencyStep.GetStepID()
 
 	return steps
 }
@@ -157,9 +214,11 @@ func (dsg *doguStepGenerator) createWaitStepForK8sComponent(serviceAccountDepend
 		return steps
 	}
 
-	waitForDependencyStep := component.NewWaitForComponentStep(dsg.componentClient, serviceAccountDependency.Type, dsg.namespace, component.TimeoutInSeconds())
+	// components are installed in a previous phase of the setup
+	waitForDependencyStep := newScheduledStep(component.NewWaitForComponentStep(dsg.componentClient, serviceAccountDependency.Type, dsg.namespace, component.TimeoutInSeconds()))
 	steps = append(steps, waitForDependencyStep)
 	waitList[labelSelector] = true
+	dsg.waitStepIDs[labelSelector] = waitForDependencyStep.GetStepID()
 
 	return steps
 }
@@ -13,6 +13,7 @@ import (
 
 	"github.com/cloudogu/cesapp-lib/core"
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 )
 
 func TestNewDoguStepGenerator(t *testing.T) {
@@ -259,6 +260,15 @@ func Test_doguStepGenerator_GenerateSteps(t *testing.T) {
 		assert.Equal(t, "Wait for dogu with selector dogu.name=postgres to be ready", doguSteps[6].GetStepDescription())
 		assert.Equal(t, "Wait for dogu with selector dogu.name=postfix to be ready", doguSteps[7].GetStepDescription())
 		assert.Equal(t, "Installing dogu [redmine]", doguSteps[8].GetStepDescription())
+		assertDependencies(t, doguSteps[0])
+		assertDependencies(t, doguSteps[1])
+		assertDependencies(t, doguSteps[2], "install-dogu/ldap")
+		assertDependencies(t, doguSteps[3], "wait-for-dogu/ldap")
+		assertDependencies(t, doguSteps[4], "install-dogu/cas")
+		assertDependencies(t, doguSteps[5], "wait-for-dogu/cas", "wait-for-dogu/ldap")
+		assertDependencies(t, doguSteps[6], "install-dogu/postgres")
+		assertDependencies(t, doguSteps[7], "install-dogu/postfix")
+		assertDependencies(t, doguSteps[8], "wait-for-dogu/postgres", "wait-for-dogu/postfix")
 	})
 
 	t.Run("should not create wait step if serviceaccount is optional and related dogu is not installed", func(t *testing.T) {
@@ -432,13 +442,40 @@ func Test_doguStepGenerator_createWaitStepForK8sComponent(t *testing.T) {
 	})
 }
 
+func assertDependencies(t *testing.T, step ExecutorStep, expected ...string) {
+	t.Helper()
+	actual, ok := getDependencies(step)
+	require.True(t, ok, "step [%s] does not declare its dependencies", step.GetStepID())
+	assert.Equal(t, expected, actual)
+}
+
 type fakeExecutorStep struct {
 }
 
+func (f *fakeExecutorStep) GetStepID() string {
+	return "wait-for-dogu/your-most-favorite"
+}
+
 func (f *fakeExecutorStep) GetStepDescription() string {
 	return "Wait for dogu with selector dogu.name=your-most-favorite to be ready"
 }
 
+func (f *fakeExecutorStep) DescribeEffect() plan.Effect {
+	return plan.Effect{}
+}
+
 func (f *fakeExecutorStep) PerformSetupStep(context.Context) error {
 	return nil
 }
+
+func Test_doguStepGenerator_DoguVersions(t *testing.T) {
+	// given
+	dogus := []*core.Dogu{{Name: "official/ldap", Version: "2.1.0-1"}, {Name: "official/cas", Version: "6.5.4-2"}}
+	sut := &doguStepGenerator{Dogus: &dogus}
+
+	// when
+	actual := sut.DoguVersions()
+
+	// then
+	assert.Equal(t, map[string]string{"ldap": "2.1.0-1", "cas": "6.5.4-2"}, actual)
+}
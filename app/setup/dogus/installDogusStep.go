@@ -2,24 +2,44 @@ package dogus
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/cloudogu/cesapp-lib/core"
 
 	"github.com/cloudogu/k8s-dogu-operator/v2/api/ecoSystem"
 	v2 "github.com/cloudogu/k8s-dogu-operator/v2/api/v2"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 )
 
 type ecoSystemClient interface {
 	ecoSystem.EcoSystemV2Interface
 }
 
+// deletionPollInterval is the interval in which the removal of a deleted dogu resource is checked.
+const deletionPollInterval = 2 * time.Second
+
 type installDogusStep struct {
 	client    ecoSystemClient
 	dogu      *core.Dogu
 	namespace string
+	// created is true if the dogu resource was created by this step.
+	created bool
+	// previousVersion contains the version of an existing dogu resource before this step updated it.
+	previousVersion string
+}
+
+// installDogusRollbackState contains the data needed to roll back the step.
+type installDogusRollbackState struct {
+	Created         bool   `json:"created"`
+	PreviousVersion string `json:"previousVersion,omitempty"`
 }
 
 // NewInstallDogusStep creates a new step responsible to apply a dogu resource to the cluster, and, thus, starting the dogu installation.
@@ -27,12 +47,28 @@ func NewInstallDogusStep(client ecoSystemClient, dogu *core.Dogu, namespace stri
 	return &installDogusStep{client: client, dogu: dogu, namespace: namespace}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (ids *installDogusStep) GetStepID() string {
+	return fmt.Sprintf("install-dogu/%s", ids.dogu.GetSimpleName())
+}
+
 // GetStepDescription return the human-readable description of the step
 func (ids *installDogusStep) GetStepDescription() string {
 	return fmt.Sprintf("Installing dogu [%s]", ids.dogu.GetFullName())
 }
 
-// PerformSetupStep applies a dogu recource for the configured dogu to the cluster.
+// DescribeEffect returns the dogu resource and the version which the step creates.
+func (ids *installDogusStep) DescribeEffect() plan.Effect {
+	return plan.Effect{
+		Action:  plan.ActionCreate,
+		Kind:    "Dogu",
+		Targets: []string{fmt.Sprintf("%s/%s", ids.namespace, ids.dogu.GetSimpleName())},
+		Version: ids.dogu.Version,
+	}
+}
+
+// PerformSetupStep applies a dogu recource for the configured dogu to the cluster. An existing dogu resource is
+// updated to the configured version.
 func (ids *installDogusStep) PerformSetupStep(ctx context.Context) error {
 	doguVersion, err := ids.dogu.GetVersion()
 	if err != nil {
@@ -41,10 +77,112 @@ func (ids *installDogusStep) PerformSetupStep(ctx context.Context) error {
 
 	cr := getDoguCr(ids.dogu.GetSimpleName(), ids.dogu.GetFullName(), doguVersion.Raw, ids.namespace)
 	_, err = ids.client.Dogus(ids.namespace).Create(ctx, cr, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// the dogu resource was applied by an interrupted setup run or belongs to an installed ecosystem
+		return ids.updateDoguCr(ctx, cr)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to apply dogu %s: %w", ids.dogu.GetSimpleName(), err)
 	}
 
+	ids.created = true
+	return nil
+}
+
+func (ids *installDogusStep) updateDoguCr(ctx context.Context, cr *v2.Dogu) error {
+	doguClient := ids.client.Dogus(ids.namespace)
+	actualCr, err := doguClient.Get(ctx, cr.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get existing dogu %s: %w", cr.Name, err)
+	}
+
+	if actualCr.Spec.Version == cr.Spec.Version {
+		logrus.Infof("dogu resource %s already exists in version %s", cr.Name, cr.Spec.Version)
+		return nil
+	}
+
+	logrus.Infof("update dogu resource %s from version %s to %s", cr.Name, actualCr.Spec.Version, cr.Spec.Version)
+	if !ids.created && ids.previousVersion == "" {
+		// keep the version recorded by an interrupted setup run
+		ids.previousVersion = actualCr.Spec.Version
+	}
+	actualCr.Spec.Version = cr.Spec.Version
+	_, err = doguClient.Update(ctx, actualCr, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update dogu %s: %w", cr.Name, err)
+	}
+
+	return nil
+}
+
+// RollbackSetupStep deletes the dogu resource if it was created by this step and waits until the dogu operator has
+// removed the dogu. The version of a dogu resource updated by this step is reset to the previous version.
+func (ids *installDogusStep) RollbackSetupStep(ctx context.Context) error {
+	if !ids.created {
+		return ids.restorePreviousVersion(ctx)
+	}
+
+	doguClient := ids.client.Dogus(ids.namespace)
+	err := doguClient.Delete(ctx, ids.dogu.GetSimpleName(), metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete dogu %s: %w", ids.dogu.GetSimpleName(), err)
+	}
+
+	err = wait.PollUntilContextTimeout(ctx, deletionPollInterval, TimeoutInSeconds(), true, func(ctx context.Context) (bool, error) {
+		_, getErr := doguClient.Get(ctx, ids.dogu.GetSimpleName(), metav1.GetOptions{})
+		if errors.IsNotFound(getErr) {
+			return true, nil
+		}
+
+		return false, getErr
+	})
+	if err != nil {
+		return fmt.Errorf("failed to wait for deletion of dogu %s: %w", ids.dogu.GetSimpleName(), err)
+	}
+
+	ids.created = false
+	return nil
+}
+
+func (ids *installDogusStep) restorePreviousVersion(ctx context.Context) error {
+	if ids.previousVersion == "" {
+		return nil
+	}
+
+	doguClient := ids.client.Dogus(ids.namespace)
+	actualCr, err := doguClient.Get(ctx, ids.dogu.GetSimpleName(), metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get dogu %s: %w", ids.dogu.GetSimpleName(), err)
+	}
+
+	if actualCr.Spec.Version != ids.previousVersion {
+		logrus.Infof("reset dogu resource %s from version %s to %s", actualCr.Name, actualCr.Spec.Version, ids.previousVersion)
+		actualCr.Spec.Version = ids.previousVersion
+		_, err = doguClient.Update(ctx, actualCr, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to reset dogu %s to version %s: %w", actualCr.Name, ids.previousVersion, err)
+		}
+	}
+
+	ids.previousVersion = ""
+	return nil
+}
+
+// GetRollbackState returns whether the dogu resource was created by this step and its version before it was updated.
+func (ids *installDogusStep) GetRollbackState() (json.RawMessage, error) {
+	return json.Marshal(installDogusRollbackState{Created: ids.created, PreviousVersion: ids.previousVersion})
+}
+
+// SetRollbackState restores the rollback data of the dogu resource recorded by a previous setup run.
+func (ids *installDogusStep) SetRollbackState(state json.RawMessage) error {
+	var rollbackState installDogusRollbackState
+	err := json.Unmarshal(state, &rollbackState)
+	if err != nil {
+		return err
+	}
+
+	ids.created = rollbackState.Created
+	ids.previousVersion = rollbackState.PreviousVersion
 	return nil
 }
 
@@ -5,11 +5,16 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/cloudogu/cesapp-lib/core"
 	v1 "github.com/cloudogu/k8s-dogu-operator/v2/api/v2"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 )
 
 var testCtx = context.Background()
@@ -123,5 +128,269 @@ func Test_installDogusStep_PerformSetupStep(t *testing.T) {
 
 		// then
 		require.NoError(t, err)
+		assert.True(t, installStep.created)
+	})
+	t.Run("should keep already existing dogu cr with the same version", func(t *testing.T) {
+		// given
+		doguCr := &v1.Dogu{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "MyName",
+				Namespace: "namespace",
+				Labels: map[string]string{
+					"app":       "ces",
+					"dogu.name": "MyName",
+				},
+			},
+			Spec: v1.DoguSpec{
+				Name:    "MyName",
+				Version: "1.1.1-1",
+			},
+			Status: v1.DoguStatus{},
+		}
+
+		doguClientMock := newMockDoguClient(t)
+		alreadyExistsErr := errors.NewAlreadyExists(schema.GroupResource{Resource: "dogus"}, "MyName")
+		doguClientMock.EXPECT().Create(context.Background(), doguCr, metav1.CreateOptions{}).Return(nil, alreadyExistsErr)
+		doguClientMock.EXPECT().Get(context.Background(), "MyName", metav1.GetOptions{}).Return(doguCr.DeepCopy(), nil)
+		ecoSystemClientMock := newMockEcoSystemClient(t)
+		ecoSystemClientMock.EXPECT().Dogus("namespace").Return(doguClientMock)
+
+		myDogu := &core.Dogu{Name: "MyName", Version: "1.1.1-1"}
+		installStep := NewInstallDogusStep(ecoSystemClientMock, myDogu, "namespace")
+
+		// when
+		err := installStep.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, installStep.created)
+	})
+
+	t.Run("should update the version of an already existing dogu cr", func(t *testing.T) {
+		// given
+		existingCr := &v1.Dogu{
+			ObjectMeta: metav1.ObjectMeta{Name: "ldap", Namespace: "namespace", ResourceVersion: "42"},
+			Spec:       v1.DoguSpec{Name: "official/ldap", Version: "2.4.48-2"},
+		}
+
+		doguClientMock := newMockDoguClient(t)
+		alreadyExistsErr := errors.NewAlreadyExists(schema.GroupResource{Resource: "dogus"}, "ldap")
+		doguClientMock.EXPECT().Create(testCtx, mock.Anything, metav1.CreateOptions{}).Return(nil, alreadyExistsErr)
+		doguClientMock.EXPECT().Get(testCtx, "ldap", metav1.GetOptions{}).Return(existingCr, nil)
+		doguClientMock.EXPECT().Update(testCtx, mock.MatchedBy(func(cr *v1.Dogu) bool {
+			return cr.Spec.Version == "2.4.48-3" && cr.Spec.Name == "official/ldap" && cr.ResourceVersion == "42"
+		}), metav1.UpdateOptions{}).Return(existingCr, nil)
+		ecoSystemClientMock := newMockEcoSystemClient(t)
+		ecoSystemClientMock.EXPECT().Dogus("namespace").Return(doguClientMock)
+
+		installStep := NewInstallDogusStep(ecoSystemClientMock, &core.Dogu{Name: "official/ldap", Version: "2.4.48-3"}, "namespace")
+
+		// when
+		err := installStep.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, installStep.created)
+		assert.Equal(t, "2.4.48-2", installStep.previousVersion)
+	})
+
+	t.Run("should keep the previous version recorded by an interrupted setup run", func(t *testing.T) {
+		// given
+		existingCr := &v1.Dogu{
+			ObjectMeta: metav1.ObjectMeta{Name: "ldap", Namespace: "namespace"},
+			Spec:       v1.DoguSpec{Name: "official/ldap", Version: "2.4.48-2"},
+		}
+
+		doguClientMock := newMockDoguClient(t)
+		alreadyExistsErr := errors.NewAlreadyExists(schema.GroupResource{Resource: "dogus"}, "ldap")
+		doguClientMock.EXPECT().Create(testCtx, mock.Anything, metav1.CreateOptions{}).Return(nil, alreadyExistsErr)
+		doguClientMock.EXPECT().Get(testCtx, "ldap", metav1.GetOptions{}).Return(existingCr, nil)
+		doguClientMock.EXPECT().Update(testCtx, mock.Anything, metav1.UpdateOptions{}).Return(existingCr, nil)
+		ecoSystemClientMock := newMockEcoSystemClient(t)
+		ecoSystemClientMock.EXPECT().Dogus("namespace").Return(doguClientMock)
+
+		installStep := NewInstallDogusStep(ecoSystemClientMock, &core.Dogu{Name: "official/ldap", Version: "2.4.48-3"}, "namespace")
+		require.NoError(t, installStep.SetRollbackState([]byte(`{"created":false,"previousVersion":"2.4.48-1"}`)))
+
+		// when
+		err := installStep.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "2.4.48-1", installStep.previousVersion)
+	})
+
+	t.Run("should fail to update an already existing dogu cr", func(t *testing.T) {
+		// given
+		existingCr := &v1.Dogu{
+			ObjectMeta: metav1.ObjectMeta{Name: "ldap", Namespace: "namespace"},
+			Spec:       v1.DoguSpec{Name: "official/ldap", Version: "2.4.48-2"},
+		}
+
+		doguClientMock := newMockDoguClient(t)
+		alreadyExistsErr := errors.NewAlreadyExists(schema.GroupResource{Resource: "dogus"}, "ldap")
+		doguClientMock.EXPECT().Create(testCtx, mock.Anything, metav1.CreateOptions{}).Return(nil, alreadyExistsErr)
+		doguClientMock.EXPECT().Get(testCtx, "ldap", metav1.GetOptions{}).Return(existingCr, nil)
+		doguClientMock.EXPECT().Update(testCtx, mock.Anything, metav1.UpdateOptions{}).Return(nil, assert.AnError)
+		ecoSystemClientMock := newMockEcoSystemClient(t)
+		ecoSystemClientMock.EXPECT().Dogus("namespace").Return(doguClientMock)
+
+		installStep := NewInstallDogusStep(ecoSystemClientMock, &core.Dogu{Name: "official/ldap", Version: "2.4.48-3"}, "namespace")
+
+		// when
+		err := installStep.PerformSetupStep(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to update dogu ldap")
+	})
+}
+
+func Test_installDogusStep_RollbackSetupStep(t *testing.T) {
+	notFound := errors.NewNotFound(schema.GroupResource{Resource: "dogus"}, "ldap")
+	myDogu := &core.Dogu{Name: "official/ldap", Version: "2.4.48-3"}
+
+	t.Run("should delete dogu created by the step and wait for its removal", func(t *testing.T) {
+		// given
+		doguClientMock := newMockDoguClient(t)
+		doguClientMock.EXPECT().Delete(testCtx, "ldap", metav1.DeleteOptions{}).Return(nil)
+		doguClientMock.EXPECT().Get(mock.Anything, "ldap", metav1.GetOptions{}).Return(nil, notFound)
+		ecoSystemClientMock := newMockEcoSystemClient(t)
+		ecoSystemClientMock.EXPECT().Dogus("ecosystem").Return(doguClientMock)
+
+		installStep := &installDogusStep{client: ecoSystemClientMock, dogu: myDogu, namespace: "ecosystem", created: true}
+
+		// when
+		err := installStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, installStep.created)
+	})
+
+	t.Run("should not delete dogu which was not created by the step", func(t *testing.T) {
+		// given
+		installStep := NewInstallDogusStep(newMockEcoSystemClient(t), myDogu, "ecosystem")
+
+		// when
+		err := installStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should reset the version of a dogu updated by the step", func(t *testing.T) {
+		// given
+		existingCr := &v1.Dogu{
+			ObjectMeta: metav1.ObjectMeta{Name: "ldap", Namespace: "ecosystem"},
+			Spec:       v1.DoguSpec{Name: "official/ldap", Version: "2.4.48-3"},
+		}
+		doguClientMock := newMockDoguClient(t)
+		doguClientMock.EXPECT().Get(testCtx, "ldap", metav1.GetOptions{}).Return(existingCr, nil)
+		doguClientMock.EXPECT().Update(testCtx, mock.MatchedBy(func(cr *v1.Dogu) bool {
+			return cr.Spec.Version == "2.4.48-2"
+		}), metav1.UpdateOptions{}).Return(existingCr, nil)
+		ecoSystemClientMock := newMockEcoSystemClient(t)
+		ecoSystemClientMock.EXPECT().Dogus("ecosystem").Return(doguClientMock)
+
+		installStep := &installDogusStep{client: ecoSystemClientMock, dogu: myDogu, namespace: "ecosystem", previousVersion: "2.4.48-2"}
+
+		// when
+		err := installStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, installStep.previousVersion)
+	})
+
+	t.Run("should fail to reset the version of a dogu updated by the step", func(t *testing.T) {
+		// given
+		existingCr := &v1.Dogu{
+			ObjectMeta: metav1.ObjectMeta{Name: "ldap", Namespace: "ecosystem"},
+			Spec:       v1.DoguSpec{Name: "official/ldap", Version: "2.4.48-3"},
+		}
+		doguClientMock := newMockDoguClient(t)
+		doguClientMock.EXPECT().Get(testCtx, "ldap", metav1.GetOptions{}).Return(existingCr, nil)
+		doguClientMock.EXPECT().Update(testCtx, mock.Anything, metav1.UpdateOptions{}).Return(nil, assert.AnError)
+		ecoSystemClientMock := newMockEcoSystemClient(t)
+		ecoSystemClientMock.EXPECT().Dogus("ecosystem").Return(doguClientMock)
+
+		installStep := &installDogusStep{client: ecoSystemClientMock, dogu: myDogu, namespace: "ecosystem", previousVersion: "2.4.48-2"}
+
+		// when
+		err := installStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to reset dogu ldap to version 2.4.48-2")
+		assert.Equal(t, "2.4.48-2", installStep.previousVersion)
+	})
+
+	t.Run("should fail to delete dogu", func(t *testing.T) {
+		// given
+		doguClientMock := newMockDoguClient(t)
+		doguClientMock.EXPECT().Delete(testCtx, "ldap", metav1.DeleteOptions{}).Return(assert.AnError)
+		ecoSystemClientMock := newMockEcoSystemClient(t)
+		ecoSystemClientMock.EXPECT().Dogus("ecosystem").Return(doguClientMock)
+
+		installStep := &installDogusStep{client: ecoSystemClientMock, dogu: myDogu, namespace: "ecosystem", created: true}
+
+		// when
+		err := installStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to delete dogu ldap")
+	})
+
+	t.Run("should fail to wait for the removal of the dogu", func(t *testing.T) {
+		// given
+		doguClientMock := newMockDoguClient(t)
+		doguClientMock.EXPECT().Delete(testCtx, "ldap", metav1.DeleteOptions{}).Return(nil)
+		doguClientMock.EXPECT().Get(mock.Anything, "ldap", metav1.GetOptions{}).Return(nil, assert.AnError)
+		ecoSystemClientMock := newMockEcoSystemClient(t)
+		ecoSystemClientMock.EXPECT().Dogus("ecosystem").Return(doguClientMock)
+
+		installStep := &installDogusStep{client: ecoSystemClientMock, dogu: myDogu, namespace: "ecosystem", created: true}
+
+		// when
+		err := installStep.RollbackSetupStep(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to wait for deletion of dogu ldap")
+	})
+}
+
+func Test_installDogusStep_RollbackState(t *testing.T) {
+	t.Run("should restore the rollback state of a previous setup run", func(t *testing.T) {
+		// given
+		myDogu := &core.Dogu{Name: "official/ldap", Version: "2.4.48-3"}
+		performedStep := &installDogusStep{dogu: myDogu, namespace: "ecosystem", previousVersion: "2.4.48-2"}
+		state, err := performedStep.GetRollbackState()
+		require.NoError(t, err)
+		installStep := NewInstallDogusStep(newMockEcoSystemClient(t), myDogu, "ecosystem")
+
+		// when
+		err = installStep.SetRollbackState(state)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, installStep.created)
+		assert.Equal(t, "2.4.48-2", installStep.previousVersion)
+	})
+}
+
+func Test_installDogusStep_DescribeEffect(t *testing.T) {
+	t.Run("should describe dogu resource and version", func(t *testing.T) {
+		// given
+		myDogu := &core.Dogu{Name: "official/ldap", Version: "2.4.48-3"}
+		installStep := NewInstallDogusStep(newMockEcoSystemClient(t), myDogu, "ecosystem")
+
+		// when
+		actual := installStep.DescribeEffect()
+
+		// then
+		assert.Equal(t, plan.Effect{Action: plan.ActionCreate, Kind: "Dogu", Targets: []string{"ecosystem/ldap"}, Version: "2.4.48-3"}, actual)
 	})
 }
@@ -3,6 +3,7 @@ package dogus
 import (
 	"context"
 	"fmt"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 	v2 "github.com/cloudogu/k8s-dogu-operator/v2/api/v2"
 	"github.com/cloudogu/retry-lib/retry"
 	"github.com/sirupsen/logrus"
@@ -44,11 +45,25 @@ func NewWaitForDoguStep(client doguClient, doguName string, namespace string, ti
 	}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (wfds *waitForDoguStep) GetStepID() string {
+	return fmt.Sprintf("wait-for-dogu/%s", wfds.doguName)
+}
+
 // GetStepDescription return the human-readable description of the step
 func (wfds *waitForDoguStep) GetStepDescription() string {
 	return fmt.Sprintf("Wait for dogu with selector %s to be ready", wfds.labelSelector)
 }
 
+// DescribeEffect returns the dogu resource which the step waits for.
+func (wfds *waitForDoguStep) DescribeEffect() plan.Effect {
+	return plan.Effect{
+		Action:  plan.ActionWait,
+		Kind:    "Dogu",
+		Targets: []string{fmt.Sprintf("%s/%s", wfds.namespace, wfds.doguName)},
+	}
+}
+
 // PerformSetupStep implements all actions in this step
 func (wfds *waitForDoguStep) PerformSetupStep(ctx context.Context) error {
 	timeoutCtx, cancel := context.WithTimeout(ctx, wfds.timeout)
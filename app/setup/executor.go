@@ -2,6 +2,8 @@ package setup
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	cescommons "github.com/cloudogu/ces-commons-lib/dogu"
 	componentEcoSystem "github.com/cloudogu/k8s-component-operator/pkg/api/ecosystem"
@@ -14,12 +16,16 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/cloudogu/cesapp-lib/core"
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	setupcore "github.com/cloudogu/k8s-ces-setup/v4/app/core"
 	"github.com/cloudogu/k8s-ces-setup/v4/app/patch"
 	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/component"
 	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/data"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/validation"
 
 	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
@@ -34,11 +40,55 @@ const (
 
 // ExecutorStep describes a valid step in the setup.
 type ExecutorStep interface {
+	// GetStepID returns an identifier of the setup step which is unique among all steps of a setup and stable across
+	// restarts of the setup. The Executor uses it to record the progress of the setup.
+	GetStepID() string
 	// GetStepDescription returns the description of the setup step. The Executor prints the description of every step
 	// when executing the setup.
 	GetStepDescription() string
 	// PerformSetupStep is called for every registered step when executing the setup.
 	PerformSetupStep(ctx context.Context) error
+	// DescribeEffect returns the intended effect of the setup step without performing it. The Executor uses it to
+	// create the plan of the setup.
+	DescribeEffect() plan.Effect
+}
+
+// repeatableStep is implemented by steps which only compute data in memory that subsequent steps depend on.
+// These steps are performed again when an interrupted setup is resumed even if they were completed before.
+type repeatableStep interface {
+	IsRepeatable() bool
+}
+
+// rollbackStep is implemented by steps which record the resources they create and are able to remove them again.
+type rollbackStep interface {
+	// RollbackSetupStep removes the resources created by the step. It is called in reverse order for every performed
+	// step when the setup is rolled back.
+	RollbackSetupStep(ctx context.Context) error
+	// GetRollbackState returns the data which the step recorded to roll back its changes, f. i. the names of the
+	// created resources. The Executor persists it after the step was performed.
+	GetRollbackState() (json.RawMessage, error)
+	// SetRollbackState restores the data recorded by the step in a previous setup run, f. i. before the setup was
+	// restarted.
+	SetRollbackState(state json.RawMessage) error
+}
+
+// StepProgressStore persists which steps of the setup have already been completed.
+type StepProgressStore interface {
+	// GetCompletedSteps returns the IDs of all steps that were completed by the current or a previous setup run.
+	GetCompletedSteps(ctx context.Context) ([]string, error)
+	// MarkStepCompleted records the step with the given ID as completed.
+	MarkStepCompleted(ctx context.Context, stepID string) error
+}
+
+// RollbackStateStore persists the data which the performed steps need to be rolled back so that a setup can be rolled
+// back after a restart of the setup.
+type RollbackStateStore interface {
+	// GetRollbackStates returns the rollback data of all performed steps by their step IDs.
+	GetRollbackStates(ctx context.Context) (map[string]json.RawMessage, error)
+	// SaveRollbackState stores the rollback data of the step with the given ID.
+	SaveRollbackState(ctx context.Context, stepID string, state json.RawMessage) error
+	// RemoveRollbackState removes the rollback data of the step with the given ID.
+	RemoveRollbackState(ctx context.Context, stepID string) error
 }
 
 // Executor is responsible to perform the actual steps of the setup.
@@ -53,16 +103,60 @@ type Executor struct {
 	Steps []ExecutorStep
 	// Repository is the dogu Descriptor repository
 	Repository cescommons.RemoteDoguDescriptorRepository
+	// ProgressStore records completed steps so that an interrupted setup can be resumed. The progress is not
+	// persisted if it is nil.
+	ProgressStore StepProgressStore
+	// RollbackStore records the rollback data of the performed steps so that they can be rolled back after a restart
+	// of the setup. The rollback data is not persisted if it is nil.
+	RollbackStore RollbackStateStore
+	// StatusTracker receives the state transitions of all steps while the setup is performed. The status is not
+	// tracked if it is nil.
+	StatusTracker *StatusTracker
+	// StepConcurrency limits the number of dependent steps which are performed at the same time. Dependent steps are
+	// performed one after another if it is less than 1.
+	StepConcurrency int
+
+	// doguVersions maps the simple names of the dogus to install to their resolved versions. It is filled when the dogu
+	// installation steps are registered.
+	doguVersions map[string]string
+	// componentNames contains the names of the configured components. It is filled when the component installation
+	// steps are registered.
+	componentNames []string
+
+	performedStepsMutex sync.Mutex
+	// performedSteps contains the indexes of all steps which were performed by this executor or a previous setup run
+	// and not rolled back yet.
+	performedSteps []int
 }
 
 // NewExecutor creates a new setup executor with the given app configuration.
 func NewExecutor(clusterConfig *rest.Config, k8sClient kubernetes.Interface, setupCtx *appcontext.SetupContext) (*Executor, error) {
+	doguRepository, err := newRemoteDoguRepository(setupCtx.DoguRegistryConfiguration)
+	if err != nil {
+		return nil, err
+	}
+
+	progressStore := NewConfigMapStepProgressStore(k8sClient, setupCtx.AppConfig.TargetNamespace)
+	return &Executor{
+		SetupContext:    setupCtx,
+		ClientSet:       k8sClient,
+		ClusterConfig:   clusterConfig,
+		Repository:      doguRepository,
+		ProgressStore:   progressStore,
+		RollbackStore:   progressStore,
+		StatusTracker:   setupStatus,
+		StepConcurrency: StepConcurrency(),
+	}, nil
+}
+
+// newRemoteDoguRepository creates a repository for the dogu descriptors of the given dogu registry.
+func newRemoteDoguRepository(doguRegistry *appcontext.DoguRegistrySecret) (cescommons.RemoteDoguDescriptorRepository, error) {
 	credentials := &core.Credentials{
-		Username: setupCtx.DoguRegistryConfiguration.Username,
-		Password: setupCtx.DoguRegistryConfiguration.Password,
+		Username: doguRegistry.Username,
+		Password: doguRegistry.Password,
 	}
 
-	config, err := getRemoteConfig(setupCtx.DoguRegistryConfiguration.Endpoint, setupCtx.DoguRegistryConfiguration.URLSchema)
+	config, err := getRemoteConfig(doguRegistry.Endpoint, doguRegistry.URLSchema)
 	if err != nil {
 		return nil, err
 	}
@@ -72,12 +166,7 @@ func NewExecutor(clusterConfig *rest.Config, k8sClient kubernetes.Interface, set
 		return nil, fmt.Errorf("failed to create new remote dogu repository: %w", err)
 	}
 
-	return &Executor{
-		SetupContext:  setupCtx,
-		ClientSet:     k8sClient,
-		ClusterConfig: clusterConfig,
-		Repository:    doguRepository,
-	}, nil
+	return doguRepository, nil
 }
 
 func getRemoteConfig(endpoint string, urlSchema string) (*core.Remote, error) {
@@ -139,22 +228,194 @@ func (e *Executor) RegisterSetupSteps(steps ...ExecutorStep) {
 	e.Steps = append(e.Steps, steps...)
 }
 
-// PerformSetup starts the setup and executes all registered setup steps
+// PerformSetup starts the setup and executes all registered setup steps. Steps which were already completed by an
+// interrupted previous run are skipped, but their rollback data is restored so that they are rolled back as well.
+// Consecutive steps which declare their dependencies are performed concurrently along their dependency graph.
 func (e *Executor) PerformSetup(ctx context.Context) (err error, errCausingAction string) {
 	logrus.Print("Starting the setup process")
 
-	for _, step := range e.Steps {
-		logrus.Printf("Setup-Step: %s", step.GetStepDescription())
+	completedSteps, err := e.getCompletedSteps(ctx)
+	if err != nil {
+		return err, "Reading the setup progress"
+	}
+
+	err = e.restoreRollbackStates(ctx)
+	if err != nil {
+		return err, "Reading the rollback state of the setup"
+	}
 
-		err := step.PerformSetupStep(ctx)
+	statusTracker := e.StatusTracker
+	if statusTracker == nil {
+		statusTracker = NewStatusTracker()
+	}
+	statusTracker.Reset(e.Steps)
+
+	for i := 0; i < len(e.Steps); {
+		if groupEnd := dependentGroupEnd(e.Steps, i); groupEnd > i {
+			err, errCausingAction := e.performDependentSteps(ctx, i, groupEnd, completedSteps, statusTracker)
+			if err != nil {
+				return err, errCausingAction
+			}
+			i = groupEnd
+			continue
+		}
+
+		err := e.performStep(ctx, i, completedSteps, statusTracker)
 		if err != nil {
-			return fmt.Errorf("failed to perform step [%s]: %w", step.GetStepDescription(), err), step.GetStepDescription()
+			return err, e.Steps[i].GetStepDescription()
 		}
+		i++
 	}
 
 	return nil, ""
 }
 
+func (e *Executor) performStep(ctx context.Context, index int, completedSteps map[string]bool, statusTracker *StatusTracker) error {
+	step := e.Steps[index]
+	if completedSteps[step.GetStepID()] && !isRepeatable(step) {
+		logrus.Printf("Skipping already completed Setup-Step: %s", step.GetStepDescription())
+		statusTracker.StepSucceeded(index)
+		return nil
+	}
+
+	logrus.Printf("Setup-Step: %s", step.GetStepDescription())
+	statusTracker.StepStarted(index)
+	e.recordPerformedStep(index)
+
+	err := step.PerformSetupStep(ctx)
+	// failed steps may have created some of their resources as well
+	err = errors.Join(err, e.saveRollbackState(ctx, step))
+	if err == nil {
+		err = e.markStepCompleted(ctx, step)
+	}
+	if err != nil {
+		statusTracker.StepFailed(index, err)
+		return fmt.Errorf("failed to perform step [%s]: %w", step.GetStepDescription(), err)
+	}
+
+	statusTracker.StepSucceeded(index)
+	return nil
+}
+
+func (e *Executor) recordPerformedStep(index int) {
+	e.performedStepsMutex.Lock()
+	defer e.performedStepsMutex.Unlock()
+
+	if !slices.Contains(e.performedSteps, index) {
+		e.performedSteps = append(e.performedSteps, index)
+	}
+}
+
+// RollbackSetup removes the resources created by the steps this executor or a previous setup run performed, in
+// reverse order of the steps. The rollback data of previous runs is restored from the RollbackStore, so that the setup
+// can be rolled back after a restart. Failed steps are included as they may have created some of their resources. The
+// rollback continues after errors and returns all of them at once. Steps which could not be rolled back are kept so
+// that the rollback can be repeated.
+func (e *Executor) RollbackSetup(ctx context.Context) error {
+	err := e.restoreRollbackStates(ctx)
+	if err != nil {
+		return err
+	}
+
+	e.performedStepsMutex.Lock()
+	defer e.performedStepsMutex.Unlock()
+
+	performedSteps := slices.Clone(e.performedSteps)
+	slices.Sort(performedSteps)
+
+	var errs []error
+	var notRolledBack []int
+	for _, index := range slices.Backward(performedSteps) {
+		step := e.Steps[index]
+		rollback, ok := unwrapStep(step).(rollbackStep)
+		if !ok {
+			continue
+		}
+
+		logrus.Printf("Rollback Setup-Step: %s", step.GetStepDescription())
+		err := rollback.RollbackSetupStep(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to roll back step [%s]: %w", step.GetStepDescription(), err))
+			notRolledBack = append(notRolledBack, index)
+			// the step may have rolled back some of its changes
+			err = e.saveRollbackState(ctx, step)
+		} else {
+			err = e.removeRollbackState(ctx, step)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	e.performedSteps = notRolledBack
+	return errors.Join(errs...)
+}
+
+// unwrapStep returns the step wrapped by the scheduler or by a pipeline hook or the given step if it is not wrapped.
+func unwrapStep(step ExecutorStep) ExecutorStep {
+	switch wrapper := step.(type) {
+	case *scheduledStep:
+		return unwrapStep(wrapper.ExecutorStep)
+	case *hookStep:
+		return unwrapStep(wrapper.ExecutorStep)
+	default:
+		return step
+	}
+}
+
+// PlanSetup describes the effects of all registered setup steps without performing them.
+func (e *Executor) PlanSetup() plan.Plan {
+	setupPlan := plan.Plan{Steps: []plan.Step{}}
+	for i, step := range e.Steps {
+		planStep := plan.Step{
+			Index:       i,
+			ID:          step.GetStepID(),
+			Description: step.GetStepDescription(),
+			Effect:      step.DescribeEffect(),
+		}
+		planStep.DependsOn, _ = getDependencies(step)
+		setupPlan.Steps = append(setupPlan.Steps, planStep)
+	}
+
+	return setupPlan
+}
+
+func (e *Executor) getCompletedSteps(ctx context.Context) (map[string]bool, error) {
+	completed := map[string]bool{}
+	if e.ProgressStore == nil {
+		return completed, nil
+	}
+
+	stepIDs, err := e.ProgressStore.GetCompletedSteps(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completed setup steps: %w", err)
+	}
+
+	for _, stepID := range stepIDs {
+		completed[stepID] = true
+	}
+
+	return completed, nil
+}
+
+func (e *Executor) markStepCompleted(ctx context.Context, step ExecutorStep) error {
+	if e.ProgressStore == nil || isRepeatable(step) {
+		return nil
+	}
+
+	err := e.ProgressStore.MarkStepCompleted(ctx, step.GetStepID())
+	if err != nil {
+		return fmt.Errorf("failed to record progress of step [%s]: %w", step.GetStepDescription(), err)
+	}
+
+	return nil
+}
+
+func isRepeatable(step ExecutorStep) bool {
+	repeatable, ok := step.(repeatableStep)
+	return ok && repeatable.IsRepeatable()
+}
+
 // RegisterComponentSetupSteps adds all setup steps responsible to install vital components into the ecosystem.
 func (e *Executor) RegisterComponentSetupSteps() error {
 	helmClient, err := componentHelm.NewClient(e.SetupContext.AppConfig.TargetNamespace, e.SetupContext.HelmRepositoryData, appcontext.IsDevelopmentStage(e.SetupContext.Stage), logrus.StandardLogger().Infof)
@@ -177,11 +438,29 @@ func (e *Executor) RegisterComponentSetupSteps() error {
 		return err
 	}
 
-	longhornComponentSteps := e.createLonghornSteps(componentsClient)
+	componentNames := slices.Sorted(maps.Keys(e.SetupContext.AppConfig.Components))
+	e.componentNames = componentNames
+	err = e.validateComponentAnchors(componentNames)
+	if err != nil {
+		return err
+	}
+
+	longhornComponentSteps, err := e.createLonghornSteps(componentsClient)
+	if err != nil {
+		return err
+	}
+
+	componentSteps, componentWaitSteps, err := e.createComponentSteps(componentsClient)
+	if err != nil {
+		return err
+	}
 
-	componentSteps, componentWaitSteps := e.createComponentSteps(componentsClient)
+	componentResourceManifestSteps, err := e.createResourceManifestSteps(patch.ComponentPhase)
+	if err != nil {
+		return err
+	}
 
-	componentResourcePatchStep, err := createResourcePatchStep(patch.ComponentPhase, e.SetupContext.AppConfig.ResourcePatches, e.ClusterConfig, namespace)
+	componentResourcePatchStep, err := e.createResourcePatchStep(patch.ComponentPhase, e.SetupContext.AppConfig.ResourcePatches)
 	if err != nil {
 		return fmt.Errorf("error while creating resource patch step for phase %s: %w", patch.ComponentPhase, err)
 	}
@@ -193,6 +472,7 @@ func (e *Executor) RegisterComponentSetupSteps() error {
 	e.RegisterSetupSteps(longhornComponentSteps...)
 	e.RegisterSetupSteps(componentSteps...)
 	e.RegisterSetupSteps(componentWaitSteps...)
+	e.RegisterSetupSteps(componentResourceManifestSteps...)
 	// Since this step should patch resources created in this phase, it should be executed last.
 	e.RegisterSetupSteps(componentResourcePatchStep)
 
@@ -241,7 +521,10 @@ func (e *Executor) createComponentStepsByString(componentClient componentEcoSyst
 	if err != nil {
 		return nil, fmt.Errorf("failed to split chart string %s: %w", chartStr, err)
 	}
-	helmNamespace, name := component.SplitHelmNamespaceFromChartString(fullChartName)
+	helmNamespace, name, err := component.SplitHelmNamespaceFromChartString(fullChartName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split chart string %s: %w", chartStr, err)
+	}
 
 	attributes := appcontext.ComponentAttributes{
 		Version:                 chartVersion,
@@ -281,7 +564,7 @@ func (e *Executor) createInstallHelmChartStepIfNameExists(name string, helmClien
 	return steps
 }
 
-func (e *Executor) createLonghornSteps(componentsClient componentEcoSystem.ComponentInterface) []ExecutorStep {
+func (e *Executor) createLonghornSteps(componentsClient componentEcoSystem.ComponentInterface) ([]ExecutorStep, error) {
 	var result []ExecutorStep
 	components := e.SetupContext.AppConfig.Components
 	namespace := e.SetupContext.AppConfig.TargetNamespace
@@ -294,35 +577,161 @@ func (e *Executor) createLonghornSteps(componentsClient componentEcoSystem.Compo
 		result = append(result, installStep)
 		result = append(result, waitStep)
 		delete(components, longhornComponentName)
+
+		anchoredPatchSteps, err := e.createResourcePatchSteps(patch.AfterComponentPhase(longhornComponentName))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, anchoredPatchSteps...)
 	}
 
-	return result
+	return result, nil
 }
 
-func (e *Executor) createComponentSteps(componentsClient componentEcoSystem.ComponentInterface) ([]ExecutorStep, []ExecutorStep) {
+func (e *Executor) createComponentSteps(componentsClient componentEcoSystem.ComponentInterface) ([]ExecutorStep, []ExecutorStep, error) {
 	namespace := e.SetupContext.AppConfig.TargetNamespace
 	var componentSteps []ExecutorStep
 	var waitSteps []ExecutorStep
+	var componentNames []string
 
 	for componentName, componentAttributes := range e.SetupContext.AppConfig.Components {
 		componentSteps = append(componentSteps, component.NewInstallComponentStep(componentsClient, componentName, componentAttributes, namespace))
-		waitSteps = append(waitSteps, component.NewWaitForComponentStep(componentsClient, componentName, namespace, component.TimeoutInSeconds()))
+		componentNames = append(componentNames, componentName)
+
+		// patches anchored to the component are applied before the next component is installed
+		anchoredPatchSteps, err := e.createResourcePatchSteps(patch.AfterComponentPhase(componentName))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(anchoredPatchSteps) > 0 {
+			componentSteps = append(componentSteps, component.NewWaitForComponentStep(componentsClient, componentName, namespace, component.TimeoutInSeconds()))
+			componentSteps = append(componentSteps, anchoredPatchSteps...)
+		}
+	}
+
+	// wait for all components at once so that every component which does not become ready is reported
+	if len(componentNames) > 0 {
+		waitSteps = append(waitSteps, component.NewWaitForComponentsStep(componentsClient, componentNames, namespace, component.TimeoutInSeconds()))
 	}
 
-	return componentSteps, waitSteps
+	return componentSteps, waitSteps, nil
 }
 
-func createResourcePatchStep(phase patch.Phase, patches []patch.ResourcePatch, clusterConfig *rest.Config, targetNamespace string) (*resourcePatchStep, error) {
-	resourcePatchApplier, err := patch.NewApplier(clusterConfig, targetNamespace)
+// validateComponentAnchors checks that all patches anchored to a component reference one of the given components
+// because other patches would never be applied.
+func (e *Executor) validateComponentAnchors(componentNames []string) error {
+	var errs []error
+	for _, resourcePatch := range e.SetupContext.AppConfig.ResourcePatches {
+		if resourcePatch.After == nil || resourcePatch.After.Component == "" {
+			continue
+		}
+		if !slices.Contains(componentNames, resourcePatch.After.Component) {
+			errs = append(errs, fmt.Errorf("resource patch for %s cannot be applied %s because the component is not installed by the setup", resourcePatch.Resource, resourcePatch.After))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (e *Executor) createResourcePatchStep(phase patch.Phase, patches []patch.ResourcePatch) (*resourcePatchStep, error) {
+	resourcePatchApplier, err := patch.NewApplier(e.ClusterConfig, e.SetupContext.AppConfig.TargetNamespace)
 	if err != nil {
 		return nil, err
 	}
 
 	resourcePatcher := patch.NewResourcePatcher(resourcePatchApplier)
-	componentResourcePatchStep := NewResourcePatchStep(phase, resourcePatcher, patches)
+	componentResourcePatchStep := NewResourcePatchStep(phase, resourcePatcher, patches, e.patchTemplateValues)
 	return componentResourcePatchStep, nil
 }
 
+// createResourcePatchSteps creates the step applying the resource patches of the given phase. No step is created if
+// the phase contains no patches.
+func (e *Executor) createResourcePatchSteps(phase patch.Phase) ([]ExecutorStep, error) {
+	patches := e.SetupContext.AppConfig.ResourcePatches
+	if !slices.ContainsFunc(patches, func(resourcePatch patch.ResourcePatch) bool { return resourcePatch.GetPhase() == phase }) {
+		return nil, nil
+	}
+
+	step, err := e.createResourcePatchStep(phase, patches)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource patch step for phase %s: %w", phase, err)
+	}
+
+	return []ExecutorStep{step}, nil
+}
+
+// createOptionalPhaseSteps creates the steps applying the resource manifests and afterward the resource patches of a
+// phase which is only performed if it contains manifests or patches.
+func (e *Executor) createOptionalPhaseSteps(phase patch.Phase) ([]ExecutorStep, error) {
+	manifestSteps, err := e.createResourceManifestSteps(phase)
+	if err != nil {
+		return nil, err
+	}
+
+	patchSteps, err := e.createResourcePatchSteps(phase)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(manifestSteps, patchSteps...), nil
+}
+
+// patchTemplateValues returns the values for templated and conditional resource patches. It is called when the patches
+// are applied so that values retrieved by earlier steps like the FQDN are available.
+func (e *Executor) patchTemplateValues() patch.TemplateValues {
+	values := patch.TemplateValues{Namespace: e.SetupContext.AppConfig.TargetNamespace, DoguVersions: e.doguVersions, Components: e.componentNames}
+	if e.SetupContext.SetupJsonConfiguration != nil {
+		naming := e.SetupContext.SetupJsonConfiguration.Naming
+		values.Fqdn = naming.Fqdn
+		values.Domain = naming.Domain
+		values.InternalIp = naming.InternalIp
+		values.SetupJson = setupJsonFields(e.SetupContext.SetupJsonConfiguration)
+	}
+
+	return values
+}
+
+// setupJsonFields converts the setup.json into nested maps with the JSON field names as keys.
+func setupJsonFields(setupJson *appcontext.SetupJsonConfiguration) map[string]any {
+	fields := map[string]any{}
+	rawSetupJson, err := json.Marshal(setupJson)
+	if err == nil {
+		err = json.Unmarshal(rawSetupJson, &fields)
+	}
+	if err != nil {
+		logrus.Warnf("Failed to convert setup.json for resource patches: %v", err)
+	}
+
+	return fields
+}
+
+// createResourceManifestSteps creates the step applying the resource manifests of the given phase. No step is created
+// if the phase contains no manifests.
+func (e *Executor) createResourceManifestSteps(phase patch.Phase) ([]ExecutorStep, error) {
+	manifests := e.SetupContext.AppConfig.ResourceManifests
+	if !slices.ContainsFunc(manifests, func(manifest patch.ResourceManifest) bool { return manifest.Phase == phase }) {
+		return nil, nil
+	}
+
+	step, err := e.createResourceManifestStep(phase, manifests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource manifest step for phase %s: %w", phase, err)
+	}
+
+	return []ExecutorStep{step}, nil
+}
+
+func (e *Executor) createResourceManifestStep(phase patch.Phase, manifests []patch.ResourceManifest) (*resourceManifestStep, error) {
+	resourceApplier, err := patch.NewApplier(e.ClusterConfig, e.SetupContext.AppConfig.TargetNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceFetcher := setupcore.NewResourceRegistryClient(e.SetupContext.AppVersion, e.SetupContext.DoguRegistryConfiguration)
+	manifestApplier := patch.NewResourceManifestApplier(resourceApplier, resourceFetcher)
+	return NewResourceManifestStep(phase, manifestApplier, manifests), nil
+}
+
 // RegisterDataSetupSteps adds all setup steps responsible to read, write, or verify data needed by the setup.
 func (e *Executor) RegisterDataSetupSteps(globalConfig *k8sreg.GlobalConfigRepository, doguConfigProvider *k8sreg.DoguConfigRepository) error {
 	configWriter := data.NewRegistryConfigurationWriter(globalConfig, doguConfigProvider)
@@ -337,6 +746,12 @@ func (e *Executor) RegisterDataSetupSteps(globalConfig *k8sreg.GlobalConfigRepos
 	e.RegisterSetupSteps(data.NewWriteRegistryConfigDataStep(configWriter, e.SetupContext.SetupJsonConfiguration))
 	e.RegisterSetupSteps(data.NewWriteDoguDataStep(configWriter, e.SetupContext.SetupJsonConfiguration))
 
+	dataPhaseSteps, err := e.createOptionalPhaseSteps(patch.DataPhase)
+	if err != nil {
+		return err
+	}
+	e.RegisterSetupSteps(dataPhaseSteps...)
+
 	return nil
 }
 
@@ -353,30 +768,75 @@ func (e *Executor) RegisterDoguInstallationSteps(ctx context.Context) error {
 	}
 
 	e.RegisterSetupSteps(doguSteps...)
+	e.doguVersions = doguStepGenerator.DoguVersions()
+
+	anchoredPatchSteps, err := e.createDoguAnchorSteps(doguStepGenerator)
+	if err != nil {
+		return err
+	}
+	e.RegisterSetupSteps(anchoredPatchSteps...)
+
+	doguResourceManifestSteps, err := e.createResourceManifestSteps(patch.DoguPhase)
+	if err != nil {
+		return err
+	}
 
-	doguResourcePatchStep, err := createResourcePatchStep(patch.DoguPhase, e.SetupContext.AppConfig.ResourcePatches, e.ClusterConfig, e.SetupContext.AppConfig.TargetNamespace)
+	doguResourcePatchStep, err := e.createResourcePatchStep(patch.DoguPhase, e.SetupContext.AppConfig.ResourcePatches)
 	if err != nil {
 		return fmt.Errorf("failed to create resource patch step for phase %s: %w", patch.DoguPhase, err)
 	}
 
+	e.RegisterSetupSteps(doguResourceManifestSteps...)
 	// Since this step should patch resources created in this phase, it should be executed last.
 	e.RegisterSetupSteps(doguResourcePatchStep)
 
 	return nil
 }
 
+// createDoguAnchorSteps creates the steps applying the patches anchored to a dogu as soon as the dogu is ready. The
+// steps are performed concurrently with the installation of the other dogus.
+func (e *Executor) createDoguAnchorSteps(doguStepGenerator *doguStepGenerator) ([]ExecutorStep, error) {
+	var steps []ExecutorStep
+	var errs []error
+	anchoredDogus := map[string]bool{}
+	for _, resourcePatch := range e.SetupContext.AppConfig.ResourcePatches {
+		if resourcePatch.After == nil || resourcePatch.After.Dogu == "" || anchoredDogus[resourcePatch.After.Dogu] {
+			continue
+		}
+		doguName := resourcePatch.After.Dogu
+		anchoredDogus[doguName] = true
+
+		var waitStepID string
+		var err error
+		steps, waitStepID, err = doguStepGenerator.AppendWaitStep(doguName, steps)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("resource patch for %s cannot be applied %s: %w", resourcePatch.Resource, resourcePatch.After, err))
+			continue
+		}
+
+		patchStep, err := e.createResourcePatchStep(patch.AfterDoguPhase(doguName), e.SetupContext.AppConfig.ResourcePatches)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create resource patch step for phase %s: %w", patch.AfterDoguPhase(doguName), err)
+		}
+		steps = append(steps, newScheduledStep(patchStep, waitStepID))
+	}
+
+	return steps, errors.Join(errs...)
+}
+
 // RegisterLoadBalancerFQDNRetrieverSteps registers the steps for creating a loadbalancer retrieving the fqdn
 func (e *Executor) RegisterLoadBalancerFQDNRetrieverSteps() error {
 	namespace := e.SetupContext.AppConfig.TargetNamespace
 	config := e.SetupContext.SetupJsonConfiguration
 	e.RegisterSetupSteps(data.NewCreateLoadBalancerStep(config, e.ClientSet, namespace))
 
-	loadbalancerResourcePatchStep, err := createResourcePatchStep(
-		patch.LoadbalancerPhase,
-		e.SetupContext.AppConfig.ResourcePatches,
-		e.ClusterConfig,
-		namespace,
-	)
+	loadbalancerResourceManifestSteps, err := e.createResourceManifestSteps(patch.LoadbalancerPhase)
+	if err != nil {
+		return err
+	}
+	e.RegisterSetupSteps(loadbalancerResourceManifestSteps...)
+
+	loadbalancerResourcePatchStep, err := e.createResourcePatchStep(patch.LoadbalancerPhase, e.SetupContext.AppConfig.ResourcePatches)
 	if err != nil {
 		return fmt.Errorf("failed to create resource patch step for phase %s: %w", patch.LoadbalancerPhase, err)
 	}
@@ -396,19 +856,130 @@ func (e *Executor) RegisterLoadBalancerFQDNRetrieverSteps() error {
 
 // RegisterValidationStep registers all validation steps
 func (e *Executor) RegisterValidationStep() error {
-	e.RegisterSetupSteps(NewValidatorStep(e.Repository, e.SetupContext))
+	preValidationSteps, err := e.createOptionalPhaseSteps(patch.PreValidationPhase)
+	if err != nil {
+		return err
+	}
+	e.RegisterSetupSteps(preValidationSteps...)
+
+	resourcePatchApplier, err := patch.NewApplier(e.ClusterConfig, e.SetupContext.AppConfig.TargetNamespace)
+	if err != nil {
+		return err
+	}
+
+	var tagResolver chartTagResolver
+	if e.SetupContext.HelmRepositoryData != nil {
+		tagResolver, err = component.NewHelmChartTagResolver(e.SetupContext.AppConfig.TargetNamespace, e.SetupContext.HelmRepositoryData, e.ClusterConfig, appcontext.IsDevelopmentStage(e.SetupContext.Stage), logrus.StandardLogger().Infof)
+		if err != nil {
+			return fmt.Errorf("failed to create helm chart tag resolver: %w", err)
+		}
+	}
+
+	e.RegisterSetupSteps(NewValidatorStep(e.Repository, e.SetupContext, patch.NewResourcePatcher(resourcePatchApplier), tagResolver, e.patchTemplateValues))
+
+	if IsUserBackendCheckEnabled() && e.SetupContext.SetupJsonConfiguration.UserBackend.DsType == validation.DsTypeExternal {
+		e.RegisterSetupSteps(NewUserBackendConnectionStep(e.SetupContext))
+	}
+	if IsSmtpCheckEnabled() {
+		e.RegisterSetupSteps(NewSmtpRelayStep(e.SetupContext, IsSmtpTestMailEnabled()))
+	}
 	return nil
 }
 
 // RegisterSSLGenerationStep registers all ssl steps
 func (e *Executor) RegisterSSLGenerationStep() error {
-	generationStep := data.NewGenerateSSLStep(e.SetupContext.SetupJsonConfiguration)
+	secretClient := e.ClientSet.CoreV1().Secrets(e.SetupContext.AppConfig.TargetNamespace)
+	generationStep := data.NewGenerateSSLStep(e.SetupContext.SetupJsonConfiguration, secretClient)
 	e.RegisterSetupSteps(generationStep)
 	return nil
 }
 
+// RegisterPreflightStep registers the step which checks the cluster before any resource is created.
+func (e *Executor) RegisterPreflightStep() error {
+	preflightStep, err := NewPreflightStep(e.ClientSet, e.SetupContext.AppConfig)
+	if err != nil {
+		return err
+	}
+
+	e.RegisterSetupSteps(preflightStep)
+	return nil
+}
+
 func (e *Executor) RegisterDisableDefaultSAAutomountStep() error {
 	namespace := e.SetupContext.AppConfig.TargetNamespace
 	e.RegisterSetupSteps(data.NewDisableDefaultSAAutomountStep(e.ClientSet, namespace))
 	return nil
 }
+
+// RegisterPostSetupSteps registers the steps applying the resource manifests and patches after all other steps.
+func (e *Executor) RegisterPostSetupSteps() error {
+	postSetupSteps, err := e.createOptionalPhaseSteps(patch.PostSetupPhase)
+	if err != nil {
+		return err
+	}
+
+	e.RegisterSetupSteps(postSetupSteps...)
+	return nil
+}
+
+// restoreRollbackStates restores the rollback data of the steps which were performed by a previous setup run and
+// records them as performed, so that they are rolled back together with the steps of this executor.
+func (e *Executor) restoreRollbackStates(ctx context.Context) error {
+	if e.RollbackStore == nil {
+		return nil
+	}
+
+	states, err := e.RollbackStore.GetRollbackStates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get rollback state of setup steps: %w", err)
+	}
+
+	e.performedStepsMutex.Lock()
+	defer e.performedStepsMutex.Unlock()
+
+	for index, step := range e.Steps {
+		state, ok := states[step.GetStepID()]
+		rollback, isRollbackStep := unwrapStep(step).(rollbackStep)
+		if !ok || !isRollbackStep || slices.Contains(e.performedSteps, index) {
+			continue
+		}
+
+		err = rollback.SetRollbackState(state)
+		if err != nil {
+			return fmt.Errorf("failed to restore rollback state of step [%s]: %w", step.GetStepDescription(), err)
+		}
+		e.performedSteps = append(e.performedSteps, index)
+	}
+
+	return nil
+}
+
+func (e *Executor) saveRollbackState(ctx context.Context, step ExecutorStep) error {
+	rollback, ok := unwrapStep(step).(rollbackStep)
+	if e.RollbackStore == nil || !ok {
+		return nil
+	}
+
+	state, err := rollback.GetRollbackState()
+	if err == nil {
+		err = e.RollbackStore.SaveRollbackState(ctx, step.GetStepID(), state)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record rollback state of step [%s]: %w", step.GetStepDescription(), err)
+	}
+
+	return nil
+}
+
+func (e *Executor) removeRollbackState(ctx context.Context, step ExecutorStep) error {
+	if e.RollbackStore == nil {
+		return nil
+	}
+
+	err := e.RollbackStore.RemoveRollbackState(ctx, step.GetStepID())
+	if err != nil {
+		return fmt.Errorf("failed to remove rollback state of step [%s]: %w", step.GetStepDescription(), err)
+	}
+
+	return nil
+}
@@ -2,13 +2,18 @@ package setup
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"testing"
 
 	"github.com/cloudogu/cesapp-lib/core"
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/dogus"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 	componentOpConfig "github.com/cloudogu/k8s-component-operator/pkg/config"
+	"github.com/cloudogu/k8s-dogu-operator/v2/api/ecoSystem"
 
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
@@ -32,10 +37,18 @@ func newSimpleSetupStep(description string, errorOnPerform bool) *mySimpleSetupS
 	}
 }
 
+func (m *mySimpleSetupStep) GetStepID() string {
+	return m.Description
+}
+
 func (m *mySimpleSetupStep) GetStepDescription() string {
 	return m.Description
 }
 
+func (m *mySimpleSetupStep) DescribeEffect() plan.Effect {
+	return plan.Effect{Action: plan.ActionCreate, Kind: "Simple", Targets: []string{m.Description}}
+}
+
 func (m *mySimpleSetupStep) PerformSetupStep(context.Context) error {
 	if m.ErrorOnPerform {
 		return errors.New("failed to do nothing")
@@ -45,6 +58,33 @@ func (m *mySimpleSetupStep) PerformSetupStep(context.Context) error {
 	return nil
 }
 
+type myRollbackSetupStep struct {
+	*mySimpleSetupStep
+	rolledBack      *[]string
+	errorOnRollback bool
+}
+
+func newRollbackSetupStep(description string, errorOnPerform bool, rolledBack *[]string) *myRollbackSetupStep {
+	return &myRollbackSetupStep{mySimpleSetupStep: newSimpleSetupStep(description, errorOnPerform), rolledBack: rolledBack}
+}
+
+func (m *myRollbackSetupStep) RollbackSetupStep(context.Context) error {
+	if m.errorOnRollback {
+		return errors.New("failed to undo nothing")
+	}
+
+	*m.rolledBack = append(*m.rolledBack, m.Description)
+	return nil
+}
+
+func (m *myRollbackSetupStep) GetRollbackState() (json.RawMessage, error) {
+	return json.Marshal(m.PerformedStep)
+}
+
+func (m *myRollbackSetupStep) SetRollbackState(state json.RawMessage) error {
+	return json.Unmarshal(state, &m.PerformedStep)
+}
+
 func TestNewExecutor(t *testing.T) {
 	t.Parallel()
 
@@ -151,6 +191,289 @@ func TestExecutor_PerformSetup(t *testing.T) {
 	})
 }
 
+func TestExecutor_PerformSetup_resume(t *testing.T) {
+	t.Run("should skip completed steps and record newly completed steps", func(t *testing.T) {
+		// given
+		step1 := newSimpleSetupStep("Step1", false)
+		step2 := newSimpleSetupStep("Step2", false)
+		step3 := newSimpleSetupStep("Step3", false)
+
+		progressStoreMock := NewMockStepProgressStore(t)
+		progressStoreMock.EXPECT().GetCompletedSteps(testCtx).Return([]string{"Step1", "Step2"}, nil)
+		progressStoreMock.EXPECT().MarkStepCompleted(testCtx, "Step3").Return(nil)
+
+		executor := Executor{ProgressStore: progressStoreMock}
+		executor.RegisterSetupSteps(step1, step2, step3)
+
+		// when
+		err, _ := executor.PerformSetup(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, step1.PerformedStep)
+		assert.False(t, step2.PerformedStep)
+		assert.True(t, step3.PerformedStep)
+	})
+
+	t.Run("should always perform repeatable steps without recording them", func(t *testing.T) {
+		// given
+		step1 := &myRepeatableSetupStep{mySimpleSetupStep: newSimpleSetupStep("Step1", false)}
+
+		progressStoreMock := NewMockStepProgressStore(t)
+		progressStoreMock.EXPECT().GetCompletedSteps(testCtx).Return([]string{"Step1"}, nil)
+
+		executor := Executor{ProgressStore: progressStoreMock}
+		executor.RegisterSetupSteps(step1)
+
+		// when
+		err, _ := executor.PerformSetup(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, step1.PerformedStep)
+	})
+
+	t.Run("should fail to get completed steps", func(t *testing.T) {
+		// given
+		step1 := newSimpleSetupStep("Step1", false)
+
+		progressStoreMock := NewMockStepProgressStore(t)
+		progressStoreMock.EXPECT().GetCompletedSteps(testCtx).Return(nil, assert.AnError)
+
+		executor := Executor{ProgressStore: progressStoreMock}
+		executor.RegisterSetupSteps(step1)
+
+		// when
+		err, _ := executor.PerformSetup(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to get completed setup steps")
+		assert.False(t, step1.PerformedStep)
+	})
+
+	t.Run("should fail to record completed step", func(t *testing.T) {
+		// given
+		step1 := newSimpleSetupStep("Step1", false)
+		step2 := newSimpleSetupStep("Step2", false)
+
+		progressStoreMock := NewMockStepProgressStore(t)
+		progressStoreMock.EXPECT().GetCompletedSteps(testCtx).Return(nil, nil)
+		progressStoreMock.EXPECT().MarkStepCompleted(testCtx, "Step1").Return(assert.AnError)
+
+		executor := Executor{ProgressStore: progressStoreMock}
+		executor.RegisterSetupSteps(step1, step2)
+
+		// when
+		err, cause := executor.PerformSetup(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to record progress of step [Step1]")
+		assert.Equal(t, "Step1", cause)
+		assert.False(t, step2.PerformedStep)
+	})
+}
+
+func TestExecutor_PerformSetup_status(t *testing.T) {
+	t.Run("should track the state of all steps", func(t *testing.T) {
+		// given
+		step1 := newSimpleSetupStep("Step1", false)
+		step2 := newSimpleSetupStep("Step2", false)
+		step3 := newSimpleSetupStep("Step3", true)
+		step4 := newSimpleSetupStep("Step4", false)
+
+		progressStoreMock := NewMockStepProgressStore(t)
+		progressStoreMock.EXPECT().GetCompletedSteps(testCtx).Return([]string{"Step1"}, nil)
+		progressStoreMock.EXPECT().MarkStepCompleted(testCtx, "Step2").Return(nil)
+
+		statusTracker := NewStatusTracker()
+		executor := Executor{ProgressStore: progressStoreMock, StatusTracker: statusTracker}
+		executor.RegisterSetupSteps(step1, step2, step3, step4)
+
+		// when
+		err, _ := executor.PerformSetup(testCtx)
+
+		// then
+		require.Error(t, err)
+		steps := statusTracker.GetStatus().Steps
+		require.Len(t, steps, 4)
+		assert.Equal(t, StepStateSucceeded, steps[0].State)
+		assert.Nil(t, steps[0].StartTime)
+		assert.Equal(t, StepStateSucceeded, steps[1].State)
+		assert.NotNil(t, steps[1].StartTime)
+		assert.NotNil(t, steps[1].EndTime)
+		assert.Equal(t, StepStateFailed, steps[2].State)
+		assert.Equal(t, "failed to do nothing", steps[2].Error)
+		assert.Equal(t, StepStatePending, steps[3].State)
+		assert.Equal(t, "Step4", steps[3].Description)
+	})
+}
+
+func TestExecutor_PlanSetup(t *testing.T) {
+	t.Run("should describe all registered steps without performing them", func(t *testing.T) {
+		// given
+		step1 := newSimpleSetupStep("Step1", false)
+		step2 := newSimpleSetupStep("Step2", false)
+		executor := Executor{}
+		executor.RegisterSetupSteps(step1, step2)
+
+		// when
+		actual := executor.PlanSetup()
+
+		// then
+		require.Len(t, actual.Steps, 2)
+		assert.Equal(t, plan.Step{
+			Index:       1,
+			ID:          "Step2",
+			Description: "Step2",
+			Effect:      plan.Effect{Action: plan.ActionCreate, Kind: "Simple", Targets: []string{"Step2"}},
+		}, actual.Steps[1])
+		assert.False(t, step1.PerformedStep)
+		assert.False(t, step2.PerformedStep)
+	})
+
+	t.Run("should return empty plan without steps", func(t *testing.T) {
+		// when
+		actual := (&Executor{}).PlanSetup()
+
+		// then
+		assert.NotNil(t, actual.Steps)
+		assert.Empty(t, actual.Steps)
+	})
+}
+
+type myRepeatableSetupStep struct {
+	*mySimpleSetupStep
+}
+
+func (m *myRepeatableSetupStep) IsRepeatable() bool {
+	return true
+}
+
+func TestExecutor_RollbackSetup(t *testing.T) {
+	t.Run("should roll back performed steps in reverse order", func(t *testing.T) {
+		// given
+		var rolledBack []string
+		executor := Executor{StepConcurrency: 2}
+		executor.RegisterSetupSteps(
+			newRollbackSetupStep("Step1", false, &rolledBack),
+			newSimpleSetupStep("Step2", false),
+			newScheduledStep(newRollbackSetupStep("Step3", false, &rolledBack)),
+			newScheduledStep(newRollbackSetupStep("Step4", true, &rolledBack)),
+			newScheduledStep(newRollbackSetupStep("Step5", false, &rolledBack), "Step4"),
+			newRollbackSetupStep("Step6", false, &rolledBack),
+		)
+		err, _ := executor.PerformSetup(testCtx)
+		require.Error(t, err)
+
+		// when
+		err = executor.RollbackSetup(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Step4", "Step3", "Step1"}, rolledBack)
+	})
+
+	t.Run("should continue after errors and keep the failed steps for another rollback", func(t *testing.T) {
+		// given
+		var rolledBack []string
+		failingStep := newRollbackSetupStep("Step2", false, &rolledBack)
+		failingStep.errorOnRollback = true
+		executor := Executor{}
+		executor.RegisterSetupSteps(
+			newRollbackSetupStep("Step1", false, &rolledBack),
+			failingStep,
+			newRollbackSetupStep("Step3", false, &rolledBack),
+		)
+		err, _ := executor.PerformSetup(testCtx)
+		require.NoError(t, err)
+
+		// when
+		err = executor.RollbackSetup(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to roll back step [Step2]: failed to undo nothing")
+		assert.Equal(t, []string{"Step3", "Step1"}, rolledBack)
+
+		failingStep.errorOnRollback = false
+		err = executor.RollbackSetup(testCtx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Step3", "Step1", "Step2"}, rolledBack)
+	})
+
+	t.Run("should not roll back steps of a previous run without recorded rollback state", func(t *testing.T) {
+		// given
+		var rolledBack []string
+		progressStoreMock := NewMockStepProgressStore(t)
+		progressStoreMock.EXPECT().GetCompletedSteps(testCtx).Return([]string{"Step1"}, nil)
+		progressStoreMock.EXPECT().MarkStepCompleted(testCtx, "Step2").Return(nil)
+		executor := Executor{ProgressStore: progressStoreMock}
+		executor.RegisterSetupSteps(newRollbackSetupStep("Step1", false, &rolledBack), newRollbackSetupStep("Step2", false, &rolledBack))
+		err, _ := executor.PerformSetup(testCtx)
+		require.NoError(t, err)
+
+		// when
+		err = executor.RollbackSetup(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Step2"}, rolledBack)
+	})
+
+	t.Run("should roll back steps of a previous run after a restart", func(t *testing.T) {
+		// given
+		clientSet := fake.NewClientset()
+		store := NewConfigMapStepProgressStore(clientSet, "test")
+		var rolledBack []string
+		previousExecutor := Executor{ProgressStore: store, RollbackStore: store}
+		previousExecutor.RegisterSetupSteps(newRollbackSetupStep("Step1", false, &rolledBack), newRollbackSetupStep("Step2", true, &rolledBack))
+		err, _ := previousExecutor.PerformSetup(testCtx)
+		require.Error(t, err)
+
+		executor := Executor{ProgressStore: store, RollbackStore: store}
+		step1 := newRollbackSetupStep("Step1", false, &rolledBack)
+		executor.RegisterSetupSteps(step1, newRollbackSetupStep("Step2", false, &rolledBack))
+
+		// when
+		err = executor.RollbackSetup(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Step2", "Step1"}, rolledBack)
+		assert.True(t, step1.PerformedStep)
+		actual, err := store.GetRollbackStates(testCtx)
+		require.NoError(t, err)
+		assert.Empty(t, actual)
+	})
+
+	t.Run("should roll back completed steps of an interrupted run after resuming", func(t *testing.T) {
+		// given
+		clientSet := fake.NewClientset()
+		store := NewConfigMapStepProgressStore(clientSet, "test")
+		var rolledBack []string
+		previousExecutor := Executor{ProgressStore: store, RollbackStore: store}
+		previousExecutor.RegisterSetupSteps(newRollbackSetupStep("Step1", false, &rolledBack), newRollbackSetupStep("Step2", true, &rolledBack))
+		err, _ := previousExecutor.PerformSetup(testCtx)
+		require.Error(t, err)
+
+		executor := Executor{ProgressStore: store, RollbackStore: store}
+		step1 := newRollbackSetupStep("Step1", false, &rolledBack)
+		executor.RegisterSetupSteps(step1, newRollbackSetupStep("Step2", false, &rolledBack))
+		err, _ = executor.PerformSetup(testCtx)
+		require.NoError(t, err)
+		require.True(t, step1.PerformedStep)
+
+		// when
+		err = executor.RollbackSetup(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Step2", "Step1"}, rolledBack)
+	})
+}
+
 func TestExecutor_RegisterFQDNRetrieverStep(t *testing.T) {
 	t.Run("successfully register 3 FQDN retriever steps with empty fqdn", func(t *testing.T) {
 		// given
@@ -202,6 +525,32 @@ func TestExecutor_RegisterFQDNRetrieverStep(t *testing.T) {
 		assert.Equal(t, "Creating the main loadbalancer service for the Cloudogu EcoSystem", executor.Steps[0].GetStepDescription())
 		assert.Equal(t, "Patching kubernetes resources in phase loadbalancer", executor.Steps[1].GetStepDescription())
 	})
+	t.Run("successfully register resource manifest step before resource patch step", func(t *testing.T) {
+		// given
+		appConfig := &appcontext.Config{
+			TargetNamespace: "test",
+			ResourceManifests: []patch.ResourceManifest{
+				{Phase: patch.LoadbalancerPhase, Manifest: "kind: ConfigMap"},
+				{Phase: patch.DoguPhase, URL: "https://example.com/manifest.yaml"},
+			},
+		}
+		testContext := &appcontext.SetupContext{SetupJsonConfiguration: &appcontext.SetupJsonConfiguration{Naming: appcontext.Naming{Fqdn: "ecosystem.example.com"}}, AppConfig: appConfig}
+		executor := &Executor{
+			ClusterConfig: &rest.Config{},
+			SetupContext:  testContext,
+		}
+
+		// when
+		err := executor.RegisterLoadBalancerFQDNRetrieverSteps()
+
+		// then
+		require.NoError(t, err)
+		assert.Len(t, executor.Steps, 3)
+		assert.Equal(t, "Creating the main loadbalancer service for the Cloudogu EcoSystem", executor.Steps[0].GetStepDescription())
+		assert.Equal(t, "Applying kubernetes resource manifests in phase loadbalancer", executor.Steps[1].GetStepDescription())
+		assert.Equal(t, []string{"inline manifest"}, executor.Steps[1].DescribeEffect().Targets)
+		assert.Equal(t, "Patching kubernetes resources in phase loadbalancer", executor.Steps[2].GetStepDescription())
+	})
 }
 
 func TestExecutor_RegisterComponentSetupSteps(t *testing.T) {
@@ -258,6 +607,29 @@ func TestExecutor_RegisterComponentSetupSteps(t *testing.T) {
 		assert.Equal(t, "Wait for component with selector app.kubernetes.io/name=k8s-longhorn to be ready", executor.Steps[7].GetStepDescription())
 	})
 
+	t.Run("should wait for all components with a single step", func(t *testing.T) {
+		// given
+		components := map[string]appcontext.ComponentAttributes{"k8s-dogu-operator": {}, "k8s-service-discovery": {}}
+
+		testContext := &appcontext.SetupContext{
+			AppConfig:          &appcontext.Config{TargetNamespace: "test", Components: components, ComponentOperatorChart: "k8s/k8s-component-operator:2.0.0", ComponentOperatorCrdChart: "k8s/k8s-component-operator-crd:2.0.0"},
+			HelmRepositoryData: &componentOpConfig.HelmRepositoryData{Endpoint: "https://helm.repo"},
+		}
+		executor := &Executor{
+			ClusterConfig: &rest.Config{},
+			SetupContext:  testContext,
+		}
+
+		// when
+		err := executor.RegisterComponentSetupSteps()
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, executor.Steps, 10)
+		assert.Equal(t, "Wait for components k8s-dogu-operator, k8s-service-discovery to be ready", executor.Steps[8].GetStepDescription())
+		assert.Equal(t, "wait-for-components/k8s-dogu-operator,k8s-service-discovery", executor.Steps[8].GetStepID())
+	})
+
 	t.Run("should install cert-manager always before the component-operator", func(t *testing.T) {
 		// given
 		components := map[string]appcontext.ComponentAttributes{"k8s-cert-manager": {HelmRepositoryNamespace: "k8s", Version: "1.0.0"}, "k8s-cert-manager-crd": {HelmRepositoryNamespace: "k8s", Version: "1.0.0"}}
@@ -306,6 +678,58 @@ func TestExecutor_RegisterComponentSetupSteps(t *testing.T) {
 		assert.Equal(t, "Install component-chart from k8s/k8s-component-operator:2.0.0 in namespace test", executor.Steps[3].GetStepDescription())
 	})
 
+	t.Run("should apply patches anchored to a component right after it is ready", func(t *testing.T) {
+		// given
+		components := map[string]appcontext.ComponentAttributes{"k8s-dogu-operator": {}, "k8s-longhorn": {Version: "1.0.0", HelmRepositoryNamespace: "k8s"}}
+		patches := []patch.ResourcePatch{
+			{After: &patch.PatchAnchor{Component: "k8s-longhorn"}, Resource: patch.ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "longhorn-default-setting"}, Patches: []patch.JsonPatch{{Operation: "add", Path: "/data/x", Value: "y"}}},
+			{After: &patch.PatchAnchor{Component: "k8s-dogu-operator"}, Resource: patch.ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "dogu-operator"}, Patches: []patch.JsonPatch{{Operation: "add", Path: "/data/x", Value: "y"}}},
+		}
+		testContext := &appcontext.SetupContext{
+			AppConfig:          &appcontext.Config{TargetNamespace: "test", Components: components, ResourcePatches: patches, ComponentOperatorChart: "k8s/k8s-component-operator:2.0.0", ComponentOperatorCrdChart: "k8s/k8s-component-operator-crd:2.0.0"},
+			HelmRepositoryData: &componentOpConfig.HelmRepositoryData{Endpoint: "https://helm.repo"},
+		}
+		executor := &Executor{
+			ClusterConfig: &rest.Config{},
+			SetupContext:  testContext,
+		}
+
+		// when
+		err := executor.RegisterComponentSetupSteps()
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, executor.Steps, 14)
+		assert.Equal(t, "wait-for-component/k8s-longhorn", executor.Steps[7].GetStepID())
+		assert.Equal(t, "resource-patch/after-component/k8s-longhorn", executor.Steps[8].GetStepID())
+		assert.Equal(t, "install-component/k8s-dogu-operator", executor.Steps[9].GetStepID())
+		assert.Equal(t, "wait-for-component/k8s-dogu-operator", executor.Steps[10].GetStepID())
+		assert.Equal(t, "resource-patch/after-component/k8s-dogu-operator", executor.Steps[11].GetStepID())
+		assert.Equal(t, "wait-for-components/k8s-dogu-operator", executor.Steps[12].GetStepID())
+		assert.Equal(t, "resource-patch/component", executor.Steps[13].GetStepID())
+	})
+
+	t.Run("should fail for patch anchored to a component which is not installed", func(t *testing.T) {
+		// given
+		patches := []patch.ResourcePatch{{After: &patch.PatchAnchor{Component: "k8s-longhorn"}, Resource: patch.ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "longhorn-default-setting"}}}
+		testContext := &appcontext.SetupContext{
+			AppConfig:          &appcontext.Config{TargetNamespace: "test", ResourcePatches: patches, ComponentOperatorChart: "k8s/k8s-component-operator:2.0.0", ComponentOperatorCrdChart: "k8s/k8s-component-operator-crd:2.0.0"},
+			HelmRepositoryData: &componentOpConfig.HelmRepositoryData{Endpoint: "https://helm.repo"},
+		}
+		executor := &Executor{
+			ClusterConfig: &rest.Config{},
+			SetupContext:  testContext,
+		}
+
+		// when
+		err := executor.RegisterComponentSetupSteps()
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "resource patch for v1/ConfigMap longhorn-default-setting cannot be applied after component 'k8s-longhorn' because the component is not installed by the setup")
+		assert.Empty(t, executor.Steps)
+	})
+
 	t.Run("failed to create ecosystem-client", func(t *testing.T) {
 		// given
 		testContext := &appcontext.SetupContext{
@@ -447,4 +871,242 @@ func TestExecutor_appendComponentStepsForComponentOperator(t *testing.T) {
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "failed to split chart string k8s/component-op")
 	})
+
+	t.Run("should fail if component op chart has no helm repository namespace", func(t *testing.T) {
+		// given
+		executor := Executor{SetupContext: &appcontext.SetupContext{AppConfig: &appcontext.Config{ComponentOperatorCrdChart: "component-op-crd:1.0.0", ComponentOperatorChart: "k8s/component-op:1.0.0"}}}
+
+		// when
+		_, err := executor.appendComponentStepsForComponentOperator(nil)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to split chart string component-op-crd:1.0.0")
+		assert.ErrorContains(t, err, "Must be '<helmRepositoryNamespace>/<chartName>'")
+	})
+}
+
+func TestExecutor_createDoguAnchorSteps(t *testing.T) {
+	ecoSystemClient, err := ecoSystem.NewForConfig(&rest.Config{})
+	require.NoError(t, err)
+	anchoredPatch := func(doguName string) patch.ResourcePatch {
+		return patch.ResourcePatch{After: &patch.PatchAnchor{Dogu: doguName}, Resource: patch.ResourceReference{ApiVersion: "apps/v1", Kind: "Deployment", Name: doguName}}
+	}
+
+	t.Run("should wait for the dogu and patch it afterward", func(t *testing.T) {
+		// given
+		generator := &doguStepGenerator{EcoSystemClient: ecoSystemClient, namespace: "test",
+			installStepIDs: map[string]string{"ldap": "install-dogu/ldap", "cas": "install-dogu/cas"},
+			waitStepIDs:    map[string]string{dogus.CreateDoguLabelSelector("ldap"): "wait-for-dogu/ldap"}}
+		testContext := &appcontext.SetupContext{AppConfig: &appcontext.Config{TargetNamespace: "test", ResourcePatches: []patch.ResourcePatch{
+			anchoredPatch("ldap"), anchoredPatch("cas"), anchoredPatch("ldap"), {Phase: patch.DoguPhase},
+		}}}
+		executor := &Executor{ClusterConfig: &rest.Config{}, SetupContext: testContext}
+
+		// when
+		steps, err := executor.createDoguAnchorSteps(generator)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, steps, 3)
+		assert.Equal(t, "resource-patch/after-dogu/ldap", steps[0].GetStepID())
+		assert.Equal(t, []string{"wait-for-dogu/ldap"}, steps[0].(*scheduledStep).GetDependencies())
+		assert.Equal(t, []string{"apps/v1/Deployment ldap", "apps/v1/Deployment ldap"}, steps[0].DescribeEffect().Targets)
+		assert.Equal(t, "wait-for-dogu/cas", steps[1].GetStepID())
+		assert.Equal(t, []string{"install-dogu/cas"}, steps[1].(*scheduledStep).GetDependencies())
+		assert.Equal(t, "resource-patch/after-dogu/cas", steps[2].GetStepID())
+		assert.Equal(t, []string{"wait-for-dogu/cas"}, steps[2].(*scheduledStep).GetDependencies())
+	})
+
+	t.Run("should fail for patch anchored to a dogu which is not installed", func(t *testing.T) {
+		// given
+		generator := &doguStepGenerator{EcoSystemClient: ecoSystemClient, namespace: "test", installStepIDs: map[string]string{}, waitStepIDs: map[string]string{}}
+		testContext := &appcontext.SetupContext{AppConfig: &appcontext.Config{TargetNamespace: "test", ResourcePatches: []patch.ResourcePatch{anchoredPatch("redmine")}}}
+		executor := &Executor{ClusterConfig: &rest.Config{}, SetupContext: testContext}
+
+		// when
+		_, err := executor.createDoguAnchorSteps(generator)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "resource patch for apps/v1/Deployment redmine cannot be applied after dogu 'redmine': dogu redmine is not installed by the setup")
+	})
+}
+
+func TestExecutor_RegisterPostSetupSteps(t *testing.T) {
+	t.Run("should register no steps without manifests and patches", func(t *testing.T) {
+		// given
+		executor := &Executor{ClusterConfig: &rest.Config{}, SetupContext: &appcontext.SetupContext{AppConfig: &appcontext.Config{TargetNamespace: "test"}}}
+
+		// when
+		err := executor.RegisterPostSetupSteps()
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, executor.Steps)
+	})
+
+	t.Run("should register resource manifest step before resource patch step", func(t *testing.T) {
+		// given
+		testContext := &appcontext.SetupContext{AppConfig: &appcontext.Config{
+			TargetNamespace:   "test",
+			ResourceManifests: []patch.ResourceManifest{{Phase: patch.PostSetupPhase, Manifest: "kind: ConfigMap"}},
+			ResourcePatches:   []patch.ResourcePatch{{Phase: patch.PostSetupPhase}, {Phase: patch.DoguPhase}},
+		}}
+		executor := &Executor{ClusterConfig: &rest.Config{}, SetupContext: testContext}
+
+		// when
+		err := executor.RegisterPostSetupSteps()
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, executor.Steps, 2)
+		assert.Equal(t, "resource-manifest/post-setup", executor.Steps[0].GetStepID())
+		assert.Equal(t, "resource-patch/post-setup", executor.Steps[1].GetStepID())
+	})
+}
+
+func TestExecutor_RegisterValidationStep(t *testing.T) {
+	// given
+	testContext := &appcontext.SetupContext{AppConfig: &appcontext.Config{
+		TargetNamespace: "test",
+		ResourcePatches: []patch.ResourcePatch{{Phase: patch.PreValidationPhase}},
+	}}
+	executor := &Executor{ClusterConfig: &rest.Config{}, SetupContext: testContext}
+
+	// when
+	err := executor.RegisterValidationStep()
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, executor.Steps, 2)
+	assert.Equal(t, "resource-patch/pre-validation", executor.Steps[0].GetStepID())
+	assert.Equal(t, "Validating the setup configuration", executor.Steps[1].GetStepDescription())
+}
+
+func TestExecutor_RegisterValidationStep_helmRepository(t *testing.T) {
+	// given
+	testContext := &appcontext.SetupContext{
+		AppConfig:          &appcontext.Config{TargetNamespace: "test"},
+		HelmRepositoryData: &componentOpConfig.HelmRepositoryData{Endpoint: "registry.cloudogu.com", Schema: componentOpConfig.EndpointSchemaOCI},
+	}
+	executor := &Executor{ClusterConfig: &rest.Config{}, SetupContext: testContext}
+
+	// when
+	err := executor.RegisterValidationStep()
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, executor.Steps, 1)
+	assert.Equal(t, "validate-setup-configuration", executor.Steps[0].GetStepID())
+}
+
+func TestExecutor_RegisterValidationStep_userBackendCheck(t *testing.T) {
+	t.Run("should register connection check of external user backend", func(t *testing.T) {
+		// given
+		t.Setenv("USER_BACKEND_CHECK", "true")
+		testContext := &appcontext.SetupContext{
+			AppConfig:              &appcontext.Config{TargetNamespace: "test"},
+			SetupJsonConfiguration: &appcontext.SetupJsonConfiguration{UserBackend: appcontext.UserBackend{DsType: "external"}},
+		}
+		executor := &Executor{ClusterConfig: &rest.Config{}, SetupContext: testContext}
+
+		// when
+		err := executor.RegisterValidationStep()
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, executor.Steps, 2)
+		assert.Equal(t, "validate-user-backend-connection", executor.Steps[1].GetStepID())
+	})
+
+	t.Run("should not check embedded user backend", func(t *testing.T) {
+		// given
+		t.Setenv("USER_BACKEND_CHECK", "true")
+		testContext := &appcontext.SetupContext{
+			AppConfig:              &appcontext.Config{TargetNamespace: "test"},
+			SetupJsonConfiguration: &appcontext.SetupJsonConfiguration{UserBackend: appcontext.UserBackend{DsType: "embedded"}},
+		}
+		executor := &Executor{ClusterConfig: &rest.Config{}, SetupContext: testContext}
+
+		// when
+		err := executor.RegisterValidationStep()
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, executor.Steps, 1)
+	})
+}
+
+func TestExecutor_RegisterValidationStep_smtpCheck(t *testing.T) {
+	// given
+	t.Setenv("SMTP_TEST_MAIL", "true")
+	testContext := &appcontext.SetupContext{
+		AppConfig:              &appcontext.Config{TargetNamespace: "test"},
+		SetupJsonConfiguration: &appcontext.SetupJsonConfiguration{},
+	}
+	executor := &Executor{ClusterConfig: &rest.Config{}, SetupContext: testContext}
+
+	// when
+	err := executor.RegisterValidationStep()
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, executor.Steps, 2)
+	assert.Equal(t, "validate-smtp-relay", executor.Steps[1].GetStepID())
+	assert.Equal(t, "Sending a test mail over the relay host", executor.Steps[1].GetStepDescription())
+}
+
+func TestExecutor_RegisterPreflightStep(t *testing.T) {
+	t.Run("should register preflight step", func(t *testing.T) {
+		// given
+		testContext := &appcontext.SetupContext{AppConfig: &appcontext.Config{TargetNamespace: "test"}}
+		executor := &Executor{ClientSet: fake.NewClientset(), SetupContext: testContext}
+
+		// when
+		err := executor.RegisterPreflightStep()
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, executor.Steps, 1)
+		assert.Equal(t, "preflight", executor.Steps[0].GetStepID())
+	})
+
+	t.Run("should fail for invalid pre-flight configuration", func(t *testing.T) {
+		// given
+		testContext := &appcontext.SetupContext{AppConfig: &appcontext.Config{Preflight: appcontext.Preflight{MinCPU: "many"}}}
+		executor := &Executor{ClientSet: fake.NewClientset(), SetupContext: testContext}
+
+		// when
+		err := executor.RegisterPreflightStep()
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to create pre-flight checker: invalid pre-flight configuration: minCpu 'many' is invalid")
+		assert.Empty(t, executor.Steps)
+	})
+}
+
+func TestExecutor_patchTemplateValues(t *testing.T) {
+	// given
+	testContext := &appcontext.SetupContext{
+		AppConfig:              &appcontext.Config{TargetNamespace: "ecosystem"},
+		SetupJsonConfiguration: &appcontext.SetupJsonConfiguration{Naming: appcontext.Naming{Fqdn: "<<ip>>", Domain: "example.com", InternalIp: "10.0.0.1"}},
+	}
+	executor := &Executor{SetupContext: testContext, doguVersions: map[string]string{"ldap": "2.1.0-1"}, componentNames: []string{"k8s-longhorn"}}
+	// the FQDN is retrieved from the loadbalancer after the step was created
+	testContext.SetupJsonConfiguration.Naming.Fqdn = "192.168.56.2"
+
+	// when
+	actual := executor.patchTemplateValues()
+
+	// then
+	assert.Equal(t, "ecosystem", actual.Namespace)
+	assert.Equal(t, "192.168.56.2", actual.Fqdn)
+	assert.Equal(t, "example.com", actual.Domain)
+	assert.Equal(t, "10.0.0.1", actual.InternalIp)
+	assert.Equal(t, map[string]string{"ldap": "2.1.0-1"}, actual.DoguVersions)
+	assert.Equal(t, []string{"k8s-longhorn"}, actual.Components)
+	require.Contains(t, actual.SetupJson, "naming")
+	assert.Equal(t, "192.168.56.2", actual.SetupJson["naming"].(map[string]any)["fqdn"])
 }
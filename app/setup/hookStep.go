@@ -0,0 +1,88 @@
+package setup
+
+import (
+	"fmt"
+	"time"
+
+	componentEcoSystem "github.com/cloudogu/k8s-component-operator/pkg/api/ecosystem"
+	"github.com/cloudogu/k8s-dogu-operator/v2/api/ecoSystem"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/component"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/dogus"
+)
+
+// hookStep attaches the name of a pipeline hook to the step performing it. The name identifies the step so that a
+// hook can wait for the same resource as a built-in step without sharing its progress.
+type hookStep struct {
+	ExecutorStep
+	name string
+}
+
+// newHookStep wraps the given step which performs the pipeline hook with the given name.
+func newHookStep(name string, step ExecutorStep) *hookStep {
+	return &hookStep{ExecutorStep: step, name: name}
+}
+
+// GetStepID returns the stable identifier of the hook.
+func (hs *hookStep) GetStepID() string {
+	return fmt.Sprintf("hook/%s", hs.name)
+}
+
+// GetStepDescription returns the description of the wrapped step prefixed with the name of the hook.
+func (hs *hookStep) GetStepDescription() string {
+	return fmt.Sprintf("Hook %s: %s", hs.name, hs.ExecutorStep.GetStepDescription())
+}
+
+// RegisterHookSteps registers the custom steps of the given pipeline hooks in the order of the hooks.
+func (e *Executor) RegisterHookSteps(hooks []appcontext.PipelineHook) error {
+	for _, hook := range hooks {
+		step, err := e.createHookStep(hook)
+		if err != nil {
+			return fmt.Errorf("failed to create step for hook %s: %w", hook.Name, err)
+		}
+
+		e.RegisterSetupSteps(newHookStep(hook.Name, step))
+	}
+
+	return nil
+}
+
+func (e *Executor) createHookStep(hook appcontext.PipelineHook) (ExecutorStep, error) {
+	namespace := e.SetupContext.AppConfig.TargetNamespace
+
+	switch {
+	case hook.Patch != nil:
+		phase := patch.Phase(hook.Name)
+		patches := []patch.ResourcePatch{{Phase: phase, Resource: hook.Patch.Resource, Patches: hook.Patch.Patches}}
+		return e.createResourcePatchStep(phase, patches)
+	case hook.Wait != nil && hook.Wait.Component != "":
+		ecoSystemClient, err := componentEcoSystem.NewForConfig(e.ClusterConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create K8s Component-EcoSystem client: %w", err)
+		}
+		timeout := hookTimeout(hook.Wait, component.TimeoutInSeconds())
+		return component.NewWaitForComponentStep(ecoSystemClient.Components(namespace), hook.Wait.Component, namespace, timeout), nil
+	case hook.Wait != nil && hook.Wait.Dogu != "":
+		ecoSystemClient, err := ecoSystem.NewForConfig(e.ClusterConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create K8s Dogu-EcoSystem client: %w", err)
+		}
+		timeout := hookTimeout(hook.Wait, dogus.TimeoutInSeconds())
+		return dogus.NewWaitForDoguStep(ecoSystemClient.Dogus(namespace), hook.Wait.Dogu, namespace, timeout), nil
+	case hook.Manifest != nil:
+		resourceManifest := hook.Manifest.ResourceManifest(hook.Name)
+		return e.createResourceManifestStep(resourceManifest.Phase, []patch.ResourceManifest{resourceManifest})
+	default:
+		return nil, fmt.Errorf("hook %s contains neither a patch, a wait nor a manifest", hook.Name)
+	}
+}
+
+func hookTimeout(wait *appcontext.WaitHook, defaultTimeout time.Duration) time.Duration {
+	if wait.TimeoutSeconds > 0 {
+		return time.Duration(wait.TimeoutSeconds) * time.Second
+	}
+
+	return defaultTimeout
+}
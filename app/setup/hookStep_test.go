@@ -0,0 +1,79 @@
+package setup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+)
+
+func TestExecutor_RegisterHookSteps(t *testing.T) {
+	testContext := &appcontext.SetupContext{AppConfig: &appcontext.Config{TargetNamespace: "test"}}
+
+	t.Run("should register steps of all hooks in their order", func(t *testing.T) {
+		// given
+		hooks := []appcontext.PipelineHook{
+			{
+				Name:  "patch-loadbalancer",
+				After: appcontext.LoadBalancerStage,
+				Patch: &appcontext.PatchHook{
+					Resource: patch.ResourceReference{ApiVersion: "v1", Kind: "Service", Name: "ces-loadbalancer"},
+					Patches:  []patch.JsonPatch{{Operation: "add", Path: "/metadata/labels/test", Value: "value"}},
+				},
+			},
+			{Name: "wait-for-longhorn", After: appcontext.ComponentStage, Wait: &appcontext.WaitHook{Component: "k8s-longhorn", TimeoutSeconds: 60}},
+			{Name: "wait-for-ldap", Before: appcontext.DoguStage, Wait: &appcontext.WaitHook{Dogu: "ldap"}},
+			{Name: "network-policies", Before: appcontext.DoguStage, Manifest: &appcontext.ManifestHook{URL: "https://example.com/policies.yaml"}},
+		}
+		executor := &Executor{ClusterConfig: &rest.Config{}, SetupContext: testContext}
+
+		// when
+		err := executor.RegisterHookSteps(hooks)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, executor.Steps, 4)
+		assert.Equal(t, "hook/patch-loadbalancer", executor.Steps[0].GetStepID())
+		assert.Equal(t, "Hook patch-loadbalancer: Patching kubernetes resources in phase patch-loadbalancer", executor.Steps[0].GetStepDescription())
+		assert.Equal(t, []string{"v1/Service ces-loadbalancer"}, executor.Steps[0].DescribeEffect().Targets)
+		assert.Equal(t, "hook/wait-for-longhorn", executor.Steps[1].GetStepID())
+		assert.Equal(t, "Hook wait-for-longhorn: Wait for component with selector app.kubernetes.io/name=k8s-longhorn to be ready", executor.Steps[1].GetStepDescription())
+		assert.Equal(t, "hook/wait-for-ldap", executor.Steps[2].GetStepID())
+		assert.Equal(t, "Hook wait-for-ldap: Wait for dogu with selector dogu.name=ldap to be ready", executor.Steps[2].GetStepDescription())
+		assert.Equal(t, "hook/network-policies", executor.Steps[3].GetStepID())
+		assert.Equal(t, "Hook network-policies: Applying kubernetes resource manifests in phase network-policies", executor.Steps[3].GetStepDescription())
+		assert.Equal(t, []string{"https://example.com/policies.yaml"}, executor.Steps[3].DescribeEffect().Targets)
+	})
+
+	t.Run("should fail for hook without patch, wait or manifest", func(t *testing.T) {
+		// given
+		executor := &Executor{ClusterConfig: &rest.Config{}, SetupContext: testContext}
+
+		// when
+		err := executor.RegisterHookSteps([]appcontext.PipelineHook{{Name: "nothing", Before: appcontext.DoguStage}})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to create step for hook nothing: hook nothing contains neither a patch, a wait nor a manifest")
+		assert.Empty(t, executor.Steps)
+	})
+}
+
+func TestExecutor_RollbackSetup_hookStep(t *testing.T) {
+	// given
+	var rolledBack []string
+	executor := &Executor{}
+	executor.RegisterSetupSteps(newHookStep("hook", newRollbackSetupStep("Step1", false, &rolledBack)))
+	_, _ = executor.PerformSetup(testCtx)
+
+	// when
+	err := executor.RollbackSetup(testCtx)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Step1"}, rolledBack)
+}
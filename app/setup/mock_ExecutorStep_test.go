@@ -5,6 +5,7 @@ package setup
 import (
 	context "context"
 
+	plan "github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -21,6 +22,51 @@ func (_m *MockExecutorStep) EXPECT() *MockExecutorStep_Expecter {
 	return &MockExecutorStep_Expecter{mock: &_m.Mock}
 }
 
+// DescribeEffect provides a mock function with no fields
+func (_m *MockExecutorStep) DescribeEffect() plan.Effect {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for DescribeEffect")
+	}
+
+	var r0 plan.Effect
+	if rf, ok := ret.Get(0).(func() plan.Effect); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(plan.Effect)
+	}
+
+	return r0
+}
+
+// MockExecutorStep_DescribeEffect_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DescribeEffect'
+type MockExecutorStep_DescribeEffect_Call struct {
+	*mock.Call
+}
+
+// DescribeEffect is a helper method to define mock.On call
+func (_e *MockExecutorStep_Expecter) DescribeEffect() *MockExecutorStep_DescribeEffect_Call {
+	return &MockExecutorStep_DescribeEffect_Call{Call: _e.mock.On("DescribeEffect")}
+}
+
+func (_c *MockExecutorStep_DescribeEffect_Call) Run(run func()) *MockExecutorStep_DescribeEffect_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockExecutorStep_DescribeEffect_Call) Return(_a0 plan.Effect) *MockExecutorStep_DescribeEffect_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockExecutorStep_DescribeEffect_Call) RunAndReturn(run func() plan.Effect) *MockExecutorStep_DescribeEffect_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetStepDescription provides a mock function with no fields
 func (_m *MockExecutorStep) GetStepDescription() string {
 	ret := _m.Called()
@@ -66,6 +112,51 @@ func (_c *MockExecutorStep_GetStepDescription_Call) RunAndReturn(run func() stri
 	return _c
 }
 
+// GetStepID provides a mock function with no fields
+func (_m *MockExecutorStep) GetStepID() string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStepID")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// MockExecutorStep_GetStepID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetStepID'
+type MockExecutorStep_GetStepID_Call struct {
+	*mock.Call
+}
+
+// GetStepID is a helper method to define mock.On call
+func (_e *MockExecutorStep_Expecter) GetStepID() *MockExecutorStep_GetStepID_Call {
+	return &MockExecutorStep_GetStepID_Call{Call: _e.mock.On("GetStepID")}
+}
+
+func (_c *MockExecutorStep_GetStepID_Call) Run(run func()) *MockExecutorStep_GetStepID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockExecutorStep_GetStepID_Call) Return(_a0 string) *MockExecutorStep_GetStepID_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockExecutorStep_GetStepID_Call) RunAndReturn(run func() string) *MockExecutorStep_GetStepID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // PerformSetupStep provides a mock function with given fields: ctx
 func (_m *MockExecutorStep) PerformSetupStep(ctx context.Context) error {
 	ret := _m.Called(ctx)
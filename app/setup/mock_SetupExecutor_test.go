@@ -5,8 +5,13 @@ package setup
 import (
 	context "context"
 
-	repository "github.com/cloudogu/k8s-registry-lib/repository"
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+
 	mock "github.com/stretchr/testify/mock"
+
+	plan "github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
+
+	repository "github.com/cloudogu/k8s-registry-lib/repository"
 )
 
 // MockSetupExecutor is an autogenerated mock type for the SetupExecutor type
@@ -78,6 +83,51 @@ func (_c *MockSetupExecutor_PerformSetup_Call) RunAndReturn(run func(context.Con
 	return _c
 }
 
+// PlanSetup provides a mock function with no fields
+func (_m *MockSetupExecutor) PlanSetup() plan.Plan {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for PlanSetup")
+	}
+
+	var r0 plan.Plan
+	if rf, ok := ret.Get(0).(func() plan.Plan); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(plan.Plan)
+	}
+
+	return r0
+}
+
+// MockSetupExecutor_PlanSetup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PlanSetup'
+type MockSetupExecutor_PlanSetup_Call struct {
+	*mock.Call
+}
+
+// PlanSetup is a helper method to define mock.On call
+func (_e *MockSetupExecutor_Expecter) PlanSetup() *MockSetupExecutor_PlanSetup_Call {
+	return &MockSetupExecutor_PlanSetup_Call{Call: _e.mock.On("PlanSetup")}
+}
+
+func (_c *MockSetupExecutor_PlanSetup_Call) Run(run func()) *MockSetupExecutor_PlanSetup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSetupExecutor_PlanSetup_Call) Return(_a0 plan.Plan) *MockSetupExecutor_PlanSetup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSetupExecutor_PlanSetup_Call) RunAndReturn(run func() plan.Plan) *MockSetupExecutor_PlanSetup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // RegisterComponentSetupSteps provides a mock function with no fields
 func (_m *MockSetupExecutor) RegisterComponentSetupSteps() error {
 	ret := _m.Called()
@@ -261,6 +311,52 @@ func (_c *MockSetupExecutor_RegisterDoguInstallationSteps_Call) RunAndReturn(run
 	return _c
 }
 
+// RegisterHookSteps provides a mock function with given fields: hooks
+func (_m *MockSetupExecutor) RegisterHookSteps(hooks []appcontext.PipelineHook) error {
+	ret := _m.Called(hooks)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RegisterHookSteps")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]appcontext.PipelineHook) error); ok {
+		r0 = rf(hooks)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockSetupExecutor_RegisterHookSteps_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RegisterHookSteps'
+type MockSetupExecutor_RegisterHookSteps_Call struct {
+	*mock.Call
+}
+
+// RegisterHookSteps is a helper method to define mock.On call
+//   - hooks []appcontext.PipelineHook
+func (_e *MockSetupExecutor_Expecter) RegisterHookSteps(hooks interface{}) *MockSetupExecutor_RegisterHookSteps_Call {
+	return &MockSetupExecutor_RegisterHookSteps_Call{Call: _e.mock.On("RegisterHookSteps", hooks)}
+}
+
+func (_c *MockSetupExecutor_RegisterHookSteps_Call) Run(run func(hooks []appcontext.PipelineHook)) *MockSetupExecutor_RegisterHookSteps_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]appcontext.PipelineHook))
+	})
+	return _c
+}
+
+func (_c *MockSetupExecutor_RegisterHookSteps_Call) Return(_a0 error) *MockSetupExecutor_RegisterHookSteps_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSetupExecutor_RegisterHookSteps_Call) RunAndReturn(run func([]appcontext.PipelineHook) error) *MockSetupExecutor_RegisterHookSteps_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // RegisterLoadBalancerFQDNRetrieverSteps provides a mock function with no fields
 func (_m *MockSetupExecutor) RegisterLoadBalancerFQDNRetrieverSteps() error {
 	ret := _m.Called()
@@ -306,6 +402,96 @@ func (_c *MockSetupExecutor_RegisterLoadBalancerFQDNRetrieverSteps_Call) RunAndR
 	return _c
 }
 
+// RegisterPostSetupSteps provides a mock function with no fields
+func (_m *MockSetupExecutor) RegisterPostSetupSteps() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for RegisterPostSetupSteps")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockSetupExecutor_RegisterPostSetupSteps_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RegisterPostSetupSteps'
+type MockSetupExecutor_RegisterPostSetupSteps_Call struct {
+	*mock.Call
+}
+
+// RegisterPostSetupSteps is a helper method to define mock.On call
+func (_e *MockSetupExecutor_Expecter) RegisterPostSetupSteps() *MockSetupExecutor_RegisterPostSetupSteps_Call {
+	return &MockSetupExecutor_RegisterPostSetupSteps_Call{Call: _e.mock.On("RegisterPostSetupSteps")}
+}
+
+func (_c *MockSetupExecutor_RegisterPostSetupSteps_Call) Run(run func()) *MockSetupExecutor_RegisterPostSetupSteps_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSetupExecutor_RegisterPostSetupSteps_Call) Return(_a0 error) *MockSetupExecutor_RegisterPostSetupSteps_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSetupExecutor_RegisterPostSetupSteps_Call) RunAndReturn(run func() error) *MockSetupExecutor_RegisterPostSetupSteps_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RegisterPreflightStep provides a mock function with no fields
+func (_m *MockSetupExecutor) RegisterPreflightStep() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for RegisterPreflightStep")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockSetupExecutor_RegisterPreflightStep_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RegisterPreflightStep'
+type MockSetupExecutor_RegisterPreflightStep_Call struct {
+	*mock.Call
+}
+
+// RegisterPreflightStep is a helper method to define mock.On call
+func (_e *MockSetupExecutor_Expecter) RegisterPreflightStep() *MockSetupExecutor_RegisterPreflightStep_Call {
+	return &MockSetupExecutor_RegisterPreflightStep_Call{Call: _e.mock.On("RegisterPreflightStep")}
+}
+
+func (_c *MockSetupExecutor_RegisterPreflightStep_Call) Run(run func()) *MockSetupExecutor_RegisterPreflightStep_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSetupExecutor_RegisterPreflightStep_Call) Return(_a0 error) *MockSetupExecutor_RegisterPreflightStep_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSetupExecutor_RegisterPreflightStep_Call) RunAndReturn(run func() error) *MockSetupExecutor_RegisterPreflightStep_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // RegisterSSLGenerationStep provides a mock function with no fields
 func (_m *MockSetupExecutor) RegisterSSLGenerationStep() error {
 	ret := _m.Called()
@@ -396,6 +582,52 @@ func (_c *MockSetupExecutor_RegisterValidationStep_Call) RunAndReturn(run func()
 	return _c
 }
 
+// RollbackSetup provides a mock function with given fields: ctx
+func (_m *MockSetupExecutor) RollbackSetup(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RollbackSetup")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockSetupExecutor_RollbackSetup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RollbackSetup'
+type MockSetupExecutor_RollbackSetup_Call struct {
+	*mock.Call
+}
+
+// RollbackSetup is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockSetupExecutor_Expecter) RollbackSetup(ctx interface{}) *MockSetupExecutor_RollbackSetup_Call {
+	return &MockSetupExecutor_RollbackSetup_Call{Call: _e.mock.On("RollbackSetup", ctx)}
+}
+
+func (_c *MockSetupExecutor_RollbackSetup_Call) Run(run func(ctx context.Context)) *MockSetupExecutor_RollbackSetup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockSetupExecutor_RollbackSetup_Call) Return(_a0 error) *MockSetupExecutor_RollbackSetup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSetupExecutor_RollbackSetup_Call) RunAndReturn(run func(context.Context) error) *MockSetupExecutor_RollbackSetup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockSetupExecutor creates a new instance of MockSetupExecutor. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockSetupExecutor(t interface {
@@ -0,0 +1,141 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package setup
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockStepProgressStore is an autogenerated mock type for the StepProgressStore type
+type MockStepProgressStore struct {
+	mock.Mock
+}
+
+type MockStepProgressStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockStepProgressStore) EXPECT() *MockStepProgressStore_Expecter {
+	return &MockStepProgressStore_Expecter{mock: &_m.Mock}
+}
+
+// GetCompletedSteps provides a mock function with given fields: ctx
+func (_m *MockStepProgressStore) GetCompletedSteps(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCompletedSteps")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]string, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockStepProgressStore_GetCompletedSteps_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCompletedSteps'
+type MockStepProgressStore_GetCompletedSteps_Call struct {
+	*mock.Call
+}
+
+// GetCompletedSteps is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockStepProgressStore_Expecter) GetCompletedSteps(ctx interface{}) *MockStepProgressStore_GetCompletedSteps_Call {
+	return &MockStepProgressStore_GetCompletedSteps_Call{Call: _e.mock.On("GetCompletedSteps", ctx)}
+}
+
+func (_c *MockStepProgressStore_GetCompletedSteps_Call) Run(run func(ctx context.Context)) *MockStepProgressStore_GetCompletedSteps_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockStepProgressStore_GetCompletedSteps_Call) Return(_a0 []string, _a1 error) *MockStepProgressStore_GetCompletedSteps_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockStepProgressStore_GetCompletedSteps_Call) RunAndReturn(run func(context.Context) ([]string, error)) *MockStepProgressStore_GetCompletedSteps_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkStepCompleted provides a mock function with given fields: ctx, stepID
+func (_m *MockStepProgressStore) MarkStepCompleted(ctx context.Context, stepID string) error {
+	ret := _m.Called(ctx, stepID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkStepCompleted")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, stepID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStepProgressStore_MarkStepCompleted_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkStepCompleted'
+type MockStepProgressStore_MarkStepCompleted_Call struct {
+	*mock.Call
+}
+
+// MarkStepCompleted is a helper method to define mock.On call
+//   - ctx context.Context
+//   - stepID string
+func (_e *MockStepProgressStore_Expecter) MarkStepCompleted(ctx interface{}, stepID interface{}) *MockStepProgressStore_MarkStepCompleted_Call {
+	return &MockStepProgressStore_MarkStepCompleted_Call{Call: _e.mock.On("MarkStepCompleted", ctx, stepID)}
+}
+
+func (_c *MockStepProgressStore_MarkStepCompleted_Call) Run(run func(ctx context.Context, stepID string)) *MockStepProgressStore_MarkStepCompleted_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockStepProgressStore_MarkStepCompleted_Call) Return(_a0 error) *MockStepProgressStore_MarkStepCompleted_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockStepProgressStore_MarkStepCompleted_Call) RunAndReturn(run func(context.Context, string) error) *MockStepProgressStore_MarkStepCompleted_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockStepProgressStore creates a new instance of MockStepProgressStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockStepProgressStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockStepProgressStore {
+	mock := &MockStepProgressStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
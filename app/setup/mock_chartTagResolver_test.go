@@ -0,0 +1,90 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package setup
+
+import mock "github.com/stretchr/testify/mock"
+
+// mockChartTagResolver is an autogenerated mock type for the chartTagResolver type
+type mockChartTagResolver struct {
+	mock.Mock
+}
+
+type mockChartTagResolver_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockChartTagResolver) EXPECT() *mockChartTagResolver_Expecter {
+	return &mockChartTagResolver_Expecter{mock: &_m.Mock}
+}
+
+// Tags provides a mock function with given fields: ref
+func (_m *mockChartTagResolver) Tags(ref string) ([]string, error) {
+	ret := _m.Called(ref)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Tags")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]string, error)); ok {
+		return rf(ref)
+	}
+	if rf, ok := ret.Get(0).(func(string) []string); ok {
+		r0 = rf(ref)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(ref)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// mockChartTagResolver_Tags_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Tags'
+type mockChartTagResolver_Tags_Call struct {
+	*mock.Call
+}
+
+// Tags is a helper method to define mock.On call
+//   - ref string
+func (_e *mockChartTagResolver_Expecter) Tags(ref interface{}) *mockChartTagResolver_Tags_Call {
+	return &mockChartTagResolver_Tags_Call{Call: _e.mock.On("Tags", ref)}
+}
+
+func (_c *mockChartTagResolver_Tags_Call) Run(run func(ref string)) *mockChartTagResolver_Tags_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *mockChartTagResolver_Tags_Call) Return(_a0 []string, _a1 error) *mockChartTagResolver_Tags_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *mockChartTagResolver_Tags_Call) RunAndReturn(run func(string) ([]string, error)) *mockChartTagResolver_Tags_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockChartTagResolver creates a new instance of mockChartTagResolver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockChartTagResolver(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockChartTagResolver {
+	mock := &mockChartTagResolver{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
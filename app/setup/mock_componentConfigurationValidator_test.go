@@ -0,0 +1,81 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package setup
+
+import (
+	context "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// mockComponentConfigurationValidator is an autogenerated mock type for the componentConfigurationValidator type
+type mockComponentConfigurationValidator struct {
+	mock.Mock
+}
+
+type mockComponentConfigurationValidator_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockComponentConfigurationValidator) EXPECT() *mockComponentConfigurationValidator_Expecter {
+	return &mockComponentConfigurationValidator_Expecter{mock: &_m.Mock}
+}
+
+// Validate provides a mock function with given fields: config
+func (_m *mockComponentConfigurationValidator) Validate(config *context.Config) error {
+	ret := _m.Called(config)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Validate")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*context.Config) error); ok {
+		r0 = rf(config)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// mockComponentConfigurationValidator_Validate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Validate'
+type mockComponentConfigurationValidator_Validate_Call struct {
+	*mock.Call
+}
+
+// Validate is a helper method to define mock.On call
+//   - config *context.Config
+func (_e *mockComponentConfigurationValidator_Expecter) Validate(config interface{}) *mockComponentConfigurationValidator_Validate_Call {
+	return &mockComponentConfigurationValidator_Validate_Call{Call: _e.mock.On("Validate", config)}
+}
+
+func (_c *mockComponentConfigurationValidator_Validate_Call) Run(run func(config *context.Config)) *mockComponentConfigurationValidator_Validate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*context.Config))
+	})
+	return _c
+}
+
+func (_c *mockComponentConfigurationValidator_Validate_Call) Return(_a0 error) *mockComponentConfigurationValidator_Validate_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *mockComponentConfigurationValidator_Validate_Call) RunAndReturn(run func(*context.Config) error) *mockComponentConfigurationValidator_Validate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockComponentConfigurationValidator creates a new instance of mockComponentConfigurationValidator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockComponentConfigurationValidator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockComponentConfigurationValidator {
+	mock := &mockComponentConfigurationValidator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,83 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package setup
+
+import (
+	context "context"
+
+	preflight "github.com/cloudogu/k8s-ces-setup/v4/app/preflight"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// mockPreflightChecker is an autogenerated mock type for the preflightChecker type
+type mockPreflightChecker struct {
+	mock.Mock
+}
+
+type mockPreflightChecker_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockPreflightChecker) EXPECT() *mockPreflightChecker_Expecter {
+	return &mockPreflightChecker_Expecter{mock: &_m.Mock}
+}
+
+// Check provides a mock function with given fields: ctx
+func (_m *mockPreflightChecker) Check(ctx context.Context) preflight.Report {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Check")
+	}
+
+	var r0 preflight.Report
+	if rf, ok := ret.Get(0).(func(context.Context) preflight.Report); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(preflight.Report)
+	}
+
+	return r0
+}
+
+// mockPreflightChecker_Check_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Check'
+type mockPreflightChecker_Check_Call struct {
+	*mock.Call
+}
+
+// Check is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *mockPreflightChecker_Expecter) Check(ctx interface{}) *mockPreflightChecker_Check_Call {
+	return &mockPreflightChecker_Check_Call{Call: _e.mock.On("Check", ctx)}
+}
+
+func (_c *mockPreflightChecker_Check_Call) Run(run func(ctx context.Context)) *mockPreflightChecker_Check_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *mockPreflightChecker_Check_Call) Return(_a0 preflight.Report) *mockPreflightChecker_Check_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *mockPreflightChecker_Check_Call) RunAndReturn(run func(context.Context) preflight.Report) *mockPreflightChecker_Check_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockPreflightChecker creates a new instance of mockPreflightChecker. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockPreflightChecker(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockPreflightChecker {
+	mock := &mockPreflightChecker{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,146 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package setup
+
+import (
+	context "context"
+
+	patch "github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+	mock "github.com/stretchr/testify/mock"
+
+	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// mockResourceManifestApplier is an autogenerated mock type for the resourceManifestApplier type
+type mockResourceManifestApplier struct {
+	mock.Mock
+}
+
+type mockResourceManifestApplier_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockResourceManifestApplier) EXPECT() *mockResourceManifestApplier_Expecter {
+	return &mockResourceManifestApplier_Expecter{mock: &_m.Mock}
+}
+
+// Apply provides a mock function with given fields: ctx, phase, manifests
+func (_m *mockResourceManifestApplier) Apply(ctx context.Context, phase patch.Phase, manifests []patch.ResourceManifest) ([]*unstructured.Unstructured, error) {
+	ret := _m.Called(ctx, phase, manifests)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Apply")
+	}
+
+	var r0 []*unstructured.Unstructured
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, patch.Phase, []patch.ResourceManifest) ([]*unstructured.Unstructured, error)); ok {
+		return rf(ctx, phase, manifests)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, patch.Phase, []patch.ResourceManifest) []*unstructured.Unstructured); ok {
+		r0 = rf(ctx, phase, manifests)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*unstructured.Unstructured)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, patch.Phase, []patch.ResourceManifest) error); ok {
+		r1 = rf(ctx, phase, manifests)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// mockResourceManifestApplier_Apply_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Apply'
+type mockResourceManifestApplier_Apply_Call struct {
+	*mock.Call
+}
+
+// Apply is a helper method to define mock.On call
+//   - ctx context.Context
+//   - phase patch.Phase
+//   - manifests []patch.ResourceManifest
+func (_e *mockResourceManifestApplier_Expecter) Apply(ctx interface{}, phase interface{}, manifests interface{}) *mockResourceManifestApplier_Apply_Call {
+	return &mockResourceManifestApplier_Apply_Call{Call: _e.mock.On("Apply", ctx, phase, manifests)}
+}
+
+func (_c *mockResourceManifestApplier_Apply_Call) Run(run func(ctx context.Context, phase patch.Phase, manifests []patch.ResourceManifest)) *mockResourceManifestApplier_Apply_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(patch.Phase), args[2].([]patch.ResourceManifest))
+	})
+	return _c
+}
+
+func (_c *mockResourceManifestApplier_Apply_Call) Return(_a0 []*unstructured.Unstructured, _a1 error) *mockResourceManifestApplier_Apply_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *mockResourceManifestApplier_Apply_Call) RunAndReturn(run func(context.Context, patch.Phase, []patch.ResourceManifest) ([]*unstructured.Unstructured, error)) *mockResourceManifestApplier_Apply_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Delete provides a mock function with given fields: ctx, objects
+func (_m *mockResourceManifestApplier) Delete(ctx context.Context, objects []*unstructured.Unstructured) error {
+	ret := _m.Called(ctx, objects)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*unstructured.Unstructured) error); ok {
+		r0 = rf(ctx, objects)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// mockResourceManifestApplier_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type mockResourceManifestApplier_Delete_Call struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - ctx context.Context
+//   - objects []*unstructured.Unstructured
+func (_e *mockResourceManifestApplier_Expecter) Delete(ctx interface{}, objects interface{}) *mockResourceManifestApplier_Delete_Call {
+	return &mockResourceManifestApplier_Delete_Call{Call: _e.mock.On("Delete", ctx, objects)}
+}
+
+func (_c *mockResourceManifestApplier_Delete_Call) Run(run func(ctx context.Context, objects []*unstructured.Unstructured)) *mockResourceManifestApplier_Delete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]*unstructured.Unstructured))
+	})
+	return _c
+}
+
+func (_c *mockResourceManifestApplier_Delete_Call) Return(_a0 error) *mockResourceManifestApplier_Delete_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *mockResourceManifestApplier_Delete_Call) RunAndReturn(run func(context.Context, []*unstructured.Unstructured) error) *mockResourceManifestApplier_Delete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockResourceManifestApplier creates a new instance of mockResourceManifestApplier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockResourceManifestApplier(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockResourceManifestApplier {
+	mock := &mockResourceManifestApplier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
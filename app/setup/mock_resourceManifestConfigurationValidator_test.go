@@ -0,0 +1,81 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package setup
+
+import (
+	patch "github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// mockResourceManifestConfigurationValidator is an autogenerated mock type for the resourceManifestConfigurationValidator type
+type mockResourceManifestConfigurationValidator struct {
+	mock.Mock
+}
+
+type mockResourceManifestConfigurationValidator_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockResourceManifestConfigurationValidator) EXPECT() *mockResourceManifestConfigurationValidator_Expecter {
+	return &mockResourceManifestConfigurationValidator_Expecter{mock: &_m.Mock}
+}
+
+// Validate provides a mock function with given fields: resourceManifestConfig
+func (_m *mockResourceManifestConfigurationValidator) Validate(resourceManifestConfig []patch.ResourceManifest) error {
+	ret := _m.Called(resourceManifestConfig)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Validate")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]patch.ResourceManifest) error); ok {
+		r0 = rf(resourceManifestConfig)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// mockResourceManifestConfigurationValidator_Validate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Validate'
+type mockResourceManifestConfigurationValidator_Validate_Call struct {
+	*mock.Call
+}
+
+// Validate is a helper method to define mock.On call
+//   - resourceManifestConfig []patch.ResourceManifest
+func (_e *mockResourceManifestConfigurationValidator_Expecter) Validate(resourceManifestConfig interface{}) *mockResourceManifestConfigurationValidator_Validate_Call {
+	return &mockResourceManifestConfigurationValidator_Validate_Call{Call: _e.mock.On("Validate", resourceManifestConfig)}
+}
+
+func (_c *mockResourceManifestConfigurationValidator_Validate_Call) Run(run func(resourceManifestConfig []patch.ResourceManifest)) *mockResourceManifestConfigurationValidator_Validate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]patch.ResourceManifest))
+	})
+	return _c
+}
+
+func (_c *mockResourceManifestConfigurationValidator_Validate_Call) Return(_a0 error) *mockResourceManifestConfigurationValidator_Validate_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *mockResourceManifestConfigurationValidator_Validate_Call) RunAndReturn(run func([]patch.ResourceManifest) error) *mockResourceManifestConfigurationValidator_Validate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockResourceManifestConfigurationValidator creates a new instance of mockResourceManifestConfigurationValidator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockResourceManifestConfigurationValidator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockResourceManifestConfigurationValidator {
+	mock := &mockResourceManifestConfigurationValidator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
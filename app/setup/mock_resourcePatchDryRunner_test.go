@@ -0,0 +1,84 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package setup
+
+import (
+	context "context"
+
+	patch "github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// mockResourcePatchDryRunner is an autogenerated mock type for the resourcePatchDryRunner type
+type mockResourcePatchDryRunner struct {
+	mock.Mock
+}
+
+type mockResourcePatchDryRunner_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockResourcePatchDryRunner) EXPECT() *mockResourcePatchDryRunner_Expecter {
+	return &mockResourcePatchDryRunner_Expecter{mock: &_m.Mock}
+}
+
+// DryRun provides a mock function with given fields: ctx, patches
+func (_m *mockResourcePatchDryRunner) DryRun(ctx context.Context, patches []patch.ResourcePatch) error {
+	ret := _m.Called(ctx, patches)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DryRun")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []patch.ResourcePatch) error); ok {
+		r0 = rf(ctx, patches)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// mockResourcePatchDryRunner_DryRun_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DryRun'
+type mockResourcePatchDryRunner_DryRun_Call struct {
+	*mock.Call
+}
+
+// DryRun is a helper method to define mock.On call
+//   - ctx context.Context
+//   - patches []patch.ResourcePatch
+func (_e *mockResourcePatchDryRunner_Expecter) DryRun(ctx interface{}, patches interface{}) *mockResourcePatchDryRunner_DryRun_Call {
+	return &mockResourcePatchDryRunner_DryRun_Call{Call: _e.mock.On("DryRun", ctx, patches)}
+}
+
+func (_c *mockResourcePatchDryRunner_DryRun_Call) Run(run func(ctx context.Context, patches []patch.ResourcePatch)) *mockResourcePatchDryRunner_DryRun_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]patch.ResourcePatch))
+	})
+	return _c
+}
+
+func (_c *mockResourcePatchDryRunner_DryRun_Call) Return(_a0 error) *mockResourcePatchDryRunner_DryRun_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *mockResourcePatchDryRunner_DryRun_Call) RunAndReturn(run func(context.Context, []patch.ResourcePatch) error) *mockResourcePatchDryRunner_DryRun_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockResourcePatchDryRunner creates a new instance of mockResourcePatchDryRunner. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockResourcePatchDryRunner(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockResourcePatchDryRunner {
+	mock := &mockResourcePatchDryRunner{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
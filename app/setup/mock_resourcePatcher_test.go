@@ -22,17 +22,17 @@ func (_m *mockResourcePatcher) EXPECT() *mockResourcePatcher_Expecter {
 	return &mockResourcePatcher_Expecter{mock: &_m.Mock}
 }
 
-// Patch provides a mock function with given fields: ctx, phase, patches
-func (_m *mockResourcePatcher) Patch(ctx context.Context, phase patch.Phase, patches []patch.ResourcePatch) error {
-	ret := _m.Called(ctx, phase, patches)
+// Patch provides a mock function with given fields: ctx, phase, patches, values
+func (_m *mockResourcePatcher) Patch(ctx context.Context, phase patch.Phase, patches []patch.ResourcePatch, values patch.TemplateValues) error {
+	ret := _m.Called(ctx, phase, patches, values)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Patch")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, patch.Phase, []patch.ResourcePatch) error); ok {
-		r0 = rf(ctx, phase, patches)
+	if rf, ok := ret.Get(0).(func(context.Context, patch.Phase, []patch.ResourcePatch, patch.TemplateValues) error); ok {
+		r0 = rf(ctx, phase, patches, values)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -49,13 +49,14 @@ type mockResourcePatcher_Patch_Call struct {
 //   - ctx context.Context
 //   - phase patch.Phase
 //   - patches []patch.ResourcePatch
-func (_e *mockResourcePatcher_Expecter) Patch(ctx interface{}, phase interface{}, patches interface{}) *mockResourcePatcher_Patch_Call {
-	return &mockResourcePatcher_Patch_Call{Call: _e.mock.On("Patch", ctx, phase, patches)}
+//   - values patch.TemplateValues
+func (_e *mockResourcePatcher_Expecter) Patch(ctx interface{}, phase interface{}, patches interface{}, values interface{}) *mockResourcePatcher_Patch_Call {
+	return &mockResourcePatcher_Patch_Call{Call: _e.mock.On("Patch", ctx, phase, patches, values)}
 }
 
-func (_c *mockResourcePatcher_Patch_Call) Run(run func(ctx context.Context, phase patch.Phase, patches []patch.ResourcePatch)) *mockResourcePatcher_Patch_Call {
+func (_c *mockResourcePatcher_Patch_Call) Run(run func(ctx context.Context, phase patch.Phase, patches []patch.ResourcePatch, values patch.TemplateValues)) *mockResourcePatcher_Patch_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(patch.Phase), args[2].([]patch.ResourcePatch))
+		run(args[0].(context.Context), args[1].(patch.Phase), args[2].([]patch.ResourcePatch), args[3].(patch.TemplateValues))
 	})
 	return _c
 }
@@ -65,7 +66,7 @@ func (_c *mockResourcePatcher_Patch_Call) Return(_a0 error) *mockResourcePatcher
 	return _c
 }
 
-func (_c *mockResourcePatcher_Patch_Call) RunAndReturn(run func(context.Context, patch.Phase, []patch.ResourcePatch) error) *mockResourcePatcher_Patch_Call {
+func (_c *mockResourcePatcher_Patch_Call) RunAndReturn(run func(context.Context, patch.Phase, []patch.ResourcePatch, patch.TemplateValues) error) *mockResourcePatcher_Patch_Call {
 	_c.Call.Return(run)
 	return _c
 }
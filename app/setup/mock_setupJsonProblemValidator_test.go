@@ -0,0 +1,89 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package setup
+
+import (
+	context "context"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	validation "github.com/cloudogu/k8s-ces-setup/v4/app/validation"
+)
+
+// mockSetupJsonProblemValidator is an autogenerated mock type for the setupJsonProblemValidator type
+type mockSetupJsonProblemValidator struct {
+	mock.Mock
+}
+
+type mockSetupJsonProblemValidator_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockSetupJsonProblemValidator) EXPECT() *mockSetupJsonProblemValidator_Expecter {
+	return &mockSetupJsonProblemValidator_Expecter{mock: &_m.Mock}
+}
+
+// ValidateAll provides a mock function with given fields: ctx, setupJson
+func (_m *mockSetupJsonProblemValidator) ValidateAll(ctx context.Context, setupJson *appcontext.SetupJsonConfiguration) validation.Problems {
+	ret := _m.Called(ctx, setupJson)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ValidateAll")
+	}
+
+	var r0 validation.Problems
+	if rf, ok := ret.Get(0).(func(context.Context, *appcontext.SetupJsonConfiguration) validation.Problems); ok {
+		r0 = rf(ctx, setupJson)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(validation.Problems)
+		}
+	}
+
+	return r0
+}
+
+// mockSetupJsonProblemValidator_ValidateAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ValidateAll'
+type mockSetupJsonProblemValidator_ValidateAll_Call struct {
+	*mock.Call
+}
+
+// ValidateAll is a helper method to define mock.On call
+//   - ctx context.Context
+//   - setupJson *appcontext.SetupJsonConfiguration
+func (_e *mockSetupJsonProblemValidator_Expecter) ValidateAll(ctx interface{}, setupJson interface{}) *mockSetupJsonProblemValidator_ValidateAll_Call {
+	return &mockSetupJsonProblemValidator_ValidateAll_Call{Call: _e.mock.On("ValidateAll", ctx, setupJson)}
+}
+
+func (_c *mockSetupJsonProblemValidator_ValidateAll_Call) Run(run func(ctx context.Context, setupJson *appcontext.SetupJsonConfiguration)) *mockSetupJsonProblemValidator_ValidateAll_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*appcontext.SetupJsonConfiguration))
+	})
+	return _c
+}
+
+func (_c *mockSetupJsonProblemValidator_ValidateAll_Call) Return(_a0 validation.Problems) *mockSetupJsonProblemValidator_ValidateAll_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *mockSetupJsonProblemValidator_ValidateAll_Call) RunAndReturn(run func(context.Context, *appcontext.SetupJsonConfiguration) validation.Problems) *mockSetupJsonProblemValidator_ValidateAll_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockSetupJsonProblemValidator creates a new instance of mockSetupJsonProblemValidator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockSetupJsonProblemValidator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockSetupJsonProblemValidator {
+	mock := &mockSetupJsonProblemValidator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,89 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package setup
+
+import (
+	context "context"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	validation "github.com/cloudogu/k8s-ces-setup/v4/app/validation"
+)
+
+// mockSmtpRelayChecker is an autogenerated mock type for the smtpRelayChecker type
+type mockSmtpRelayChecker struct {
+	mock.Mock
+}
+
+type mockSmtpRelayChecker_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockSmtpRelayChecker) EXPECT() *mockSmtpRelayChecker_Expecter {
+	return &mockSmtpRelayChecker_Expecter{mock: &_m.Mock}
+}
+
+// CheckSmtpRelay provides a mock function with given fields: ctx, configuration
+func (_m *mockSmtpRelayChecker) CheckSmtpRelay(ctx context.Context, configuration *appcontext.SetupJsonConfiguration) validation.Problems {
+	ret := _m.Called(ctx, configuration)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckSmtpRelay")
+	}
+
+	var r0 validation.Problems
+	if rf, ok := ret.Get(0).(func(context.Context, *appcontext.SetupJsonConfiguration) validation.Problems); ok {
+		r0 = rf(ctx, configuration)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(validation.Problems)
+		}
+	}
+
+	return r0
+}
+
+// mockSmtpRelayChecker_CheckSmtpRelay_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CheckSmtpRelay'
+type mockSmtpRelayChecker_CheckSmtpRelay_Call struct {
+	*mock.Call
+}
+
+// CheckSmtpRelay is a helper method to define mock.On call
+//   - ctx context.Context
+//   - configuration *appcontext.SetupJsonConfiguration
+func (_e *mockSmtpRelayChecker_Expecter) CheckSmtpRelay(ctx interface{}, configuration interface{}) *mockSmtpRelayChecker_CheckSmtpRelay_Call {
+	return &mockSmtpRelayChecker_CheckSmtpRelay_Call{Call: _e.mock.On("CheckSmtpRelay", ctx, configuration)}
+}
+
+func (_c *mockSmtpRelayChecker_CheckSmtpRelay_Call) Run(run func(ctx context.Context, configuration *appcontext.SetupJsonConfiguration)) *mockSmtpRelayChecker_CheckSmtpRelay_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*appcontext.SetupJsonConfiguration))
+	})
+	return _c
+}
+
+func (_c *mockSmtpRelayChecker_CheckSmtpRelay_Call) Return(_a0 validation.Problems) *mockSmtpRelayChecker_CheckSmtpRelay_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *mockSmtpRelayChecker_CheckSmtpRelay_Call) RunAndReturn(run func(context.Context, *appcontext.SetupJsonConfiguration) validation.Problems) *mockSmtpRelayChecker_CheckSmtpRelay_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockSmtpRelayChecker creates a new instance of mockSmtpRelayChecker. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockSmtpRelayChecker(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockSmtpRelayChecker {
+	mock := &mockSmtpRelayChecker{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
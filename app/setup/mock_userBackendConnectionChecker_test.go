@@ -0,0 +1,89 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package setup
+
+import (
+	context "context"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	validation "github.com/cloudogu/k8s-ces-setup/v4/app/validation"
+)
+
+// mockUserBackendConnectionChecker is an autogenerated mock type for the userBackendConnectionChecker type
+type mockUserBackendConnectionChecker struct {
+	mock.Mock
+}
+
+type mockUserBackendConnectionChecker_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockUserBackendConnectionChecker) EXPECT() *mockUserBackendConnectionChecker_Expecter {
+	return &mockUserBackendConnectionChecker_Expecter{mock: &_m.Mock}
+}
+
+// CheckUserBackendConnection provides a mock function with given fields: ctx, backend
+func (_m *mockUserBackendConnectionChecker) CheckUserBackendConnection(ctx context.Context, backend appcontext.UserBackend) validation.Problems {
+	ret := _m.Called(ctx, backend)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckUserBackendConnection")
+	}
+
+	var r0 validation.Problems
+	if rf, ok := ret.Get(0).(func(context.Context, appcontext.UserBackend) validation.Problems); ok {
+		r0 = rf(ctx, backend)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(validation.Problems)
+		}
+	}
+
+	return r0
+}
+
+// mockUserBackendConnectionChecker_CheckUserBackendConnection_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CheckUserBackendConnection'
+type mockUserBackendConnectionChecker_CheckUserBackendConnection_Call struct {
+	*mock.Call
+}
+
+// CheckUserBackendConnection is a helper method to define mock.On call
+//   - ctx context.Context
+//   - backend appcontext.UserBackend
+func (_e *mockUserBackendConnectionChecker_Expecter) CheckUserBackendConnection(ctx interface{}, backend interface{}) *mockUserBackendConnectionChecker_CheckUserBackendConnection_Call {
+	return &mockUserBackendConnectionChecker_CheckUserBackendConnection_Call{Call: _e.mock.On("CheckUserBackendConnection", ctx, backend)}
+}
+
+func (_c *mockUserBackendConnectionChecker_CheckUserBackendConnection_Call) Run(run func(ctx context.Context, backend appcontext.UserBackend)) *mockUserBackendConnectionChecker_CheckUserBackendConnection_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(appcontext.UserBackend))
+	})
+	return _c
+}
+
+func (_c *mockUserBackendConnectionChecker_CheckUserBackendConnection_Call) Return(_a0 validation.Problems) *mockUserBackendConnectionChecker_CheckUserBackendConnection_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *mockUserBackendConnectionChecker_CheckUserBackendConnection_Call) RunAndReturn(run func(context.Context, appcontext.UserBackend) validation.Problems) *mockUserBackendConnectionChecker_CheckUserBackendConnection_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockUserBackendConnectionChecker creates a new instance of mockUserBackendConnectionChecker. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockUserBackendConnectionChecker(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockUserBackendConnectionChecker {
+	mock := &mockUserBackendConnectionChecker{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,59 @@
+// Package plan describes the intended effects of the setup steps without performing them.
+package plan
+
+// Action describes what a setup step intends to do with its targets.
+type Action string
+
+const (
+	// ActionInstall installs the targets, f. i. helm charts.
+	ActionInstall Action = "install"
+	// ActionCreate creates the targets if they do not exist yet.
+	ActionCreate Action = "create"
+	// ActionUpdate modifies existing targets.
+	ActionUpdate Action = "update"
+	// ActionWait waits for the targets to become ready.
+	ActionWait Action = "wait"
+	// ActionWrite writes keys into the targets.
+	ActionWrite Action = "write"
+	// ActionApply creates or updates the targets with server-side apply.
+	ActionApply Action = "apply"
+	// ActionPatch applies patches to the targets.
+	ActionPatch Action = "patch"
+	// ActionValidate checks the targets without modifying them.
+	ActionValidate Action = "validate"
+	// ActionGenerate computes data which is kept in the setup configuration in memory.
+	ActionGenerate Action = "generate"
+)
+
+// Effect describes the intended effect of a single setup step.
+type Effect struct {
+	// Action describes what the step intends to do with its targets.
+	Action Action `json:"action"`
+	// Kind contains the type of the targets, f. i. Component, Dogu, or Secret.
+	Kind string `json:"kind"`
+	// Targets contains the names of the objects the step acts on.
+	Targets []string `json:"targets,omitempty"`
+	// Version contains the version of the target that the step will install.
+	Version string `json:"version,omitempty"`
+	// Keys contains the configuration keys that the step will write.
+	Keys []string `json:"keys,omitempty"`
+}
+
+// Step describes a single registered setup step and its intended effect.
+type Step struct {
+	// Index is the position of the step in the ordered list of registered steps.
+	Index int `json:"index"`
+	// ID is the stable identifier of the step.
+	ID string `json:"id"`
+	// Description is the human-readable description of the step.
+	Description string `json:"description"`
+	// DependsOn contains the IDs of the steps which must succeed before this step is performed. Steps with
+	// dependencies are performed concurrently with their neighbouring steps with dependencies.
+	DependsOn []string `json:"dependsOn,omitempty"`
+	Effect
+}
+
+// Plan contains all registered setup steps in the order of their execution.
+type Plan struct {
+	Steps []Step `json:"steps"`
+}
@@ -0,0 +1,70 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/preflight"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
+)
+
+// preflightChecker is responsible to check whether the cluster is able to host the ecosystem.
+type preflightChecker interface {
+	Check(ctx context.Context) preflight.Report
+}
+
+type preflightStep struct {
+	checker  preflightChecker
+	warnOnly bool
+}
+
+// NewPreflightStep creates a new setup step which checks the cluster before any resource is created. Failed checks
+// are only logged if the pre-flight configuration only allows warnings.
+func NewPreflightStep(clientSet kubernetes.Interface, appConfig *appcontext.Config) (*preflightStep, error) {
+	checker, err := preflight.NewChecker(clientSet, appConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pre-flight checker: %w", err)
+	}
+
+	return &preflightStep{checker: checker, warnOnly: appConfig.Preflight.WarnOnly}, nil
+}
+
+// GetStepID returns the stable identifier of the step.
+func (ps *preflightStep) GetStepID() string {
+	return "preflight"
+}
+
+// GetStepDescription return the human-readable description of the step.
+func (ps *preflightStep) GetStepDescription() string {
+	return "Checking whether the cluster is able to host the Cloudogu EcoSystem"
+}
+
+// DescribeEffect returns the cluster which the step checks.
+func (ps *preflightStep) DescribeEffect() plan.Effect {
+	return plan.Effect{Action: plan.ActionValidate, Kind: "Cluster"}
+}
+
+// PerformSetupStep performs all pre-flight checks and fails if a check failed and warnOnly is false.
+func (ps *preflightStep) PerformSetupStep(ctx context.Context) error {
+	report := ps.checker.Check(ctx)
+	for _, result := range report.Results {
+		switch result.Status {
+		case preflight.StatusFailed, preflight.StatusWarning:
+			logrus.Warnf("Pre-flight check %s: %s", result.Check, result.Message)
+		default:
+			logrus.Infof("Pre-flight check %s: %s", result.Check, result.Message)
+		}
+	}
+
+	err := report.Err()
+	if err != nil && ps.warnOnly {
+		logrus.Warn("Continuing the setup despite failed pre-flight checks because only warnings are configured")
+		return nil
+	}
+
+	return err
+}
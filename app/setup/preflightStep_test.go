@@ -0,0 +1,95 @@
+package setup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/preflight"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
+)
+
+func TestNewPreflightStep(t *testing.T) {
+	t.Run("should create step", func(t *testing.T) {
+		// given
+		appConfig := &appcontext.Config{TargetNamespace: "ecosystem", Preflight: appcontext.Preflight{WarnOnly: true}}
+
+		// when
+		step, err := NewPreflightStep(fake.NewClientset(), appConfig)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, step.warnOnly)
+		assert.Equal(t, "preflight", step.GetStepID())
+		assert.Equal(t, "Checking whether the cluster is able to host the Cloudogu EcoSystem", step.GetStepDescription())
+		assert.Equal(t, plan.Effect{Action: plan.ActionValidate, Kind: "Cluster"}, step.DescribeEffect())
+	})
+
+	t.Run("should fail for invalid configuration", func(t *testing.T) {
+		// given
+		appConfig := &appcontext.Config{Preflight: appcontext.Preflight{MinKubernetesVersion: "latest"}}
+
+		// when
+		_, err := NewPreflightStep(fake.NewClientset(), appConfig)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to create pre-flight checker")
+	})
+}
+
+func Test_preflightStep_PerformSetupStep(t *testing.T) {
+	failedReport := preflight.Report{Results: []preflight.Result{
+		{Check: "kubernetes-version", Status: preflight.StatusPassed, Message: "kubernetes version v1.30.0 is supported"},
+		{Check: "default-storage-class", Status: preflight.StatusFailed, Message: "no default storage class found"},
+	}}
+
+	t.Run("should succeed with warnings", func(t *testing.T) {
+		// given
+		checkerMock := newMockPreflightChecker(t)
+		checkerMock.EXPECT().Check(testCtx).Return(preflight.Report{Results: []preflight.Result{
+			{Check: "allocatable-resources", Status: preflight.StatusWarning, Message: "not permitted to list nodes"},
+		}})
+		sut := &preflightStep{checker: checkerMock}
+
+		// when
+		err := sut.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail on failed checks", func(t *testing.T) {
+		// given
+		checkerMock := newMockPreflightChecker(t)
+		checkerMock.EXPECT().Check(testCtx).Return(failedReport)
+		sut := &preflightStep{checker: checkerMock}
+
+		// when
+		err := sut.PerformSetupStep(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.Equal(t, "pre-flight check default-storage-class failed: no default storage class found", err.Error())
+	})
+
+	t.Run("should only log failed checks if configured", func(t *testing.T) {
+		// given
+		checkerMock := newMockPreflightChecker(t)
+		checkerMock.EXPECT().Check(testCtx).Return(failedReport)
+		sut := &preflightStep{checker: checkerMock, warnOnly: true}
+
+		// when
+		logs, err := captureLogs(func() {
+			require.NoError(t, sut.PerformSetupStep(testCtx))
+		})
+
+		// then
+		require.NoError(t, err)
+		assert.Contains(t, logs, "Pre-flight check default-storage-class: no default storage class found")
+		assert.Contains(t, logs, "Continuing the setup despite failed pre-flight checks")
+	})
+}
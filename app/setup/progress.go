@@ -0,0 +1,156 @@
+package setup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+)
+
+// configMapStepProgressStore stores the IDs of completed setup steps and the data needed to roll back the performed
+// steps in the setup state config map.
+type configMapStepProgressStore struct {
+	// mutex serializes the updates of the config map because steps may be completed concurrently.
+	mutex     sync.Mutex
+	clientSet kubernetes.Interface
+	namespace string
+}
+
+// NewConfigMapStepProgressStore creates a new store which persists the setup progress in the setup state config map.
+func NewConfigMapStepProgressStore(clientSet kubernetes.Interface, namespace string) *configMapStepProgressStore {
+	return &configMapStepProgressStore{clientSet: clientSet, namespace: namespace}
+}
+
+// GetCompletedSteps returns the IDs of all completed setup steps.
+func (s *configMapStepProgressStore) GetCompletedSteps(ctx context.Context) ([]string, error) {
+	stateCM, err := appcontext.GetSetupStateConfigMap(ctx, s.clientSet, s.namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get k8s-ces-setup configmap: %w", err)
+	}
+
+	return parseCompletedSteps(stateCM.Data[appcontext.SetupCompletedStepsKey])
+}
+
+// MarkStepCompleted adds the given step ID to the completed setup steps.
+func (s *configMapStepProgressStore) MarkStepCompleted(ctx context.Context, stepID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stateCM, err := appcontext.GetSetupStateConfigMap(ctx, s.clientSet, s.namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get k8s-ces-setup configmap: %w", err)
+	}
+
+	completedSteps, err := parseCompletedSteps(stateCM.Data[appcontext.SetupCompletedStepsKey])
+	if err != nil {
+		return err
+	}
+
+	if slices.Contains(completedSteps, stepID) {
+		return nil
+	}
+
+	rawSteps, err := json.Marshal(append(completedSteps, stepID))
+	if err != nil {
+		return fmt.Errorf("failed to marshal completed setup steps: %w", err)
+	}
+
+	stateCM.Data[appcontext.SetupCompletedStepsKey] = string(rawSteps)
+	_, err = s.clientSet.CoreV1().ConfigMaps(s.namespace).Update(ctx, stateCM, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update k8s-ces-setup configmap: %w", err)
+	}
+
+	return nil
+}
+
+func parseCompletedSteps(rawSteps string) ([]string, error) {
+	var completedSteps []string
+	if rawSteps == "" {
+		return completedSteps, nil
+	}
+
+	err := json.Unmarshal([]byte(rawSteps), &completedSteps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal completed setup steps: %w", err)
+	}
+
+	return completedSteps, nil
+}
+
+// GetRollbackStates returns the rollback data of all performed setup steps by their step IDs.
+func (s *configMapStepProgressStore) GetRollbackStates(ctx context.Context) (map[string]json.RawMessage, error) {
+	stateCM, err := appcontext.GetSetupStateConfigMap(ctx, s.clientSet, s.namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get k8s-ces-setup configmap: %w", err)
+	}
+
+	return parseRollbackStates(stateCM.Data[appcontext.SetupRollbackStateKey])
+}
+
+// SaveRollbackState stores the rollback data of the step with the given ID. Existing data of the step is replaced.
+func (s *configMapStepProgressStore) SaveRollbackState(ctx context.Context, stepID string, state json.RawMessage) error {
+	return s.updateRollbackStates(ctx, func(states map[string]json.RawMessage) {
+		states[stepID] = state
+	})
+}
+
+// RemoveRollbackState removes the rollback data of the step with the given ID after the step was rolled back.
+func (s *configMapStepProgressStore) RemoveRollbackState(ctx context.Context, stepID string) error {
+	return s.updateRollbackStates(ctx, func(states map[string]json.RawMessage) {
+		delete(states, stepID)
+	})
+}
+
+func (s *configMapStepProgressStore) updateRollbackStates(ctx context.Context, update func(states map[string]json.RawMessage)) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stateCM, err := appcontext.GetSetupStateConfigMap(ctx, s.clientSet, s.namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get k8s-ces-setup configmap: %w", err)
+	}
+
+	states, err := parseRollbackStates(stateCM.Data[appcontext.SetupRollbackStateKey])
+	if err != nil {
+		return err
+	}
+
+	update(states)
+	if len(states) == 0 {
+		delete(stateCM.Data, appcontext.SetupRollbackStateKey)
+	} else {
+		rawStates, err := json.Marshal(states)
+		if err != nil {
+			return fmt.Errorf("failed to marshal rollback state of setup steps: %w", err)
+		}
+		stateCM.Data[appcontext.SetupRollbackStateKey] = string(rawStates)
+	}
+
+	_, err = s.clientSet.CoreV1().ConfigMaps(s.namespace).Update(ctx, stateCM, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update k8s-ces-setup configmap: %w", err)
+	}
+
+	return nil
+}
+
+func parseRollbackStates(rawStates string) (map[string]json.RawMessage, error) {
+	states := map[string]json.RawMessage{}
+	if rawStates == "" {
+		return states, nil
+	}
+
+	err := json.Unmarshal([]byte(rawStates), &states)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rollback state of setup steps: %w", err)
+	}
+
+	return states, nil
+}
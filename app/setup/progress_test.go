@@ -0,0 +1,140 @@
+package setup
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+)
+
+func Test_configMapStepProgressStore_GetCompletedSteps(t *testing.T) {
+	t.Run("should return no steps if the state config map does not exist", func(t *testing.T) {
+		// given
+		sut := NewConfigMapStepProgressStore(fake.NewClientset(), "test")
+
+		// when
+		actual, err := sut.GetCompletedSteps(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, actual)
+	})
+
+	t.Run("should return completed steps", func(t *testing.T) {
+		// given
+		stateCM := createStateConfigMap(map[string]string{appcontext.SetupCompletedStepsKey: `["step-1","step-2"]`})
+		sut := NewConfigMapStepProgressStore(fake.NewClientset(stateCM), "test")
+
+		// when
+		actual, err := sut.GetCompletedSteps(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"step-1", "step-2"}, actual)
+	})
+
+	t.Run("should fail on invalid progress", func(t *testing.T) {
+		// given
+		stateCM := createStateConfigMap(map[string]string{appcontext.SetupCompletedStepsKey: "invalid"})
+		sut := NewConfigMapStepProgressStore(fake.NewClientset(stateCM), "test")
+
+		// when
+		_, err := sut.GetCompletedSteps(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to unmarshal completed setup steps")
+	})
+}
+
+func Test_configMapStepProgressStore_MarkStepCompleted(t *testing.T) {
+	t.Run("should append step to completed steps", func(t *testing.T) {
+		// given
+		stateCM := createStateConfigMap(map[string]string{
+			appcontext.SetupStateKey:          appcontext.SetupStateInstalling,
+			appcontext.SetupCompletedStepsKey: `["step-1"]`,
+		})
+		clientSet := fake.NewClientset(stateCM)
+		sut := NewConfigMapStepProgressStore(clientSet, "test")
+
+		// when
+		err := sut.MarkStepCompleted(testCtx, "step-2")
+
+		// then
+		require.NoError(t, err)
+		actualCM, err := clientSet.CoreV1().ConfigMaps("test").Get(testCtx, appcontext.SetupStateConfigMap, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, `["step-1","step-2"]`, actualCM.Data[appcontext.SetupCompletedStepsKey])
+		assert.Equal(t, appcontext.SetupStateInstalling, actualCM.Data[appcontext.SetupStateKey])
+	})
+
+	t.Run("should not add a step twice", func(t *testing.T) {
+		// given
+		stateCM := createStateConfigMap(map[string]string{appcontext.SetupCompletedStepsKey: `["step-1"]`})
+		clientSet := fake.NewClientset(stateCM)
+		sut := NewConfigMapStepProgressStore(clientSet, "test")
+
+		// when
+		err := sut.MarkStepCompleted(testCtx, "step-1")
+
+		// then
+		require.NoError(t, err)
+		actualCM, err := clientSet.CoreV1().ConfigMaps("test").Get(testCtx, appcontext.SetupStateConfigMap, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, `["step-1"]`, actualCM.Data[appcontext.SetupCompletedStepsKey])
+	})
+}
+
+func Test_configMapStepProgressStore_RollbackStates(t *testing.T) {
+	t.Run("should save, return and remove rollback states", func(t *testing.T) {
+		// given
+		stateCM := createStateConfigMap(map[string]string{appcontext.SetupStateKey: appcontext.SetupStateInstalling})
+		clientSet := fake.NewClientset(stateCM)
+		sut := NewConfigMapStepProgressStore(clientSet, "test")
+
+		// when
+		err := sut.SaveRollbackState(testCtx, "step-1", json.RawMessage(`true`))
+		require.NoError(t, err)
+		err = sut.SaveRollbackState(testCtx, "step-2", json.RawMessage(`["ldap"]`))
+		require.NoError(t, err)
+		actual, err := sut.GetRollbackStates(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, map[string]json.RawMessage{"step-1": json.RawMessage(`true`), "step-2": json.RawMessage(`["ldap"]`)}, actual)
+
+		err = sut.RemoveRollbackState(testCtx, "step-1")
+		require.NoError(t, err)
+		err = sut.RemoveRollbackState(testCtx, "step-2")
+		require.NoError(t, err)
+		actualCM, err := clientSet.CoreV1().ConfigMaps("test").Get(testCtx, appcontext.SetupStateConfigMap, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{appcontext.SetupStateKey: appcontext.SetupStateInstalling}, actualCM.Data)
+	})
+
+	t.Run("should fail on invalid rollback state", func(t *testing.T) {
+		// given
+		stateCM := createStateConfigMap(map[string]string{appcontext.SetupRollbackStateKey: "invalid"})
+		sut := NewConfigMapStepProgressStore(fake.NewClientset(stateCM), "test")
+
+		// when
+		_, err := sut.GetRollbackStates(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to unmarshal rollback state of setup steps")
+	})
+}
+
+func createStateConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: appcontext.SetupStateConfigMap, Namespace: "test"},
+		Data:       data,
+	}
+}
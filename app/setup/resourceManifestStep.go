@@ -0,0 +1,85 @@
+package setup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
+)
+
+type resourceManifestApplier interface {
+	// Apply creates or updates the resources of all manifests of the given phase and returns the created resources.
+	Apply(ctx context.Context, phase patch.Phase, manifests []patch.ResourceManifest) ([]*unstructured.Unstructured, error)
+	// Delete removes the given resources.
+	Delete(ctx context.Context, objects []*unstructured.Unstructured) error
+}
+
+// NewResourceManifestStep creates a new setup step which applies arbitrary Kubernetes resources according the given setup phase.
+func NewResourceManifestStep(phase patch.Phase, applier resourceManifestApplier, manifests []patch.ResourceManifest) *resourceManifestStep {
+	return &resourceManifestStep{phase: phase, applier: applier, manifests: manifests}
+}
+
+type resourceManifestStep struct {
+	phase     patch.Phase
+	applier   resourceManifestApplier
+	manifests []patch.ResourceManifest
+	created   []*unstructured.Unstructured
+}
+
+// GetStepID returns the stable identifier of the step.
+func (r *resourceManifestStep) GetStepID() string {
+	return fmt.Sprintf("resource-manifest/%s", r.phase)
+}
+
+// GetStepDescription returns the textual description of the resource manifest step.
+func (r *resourceManifestStep) GetStepDescription() string {
+	return fmt.Sprintf("Applying kubernetes resource manifests in phase %s", r.phase)
+}
+
+// DescribeEffect returns the sources of the manifests which the step applies in its phase.
+func (r *resourceManifestStep) DescribeEffect() plan.Effect {
+	effect := plan.Effect{Action: plan.ActionApply, Kind: "Resource"}
+	for _, manifest := range r.manifests {
+		if manifest.Phase == r.phase {
+			effect.Targets = append(effect.Targets, manifest.Source())
+		}
+	}
+
+	return effect
+}
+
+// PerformSetupStep executes the resource manifest setup step.
+func (r *resourceManifestStep) PerformSetupStep(ctx context.Context) error {
+	created, err := r.applier.Apply(ctx, r.phase, r.manifests)
+	r.created = append(r.created, created...)
+	if err != nil {
+		return fmt.Errorf("failed to apply resource manifests in phase %s: %w", r.phase, err)
+	}
+
+	return nil
+}
+
+// RollbackSetupStep deletes the resources created by this step. Resources which already existed before are kept.
+func (r *resourceManifestStep) RollbackSetupStep(ctx context.Context) error {
+	err := r.applier.Delete(ctx, r.created)
+	if err != nil {
+		return fmt.Errorf("failed to delete resources applied in phase %s: %w", r.phase, err)
+	}
+
+	r.created = nil
+	return nil
+}
+
+// GetRollbackState returns the resources created by this step.
+func (r *resourceManifestStep) GetRollbackState() (json.RawMessage, error) {
+	return json.Marshal(r.created)
+}
+
+// SetRollbackState restores the resources created by a previous setup run.
+func (r *resourceManifestStep) SetRollbackState(state json.RawMessage) error {
+	return json.Unmarshal(state, &r.created)
+}
@@ -0,0 +1,118 @@
+package setup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
+)
+
+var testManifests = []patch.ResourceManifest{{Phase: patch.DoguPhase, Manifest: "kind: ConfigMap"}}
+
+func Test_resourceManifestStep_PerformSetupStep(t *testing.T) {
+	t.Run("should succeed and record created resources", func(t *testing.T) {
+		// given
+		created := []*unstructured.Unstructured{{Object: map[string]interface{}{"kind": "ConfigMap"}}}
+		mockApplier := newMockResourceManifestApplier(t)
+		mockApplier.EXPECT().Apply(testCtx, patch.DoguPhase, testManifests).Return(created, nil)
+		sut := NewResourceManifestStep(patch.DoguPhase, mockApplier, testManifests)
+
+		// when
+		err := sut.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, created, sut.created)
+	})
+	t.Run("should record created resources on error", func(t *testing.T) {
+		// given
+		created := []*unstructured.Unstructured{{Object: map[string]interface{}{"kind": "ConfigMap"}}}
+		mockApplier := newMockResourceManifestApplier(t)
+		mockApplier.EXPECT().Apply(testCtx, patch.DoguPhase, testManifests).Return(created, assert.AnError)
+		sut := NewResourceManifestStep(patch.DoguPhase, mockApplier, testManifests)
+
+		// when
+		err := sut.PerformSetupStep(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to apply resource manifests in phase dogu")
+		assert.Equal(t, created, sut.created)
+	})
+}
+
+func Test_resourceManifestStep_RollbackSetupStep(t *testing.T) {
+	t.Run("should delete created resources", func(t *testing.T) {
+		// given
+		created := []*unstructured.Unstructured{{Object: map[string]interface{}{"kind": "ConfigMap"}}}
+		mockApplier := newMockResourceManifestApplier(t)
+		mockApplier.EXPECT().Delete(testCtx, created).Return(nil)
+		sut := &resourceManifestStep{phase: patch.DoguPhase, applier: mockApplier, created: created}
+
+		// when
+		err := sut.RollbackSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, sut.created)
+	})
+	t.Run("should return an error", func(t *testing.T) {
+		// given
+		created := []*unstructured.Unstructured{{Object: map[string]interface{}{"kind": "ConfigMap"}}}
+		mockApplier := newMockResourceManifestApplier(t)
+		mockApplier.EXPECT().Delete(testCtx, created).Return(assert.AnError)
+		sut := &resourceManifestStep{phase: patch.DoguPhase, applier: mockApplier, created: created}
+
+		// when
+		err := sut.RollbackSetupStep(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, created, sut.created)
+	})
+}
+
+func Test_resourceManifestStep_RollbackState(t *testing.T) {
+	t.Run("should restore created resources of a previous setup run", func(t *testing.T) {
+		// given
+		created := []*unstructured.Unstructured{{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "test"}}}}
+		previousStep := &resourceManifestStep{phase: patch.DoguPhase, created: created}
+		state, err := previousStep.GetRollbackState()
+		require.NoError(t, err)
+		sut := NewResourceManifestStep(patch.DoguPhase, nil, testManifests)
+
+		// when
+		err = sut.SetRollbackState(state)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, created, sut.created)
+	})
+}
+
+func Test_resourceManifestStep_DescribeEffect(t *testing.T) {
+	// given
+	manifests := []patch.ResourceManifest{
+		{Phase: patch.DoguPhase, URL: "https://example.com/manifest.yaml"},
+		{Phase: patch.ComponentPhase, Manifest: "kind: Secret"},
+		{Phase: patch.DoguPhase, Manifest: "kind: ConfigMap"},
+	}
+	sut := NewResourceManifestStep(patch.DoguPhase, nil, manifests)
+
+	// when
+	actual := sut.DescribeEffect()
+
+	// then
+	assert.Equal(t, plan.Effect{
+		Action:  plan.ActionApply,
+		Kind:    "Resource",
+		Targets: []string{"https://example.com/manifest.yaml", "inline manifest"},
+	}, actual)
+	assert.Equal(t, "resource-manifest/dogu", sut.GetStepID())
+}
@@ -3,24 +3,35 @@ package setup
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 )
 
 type resourcePatcher interface {
-	// Patch applies a configured patch to a Kubernetes resource.
-	Patch(ctx context.Context, phase patch.Phase, patches []patch.ResourcePatch) error
+	// Patch applies the configured patches of the phase whose conditions are fulfilled after rendering their templates
+	// with the given values.
+	Patch(ctx context.Context, phase patch.Phase, patches []patch.ResourcePatch, values patch.TemplateValues) error
 }
 
 // NewResourcePatchStep creates a new setup step which patches arbitrary Kubernetes resources according the given setup phase.
-func NewResourcePatchStep(phase patch.Phase, patcher resourcePatcher, patches []patch.ResourcePatch) *resourcePatchStep {
-	return &resourcePatchStep{phase: phase, patcher: patcher, patches: patches}
+// The templates and conditions of the patches use the values returned by templateValues when the step is performed.
+func NewResourcePatchStep(phase patch.Phase, patcher resourcePatcher, patches []patch.ResourcePatch, templateValues func() patch.TemplateValues) *resourcePatchStep {
+	return &resourcePatchStep{phase: phase, patcher: patcher, patches: patches, templateValues: templateValues}
 }
 
 type resourcePatchStep struct {
-	phase   patch.Phase
-	patcher resourcePatcher
-	patches []patch.ResourcePatch
+	phase          patch.Phase
+	patcher        resourcePatcher
+	patches        []patch.ResourcePatch
+	templateValues func() patch.TemplateValues
+}
+
+// GetStepID returns the stable identifier of the step.
+func (r *resourcePatchStep) GetStepID() string {
+	return fmt.Sprintf("resource-patch/%s", r.phase)
 }
 
 // GetStepDescription returns the textual description of the resource patch step.
@@ -28,9 +39,34 @@ func (r *resourcePatchStep) GetStepDescription() string {
 	return fmt.Sprintf("Patching kubernetes resources in phase %s", r.phase)
 }
 
+// DescribeEffect returns the resources which the step patches in its phase.
+func (r *resourcePatchStep) DescribeEffect() plan.Effect {
+	effect := plan.Effect{Action: plan.ActionPatch, Kind: "Resource"}
+	for _, resourcePatch := range r.patches {
+		if resourcePatch.GetPhase() != r.phase {
+			continue
+		}
+
+		effect.Targets = append(effect.Targets, resourcePatch.Resource.String())
+		for _, jsonPatch := range resourcePatch.Patches {
+			effect.Keys = append(effect.Keys, fmt.Sprintf("%s %s", jsonPatch.Operation, jsonPatch.Path))
+		}
+		for _, field := range slices.Sorted(maps.Keys(resourcePatch.Patch)) {
+			effect.Keys = append(effect.Keys, fmt.Sprintf("%s /%s", resourcePatch.GetType(), field))
+		}
+	}
+
+	return effect
+}
+
 // PerformSetupStep executes the resource patch setup step.
 func (r *resourcePatchStep) PerformSetupStep(ctx context.Context) error {
-	err := r.patcher.Patch(ctx, r.phase, r.patches)
+	values := patch.TemplateValues{}
+	if r.templateValues != nil {
+		values = r.templateValues()
+	}
+
+	err := r.patcher.Patch(ctx, r.phase, r.patches, values)
 	if err != nil {
 		return fmt.Errorf("failed to patch resources in phase %s: %w", r.phase, err)
 	}
@@ -2,6 +2,7 @@ package setup
 
 import (
 	"github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -13,7 +14,7 @@ func Test_resourcePatchStep_PerformSetupStep(t *testing.T) {
 	t.Run("should succeed", func(t *testing.T) {
 		// given
 		mockPatcher := newMockResourcePatcher(t)
-		mockPatcher.EXPECT().Patch(testCtx, patch.DoguPhase, testPatches).Return(nil)
+		mockPatcher.EXPECT().Patch(testCtx, patch.DoguPhase, testPatches, patch.TemplateValues{}).Return(nil)
 		sut := &resourcePatchStep{phase: patch.DoguPhase, patcher: mockPatcher, patches: testPatches}
 
 		// when
@@ -25,7 +26,7 @@ func Test_resourcePatchStep_PerformSetupStep(t *testing.T) {
 	t.Run("should return an error", func(t *testing.T) {
 		// given
 		mockPatcher := newMockResourcePatcher(t)
-		mockPatcher.EXPECT().Patch(testCtx, patch.DoguPhase, testPatches).Return(assert.AnError)
+		mockPatcher.EXPECT().Patch(testCtx, patch.DoguPhase, testPatches, patch.TemplateValues{}).Return(assert.AnError)
 		sut := &resourcePatchStep{phase: patch.DoguPhase, patcher: mockPatcher, patches: testPatches}
 
 		// when
@@ -36,3 +37,63 @@ func Test_resourcePatchStep_PerformSetupStep(t *testing.T) {
 		assert.ErrorIs(t, err, assert.AnError)
 	})
 }
+
+func Test_resourcePatchStep_PerformSetupStep_templateValues(t *testing.T) {
+	t.Run("should pass the current values to the patcher", func(t *testing.T) {
+		// given
+		values := patch.TemplateValues{Namespace: "ecosystem", Fqdn: "192.168.56.2", Components: []string{"k8s-longhorn"}}
+		mockPatcher := newMockResourcePatcher(t)
+		mockPatcher.EXPECT().Patch(testCtx, patch.LoadbalancerPhase, testPatches, values).Return(nil)
+		sut := NewResourcePatchStep(patch.LoadbalancerPhase, mockPatcher, testPatches, func() patch.TemplateValues { return values })
+
+		// when
+		err := sut.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+}
+
+func Test_resourcePatchStep_DescribeEffect(t *testing.T) {
+	t.Run("should describe patches of the phase", func(t *testing.T) {
+		// given
+		patches := []patch.ResourcePatch{
+			{
+				Phase:    patch.DoguPhase,
+				Resource: patch.ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "my-cm"},
+				Patches:  []patch.JsonPatch{{Operation: "add", Path: "/data/key", Value: "value"}},
+			},
+			{
+				Phase:    patch.ComponentPhase,
+				Resource: patch.ResourceReference{ApiVersion: "v1", Kind: "Secret", Name: "my-secret"},
+				Patches:  []patch.JsonPatch{{Operation: "remove", Path: "/data/key"}},
+			},
+		}
+		sut := &resourcePatchStep{phase: patch.DoguPhase, patches: patches}
+
+		// when
+		actual := sut.DescribeEffect()
+
+		// then
+		assert.Equal(t, plan.ActionPatch, actual.Action)
+		assert.Equal(t, []string{"v1/ConfigMap my-cm"}, actual.Targets)
+		assert.Equal(t, []string{"add /data/key"}, actual.Keys)
+	})
+	t.Run("should describe the fields of patch documents", func(t *testing.T) {
+		// given
+		patches := []patch.ResourcePatch{{
+			Phase:    patch.DoguPhase,
+			Type:     patch.MergePatchType,
+			Resource: patch.ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "my-cm"},
+			Patch:    map[string]any{"metadata": map[string]any{"labels": map[string]any{"key": "value"}}, "data": map[string]any{"key": "value"}},
+		}}
+		sut := &resourcePatchStep{phase: patch.DoguPhase, patches: patches}
+
+		// when
+		actual := sut.DescribeEffect()
+
+		// then
+		assert.Equal(t, []string{"v1/ConfigMap my-cm"}, actual.Targets)
+		assert.Equal(t, []string{"merge /data", "merge /metadata"}, actual.Keys)
+	})
+}
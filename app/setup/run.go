@@ -0,0 +1,94 @@
+package setup
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+// RunState describes the state of a setup run.
+type RunState string
+
+const (
+	// RunStateRunning marks a setup run which is currently performed.
+	RunStateRunning RunState = "running"
+	// RunStateSucceeded marks a setup run which finished successfully.
+	RunStateSucceeded RunState = "succeeded"
+	// RunStateFailed marks a setup run which was aborted because of an error.
+	RunStateFailed RunState = "failed"
+)
+
+// Run contains the outcome of a setup run started via the API.
+type Run struct {
+	// ID is the unique identifier of the run.
+	ID string `json:"id"`
+	// State is the current state of the run.
+	State RunState `json:"state"`
+	// StartTime is the time when the run was started.
+	StartTime time.Time `json:"startTime"`
+	// EndTime is the time when the run succeeded or failed.
+	EndTime *time.Time `json:"endTime,omitempty"`
+	// Error contains the error text if the run failed.
+	Error string `json:"error,omitempty"`
+
+	done chan struct{}
+}
+
+// runRegistry keeps all setup runs started by this process.
+type runRegistry struct {
+	mutex sync.RWMutex
+	runs  map[string]*Run
+}
+
+// setupRuns contains all setup runs started via the API of this process.
+var setupRuns = newRunRegistry()
+
+func newRunRegistry() *runRegistry {
+	return &runRegistry{runs: map[string]*Run{}}
+}
+
+// start registers a new run in the running state.
+func (rr *runRegistry) start() *Run {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	run := &Run{
+		ID:        string(uuid.NewUUID()),
+		State:     RunStateRunning,
+		StartTime: time.Now(),
+		done:      make(chan struct{}),
+	}
+	rr.runs[run.ID] = run
+
+	return run
+}
+
+// finish marks the given run as succeeded or, if err is not nil, as failed.
+func (rr *runRegistry) finish(run *Run, err error) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	now := time.Now()
+	run.EndTime = &now
+	run.State = RunStateSucceeded
+	if err != nil {
+		run.State = RunStateFailed
+		run.Error = err.Error()
+	}
+
+	close(run.done)
+}
+
+// get returns a copy of the run with the given ID.
+func (rr *runRegistry) get(id string) (Run, bool) {
+	rr.mutex.RLock()
+	defer rr.mutex.RUnlock()
+
+	run, ok := rr.runs[id]
+	if !ok {
+		return Run{}, false
+	}
+
+	return *run, true
+}
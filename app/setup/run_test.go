@@ -0,0 +1,65 @@
+package setup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runRegistry(t *testing.T) {
+	t.Run("should start a running run with unique id", func(t *testing.T) {
+		// given
+		sut := newRunRegistry()
+
+		// when
+		run1 := sut.start()
+		run2 := sut.start()
+
+		// then
+		assert.NotEqual(t, run1.ID, run2.ID)
+		actual, ok := sut.get(run1.ID)
+		require.True(t, ok)
+		assert.Equal(t, RunStateRunning, actual.State)
+		assert.Nil(t, actual.EndTime)
+	})
+
+	t.Run("should finish run successfully", func(t *testing.T) {
+		// given
+		sut := newRunRegistry()
+		run := sut.start()
+
+		// when
+		sut.finish(run, nil)
+
+		// then
+		actual, ok := sut.get(run.ID)
+		require.True(t, ok)
+		assert.Equal(t, RunStateSucceeded, actual.State)
+		assert.NotNil(t, actual.EndTime)
+		assert.Empty(t, actual.Error)
+		assert.NotPanics(t, func() { <-actual.done })
+	})
+
+	t.Run("should finish run with error", func(t *testing.T) {
+		// given
+		sut := newRunRegistry()
+		run := sut.start()
+
+		// when
+		sut.finish(run, assert.AnError)
+
+		// then
+		actual, _ := sut.get(run.ID)
+		assert.Equal(t, RunStateFailed, actual.State)
+		assert.Equal(t, assert.AnError.Error(), actual.Error)
+	})
+
+	t.Run("should not find unknown run", func(t *testing.T) {
+		// when
+		_, ok := newRunRegistry().get("unknown")
+
+		// then
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,179 @@
+package setup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// stepConcurrencyEnvVar contains the name of the environment variable which limits the number of dependent steps
+	// performed at the same time.
+	stepConcurrencyEnvVar = "DOGU_INSTALL_CONCURRENCY"
+	// defaultStepConcurrency is the number of dependent steps performed at the same time if stepConcurrencyEnvVar is
+	// not set.
+	defaultStepConcurrency = 5
+)
+
+// dependentStep is implemented by steps which depend only on some of the previous steps. Consecutive dependent steps
+// are performed concurrently, each one as soon as all the steps it depends on have succeeded.
+type dependentStep interface {
+	// GetDependencies returns the IDs of the steps which must succeed before this step is performed.
+	GetDependencies() []string
+}
+
+// scheduledStep attaches the IDs of the steps it depends on to a setup step.
+type scheduledStep struct {
+	ExecutorStep
+	dependencies []string
+}
+
+// newScheduledStep wraps the given step so that it is performed as soon as the steps with the given IDs have
+// succeeded.
+func newScheduledStep(step ExecutorStep, dependencies ...string) *scheduledStep {
+	return &scheduledStep{ExecutorStep: step, dependencies: dependencies}
+}
+
+// GetDependencies returns the IDs of the steps which must succeed before this step is performed.
+func (ss *scheduledStep) GetDependencies() []string {
+	return ss.dependencies
+}
+
+// StepConcurrency returns either defaultStepConcurrency or a positive integer if set as EnvVar
+// DOGU_INSTALL_CONCURRENCY.
+func StepConcurrency() int {
+	rawConcurrency, ok := os.LookupEnv(stepConcurrencyEnvVar)
+	if !ok {
+		return defaultStepConcurrency
+	}
+
+	concurrency, err := strconv.Atoi(rawConcurrency)
+	if err != nil || concurrency < 1 {
+		logrus.Errorf("Invalid step concurrency %s=%s (fallback to %d)", stepConcurrencyEnvVar, rawConcurrency, defaultStepConcurrency)
+		return defaultStepConcurrency
+	}
+
+	return concurrency
+}
+
+func getDependencies(step ExecutorStep) ([]string, bool) {
+	dependent, ok := step.(dependentStep)
+	if !ok {
+		return nil, false
+	}
+
+	return dependent.GetDependencies(), true
+}
+
+// dependentGroupEnd returns the index after the last step of the group of consecutive dependent steps beginning at the
+// given index. It returns the given index if the step at this index is not a dependent step.
+func dependentGroupEnd(steps []ExecutorStep, start int) int {
+	end := start
+	for end < len(steps) {
+		if _, ok := getDependencies(steps[end]); !ok {
+			break
+		}
+		end++
+	}
+
+	return end
+}
+
+// performDependentSteps performs the registered steps from start to end (exclusive) along their dependencies. A step
+// is skipped if one of its dependencies failed. Independent steps are still performed so that the errors of all
+// failed steps are returned at once.
+func (e *Executor) performDependentSteps(ctx context.Context, start int, end int, completedSteps map[string]bool, statusTracker *StatusTracker) (error, string) {
+	group := e.Steps[start:end]
+
+	dependencies, err := resolveDependencies(group)
+	if err != nil {
+		return err, "Scheduling the setup steps"
+	}
+
+	concurrency := max(e.StepConcurrency, 1)
+	semaphore := make(chan struct{}, concurrency)
+	done := make([]chan struct{}, len(group))
+	for i := range group {
+		done[i] = make(chan struct{})
+	}
+	failed := make([]bool, len(group))
+	errs := make([]error, len(group))
+
+	var wg sync.WaitGroup
+	for i, step := range group {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[i])
+
+			for _, dependency := range dependencies[i] {
+				<-done[dependency]
+				if failed[dependency] {
+					logrus.Warnf("Skipping Setup-Step: %s because step [%s] failed", step.GetStepDescription(), group[dependency].GetStepDescription())
+					failed[i] = true
+					return
+				}
+			}
+
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				failed[i] = true
+				errs[i] = fmt.Errorf("failed to perform step [%s]: %w", step.GetStepDescription(), ctx.Err())
+				return
+			}
+
+			errs[i] = e.performStep(ctx, start+i, completedSteps, statusTracker)
+			failed[i] = errs[i] != nil
+		}()
+	}
+	wg.Wait()
+
+	var failedSteps []string
+	for i, stepErr := range errs {
+		if stepErr != nil {
+			failedSteps = append(failedSteps, group[i].GetStepDescription())
+		}
+	}
+	if len(failedSteps) > 0 {
+		return errors.Join(errs...), strings.Join(failedSteps, ", ")
+	}
+
+	return nil, ""
+}
+
+// resolveDependencies returns the positions of the dependencies of every step inside the given group. Dependencies
+// which are not part of the group are expected to be performed before the group. A step must not depend on a step
+// which is registered after it, which also rules out dependency cycles.
+func resolveDependencies(group []ExecutorStep) ([][]int, error) {
+	positions := map[string]int{}
+	for i, step := range group {
+		positions[step.GetStepID()] = i
+	}
+
+	result := make([][]int, len(group))
+	for i, step := range group {
+		stepDependencies, _ := getDependencies(step)
+		for _, dependency := range stepDependencies {
+			position, ok := positions[dependency]
+			if !ok {
+				continue
+			}
+			if position >= i {
+				return nil, fmt.Errorf("step [%s] depends on step [%s] which is not registered before it", step.GetStepID(), dependency)
+			}
+			result[i] = append(result[i], position)
+		}
+	}
+
+	return result, nil
+}
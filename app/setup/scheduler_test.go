@@ -0,0 +1,279 @@
+package setup
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
+)
+
+type funcSetupStep struct {
+	id      string
+	perform func(ctx context.Context) error
+}
+
+func newFuncSetupStep(id string, perform func(ctx context.Context) error, dependencies ...string) *scheduledStep {
+	return newScheduledStep(&funcSetupStep{id: id, perform: perform}, dependencies...)
+}
+
+func (f *funcSetupStep) GetStepID() string {
+	return f.id
+}
+
+func (f *funcSetupStep) GetStepDescription() string {
+	return f.id
+}
+
+func (f *funcSetupStep) DescribeEffect() plan.Effect {
+	return plan.Effect{Action: plan.ActionCreate, Kind: "Func", Targets: []string{f.id}}
+}
+
+func (f *funcSetupStep) PerformSetupStep(ctx context.Context) error {
+	return f.perform(ctx)
+}
+
+func TestExecutor_PerformSetup_dependentSteps(t *testing.T) {
+	t.Run("should perform independent steps concurrently", func(t *testing.T) {
+		// given
+		var started sync.WaitGroup
+		started.Add(2)
+		waitForEachOther := func(ctx context.Context) error {
+			started.Done()
+			started.Wait()
+			return nil
+		}
+
+		executor := Executor{StepConcurrency: 2}
+		executor.RegisterSetupSteps(newFuncSetupStep("A", waitForEachOther), newFuncSetupStep("B", waitForEachOther))
+
+		// when
+		result := make(chan error)
+		go func() {
+			err, _ := executor.PerformSetup(testCtx)
+			result <- err
+		}()
+
+		// then
+		select {
+		case err := <-result:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("independent steps were not performed concurrently")
+		}
+	})
+
+	t.Run("should not exceed the concurrency limit", func(t *testing.T) {
+		// given
+		var running, maxRunning atomic.Int32
+		perform := func(ctx context.Context) error {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				highest := maxRunning.Load()
+				if current <= highest || maxRunning.CompareAndSwap(highest, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		}
+
+		executor := Executor{StepConcurrency: 2}
+		executor.RegisterSetupSteps(
+			newFuncSetupStep("A", perform),
+			newFuncSetupStep("B", perform),
+			newFuncSetupStep("C", perform),
+			newFuncSetupStep("D", perform),
+			newFuncSetupStep("E", perform),
+		)
+
+		// when
+		err, _ := executor.PerformSetup(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+	})
+
+	t.Run("should perform a step only after its dependencies", func(t *testing.T) {
+		// given
+		var mutex sync.Mutex
+		var order []string
+		record := func(id string) func(ctx context.Context) error {
+			return func(ctx context.Context) error {
+				mutex.Lock()
+				defer mutex.Unlock()
+				order = append(order, id)
+				return nil
+			}
+		}
+
+		executor := Executor{StepConcurrency: 5}
+		executor.RegisterSetupSteps(
+			newSimpleSetupStep("Before", false),
+			newFuncSetupStep("install-ldap", record("install-ldap")),
+			newFuncSetupStep("wait-ldap", record("wait-ldap"), "install-ldap"),
+			newFuncSetupStep("install-cas", record("install-cas"), "wait-ldap", "Before"),
+			newFuncSetupStep("install-nginx", record("install-nginx")),
+		)
+
+		// when
+		err, _ := executor.PerformSetup(testCtx)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, order, 4)
+		assert.Less(t, indexOf(order, "install-ldap"), indexOf(order, "wait-ldap"))
+		assert.Less(t, indexOf(order, "wait-ldap"), indexOf(order, "install-cas"))
+	})
+
+	t.Run("should aggregate the errors of independent branches and skip dependent steps", func(t *testing.T) {
+		// given
+		succeed := func(ctx context.Context) error { return nil }
+		performedDependent := false
+		after := newSimpleSetupStep("After", false)
+
+		statusTracker := NewStatusTracker()
+		executor := Executor{StepConcurrency: 2, StatusTracker: statusTracker}
+		executor.RegisterSetupSteps(
+			newFuncSetupStep("A", func(ctx context.Context) error { return errors.New("error A") }),
+			newFuncSetupStep("B", func(ctx context.Context) error { return errors.New("error B") }),
+			newFuncSetupStep("C", func(ctx context.Context) error {
+				performedDependent = true
+				return nil
+			}, "A"),
+			newFuncSetupStep("D", succeed),
+			after,
+		)
+
+		// when
+		err, errCausingAction := executor.PerformSetup(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to perform step [A]: error A")
+		assert.ErrorContains(t, err, "failed to perform step [B]: error B")
+		assert.Equal(t, "A, B", errCausingAction)
+		assert.False(t, performedDependent)
+		assert.False(t, after.PerformedStep)
+		steps := statusTracker.GetStatus().Steps
+		assert.Equal(t, StepStateFailed, steps[0].State)
+		assert.Equal(t, StepStateFailed, steps[1].State)
+		assert.Equal(t, StepStatePending, steps[2].State)
+		assert.Equal(t, StepStateSucceeded, steps[3].State)
+		assert.Equal(t, StepStatePending, steps[4].State)
+	})
+
+	t.Run("should skip completed dependent steps and record newly completed steps", func(t *testing.T) {
+		// given
+		performedA := false
+		progressStoreMock := NewMockStepProgressStore(t)
+		progressStoreMock.EXPECT().GetCompletedSteps(testCtx).Return([]string{"A"}, nil)
+		progressStoreMock.EXPECT().MarkStepCompleted(testCtx, "B").Return(nil)
+
+		executor := Executor{StepConcurrency: 2, ProgressStore: progressStoreMock}
+		executor.RegisterSetupSteps(
+			newFuncSetupStep("A", func(ctx context.Context) error {
+				performedA = true
+				return nil
+			}),
+			newFuncSetupStep("B", func(ctx context.Context) error { return nil }, "A"),
+		)
+
+		// when
+		err, _ := executor.PerformSetup(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, performedA)
+	})
+
+	t.Run("should fail if a step depends on a later step", func(t *testing.T) {
+		// given
+		succeed := func(ctx context.Context) error { return nil }
+		executor := Executor{StepConcurrency: 2}
+		executor.RegisterSetupSteps(newFuncSetupStep("A", succeed, "B"), newFuncSetupStep("B", succeed))
+
+		// when
+		err, errCausingAction := executor.PerformSetup(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.Equal(t, "step [A] depends on step [B] which is not registered before it", err.Error())
+		assert.Equal(t, "Scheduling the setup steps", errCausingAction)
+	})
+
+	t.Run("should not start further steps if the context is canceled", func(t *testing.T) {
+		// given
+		ctx, cancel := context.WithCancel(testCtx)
+		cancel()
+		performed := false
+
+		executor := Executor{StepConcurrency: 1}
+		executor.RegisterSetupSteps(newFuncSetupStep("A", func(ctx context.Context) error {
+			performed = true
+			return nil
+		}))
+
+		// when
+		err, _ := executor.PerformSetup(ctx)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.False(t, performed)
+	})
+}
+
+func TestExecutor_PlanSetup_dependencies(t *testing.T) {
+	// given
+	succeed := func(ctx context.Context) error { return nil }
+	executor := Executor{}
+	executor.RegisterSetupSteps(newFuncSetupStep("A", succeed), newFuncSetupStep("B", succeed, "A"))
+
+	// when
+	actual := executor.PlanSetup()
+
+	// then
+	require.Len(t, actual.Steps, 2)
+	assert.Nil(t, actual.Steps[0].DependsOn)
+	assert.Equal(t, []string{"A"}, actual.Steps[1].DependsOn)
+}
+
+func TestStepConcurrency(t *testing.T) {
+	t.Run("should return default without environment variable", func(t *testing.T) {
+		assert.Equal(t, defaultStepConcurrency, StepConcurrency())
+	})
+
+	t.Run("should return configured concurrency", func(t *testing.T) {
+		t.Setenv(stepConcurrencyEnvVar, "3")
+		assert.Equal(t, 3, StepConcurrency())
+	})
+
+	t.Run("should return default for invalid concurrency", func(t *testing.T) {
+		t.Setenv(stepConcurrencyEnvVar, "zero")
+		assert.Equal(t, defaultStepConcurrency, StepConcurrency())
+	})
+
+	t.Run("should return default for concurrency less than 1", func(t *testing.T) {
+		t.Setenv(stepConcurrencyEnvVar, "0")
+		assert.Equal(t, defaultStepConcurrency, StepConcurrency())
+	})
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -7,14 +7,22 @@ import (
 	cescommons "github.com/cloudogu/ces-commons-lib/dogu"
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
 	"github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 	"github.com/cloudogu/k8s-ces-setup/v4/app/validation"
+	"github.com/sirupsen/logrus"
 )
 
 type setupValidatorStep struct {
-	setupJsonValidator         setupJsonConfigurationValidator
-	resourcePatchValidator     resourcePatchConfigurationValidator
-	setupJsonConfiguration     *appcontext.SetupJsonConfiguration
-	resourcePatchConfiguration []patch.ResourcePatch
+	setupJsonValidator            setupJsonConfigurationValidator
+	resourcePatchValidator        resourcePatchConfigurationValidator
+	resourceManifestValidator     resourceManifestConfigurationValidator
+	componentValidator            componentConfigurationValidator
+	resourcePatchDryRunner        resourcePatchDryRunner
+	setupJsonConfiguration        *appcontext.SetupJsonConfiguration
+	resourcePatchConfiguration    []patch.ResourcePatch
+	resourceManifestConfiguration []patch.ResourceManifest
+	appConfig                     *appcontext.Config
+	templateValues                func() patch.TemplateValues
 }
 
 // setupJsonConfigurationValidator is responsible to validate the Cloudogu EcoSystem setup JSON configuration to prevent inconsistent state after a setup.
@@ -27,30 +35,130 @@ type resourcePatchConfigurationValidator interface {
 	Validate(resourcePatchConfig []patch.ResourcePatch) error
 }
 
-// NewValidatorStep creates a new setup step to validate the setup configuration.
-func NewValidatorStep(repository cescommons.RemoteDoguDescriptorRepository, setupCtx *appcontext.SetupContext) *setupValidatorStep {
+// resourcePatchDryRunner is responsible to check the setup resource patch configuration against the cluster without changing any resource.
+type resourcePatchDryRunner interface {
+	DryRun(ctx context.Context, patches []patch.ResourcePatch) error
+}
+
+// resourceManifestConfigurationValidator is responsible to validate the setup resource manifest configuration to prevent inconsistent state after a setup.
+type resourceManifestConfigurationValidator interface {
+	Validate(resourceManifestConfig []patch.ResourceManifest) error
+}
+
+// componentConfigurationValidator is responsible to validate the component operator charts and the components of the
+// setup configuration before any of them is installed.
+type componentConfigurationValidator interface {
+	Validate(config *appcontext.Config) error
+}
+
+// chartTagResolver lists the versions of a helm chart in the helm repository.
+type chartTagResolver interface {
+	Tags(ref string) ([]string, error)
+}
+
+// NewValidatorStep creates a new setup step to validate the setup configuration. If patchDryRunner is not nil, the
+// resource patches are additionally checked against the cluster. If tagResolver is not nil, the charts of the
+// components are additionally checked against the helm repository. If templateValues is not nil, the templates of the
+// resource patches are additionally rendered with the values of the setup.
+func NewValidatorStep(repository cescommons.RemoteDoguDescriptorRepository, setupCtx *appcontext.SetupContext, patchDryRunner resourcePatchDryRunner, tagResolver chartTagResolver, templateValues func() patch.TemplateValues) *setupValidatorStep {
 	setupJsonValidator := validation.NewSetupJsonConfigurationValidator(repository)
 	resourcePatchValidator := validation.NewResourcePatchConfigurationValidator()
+	resourceManifestValidator := validation.NewResourceManifestConfigurationValidator()
+	componentValidator := validation.NewComponentConfigurationValidator(tagResolver, setupCtx.HelmRepositoryData)
 
 	return &setupValidatorStep{
-		setupJsonValidator:         setupJsonValidator,
-		resourcePatchValidator:     resourcePatchValidator,
-		setupJsonConfiguration:     setupCtx.SetupJsonConfiguration,
-		resourcePatchConfiguration: setupCtx.AppConfig.ResourcePatches,
+		setupJsonValidator:            setupJsonValidator,
+		resourcePatchValidator:        resourcePatchValidator,
+		resourceManifestValidator:     resourceManifestValidator,
+		componentValidator:            componentValidator,
+		resourcePatchDryRunner:        patchDryRunner,
+		setupJsonConfiguration:        setupCtx.SetupJsonConfiguration,
+		resourcePatchConfiguration:    setupCtx.AppConfig.ResourcePatches,
+		resourceManifestConfiguration: setupCtx.AppConfig.ResourceManifests,
+		appConfig:                     setupCtx.AppConfig,
+		templateValues:                templateValues,
 	}
 }
 
+// GetStepID returns the stable identifier of the step.
+func (svs *setupValidatorStep) GetStepID() string {
+	return "validate-setup-configuration"
+}
+
 // GetStepDescription return the human-readable description of the step.
 func (svs *setupValidatorStep) GetStepDescription() string {
 	return "Validating the setup configuration"
 }
 
+// DescribeEffect returns the configurations which the step validates.
+func (svs *setupValidatorStep) DescribeEffect() plan.Effect {
+	return plan.Effect{Action: plan.ActionValidate, Kind: "Configuration", Targets: []string{"setup.json", "resource_patches", "resource_manifests", "components"}}
+}
+
 // PerformSetupStep validates the setup configuration.
 func (svs *setupValidatorStep) PerformSetupStep(ctx context.Context) error {
 	var errs []error
 
-	errs = append(errs, svs.resourcePatchValidator.Validate(svs.resourcePatchConfiguration))
+	patchErr := svs.resourcePatchValidator.Validate(svs.resourcePatchConfiguration)
+	errs = append(errs, patchErr)
+	if patchErr == nil && svs.templateValues != nil {
+		errs = append(errs, patch.ValidateTemplates(svs.resourcePatchConfiguration, svs.templateValues()))
+	}
+	// only well-formed patches are sent to the cluster
+	if patchErr == nil && svs.resourcePatchDryRunner != nil {
+		errs = append(errs, svs.resourcePatchDryRunner.DryRun(ctx, patchesAfterValidation(svs.appConfig.Pipeline, svs.resourcePatchConfiguration)))
+	}
+	errs = append(errs, svs.resourceManifestValidator.Validate(svs.resourceManifestConfiguration))
+	errs = append(errs, svs.componentValidator.Validate(svs.appConfig))
 	errs = append(errs, svs.setupJsonValidator.Validate(ctx, svs.setupJsonConfiguration))
 
 	return errors.Join(errs...)
 }
+
+// patchesAfterValidation returns the resource patches which are applied after the validation. The patches of the
+// pre-validation phase and of the stages performed before the validation have already changed the cluster. Sending
+// them again would fail, f. i. because of a test operation or a removed path.
+func patchesAfterValidation(pipeline appcontext.Pipeline, patches []patch.ResourcePatch) []patch.ResourcePatch {
+	performedStages := map[appcontext.PipelineStage]bool{}
+	for _, stage := range pipeline.Stages() {
+		if stage == appcontext.ValidationStage {
+			break
+		}
+		performedStages[stage] = !pipeline.IsDisabled(stage)
+	}
+
+	var result []patch.ResourcePatch
+	for _, resourcePatch := range patches {
+		if resourcePatch.GetPhase() == patch.PreValidationPhase || performedStages[stageOfPatch(resourcePatch)] {
+			logrus.Debugf("Skipping dry-run of patch for %s because it was already applied in phase %s", resourcePatch.Resource, resourcePatch.GetPhase())
+			continue
+		}
+		result = append(result, resourcePatch)
+	}
+
+	return result
+}
+
+// stageOfPatch returns the built-in stage which applies the resource patch or an empty stage for the patches of the
+// pre-validation and post-setup phases.
+func stageOfPatch(resourcePatch patch.ResourcePatch) appcontext.PipelineStage {
+	if resourcePatch.After != nil {
+		if resourcePatch.After.Component != "" {
+			return appcontext.ComponentStage
+		}
+		return appcontext.DoguStage
+	}
+
+	switch resourcePatch.Phase {
+	case patch.LoadbalancerPhase:
+		return appcontext.LoadBalancerStage
+	case patch.DataPhase:
+		return appcontext.DataStage
+	case patch.ComponentPhase:
+		return appcontext.ComponentStage
+	case patch.DoguPhase:
+		return appcontext.DoguStage
+	default:
+		return ""
+	}
+}
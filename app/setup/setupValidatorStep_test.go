@@ -4,11 +4,13 @@ import (
 	"context"
 	"testing"
 
+	componentOpConfig "github.com/cloudogu/k8s-component-operator/pkg/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/patch"
 )
 
 var testCtx = context.Background()
@@ -16,7 +18,9 @@ var testCtx = context.Background()
 func getSetupCtx() appcontext.SetupContext {
 	return appcontext.SetupContext{
 		AppConfig: &appcontext.Config{
-			TargetNamespace: "mynamespace",
+			TargetNamespace:           "mynamespace",
+			ComponentOperatorCrdChart: "k8s/k8s-component-operator-crd:1.2.0",
+			ComponentOperatorChart:    "k8s/k8s-component-operator:1.2.0",
 		},
 		SetupJsonConfiguration: &appcontext.SetupJsonConfiguration{},
 	}
@@ -29,7 +33,7 @@ func TestNewValidatorStep(t *testing.T) {
 		remoteDoguRepo := newMockRemoteDoguDescriptorRepository(t)
 
 		// when
-		step := NewValidatorStep(remoteDoguRepo, &ctx)
+		step := NewValidatorStep(remoteDoguRepo, &ctx, nil, nil, nil)
 
 		// then
 		require.NotNil(t, step)
@@ -41,7 +45,7 @@ func Test_setupValidatorStep_GetStepDescription(t *testing.T) {
 		// given
 		ctx := getSetupCtx()
 		remoteDoguRepo := newMockRemoteDoguDescriptorRepository(t)
-		step := NewValidatorStep(remoteDoguRepo, &ctx)
+		step := NewValidatorStep(remoteDoguRepo, &ctx, nil, nil, nil)
 
 		// when
 		description := step.GetStepDescription()
@@ -58,7 +62,7 @@ func Test_setupValidatorStep_PerformSetupStep(t *testing.T) {
 		validatorMock.EXPECT().Validate(mock.Anything, mock.Anything).Return(nil)
 		appCtx := getSetupCtx()
 		remoteDoguRepo := newMockRemoteDoguDescriptorRepository(t)
-		step := NewValidatorStep(remoteDoguRepo, &appCtx)
+		step := NewValidatorStep(remoteDoguRepo, &appCtx, nil, nil, nil)
 		step.setupJsonValidator = validatorMock
 
 		// when
@@ -67,4 +71,166 @@ func Test_setupValidatorStep_PerformSetupStep(t *testing.T) {
 		// then
 		require.NoError(t, err)
 	})
+
+	t.Run("should fail for invalid resource manifests", func(t *testing.T) {
+		// given
+		validatorMock := newMockSetupJsonConfigurationValidator(t)
+		validatorMock.EXPECT().Validate(mock.Anything, mock.Anything).Return(nil)
+		manifestValidatorMock := newMockResourceManifestConfigurationValidator(t)
+		manifestValidatorMock.EXPECT().Validate(mock.Anything).Return(assert.AnError)
+		appCtx := getSetupCtx()
+		remoteDoguRepo := newMockRemoteDoguDescriptorRepository(t)
+		step := NewValidatorStep(remoteDoguRepo, &appCtx, nil, nil, nil)
+		step.setupJsonValidator = validatorMock
+		step.resourceManifestValidator = manifestValidatorMock
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+	t.Run("should fail for invalid components", func(t *testing.T) {
+		// given
+		validatorMock := newMockSetupJsonConfigurationValidator(t)
+		validatorMock.EXPECT().Validate(mock.Anything, mock.Anything).Return(nil)
+		appCtx := getSetupCtx()
+		componentValidatorMock := newMockComponentConfigurationValidator(t)
+		componentValidatorMock.EXPECT().Validate(appCtx.AppConfig).Return(assert.AnError)
+		step := NewValidatorStep(newMockRemoteDoguDescriptorRepository(t), &appCtx, nil, nil, nil)
+		step.setupJsonValidator = validatorMock
+		step.componentValidator = componentValidatorMock
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("should check the component charts against the helm repository", func(t *testing.T) {
+		// given
+		validatorMock := newMockSetupJsonConfigurationValidator(t)
+		validatorMock.EXPECT().Validate(mock.Anything, mock.Anything).Return(nil)
+		appCtx := getSetupCtx()
+		appCtx.HelmRepositoryData = &componentOpConfig.HelmRepositoryData{Endpoint: "registry.cloudogu.com", Schema: componentOpConfig.EndpointSchemaOCI}
+		tagResolverMock := newMockChartTagResolver(t)
+		tagResolverMock.EXPECT().Tags("registry.cloudogu.com/k8s/k8s-component-operator-crd").Return([]string{"1.2.0"}, nil)
+		tagResolverMock.EXPECT().Tags("registry.cloudogu.com/k8s/k8s-component-operator").Return([]string{"1.1.0"}, nil)
+		step := NewValidatorStep(newMockRemoteDoguDescriptorRepository(t), &appCtx, nil, tagResolverMock, nil)
+		step.setupJsonValidator = validatorMock
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "version 1.2.0 of chart k8s/k8s-component-operator does not exist in the helm repository")
+	})
+
+	t.Run("should check resource patches against the cluster", func(t *testing.T) {
+		// given
+		validatorMock := newMockSetupJsonConfigurationValidator(t)
+		validatorMock.EXPECT().Validate(mock.Anything, mock.Anything).Return(nil)
+		appCtx := getSetupCtx()
+		appCtx.AppConfig.ResourcePatches = []patch.ResourcePatch{{Phase: patch.DoguPhase, Resource: patch.ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "config"}, Patches: []patch.JsonPatch{{Operation: "add", Path: "/data/a", Value: "b"}}}}
+		dryRunnerMock := newMockResourcePatchDryRunner(t)
+		dryRunnerMock.EXPECT().DryRun(testCtx, appCtx.AppConfig.ResourcePatches).Return(assert.AnError)
+		step := NewValidatorStep(newMockRemoteDoguDescriptorRepository(t), &appCtx, dryRunnerMock, nil, nil)
+		step.setupJsonValidator = validatorMock
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("should render the templates of resource patches with the values of the setup", func(t *testing.T) {
+		// given
+		validatorMock := newMockSetupJsonConfigurationValidator(t)
+		validatorMock.EXPECT().Validate(mock.Anything, mock.Anything).Return(nil)
+		appCtx := getSetupCtx()
+		appCtx.AppConfig.ResourcePatches = []patch.ResourcePatch{{Phase: patch.DoguPhase, Resource: patch.ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "config"}, Patches: []patch.JsonPatch{
+			{Operation: "add", Path: "/data/fqdn", Value: "{{ .SetupJson.naming.fqdnn }}"},
+			{Operation: "add", Path: "/data/version", Value: "{{ .DoguVersions.ldap }}"},
+		}}}
+		values := patch.TemplateValues{DoguVersions: map[string]string{"ldap": "2.1.0-1"}, SetupJson: map[string]any{"naming": map[string]any{"fqdn": "ces.example.com"}}}
+		step := NewValidatorStep(newMockRemoteDoguDescriptorRepository(t), &appCtx, nil, nil, func() patch.TemplateValues { return values })
+		step.setupJsonValidator = validatorMock
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "templates of patch for v1/ConfigMap config are invalid")
+		assert.ErrorContains(t, err, `map has no entry for key "fqdnn"`)
+		assert.NotContains(t, err.Error(), "ldap")
+	})
+
+	t.Run("should not check resource patches against the cluster which are already applied", func(t *testing.T) {
+		// given
+		validatorMock := newMockSetupJsonConfigurationValidator(t)
+		validatorMock.EXPECT().Validate(mock.Anything, mock.Anything).Return(nil)
+		loadbalancer := patch.ResourceReference{ApiVersion: "v1", Kind: "Service", Name: "ces-loadbalancer"}
+		loadbalancerPatch := patch.ResourcePatch{Phase: patch.LoadbalancerPhase, Resource: loadbalancer, Patches: []patch.JsonPatch{
+			{Operation: "test", Path: "/spec/type", Value: "LoadBalancer"},
+			{Operation: "replace", Path: "/spec/type", Value: "NodePort"},
+		}}
+		preValidationPatch := patch.ResourcePatch{Phase: patch.PreValidationPhase, Resource: loadbalancer, Patches: []patch.JsonPatch{{Operation: "remove", Path: "/metadata/labels/a"}}}
+		doguPatch := patch.ResourcePatch{Phase: patch.DoguPhase, Resource: patch.ResourceReference{ApiVersion: "v1", Kind: "ConfigMap", Name: "config"}, Patches: []patch.JsonPatch{{Operation: "add", Path: "/data/a", Value: "b"}}}
+		appCtx := getSetupCtx()
+		appCtx.AppConfig.ResourcePatches = []patch.ResourcePatch{loadbalancerPatch, preValidationPatch, doguPatch}
+		dryRunnerMock := newMockResourcePatchDryRunner(t)
+		dryRunnerMock.EXPECT().DryRun(testCtx, []patch.ResourcePatch{doguPatch}).Return(nil)
+		step := NewValidatorStep(newMockRemoteDoguDescriptorRepository(t), &appCtx, dryRunnerMock, nil, nil)
+		step.setupJsonValidator = validatorMock
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should check patches of stages against the cluster which are performed after the validation", func(t *testing.T) {
+		// given
+		validatorMock := newMockSetupJsonConfigurationValidator(t)
+		validatorMock.EXPECT().Validate(mock.Anything, mock.Anything).Return(nil)
+		loadbalancerPatch := patch.ResourcePatch{Phase: patch.LoadbalancerPhase, Resource: patch.ResourceReference{ApiVersion: "v1", Kind: "Service", Name: "ces-loadbalancer"}, Patches: []patch.JsonPatch{{Operation: "replace", Path: "/spec/type", Value: "NodePort"}}}
+		appCtx := getSetupCtx()
+		appCtx.AppConfig.ResourcePatches = []patch.ResourcePatch{loadbalancerPatch}
+		appCtx.AppConfig.Pipeline = appcontext.Pipeline{Order: []appcontext.PipelineStage{appcontext.ValidationStage}}
+		dryRunnerMock := newMockResourcePatchDryRunner(t)
+		dryRunnerMock.EXPECT().DryRun(testCtx, []patch.ResourcePatch{loadbalancerPatch}).Return(nil)
+		step := NewValidatorStep(newMockRemoteDoguDescriptorRepository(t), &appCtx, dryRunnerMock, nil, nil)
+		step.setupJsonValidator = validatorMock
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should not check invalid resource patches against the cluster", func(t *testing.T) {
+		// given
+		validatorMock := newMockSetupJsonConfigurationValidator(t)
+		validatorMock.EXPECT().Validate(mock.Anything, mock.Anything).Return(nil)
+		appCtx := getSetupCtx()
+		appCtx.AppConfig.ResourcePatches = []patch.ResourcePatch{{Phase: "unknown"}}
+		step := NewValidatorStep(newMockRemoteDoguDescriptorRepository(t), &appCtx, newMockResourcePatchDryRunner(t), nil, nil)
+		step.setupJsonValidator = validatorMock
+
+		// when
+		err := step.PerformSetupStep(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "phase 'unknown' does not exist")
+	})
 }
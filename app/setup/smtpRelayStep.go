@@ -0,0 +1,61 @@
+package setup
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/validation"
+)
+
+// smtpRelayChecker is responsible to check the relay host over which the ecosystem sends mails.
+type smtpRelayChecker interface {
+	CheckSmtpRelay(ctx context.Context, configuration *appcontext.SetupJsonConfiguration) validation.Problems
+}
+
+type smtpRelayStep struct {
+	checker       smtpRelayChecker
+	configuration *appcontext.SetupJsonConfiguration
+	sendTestMail  bool
+}
+
+// NewSmtpRelayStep creates a new setup step which checks the relay host of the setup configuration. If sendTestMail is
+// true, a test mail is sent to the admin.
+func NewSmtpRelayStep(setupCtx *appcontext.SetupContext, sendTestMail bool) *smtpRelayStep {
+	return &smtpRelayStep{
+		checker:       validation.NewSmtpRelayChecker(sendTestMail),
+		configuration: setupCtx.SetupJsonConfiguration,
+		sendTestMail:  sendTestMail,
+	}
+}
+
+// GetStepID returns the stable identifier of the step.
+func (srs *smtpRelayStep) GetStepID() string {
+	return "validate-smtp-relay"
+}
+
+// GetStepDescription return the human-readable description of the step.
+func (srs *smtpRelayStep) GetStepDescription() string {
+	if srs.sendTestMail {
+		return "Sending a test mail over the relay host"
+	}
+
+	return "Checking the relay host"
+}
+
+// DescribeEffect returns the relay host which the step checks.
+func (srs *smtpRelayStep) DescribeEffect() plan.Effect {
+	return plan.Effect{Action: plan.ActionValidate, Kind: "RelayHost", Targets: []string{srs.configuration.Naming.RelayHost}}
+}
+
+// PerformSetupStep checks the relay host. Problems which do not prevent sending mails are only logged as warnings.
+func (srs *smtpRelayStep) PerformSetupStep(ctx context.Context) error {
+	problems := srs.checker.CheckSmtpRelay(ctx, srs.configuration)
+	for _, warning := range problems.Warnings() {
+		logrus.Warnf("%s: %s", warning.Path, warning.Message)
+	}
+
+	return problems.Errors().Err()
+}
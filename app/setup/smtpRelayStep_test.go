@@ -0,0 +1,75 @@
+package setup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/validation"
+)
+
+func TestNewSmtpRelayStep(t *testing.T) {
+	t.Run("should check relay host", func(t *testing.T) {
+		// given
+		ctx := getSetupCtx()
+		ctx.SetupJsonConfiguration.Naming.RelayHost = "mail.example.com"
+
+		// when
+		step := NewSmtpRelayStep(&ctx, false)
+
+		// then
+		require.NotNil(t, step)
+		assert.Equal(t, "validate-smtp-relay", step.GetStepID())
+		assert.Equal(t, "Checking the relay host", step.GetStepDescription())
+		assert.Equal(t, plan.Effect{Action: plan.ActionValidate, Kind: "RelayHost", Targets: []string{"mail.example.com"}}, step.DescribeEffect())
+	})
+
+	t.Run("should send test mail", func(t *testing.T) {
+		// given
+		ctx := getSetupCtx()
+
+		// when
+		step := NewSmtpRelayStep(&ctx, true)
+
+		// then
+		assert.Equal(t, "Sending a test mail over the relay host", step.GetStepDescription())
+	})
+}
+
+func Test_smtpRelayStep_PerformSetupStep(t *testing.T) {
+	configuration := &appcontext.SetupJsonConfiguration{Naming: appcontext.Naming{RelayHost: "mail.example.com"}}
+
+	t.Run("should succeed with warnings", func(t *testing.T) {
+		// given
+		checkerMock := newMockSmtpRelayChecker(t)
+		checkerMock.EXPECT().CheckSmtpRelay(testCtx, configuration).Return(validation.Problems{
+			{Path: "naming.relayHost", Code: validation.CodeUnavailable, Message: "failed to start TLS", Severity: validation.SeverityWarning},
+		})
+		sut := &smtpRelayStep{checker: checkerMock, configuration: configuration}
+
+		// when
+		err := sut.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail on errors", func(t *testing.T) {
+		// given
+		checkerMock := newMockSmtpRelayChecker(t)
+		checkerMock.EXPECT().CheckSmtpRelay(testCtx, configuration).Return(validation.Problems{
+			{Path: "naming.mailAddress", Code: validation.CodeRejected, Message: "relay host rejected sender", Severity: validation.SeverityError},
+		})
+		sut := &smtpRelayStep{checker: checkerMock, configuration: configuration}
+
+		// when
+		err := sut.PerformSetupStep(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.Equal(t, "relay host rejected sender", err.Error())
+	})
+}
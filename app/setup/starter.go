@@ -2,17 +2,28 @@ package setup
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
 	k8sreg "github.com/cloudogu/k8s-registry-lib/repository"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
+	"github.com/sirupsen/logrus"
+
 	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 )
 
 // SetupExecutor is uses to register all necessary steps and executes them
 type SetupExecutor interface {
+	// RegisterPreflightStep registers the step to check the cluster before any resource is created
+	RegisterPreflightStep() error
 	// RegisterDisableDefaultSAAutomountStep registers the step to disable automount for the default service account
 	RegisterDisableDefaultSAAutomountStep() error
 	// RegisterLoadBalancerFQDNRetrieverSteps registers the FQDN retriever step
@@ -27,10 +38,87 @@ type SetupExecutor interface {
 	RegisterDataSetupSteps(globalConfig *k8sreg.GlobalConfigRepository, doguConfigProvider *k8sreg.DoguConfigRepository) error
 	// RegisterDoguInstallationSteps creates install steps for the dogu install list
 	RegisterDoguInstallationSteps(ctx context.Context) error
+	// RegisterHookSteps registers the custom steps of the given pipeline hooks
+	RegisterHookSteps(hooks []appcontext.PipelineHook) error
+	// RegisterPostSetupSteps registers the steps which are performed after all other steps
+	RegisterPostSetupSteps() error
 	// PerformSetup starts the setup and executes all registered setup steps
 	PerformSetup(ctx context.Context) (error, string)
+	// PlanSetup describes the effects of all registered setup steps without performing them
+	PlanSetup() plan.Plan
+	// RollbackSetup removes the resources created by the performed setup steps in reverse order
+	RollbackSetup(ctx context.Context) error
+}
+
+// dryRunEnvVar contains the name of the environment variable that enables the dry-run mode. In this mode the setup
+// only creates the plan of all steps instead of performing them.
+const dryRunEnvVar = "DRY_RUN"
+
+// IsDryRunEnabled checks whether the dry-run mode is enabled by the environment.
+func IsDryRunEnabled() bool {
+	dryRun, _ := strconv.ParseBool(os.Getenv(dryRunEnvVar))
+	return dryRun
+}
+
+// rollbackOnFailureEnvVar contains the name of the environment variable that enables the automatic rollback of a
+// failed setup.
+const rollbackOnFailureEnvVar = "ROLLBACK_ON_FAILURE"
+
+// IsRollbackOnFailureEnabled checks whether a failed setup should be rolled back automatically.
+func IsRollbackOnFailureEnabled() bool {
+	rollbackOnFailure, _ := strconv.ParseBool(os.Getenv(rollbackOnFailureEnvVar))
+	return rollbackOnFailure
+}
+
+// reconcileEnvVar contains the name of the environment variable that enables the reconcile mode. In this mode an
+// already installed ecosystem is reconciled with the setup configuration instead of refusing another setup run.
+const reconcileEnvVar = "RECONCILE"
+
+// IsReconcileEnabled checks whether the reconcile mode is enabled by the environment.
+func IsReconcileEnabled() bool {
+	reconcile, _ := strconv.ParseBool(os.Getenv(reconcileEnvVar))
+	return reconcile
 }
 
+// userBackendCheckEnvVar contains the name of the environment variable that enables the connection check of an
+// external user backend during the validation.
+const userBackendCheckEnvVar = "USER_BACKEND_CHECK"
+
+// IsUserBackendCheckEnabled checks whether the connection to an external user backend should be checked.
+func IsUserBackendCheckEnabled() bool {
+	userBackendCheck, _ := strconv.ParseBool(os.Getenv(userBackendCheckEnvVar))
+	return userBackendCheck
+}
+
+// smtpCheckEnvVar contains the name of the environment variable that enables the check of the relay host during the
+// validation.
+const smtpCheckEnvVar = "SMTP_CHECK"
+
+// IsSmtpCheckEnabled checks whether the relay host should be checked.
+func IsSmtpCheckEnabled() bool {
+	smtpCheck, _ := strconv.ParseBool(os.Getenv(smtpCheckEnvVar))
+	return smtpCheck || IsSmtpTestMailEnabled()
+}
+
+// smtpTestMailEnvVar contains the name of the environment variable that enables sending a test mail to the admin
+// during the check of the relay host.
+const smtpTestMailEnvVar = "SMTP_TEST_MAIL"
+
+// IsSmtpTestMailEnabled checks whether a test mail should be sent to the admin.
+func IsSmtpTestMailEnabled() bool {
+	smtpTestMail, _ := strconv.ParseBool(os.Getenv(smtpTestMailEnvVar))
+	return smtpTestMail
+}
+
+// runningSetup guards against concurrent setup runs within this process. A setup state "installing" without a
+// running setup means that a previous run was interrupted, f. i. by a pod restart, and can be resumed.
+var runningSetup sync.Mutex
+
+// lastSetup contains the starter of the last setup run of this process. Its executor knows which steps were performed
+// and is used to roll them back. If it is nil, f. i. after a restart of the setup, the setup run recorded in the setup
+// state config map is rolled back. It is guarded by runningSetup.
+var lastSetup *Starter
+
 // Starter is used to init and start the setup process
 type Starter struct {
 	globalConfigRepo *k8sreg.GlobalConfigRepository
@@ -40,6 +128,9 @@ type Starter struct {
 	SetupContext     *appcontext.SetupContext
 	Namespace        string
 	SetupExecutor    SetupExecutor
+	// Reconcile allows to run the setup on an already installed ecosystem. The steps only apply the difference
+	// between the setup configuration and the cluster.
+	Reconcile bool
 }
 
 // NewStarter creates a new setup starter struct which one inits registries and starts the setup process
@@ -67,16 +158,30 @@ func NewStarter(ctx context.Context, clusterConfig *rest.Config, k8sClient kuber
 		SetupContext:     setupContext,
 		Namespace:        namespace,
 		SetupExecutor:    setupExecutor,
+		Reconcile:        IsReconcileEnabled(),
 	}, nil
 }
 
-// StartSetup creates necessary k8s config and client, register steps and executes them
+// StartSetup creates necessary k8s config and client, register steps and executes them. If a previous setup run was
+// interrupted, the setup continues with the first step that did not finish.
 func (s *Starter) StartSetup(ctx context.Context) error {
-	err := setSetupState(ctx, s.ClientSet, s.Namespace, appcontext.SetupStateInstalling)
+	if !runningSetup.TryLock() {
+		return fmt.Errorf("setup is busy or already done")
+	}
+	defer runningSetup.Unlock()
+
+	return s.performSetup(ctx)
+}
+
+// performSetup executes the setup. The caller must hold the lock of runningSetup. A failed setup is rolled back if
+// IsRollbackOnFailureEnabled returns true.
+func (s *Starter) performSetup(ctx context.Context) error {
+	err := setSetupState(ctx, s.ClientSet, s.Namespace, appcontext.SetupStateInstalling, s.Reconcile)
 	if err != nil {
 		return err
 	}
 
+	lastSetup = s
 	err = registerSteps(ctx, s.SetupExecutor, s.globalConfigRepo, s.doguConfigRepo, s.SetupContext)
 	if err != nil {
 		return err
@@ -84,10 +189,21 @@ func (s *Starter) StartSetup(ctx context.Context) error {
 
 	err, errCausingAction := s.SetupExecutor.PerformSetup(ctx)
 	if err != nil {
-		return fmt.Errorf("error while performing setup [%s]: %w", errCausingAction, err)
+		err = fmt.Errorf("error while performing setup [%s]: %w", errCausingAction, err)
+		if !IsRollbackOnFailureEnabled() {
+			return err
+		}
+
+		logrus.Info("Rolling back the failed setup...")
+		rollbackErr := s.rollbackSetup(ctx)
+		if rollbackErr != nil {
+			return goerrors.Join(err, rollbackErr)
+		}
+
+		return err
 	}
 
-	err = setSetupState(ctx, s.ClientSet, s.Namespace, appcontext.SetupStateInstalled)
+	err = setSetupState(ctx, s.ClientSet, s.Namespace, appcontext.SetupStateInstalled, s.Reconcile)
 	if err != nil {
 		return err
 	}
@@ -95,58 +211,191 @@ func (s *Starter) StartSetup(ctx context.Context) error {
 	return nil
 }
 
-func registerSteps(ctx context.Context, setupExecutor SetupExecutor, globalConfig *k8sreg.GlobalConfigRepository, doguConfig *k8sreg.DoguConfigRepository, setupContext *appcontext.SetupContext) error {
-	err := setupExecutor.RegisterDisableDefaultSAAutomountStep()
+// rollbackSetup removes the resources created by the performed setup steps, restores the registry keys which they
+// overwrote and resets the setup state so that the setup can be started again. Resources which existed before are kept,
+// so the rollback of a reconcile run leaves the installed ecosystem in place and marks the setup as installed again.
+// The caller must hold the lock of runningSetup.
+func (s *Starter) rollbackSetup(ctx context.Context) error {
+	err := s.SetupExecutor.RollbackSetup(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to register step for disabling automount of the default service account in the ecosystem namespace: %w", err)
+		return fmt.Errorf("error while rolling back setup: %w", err)
 	}
 
-	err = setupExecutor.RegisterLoadBalancerFQDNRetrieverSteps()
+	if s.Reconcile {
+		return setSetupState(ctx, s.ClientSet, s.Namespace, appcontext.SetupStateInstalled, s.Reconcile)
+	}
+
+	return resetSetupState(ctx, s.ClientSet, s.Namespace)
+}
+
+// rollbackRecordedSetup rolls back the setup run whose rollback data is recorded in the setup state config map, f. i.
+// after the setup was restarted. The steps are registered again so that the executor can restore their rollback data.
+// The caller must hold the lock of runningSetup.
+func (s *Starter) rollbackRecordedSetup(ctx context.Context) error {
+	stateCM, err := appcontext.GetSetupStateConfigMap(ctx, s.ClientSet, s.Namespace)
 	if err != nil {
-		return fmt.Errorf("failed to register steps for creating loadbalancer and retrieving its ip as fqdn: %w", err)
+		return fmt.Errorf("failed to get k8s-ces-setup configmap: %w", err)
 	}
+	s.Reconcile = stateCM.Data[appcontext.SetupReconcileKey] == "true"
 
-	if setupContext.SetupJsonConfiguration.Naming.CertificateType == "selfsigned" {
-		err := setupExecutor.RegisterSSLGenerationStep()
-		if err != nil {
-			return fmt.Errorf("failed to register ssl generation setup step: %w", err)
-		}
+	err = registerSteps(ctx, s.SetupExecutor, s.globalConfigRepo, s.doguConfigRepo, s.SetupContext)
+	if err != nil {
+		return err
 	}
 
-	err = setupExecutor.RegisterValidationStep()
+	return s.rollbackSetup(ctx)
+}
+
+// PlanSetup registers all steps and describes their effects without performing them. In contrast to StartSetup, the
+// setup state is not modified.
+func (s *Starter) PlanSetup(ctx context.Context) (plan.Plan, error) {
+	err := registerSteps(ctx, s.SetupExecutor, s.globalConfigRepo, s.doguConfigRepo, s.SetupContext)
 	if err != nil {
-		return fmt.Errorf("failed to register validation setup steps: %w", err)
+		return plan.Plan{}, err
 	}
 
-	err = setupExecutor.RegisterDataSetupSteps(globalConfig, doguConfig)
+	return s.SetupExecutor.PlanSetup(), nil
+}
+
+// registerSteps registers the steps of all built-in stages which are not disabled by the pipeline configuration
+// together with the custom steps of the pipeline hooks and finally the post-setup steps.
+func registerSteps(ctx context.Context, setupExecutor SetupExecutor, globalConfig *k8sreg.GlobalConfigRepository, doguConfig *k8sreg.DoguConfigRepository, setupContext *appcontext.SetupContext) error {
+	pipeline := setupContext.AppConfig.Pipeline
+	err := pipeline.Validate()
 	if err != nil {
-		return fmt.Errorf("failed to register data setup steps: %w", err)
+		return fmt.Errorf("invalid pipeline configuration: %w", err)
+	}
+
+	stages := map[appcontext.PipelineStage]func() error{
+		appcontext.PreflightStage: func() error {
+			err := setupExecutor.RegisterPreflightStep()
+			if err != nil {
+				return fmt.Errorf("failed to register preflight step: %w", err)
+			}
+			return nil
+		},
+		appcontext.DefaultSAAutomountStage: func() error {
+			err := setupExecutor.RegisterDisableDefaultSAAutomountStep()
+			if err != nil {
+				return fmt.Errorf("failed to register step for disabling automount of the default service account in the ecosystem namespace: %w", err)
+			}
+			return nil
+		},
+		appcontext.LoadBalancerStage: func() error {
+			err := setupExecutor.RegisterLoadBalancerFQDNRetrieverSteps()
+			if err != nil {
+				return fmt.Errorf("failed to register steps for creating loadbalancer and retrieving its ip as fqdn: %w", err)
+			}
+			return nil
+		},
+		appcontext.SSLGenerationStage: func() error {
+			if setupContext.SetupJsonConfiguration.Naming.CertificateType != "selfsigned" {
+				return nil
+			}
+			err := setupExecutor.RegisterSSLGenerationStep()
+			if err != nil {
+				return fmt.Errorf("failed to register ssl generation setup step: %w", err)
+			}
+			return nil
+		},
+		appcontext.ValidationStage: func() error {
+			err := setupExecutor.RegisterValidationStep()
+			if err != nil {
+				return fmt.Errorf("failed to register validation setup steps: %w", err)
+			}
+			return nil
+		},
+		appcontext.DataStage: func() error {
+			err := setupExecutor.RegisterDataSetupSteps(globalConfig, doguConfig)
+			if err != nil {
+				return fmt.Errorf("failed to register data setup steps: %w", err)
+			}
+			return nil
+		},
+		appcontext.ComponentStage: func() error {
+			err := setupExecutor.RegisterComponentSetupSteps()
+			if err != nil {
+				return fmt.Errorf("failed to register component setup steps: %w", err)
+			}
+			return nil
+		},
+		appcontext.DoguStage: func() error {
+			err := setupExecutor.RegisterDoguInstallationSteps(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to register dogu installation steps: %w", err)
+			}
+			return nil
+		},
+	}
+
+	for _, stage := range pipeline.Stages() {
+		err := registerHooks(setupExecutor, pipeline.HooksBefore(stage))
+		if err != nil {
+			return err
+		}
+
+		if pipeline.IsDisabled(stage) {
+			logrus.Infof("Skipping disabled setup stage %s", stage)
+		} else {
+			err = stages[stage]()
+			if err != nil {
+				return err
+			}
+		}
+
+		err = registerHooks(setupExecutor, pipeline.HooksAfter(stage))
+		if err != nil {
+			return err
+		}
 	}
 
-	err = setupExecutor.RegisterComponentSetupSteps()
+	err = setupExecutor.RegisterPostSetupSteps()
 	if err != nil {
-		return fmt.Errorf("failed to register component setup steps: %w", err)
+		return fmt.Errorf("failed to register post-setup steps: %w", err)
 	}
 
-	err = setupExecutor.RegisterDoguInstallationSteps(ctx)
+	return nil
+}
+
+func registerHooks(setupExecutor SetupExecutor, hooks []appcontext.PipelineHook) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	err := setupExecutor.RegisterHookSteps(hooks)
 	if err != nil {
-		return fmt.Errorf("failed to register dogu installation steps: %w", err)
+		return fmt.Errorf("failed to register pipeline hook steps: %w", err)
 	}
 
 	return nil
 }
 
-func setSetupState(ctx context.Context, clientSet kubernetes.Interface, namespace string, state string) error {
+// setSetupState updates the setup state. A setup of an installed ecosystem is only allowed if reconcile is true.
+func setSetupState(ctx context.Context, clientSet kubernetes.Interface, namespace string, state string, reconcile bool) error {
 	stateCM, err := appcontext.GetSetupStateConfigMap(ctx, clientSet, namespace)
 	if err != nil {
 		return fmt.Errorf("failed to get k8s-ces-setup configmap: %w", err)
 	}
 
+	actualState := stateCM.Data[appcontext.SetupStateKey]
 	if state == appcontext.SetupStateInstalling {
-		actualState := stateCM.Data[appcontext.SetupStateKey]
-		if actualState == appcontext.SetupStateInstalling || actualState == appcontext.SetupStateInstalled {
+		if actualState == appcontext.SetupStateInstalled && !reconcile {
 			return fmt.Errorf("setup is busy or already done")
 		}
+		if actualState == appcontext.SetupStateInstalled {
+			logrus.Info("Reconciling the installed ecosystem with the setup configuration...")
+		}
+		if actualState == appcontext.SetupStateInstalling {
+			logrus.Info("Found interrupted setup. Resuming setup from the first step that did not finish...")
+		} else {
+			// the rollback data of a finished setup run must not be rolled back together with the new run
+			delete(stateCM.Data, appcontext.SetupRollbackStateKey)
+			stateCM.Data[appcontext.SetupReconcileKey] = strconv.FormatBool(reconcile)
+		}
+	}
+
+	if state == appcontext.SetupStateInstalled {
+		delete(stateCM.Data, appcontext.SetupCompletedStepsKey)
 	}
 
 	stateCM.Data[appcontext.SetupStateKey] = state
@@ -157,3 +406,51 @@ func setSetupState(ctx context.Context, clientSet kubernetes.Interface, namespac
 
 	return nil
 }
+
+// resetSetupState removes the setup state, the progress and the rollback data of the setup so that the next setup run
+// starts from the first step.
+func resetSetupState(ctx context.Context, clientSet kubernetes.Interface, namespace string) error {
+	stateCM, err := appcontext.GetSetupStateConfigMap(ctx, clientSet, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get k8s-ces-setup configmap: %w", err)
+	}
+
+	delete(stateCM.Data, appcontext.SetupStateKey)
+	delete(stateCM.Data, appcontext.SetupCompletedStepsKey)
+	delete(stateCM.Data, appcontext.SetupRollbackStateKey)
+	delete(stateCM.Data, appcontext.SetupReconcileKey)
+	_, err = clientSet.CoreV1().ConfigMaps(namespace).Update(ctx, stateCM, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update k8s-ces-setup configmap: %w", err)
+	}
+
+	return nil
+}
+
+// IsSetupInterrupted checks whether the setup state indicates a setup run that did not finish. As only one setup can
+// run at a time, such a state found at startup belongs to a run that was interrupted.
+func IsSetupInterrupted(ctx context.Context, clientSet kubernetes.Interface, namespace string) (bool, error) {
+	stateCM, err := clientSet.CoreV1().ConfigMaps(namespace).Get(ctx, appcontext.SetupStateConfigMap, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get configmap [%s]: %w", appcontext.SetupStateConfigMap, err)
+	}
+
+	return stateCM.Data[appcontext.SetupStateKey] == appcontext.SetupStateInstalling, nil
+}
+
+// hasRecordedSetup checks whether the setup state config map contains rollback data of a setup run which can be rolled
+// back.
+func hasRecordedSetup(ctx context.Context, clientSet kubernetes.Interface, namespace string) (bool, error) {
+	stateCM, err := clientSet.CoreV1().ConfigMaps(namespace).Get(ctx, appcontext.SetupStateConfigMap, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get configmap [%s]: %w", appcontext.SetupStateConfigMap, err)
+	}
+
+	return stateCM.Data[appcontext.SetupRollbackStateKey] != "", nil
+}
@@ -1,9 +1,14 @@
 package setup
 
 import (
+	gocontext "context"
+	"errors"
 	"testing"
 
+	k8sreg "github.com/cloudogu/k8s-registry-lib/repository"
+
 	"github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -22,6 +27,7 @@ func TestStarter_StartSetup(t *testing.T) {
 		// given
 		executorMock := NewMockSetupExecutor(t)
 		expect := executorMock.EXPECT()
+		expect.RegisterPreflightStep().Return(nil)
 		expect.RegisterDisableDefaultSAAutomountStep().Return(nil)
 		expect.RegisterLoadBalancerFQDNRetrieverSteps().Return(nil)
 		expect.RegisterSSLGenerationStep().Return(nil)
@@ -29,6 +35,7 @@ func TestStarter_StartSetup(t *testing.T) {
 		expect.RegisterComponentSetupSteps().Return(nil)
 		expect.RegisterDataSetupSteps(mock.Anything, mock.Anything).Return(nil)
 		expect.RegisterDoguInstallationSteps(mock.Anything).Return(nil)
+		expect.RegisterPostSetupSteps().Return(nil)
 		expect.PerformSetup(testCtx).Return(nil, "")
 		starter.SetupExecutor = executorMock
 		starter.ClientSet = fake.NewClientset()
@@ -45,6 +52,7 @@ func TestStarter_StartSetup(t *testing.T) {
 		setupContext.SetupJsonConfiguration.Naming.Fqdn = "My-Test-FQDN"
 		executorMock := NewMockSetupExecutor(t)
 		expect := executorMock.EXPECT()
+		expect.RegisterPreflightStep().Return(nil)
 		expect.RegisterDisableDefaultSAAutomountStep().Return(nil)
 		expect.RegisterLoadBalancerFQDNRetrieverSteps().Return(nil)
 		expect.RegisterSSLGenerationStep().Return(nil)
@@ -52,6 +60,7 @@ func TestStarter_StartSetup(t *testing.T) {
 		expect.RegisterDataSetupSteps(mock.Anything, mock.Anything).Return(nil)
 		expect.RegisterComponentSetupSteps().Return(nil)
 		expect.RegisterDoguInstallationSteps(mock.Anything).Return(nil)
+		expect.RegisterPostSetupSteps().Return(nil)
 		expect.PerformSetup(testCtx).Return(nil, "")
 		starter.SetupExecutor = executorMock
 		starter.ClientSet = fake.NewClientset()
@@ -63,18 +72,54 @@ func TestStarter_StartSetup(t *testing.T) {
 		require.NoError(t, err)
 	})
 
-	t.Run("failed because setup is busy", func(t *testing.T) {
+	t.Run("should resume interrupted setup", func(t *testing.T) {
 		// given
-		doneStarter := &Starter{}
-		doneStarter.SetupContext = &setupContext
-		doneStarter.Namespace = "test"
+		executorMock := NewMockSetupExecutor(t)
+		expect := executorMock.EXPECT()
+		expect.RegisterPreflightStep().Return(nil)
+		expect.RegisterDisableDefaultSAAutomountStep().Return(nil)
+		expect.RegisterLoadBalancerFQDNRetrieverSteps().Return(nil)
+		expect.RegisterSSLGenerationStep().Return(nil)
+		expect.RegisterValidationStep().Return(nil)
+		expect.RegisterComponentSetupSteps().Return(nil)
+		expect.RegisterDataSetupSteps(mock.Anything, mock.Anything).Return(nil)
+		expect.RegisterDoguInstallationSteps(mock.Anything).Return(nil)
+		expect.RegisterPostSetupSteps().Return(nil)
+		expect.PerformSetup(testCtx).Return(nil, "")
+
+		interruptedStarter := &Starter{}
+		interruptedStarter.SetupContext = &setupContext
+		interruptedStarter.Namespace = "test"
+		interruptedStarter.SetupExecutor = executorMock
 		data := make(map[string]string)
 		data[context.SetupStateKey] = context.SetupStateInstalling
+		data[context.SetupCompletedStepsKey] = `["step-1"]`
 		configmap := &v1.ConfigMap{ObjectMeta: v12.ObjectMeta{Name: context.SetupStateConfigMap, Namespace: "test"}, Data: data}
-		doneStarter.ClientSet = fake.NewClientset(configmap)
+		interruptedStarter.ClientSet = fake.NewClientset(configmap)
 
 		// when
-		err := doneStarter.StartSetup(testCtx)
+		err := interruptedStarter.StartSetup(testCtx)
+
+		// then
+		require.NoError(t, err)
+		actualCM, err := interruptedStarter.ClientSet.CoreV1().ConfigMaps("test").Get(testCtx, context.SetupStateConfigMap, v12.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, context.SetupStateInstalled, actualCM.Data[context.SetupStateKey])
+		assert.NotContains(t, actualCM.Data, context.SetupCompletedStepsKey)
+	})
+
+	t.Run("failed because setup is busy", func(t *testing.T) {
+		// given
+		runningSetup.Lock()
+		defer runningSetup.Unlock()
+
+		busyStarter := &Starter{}
+		busyStarter.SetupContext = &setupContext
+		busyStarter.Namespace = "test"
+		busyStarter.ClientSet = fake.NewClientset()
+
+		// when
+		err := busyStarter.StartSetup(testCtx)
 
 		// then
 		require.Error(t, err)
@@ -99,9 +144,62 @@ func TestStarter_StartSetup(t *testing.T) {
 		assert.Contains(t, err.Error(), "setup is busy or already done")
 	})
 
+	t.Run("should reconcile installed ecosystem", func(t *testing.T) {
+		// given
+		executorMock := NewMockSetupExecutor(t)
+		expect := executorMock.EXPECT()
+		expect.RegisterPreflightStep().Return(nil)
+		expect.RegisterDisableDefaultSAAutomountStep().Return(nil)
+		expect.RegisterLoadBalancerFQDNRetrieverSteps().Return(nil)
+		expect.RegisterSSLGenerationStep().Return(nil)
+		expect.RegisterValidationStep().Return(nil)
+		expect.RegisterComponentSetupSteps().Return(nil)
+		expect.RegisterDataSetupSteps(mock.Anything, mock.Anything).Return(nil)
+		expect.RegisterDoguInstallationSteps(mock.Anything).Return(nil)
+		expect.RegisterPostSetupSteps().Return(nil)
+		expect.PerformSetup(testCtx).Return(nil, "")
+
+		reconcileStarter := &Starter{Reconcile: true}
+		reconcileStarter.SetupContext = &setupContext
+		reconcileStarter.Namespace = "test"
+		reconcileStarter.SetupExecutor = executorMock
+		data := make(map[string]string)
+		data[context.SetupStateKey] = context.SetupStateInstalled
+		data[context.SetupRollbackStateKey] = `{"create-loadbalancer":true}`
+		configmap := &v1.ConfigMap{ObjectMeta: v12.ObjectMeta{Name: context.SetupStateConfigMap, Namespace: "test"}, Data: data}
+		reconcileStarter.ClientSet = fake.NewClientset(configmap)
+
+		// when
+		err := reconcileStarter.StartSetup(testCtx)
+
+		// then
+		require.NoError(t, err)
+		actualCM, err := reconcileStarter.ClientSet.CoreV1().ConfigMaps("test").Get(testCtx, context.SetupStateConfigMap, v12.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, context.SetupStateInstalled, actualCM.Data[context.SetupStateKey])
+		assert.Equal(t, "true", actualCM.Data[context.SetupReconcileKey])
+		assert.NotContains(t, actualCM.Data, context.SetupRollbackStateKey)
+	})
+
+	t.Run("failed to register preflight step", func(t *testing.T) {
+		// given
+		executorMock := NewMockSetupExecutor(t)
+		executorMock.EXPECT().RegisterPreflightStep().Return(assert.AnError)
+		starter.SetupExecutor = executorMock
+		starter.ClientSet = fake.NewClientset()
+
+		// when
+		err := starter.StartSetup(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to register preflight step")
+	})
+
 	t.Run("failed to register disable default service account automount step", func(t *testing.T) {
 		// given
 		executorMock := NewMockSetupExecutor(t)
+		executorMock.EXPECT().RegisterPreflightStep().Return(nil)
 		executorMock.EXPECT().RegisterDisableDefaultSAAutomountStep().Return(assert.AnError)
 		starter.SetupExecutor = executorMock
 		starter.ClientSet = fake.NewClientset()
@@ -117,6 +215,7 @@ func TestStarter_StartSetup(t *testing.T) {
 	t.Run("failed to register loadbalancer fqdn retriever steps", func(t *testing.T) {
 		// given
 		executorMock := NewMockSetupExecutor(t)
+		executorMock.EXPECT().RegisterPreflightStep().Return(nil)
 		executorMock.EXPECT().RegisterDisableDefaultSAAutomountStep().Return(nil)
 		executorMock.EXPECT().RegisterLoadBalancerFQDNRetrieverSteps().Return(assert.AnError)
 		starter.SetupExecutor = executorMock
@@ -133,6 +232,7 @@ func TestStarter_StartSetup(t *testing.T) {
 	t.Run("failed to register ssl generate step", func(t *testing.T) {
 		// given
 		executorMock := NewMockSetupExecutor(t)
+		executorMock.EXPECT().RegisterPreflightStep().Return(nil)
 		executorMock.EXPECT().RegisterDisableDefaultSAAutomountStep().Return(nil)
 		executorMock.EXPECT().RegisterLoadBalancerFQDNRetrieverSteps().Return(nil)
 		executorMock.EXPECT().RegisterSSLGenerationStep().Return(assert.AnError)
@@ -151,6 +251,7 @@ func TestStarter_StartSetup(t *testing.T) {
 		// given
 		executorMock := NewMockSetupExecutor(t)
 		expect := executorMock.EXPECT()
+		executorMock.EXPECT().RegisterPreflightStep().Return(nil)
 		executorMock.EXPECT().RegisterDisableDefaultSAAutomountStep().Return(nil)
 		expect.RegisterLoadBalancerFQDNRetrieverSteps().Return(nil)
 		expect.RegisterSSLGenerationStep().Return(nil)
@@ -170,6 +271,7 @@ func TestStarter_StartSetup(t *testing.T) {
 		// given
 		executorMock := NewMockSetupExecutor(t)
 		expect := executorMock.EXPECT()
+		executorMock.EXPECT().RegisterPreflightStep().Return(nil)
 		executorMock.EXPECT().RegisterDisableDefaultSAAutomountStep().Return(nil)
 		expect.RegisterLoadBalancerFQDNRetrieverSteps().Return(nil)
 		expect.RegisterSSLGenerationStep().Return(nil)
@@ -191,6 +293,7 @@ func TestStarter_StartSetup(t *testing.T) {
 		// given
 		executorMock := NewMockSetupExecutor(t)
 		expect := executorMock.EXPECT()
+		executorMock.EXPECT().RegisterPreflightStep().Return(nil)
 		executorMock.EXPECT().RegisterDisableDefaultSAAutomountStep().Return(nil)
 		expect.RegisterLoadBalancerFQDNRetrieverSteps().Return(nil)
 		expect.RegisterSSLGenerationStep().Return(nil)
@@ -211,6 +314,7 @@ func TestStarter_StartSetup(t *testing.T) {
 		// given
 		executorMock := NewMockSetupExecutor(t)
 		expect := executorMock.EXPECT()
+		executorMock.EXPECT().RegisterPreflightStep().Return(nil)
 		executorMock.EXPECT().RegisterDisableDefaultSAAutomountStep().Return(nil)
 		expect.RegisterLoadBalancerFQDNRetrieverSteps().Return(nil)
 		expect.RegisterSSLGenerationStep().Return(nil)
@@ -228,4 +332,451 @@ func TestStarter_StartSetup(t *testing.T) {
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to register dogu installation steps")
 	})
+
+	t.Run("failed to register post-setup steps", func(t *testing.T) {
+		// given
+		executorMock := NewMockSetupExecutor(t)
+		expect := executorMock.EXPECT()
+		expect.RegisterPreflightStep().Return(nil)
+		expect.RegisterDisableDefaultSAAutomountStep().Return(nil)
+		expect.RegisterLoadBalancerFQDNRetrieverSteps().Return(nil)
+		expect.RegisterSSLGenerationStep().Return(nil)
+		expect.RegisterValidationStep().Return(nil)
+		expect.RegisterComponentSetupSteps().Return(nil)
+		expect.RegisterDataSetupSteps(mock.Anything, mock.Anything).Return(nil)
+		expect.RegisterDoguInstallationSteps(mock.Anything).Return(nil)
+		expect.RegisterPostSetupSteps().Return(assert.AnError)
+		starter.SetupExecutor = executorMock
+		starter.ClientSet = fake.NewClientset()
+
+		// when
+		err := starter.StartSetup(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Contains(t, err.Error(), "failed to register post-setup steps")
+	})
+}
+
+func TestStarter_PlanSetup(t *testing.T) {
+	setupContext := context.SetupContext{AppConfig: &context.Config{TargetNamespace: "test"}, SetupJsonConfiguration: &context.SetupJsonConfiguration{}}
+
+	t.Run("should return plan without modifying the setup state", func(t *testing.T) {
+		// given
+		expectedPlan := plan.Plan{Steps: []plan.Step{{Index: 0, ID: "Step1", Description: "Step1"}}}
+		executorMock := NewMockSetupExecutor(t)
+		expect := executorMock.EXPECT()
+		expect.RegisterPreflightStep().Return(nil)
+		expect.RegisterDisableDefaultSAAutomountStep().Return(nil)
+		expect.RegisterLoadBalancerFQDNRetrieverSteps().Return(nil)
+		expect.RegisterValidationStep().Return(nil)
+		expect.RegisterComponentSetupSteps().Return(nil)
+		expect.RegisterDataSetupSteps(mock.Anything, mock.Anything).Return(nil)
+		expect.RegisterDoguInstallationSteps(mock.Anything).Return(nil)
+		expect.RegisterPostSetupSteps().Return(nil)
+		expect.PlanSetup().Return(expectedPlan)
+		clientSet := fake.NewClientset()
+		starter := &Starter{SetupContext: &setupContext, Namespace: "test", SetupExecutor: executorMock, ClientSet: clientSet}
+
+		// when
+		actual, err := starter.PlanSetup(testCtx)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, expectedPlan, actual)
+		configMaps, err := clientSet.CoreV1().ConfigMaps("test").List(testCtx, v12.ListOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, configMaps.Items)
+	})
+
+	t.Run("should fail to register steps", func(t *testing.T) {
+		// given
+		executorMock := NewMockSetupExecutor(t)
+		executorMock.EXPECT().RegisterPreflightStep().Return(nil)
+		executorMock.EXPECT().RegisterDisableDefaultSAAutomountStep().Return(assert.AnError)
+		starter := &Starter{SetupContext: &setupContext, Namespace: "test", SetupExecutor: executorMock}
+
+		// when
+		_, err := starter.PlanSetup(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func Test_registerSteps_pipeline(t *testing.T) {
+	t.Run("should register stages and hooks in the configured order", func(t *testing.T) {
+		// given
+		var registered []string
+		record := func(name string) func() {
+			return func() { registered = append(registered, name) }
+		}
+		beforeDogus := context.PipelineHook{Name: "before-dogus", Before: context.DoguStage, Wait: &context.WaitHook{Component: "k8s-longhorn"}}
+		afterLoadBalancer := context.PipelineHook{Name: "after-loadbalancer", After: context.LoadBalancerStage, Wait: &context.WaitHook{Dogu: "ldap"}}
+		pipeline := context.Pipeline{
+			Disable: []context.PipelineStage{context.DefaultSAAutomountStage, context.LoadBalancerStage},
+			Order:   []context.PipelineStage{context.ValidationStage, context.DataStage},
+			Hooks:   []context.PipelineHook{beforeDogus, afterLoadBalancer},
+		}
+		setupContext := &context.SetupContext{
+			AppConfig:              &context.Config{TargetNamespace: "test", Pipeline: pipeline},
+			SetupJsonConfiguration: &context.SetupJsonConfiguration{Naming: context.Naming{CertificateType: "selfsigned"}},
+		}
+
+		executorMock := NewMockSetupExecutor(t)
+		expect := executorMock.EXPECT()
+		expect.RegisterPreflightStep().Run(record("preflight")).Return(nil)
+		expect.RegisterValidationStep().Run(record("validation")).Return(nil)
+		expect.RegisterDataSetupSteps(mock.Anything, mock.Anything).Run(func(*k8sreg.GlobalConfigRepository, *k8sreg.DoguConfigRepository) {
+			record("data")()
+		}).Return(nil)
+		expect.RegisterSSLGenerationStep().Run(record("ssl-generation")).Return(nil)
+		expect.RegisterComponentSetupSteps().Run(record("components")).Return(nil)
+		expect.RegisterDoguInstallationSteps(testCtx).Run(func(gocontext.Context) { record("dogus")() }).Return(nil)
+		expect.RegisterHookSteps([]context.PipelineHook{afterLoadBalancer}).Run(func([]context.PipelineHook) { record("after-loadbalancer")() }).Return(nil)
+		expect.RegisterHookSteps([]context.PipelineHook{beforeDogus}).Run(func([]context.PipelineHook) { record("before-dogus")() }).Return(nil)
+		expect.RegisterPostSetupSteps().Run(record("post-setup")).Return(nil)
+
+		// when
+		err := registerSteps(testCtx, executorMock, nil, nil, setupContext)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"preflight", "validation", "data", "after-loadbalancer", "ssl-generation", "components", "before-dogus", "dogus", "post-setup"}, registered)
+	})
+
+	t.Run("should fail for invalid pipeline before registering any step", func(t *testing.T) {
+		// given
+		setupContext := &context.SetupContext{
+			AppConfig:              &context.Config{Pipeline: context.Pipeline{Disable: []context.PipelineStage{"unknown"}}},
+			SetupJsonConfiguration: &context.SetupJsonConfiguration{},
+		}
+
+		// when
+		err := registerSteps(testCtx, NewMockSetupExecutor(t), nil, nil, setupContext)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "invalid pipeline configuration: disabled stage 'unknown' does not exist")
+	})
+
+	t.Run("should fail to register hook steps", func(t *testing.T) {
+		// given
+		hook := context.PipelineHook{Name: "hook", Before: context.DefaultSAAutomountStage, Wait: &context.WaitHook{Dogu: "ldap"}}
+		setupContext := &context.SetupContext{
+			AppConfig:              &context.Config{Pipeline: context.Pipeline{Hooks: []context.PipelineHook{hook}}},
+			SetupJsonConfiguration: &context.SetupJsonConfiguration{},
+		}
+		executorMock := NewMockSetupExecutor(t)
+		executorMock.EXPECT().RegisterPreflightStep().Return(nil)
+		executorMock.EXPECT().RegisterHookSteps([]context.PipelineHook{hook}).Return(assert.AnError)
+
+		// when
+		err := registerSteps(testCtx, executorMock, nil, nil, setupContext)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to register pipeline hook steps")
+	})
+}
+
+func TestStarter_StartSetup_rollback(t *testing.T) {
+	setupContext := context.SetupContext{AppConfig: &context.Config{TargetNamespace: "test"}, SetupJsonConfiguration: &context.SetupJsonConfiguration{}}
+	newExecutorMock := func(t *testing.T) *MockSetupExecutor {
+		executorMock := NewMockSetupExecutor(t)
+		expect := executorMock.EXPECT()
+		expect.RegisterPreflightStep().Return(nil)
+		expect.RegisterDisableDefaultSAAutomountStep().Return(nil)
+		expect.RegisterLoadBalancerFQDNRetrieverSteps().Return(nil)
+		expect.RegisterValidationStep().Return(nil)
+		expect.RegisterComponentSetupSteps().Return(nil)
+		expect.RegisterDataSetupSteps(mock.Anything, mock.Anything).Return(nil)
+		expect.RegisterDoguInstallationSteps(mock.Anything).Return(nil)
+		expect.RegisterPostSetupSteps().Return(nil)
+		expect.PerformSetup(testCtx).Return(assert.AnError, "Step1")
+		return executorMock
+	}
+
+	t.Run("should roll back failed setup and reset the setup state", func(t *testing.T) {
+		// given
+		t.Setenv("ROLLBACK_ON_FAILURE", "true")
+		executorMock := newExecutorMock(t)
+		executorMock.EXPECT().RollbackSetup(testCtx).Return(nil)
+		clientSet := fake.NewClientset()
+		starter := &Starter{SetupContext: &setupContext, Namespace: "test", SetupExecutor: executorMock, ClientSet: clientSet}
+
+		// when
+		err := starter.StartSetup(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "error while performing setup [Step1]")
+		actualCM, err := clientSet.CoreV1().ConfigMaps("test").Get(testCtx, context.SetupStateConfigMap, v12.GetOptions{})
+		require.NoError(t, err)
+		assert.NotContains(t, actualCM.Data, context.SetupStateKey)
+		assert.NotContains(t, actualCM.Data, context.SetupCompletedStepsKey)
+	})
+
+	t.Run("should return error of failed rollback", func(t *testing.T) {
+		// given
+		t.Setenv("ROLLBACK_ON_FAILURE", "true")
+		rollbackErr := errors.New("rollback error")
+		executorMock := newExecutorMock(t)
+		executorMock.EXPECT().RollbackSetup(testCtx).Return(rollbackErr)
+		clientSet := fake.NewClientset()
+		starter := &Starter{SetupContext: &setupContext, Namespace: "test", SetupExecutor: executorMock, ClientSet: clientSet}
+
+		// when
+		err := starter.StartSetup(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		require.ErrorIs(t, err, rollbackErr)
+		assert.ErrorContains(t, err, "error while rolling back setup")
+		actualCM, err := clientSet.CoreV1().ConfigMaps("test").Get(testCtx, context.SetupStateConfigMap, v12.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, context.SetupStateInstalling, actualCM.Data[context.SetupStateKey])
+	})
+
+	t.Run("should restore the installed state after rolling back a failed reconcile", func(t *testing.T) {
+		// given
+		t.Setenv("ROLLBACK_ON_FAILURE", "true")
+		executorMock := newExecutorMock(t)
+		executorMock.EXPECT().RollbackSetup(testCtx).Return(nil)
+		data := map[string]string{context.SetupStateKey: context.SetupStateInstalled}
+		configmap := &v1.ConfigMap{ObjectMeta: v12.ObjectMeta{Name: context.SetupStateConfigMap, Namespace: "test"}, Data: data}
+		clientSet := fake.NewClientset(configmap)
+		starter := &Starter{SetupContext: &setupContext, Namespace: "test", SetupExecutor: executorMock, ClientSet: clientSet, Reconcile: true}
+
+		// when
+		err := starter.StartSetup(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		actualCM, err := clientSet.CoreV1().ConfigMaps("test").Get(testCtx, context.SetupStateConfigMap, v12.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, context.SetupStateInstalled, actualCM.Data[context.SetupStateKey])
+	})
+
+	t.Run("should not roll back failed setup by default", func(t *testing.T) {
+		// given
+		starter := &Starter{SetupContext: &setupContext, Namespace: "test", SetupExecutor: newExecutorMock(t), ClientSet: fake.NewClientset()}
+
+		// when
+		err := starter.StartSetup(testCtx)
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func TestStarter_rollbackRecordedSetup(t *testing.T) {
+	setupContext := context.SetupContext{AppConfig: &context.Config{TargetNamespace: "test"}, SetupJsonConfiguration: &context.SetupJsonConfiguration{}}
+	newExecutorMock := func(t *testing.T) *MockSetupExecutor {
+		executorMock := NewMockSetupExecutor(t)
+		expect := executorMock.EXPECT()
+		expect.RegisterPreflightStep().Return(nil)
+		expect.RegisterDisableDefaultSAAutomountStep().Return(nil)
+		expect.RegisterLoadBalancerFQDNRetrieverSteps().Return(nil)
+		expect.RegisterValidationStep().Return(nil)
+		expect.RegisterComponentSetupSteps().Return(nil)
+		expect.RegisterDataSetupSteps(mock.Anything, mock.Anything).Return(nil)
+		expect.RegisterDoguInstallationSteps(mock.Anything).Return(nil)
+		expect.RegisterPostSetupSteps().Return(nil)
+		expect.RollbackSetup(testCtx).Return(nil)
+		return executorMock
+	}
+
+	t.Run("should register the steps, roll them back and reset the setup state", func(t *testing.T) {
+		// given
+		data := map[string]string{
+			context.SetupStateKey:         context.SetupStateInstalling,
+			context.SetupRollbackStateKey: `{"create-loadbalancer":true}`,
+			context.SetupReconcileKey:     "false",
+		}
+		configmap := &v1.ConfigMap{ObjectMeta: v12.ObjectMeta{Name: context.SetupStateConfigMap, Namespace: "test"}, Data: data}
+		clientSet := fake.NewClientset(configmap)
+		starter := &Starter{SetupContext: &setupContext, Namespace: "test", SetupExecutor: newExecutorMock(t), ClientSet: clientSet}
+
+		// when
+		err := starter.rollbackRecordedSetup(testCtx)
+
+		// then
+		require.NoError(t, err)
+		actualCM, err := clientSet.CoreV1().ConfigMaps("test").Get(testCtx, context.SetupStateConfigMap, v12.GetOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, actualCM.Data)
+	})
+
+	t.Run("should restore the installed state after rolling back a recorded reconcile", func(t *testing.T) {
+		// given
+		data := map[string]string{
+			context.SetupStateKey:         context.SetupStateInstalling,
+			context.SetupRollbackStateKey: `{"create-loadbalancer":false}`,
+			context.SetupReconcileKey:     "true",
+		}
+		configmap := &v1.ConfigMap{ObjectMeta: v12.ObjectMeta{Name: context.SetupStateConfigMap, Namespace: "test"}, Data: data}
+		clientSet := fake.NewClientset(configmap)
+		starter := &Starter{SetupContext: &setupContext, Namespace: "test", SetupExecutor: newExecutorMock(t), ClientSet: clientSet}
+
+		// when
+		err := starter.rollbackRecordedSetup(testCtx)
+
+		// then
+		require.NoError(t, err)
+		actualCM, err := clientSet.CoreV1().ConfigMaps("test").Get(testCtx, context.SetupStateConfigMap, v12.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, context.SetupStateInstalled, actualCM.Data[context.SetupStateKey])
+	})
+}
+
+func TestIsRollbackOnFailureEnabled(t *testing.T) {
+	t.Run("should be disabled by default", func(t *testing.T) {
+		assert.False(t, IsRollbackOnFailureEnabled())
+	})
+
+	t.Run("should be enabled by environment variable", func(t *testing.T) {
+		// given
+		t.Setenv("ROLLBACK_ON_FAILURE", "true")
+
+		// when
+		actual := IsRollbackOnFailureEnabled()
+
+		// then
+		assert.True(t, actual)
+	})
+}
+
+func TestIsDryRunEnabled(t *testing.T) {
+	t.Run("should be disabled by default", func(t *testing.T) {
+		assert.False(t, IsDryRunEnabled())
+	})
+
+	t.Run("should be enabled by environment variable", func(t *testing.T) {
+		// given
+		t.Setenv("DRY_RUN", "true")
+
+		// when
+		actual := IsDryRunEnabled()
+
+		// then
+		assert.True(t, actual)
+	})
+}
+
+func TestIsReconcileEnabled(t *testing.T) {
+	t.Run("should be disabled by default", func(t *testing.T) {
+		assert.False(t, IsReconcileEnabled())
+	})
+
+	t.Run("should be enabled by environment variable", func(t *testing.T) {
+		// given
+		t.Setenv("RECONCILE", "true")
+
+		// when
+		actual := IsReconcileEnabled()
+
+		// then
+		assert.True(t, actual)
+	})
+}
+
+func TestIsUserBackendCheckEnabled(t *testing.T) {
+	t.Run("should be disabled by default", func(t *testing.T) {
+		assert.False(t, IsUserBackendCheckEnabled())
+	})
+
+	t.Run("should be enabled by environment variable", func(t *testing.T) {
+		// given
+		t.Setenv("USER_BACKEND_CHECK", "true")
+
+		// when
+		actual := IsUserBackendCheckEnabled()
+
+		// then
+		assert.True(t, actual)
+	})
+}
+
+func TestIsSmtpCheckEnabled(t *testing.T) {
+	t.Run("should be disabled by default", func(t *testing.T) {
+		assert.False(t, IsSmtpCheckEnabled())
+	})
+
+	t.Run("should be enabled by environment variable", func(t *testing.T) {
+		// given
+		t.Setenv("SMTP_CHECK", "true")
+
+		// when
+		actual := IsSmtpCheckEnabled()
+
+		// then
+		assert.True(t, actual)
+	})
+
+	t.Run("should be enabled by test mail", func(t *testing.T) {
+		// given
+		t.Setenv("SMTP_TEST_MAIL", "true")
+
+		// when
+		actual := IsSmtpCheckEnabled()
+
+		// then
+		assert.True(t, actual)
+	})
+}
+
+func TestIsSmtpTestMailEnabled(t *testing.T) {
+	t.Run("should be disabled by default", func(t *testing.T) {
+		assert.False(t, IsSmtpTestMailEnabled())
+	})
+
+	t.Run("should be enabled by environment variable", func(t *testing.T) {
+		// given
+		t.Setenv("SMTP_TEST_MAIL", "true")
+
+		// when
+		actual := IsSmtpTestMailEnabled()
+
+		// then
+		assert.True(t, actual)
+	})
+}
+
+func TestIsSetupInterrupted(t *testing.T) {
+	t.Run("should return false if state config map does not exist", func(t *testing.T) {
+		// when
+		actual, err := IsSetupInterrupted(testCtx, fake.NewClientset(), "test")
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, actual)
+	})
+
+	t.Run("should return true if setup is installing", func(t *testing.T) {
+		// given
+		data := map[string]string{context.SetupStateKey: context.SetupStateInstalling}
+		configmap := &v1.ConfigMap{ObjectMeta: v12.ObjectMeta{Name: context.SetupStateConfigMap, Namespace: "test"}, Data: data}
+
+		// when
+		actual, err := IsSetupInterrupted(testCtx, fake.NewClientset(configmap), "test")
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, actual)
+	})
+
+	t.Run("should return false if setup is installed", func(t *testing.T) {
+		// given
+		data := map[string]string{context.SetupStateKey: context.SetupStateInstalled}
+		configmap := &v1.ConfigMap{ObjectMeta: v12.ObjectMeta{Name: context.SetupStateConfigMap, Namespace: "test"}, Data: data}
+
+		// when
+		actual, err := IsSetupInterrupted(testCtx, fake.NewClientset(configmap), "test")
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, actual)
+	})
 }
@@ -0,0 +1,160 @@
+package setup
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StepState describes the state of a single setup step.
+type StepState string
+
+const (
+	// StepStatePending marks a step which was not started yet.
+	StepStatePending StepState = "pending"
+	// StepStateRunning marks the step which is currently performed.
+	StepStateRunning StepState = "running"
+	// StepStateSucceeded marks a step which was performed successfully.
+	StepStateSucceeded StepState = "succeeded"
+	// StepStateFailed marks a step whose execution failed.
+	StepStateFailed StepState = "failed"
+)
+
+// subscriberBufferSize defines how many step transitions are buffered for a single subscriber before further
+// transitions are dropped for it.
+const subscriberBufferSize = 64
+
+// StepStatus contains the execution status of a single setup step.
+type StepStatus struct {
+	// Index is the position of the step in the ordered list of registered steps.
+	Index int `json:"index"`
+	// ID is the stable identifier of the step.
+	ID string `json:"id"`
+	// Description is the human-readable description of the step.
+	Description string `json:"description"`
+	// State is the current state of the step.
+	State StepState `json:"state"`
+	// StartTime is the time when the step was started.
+	StartTime *time.Time `json:"startTime,omitempty"`
+	// EndTime is the time when the step succeeded or failed.
+	EndTime *time.Time `json:"endTime,omitempty"`
+	// Error contains the error text if the step failed.
+	Error string `json:"error,omitempty"`
+}
+
+// SetupStatus contains the execution status of all registered setup steps.
+type SetupStatus struct {
+	// Steps contains the status of all registered steps in the order of their execution.
+	Steps []StepStatus `json:"steps"`
+}
+
+// StatusTracker keeps the execution status of the setup steps and notifies subscribers about every step transition.
+type StatusTracker struct {
+	mutex       sync.RWMutex
+	steps       []StepStatus
+	subscribers map[chan StepStatus]struct{}
+}
+
+// setupStatus tracks the status of the setup run of this process. It is shared between the executor performing the
+// setup and the API reporting the progress.
+var setupStatus = NewStatusTracker()
+
+// NewStatusTracker creates a new status tracker without any steps.
+func NewStatusTracker() *StatusTracker {
+	return &StatusTracker{subscribers: map[chan StepStatus]struct{}{}}
+}
+
+// Reset replaces all tracked steps with the given steps in the pending state.
+func (st *StatusTracker) Reset(steps []ExecutorStep) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	st.steps = make([]StepStatus, len(steps))
+	for i, step := range steps {
+		st.steps[i] = StepStatus{
+			Index:       i,
+			ID:          step.GetStepID(),
+			Description: step.GetStepDescription(),
+			State:       StepStatePending,
+		}
+	}
+}
+
+// StepStarted marks the step at the given index as running.
+func (st *StatusTracker) StepStarted(index int) {
+	st.update(index, func(status *StepStatus) {
+		now := time.Now()
+		status.State = StepStateRunning
+		status.StartTime = &now
+		status.EndTime = nil
+		status.Error = ""
+	})
+}
+
+// StepSucceeded marks the step at the given index as succeeded.
+func (st *StatusTracker) StepSucceeded(index int) {
+	st.update(index, func(status *StepStatus) {
+		now := time.Now()
+		status.State = StepStateSucceeded
+		status.EndTime = &now
+	})
+}
+
+// StepFailed marks the step at the given index as failed and records the error.
+func (st *StatusTracker) StepFailed(index int, err error) {
+	st.update(index, func(status *StepStatus) {
+		now := time.Now()
+		status.State = StepStateFailed
+		status.EndTime = &now
+		status.Error = err.Error()
+	})
+}
+
+func (st *StatusTracker) update(index int, modify func(status *StepStatus)) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	if index < 0 || index >= len(st.steps) {
+		return
+	}
+
+	modify(&st.steps[index])
+	transition := st.steps[index]
+	for subscriber := range st.subscribers {
+		select {
+		case subscriber <- transition:
+		default:
+			logrus.Debugf("Dropped status transition of step [%s] for a slow subscriber", transition.ID)
+		}
+	}
+}
+
+// GetStatus returns a snapshot of the status of all tracked steps.
+func (st *StatusTracker) GetStatus() SetupStatus {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+
+	steps := make([]StepStatus, len(st.steps))
+	copy(steps, st.steps)
+
+	return SetupStatus{Steps: steps}
+}
+
+// Subscribe returns a channel which receives every subsequent step transition. The returned function must be called
+// to end the subscription.
+func (st *StatusTracker) Subscribe() (<-chan StepStatus, func()) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	subscriber := make(chan StepStatus, subscriberBufferSize)
+	st.subscribers[subscriber] = struct{}{}
+
+	unsubscribe := func() {
+		st.mutex.Lock()
+		defer st.mutex.Unlock()
+		delete(st.subscribers, subscriber)
+	}
+
+	return subscriber, unsubscribe
+}
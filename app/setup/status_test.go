@@ -0,0 +1,93 @@
+package setup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusTracker(t *testing.T) {
+	t.Run("should reset all steps to pending", func(t *testing.T) {
+		// given
+		sut := NewStatusTracker()
+		sut.Reset([]ExecutorStep{newSimpleSetupStep("Step1", false)})
+		sut.StepStarted(0)
+
+		// when
+		sut.Reset([]ExecutorStep{newSimpleSetupStep("Step1", false), newSimpleSetupStep("Step2", false)})
+
+		// then
+		steps := sut.GetStatus().Steps
+		require.Len(t, steps, 2)
+		assert.Equal(t, StepStatus{Index: 0, ID: "Step1", Description: "Step1", State: StepStatePending}, steps[0])
+		assert.Equal(t, StepStatus{Index: 1, ID: "Step2", Description: "Step2", State: StepStatePending}, steps[1])
+	})
+
+	t.Run("should track transitions of a step", func(t *testing.T) {
+		// given
+		sut := NewStatusTracker()
+		sut.Reset([]ExecutorStep{newSimpleSetupStep("Step1", false)})
+
+		// when
+		sut.StepStarted(0)
+		running := sut.GetStatus().Steps[0]
+		sut.StepFailed(0, assert.AnError)
+
+		// then
+		assert.Equal(t, StepStateRunning, running.State)
+		assert.NotNil(t, running.StartTime)
+		assert.Nil(t, running.EndTime)
+		failed := sut.GetStatus().Steps[0]
+		assert.Equal(t, StepStateFailed, failed.State)
+		assert.NotNil(t, failed.EndTime)
+		assert.Equal(t, assert.AnError.Error(), failed.Error)
+	})
+
+	t.Run("should ignore unknown step index", func(t *testing.T) {
+		// given
+		sut := NewStatusTracker()
+		sut.Reset([]ExecutorStep{newSimpleSetupStep("Step1", false)})
+
+		// when
+		sut.StepSucceeded(1)
+		sut.StepSucceeded(-1)
+
+		// then
+		assert.Equal(t, StepStatePending, sut.GetStatus().Steps[0].State)
+	})
+
+	t.Run("should notify subscribers about transitions until unsubscribed", func(t *testing.T) {
+		// given
+		sut := NewStatusTracker()
+		sut.Reset([]ExecutorStep{newSimpleSetupStep("Step1", false)})
+		transitions, unsubscribe := sut.Subscribe()
+
+		// when
+		sut.StepStarted(0)
+		sut.StepSucceeded(0)
+		unsubscribe()
+		sut.StepStarted(0)
+
+		// then
+		require.Len(t, transitions, 2)
+		assert.Equal(t, StepStateRunning, (<-transitions).State)
+		assert.Equal(t, StepStateSucceeded, (<-transitions).State)
+	})
+
+	t.Run("should not block on slow subscribers", func(t *testing.T) {
+		// given
+		sut := NewStatusTracker()
+		sut.Reset([]ExecutorStep{newSimpleSetupStep("Step1", false)})
+		transitions, unsubscribe := sut.Subscribe()
+		defer unsubscribe()
+
+		// when
+		for i := 0; i < subscriberBufferSize+10; i++ {
+			sut.StepStarted(0)
+		}
+
+		// then
+		assert.Len(t, transitions, subscriberBufferSize)
+	})
+}
@@ -0,0 +1,55 @@
+package setup
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/validation"
+)
+
+// userBackendConnectionChecker is responsible to check the connection to an external user backend.
+type userBackendConnectionChecker interface {
+	CheckUserBackendConnection(ctx context.Context, backend appcontext.UserBackend) validation.Problems
+}
+
+type userBackendConnectionStep struct {
+	checker     userBackendConnectionChecker
+	userBackend appcontext.UserBackend
+}
+
+// NewUserBackendConnectionStep creates a new setup step which checks the connection to the external user backend of
+// the setup configuration.
+func NewUserBackendConnectionStep(setupCtx *appcontext.SetupContext) *userBackendConnectionStep {
+	return &userBackendConnectionStep{
+		checker:     validation.NewUserBackendConnectionChecker(),
+		userBackend: setupCtx.SetupJsonConfiguration.UserBackend,
+	}
+}
+
+// GetStepID returns the stable identifier of the step.
+func (ubcs *userBackendConnectionStep) GetStepID() string {
+	return "validate-user-backend-connection"
+}
+
+// GetStepDescription return the human-readable description of the step.
+func (ubcs *userBackendConnectionStep) GetStepDescription() string {
+	return "Checking the connection to the external user backend"
+}
+
+// DescribeEffect returns the user backend which the step checks.
+func (ubcs *userBackendConnectionStep) DescribeEffect() plan.Effect {
+	return plan.Effect{Action: plan.ActionValidate, Kind: "UserBackend", Targets: []string{ubcs.userBackend.Host}}
+}
+
+// PerformSetupStep connects to the external user backend. Searches without results are only logged as warnings.
+func (ubcs *userBackendConnectionStep) PerformSetupStep(ctx context.Context) error {
+	problems := ubcs.checker.CheckUserBackendConnection(ctx, ubcs.userBackend)
+	for _, warning := range problems.Warnings() {
+		logrus.Warnf("%s: %s", warning.Path, warning.Message)
+	}
+
+	return problems.Errors().Err()
+}
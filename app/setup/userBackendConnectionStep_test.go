@@ -0,0 +1,63 @@
+package setup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/plan"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/validation"
+)
+
+func TestNewUserBackendConnectionStep(t *testing.T) {
+	// given
+	ctx := getSetupCtx()
+	ctx.SetupJsonConfiguration.UserBackend = appcontext.UserBackend{DsType: validation.DsTypeExternal, Host: "ldap.example.com"}
+
+	// when
+	step := NewUserBackendConnectionStep(&ctx)
+
+	// then
+	require.NotNil(t, step)
+	assert.Equal(t, "validate-user-backend-connection", step.GetStepID())
+	assert.Equal(t, "Checking the connection to the external user backend", step.GetStepDescription())
+	assert.Equal(t, plan.Effect{Action: plan.ActionValidate, Kind: "UserBackend", Targets: []string{"ldap.example.com"}}, step.DescribeEffect())
+}
+
+func Test_userBackendConnectionStep_PerformSetupStep(t *testing.T) {
+	backend := appcontext.UserBackend{DsType: validation.DsTypeExternal, Host: "ldap.example.com"}
+
+	t.Run("should succeed with warnings", func(t *testing.T) {
+		// given
+		checkerMock := newMockUserBackendConnectionChecker(t)
+		checkerMock.EXPECT().CheckUserBackendConnection(testCtx, backend).Return(validation.Problems{
+			{Path: "userBackend.searchFilter", Code: validation.CodeNoResults, Message: "no users found", Severity: validation.SeverityWarning},
+		})
+		sut := &userBackendConnectionStep{checker: checkerMock, userBackend: backend}
+
+		// when
+		err := sut.PerformSetupStep(testCtx)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail on errors", func(t *testing.T) {
+		// given
+		checkerMock := newMockUserBackendConnectionChecker(t)
+		checkerMock.EXPECT().CheckUserBackendConnection(testCtx, backend).Return(validation.Problems{
+			{Path: "userBackend.connectionDN", Code: validation.CodeAuthenticationFailed, Message: "failed to bind to user backend", Severity: validation.SeverityError},
+			{Path: "userBackend.groupSearchFilter", Code: validation.CodeNoResults, Message: "no groups found", Severity: validation.SeverityWarning},
+		})
+		sut := &userBackendConnectionStep{checker: checkerMock, userBackend: backend}
+
+		// when
+		err := sut.PerformSetupStep(testCtx)
+
+		// then
+		require.Error(t, err)
+		assert.Equal(t, "failed to bind to user backend", err.Error())
+	})
+}
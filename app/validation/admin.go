@@ -1,7 +1,6 @@
 package validation
 
 import (
-	"fmt"
 	"net/mail"
 
 	"github.com/cloudogu/k8s-ces-setup/v4/app/context"
@@ -14,30 +13,30 @@ func NewAdminValidator() *adminValidator {
 	return &adminValidator{}
 }
 
-// ValidateAdmin validates all properties of the admin section from a setup json
+// ValidateAdmin validates all properties of the admin section from a setup json and returns all found problems
 // see: https://docs.cloudogu.com/docs/system-components/ces-setup/operations/setup-json_de/
 func (av *adminValidator) ValidateAdmin(admin context.User, dsType string) error {
+	var problems Problems
+
 	if admin.AdminGroup == "" {
-		return getPropertyNotSetError("admin group")
+		problems.addPropertyNotSet("admin.adminGroup", "admin group")
 	}
 
 	if dsType == DsTypeExternal {
-		return nil
+		return problems.Err()
 	}
 
 	if admin.Mail == "" {
-		return getPropertyNotSetError("admin mail")
-	}
-	_, err := mail.ParseAddress(admin.Mail)
-	if err != nil {
-		return fmt.Errorf("invalid admin mail")
+		problems.addPropertyNotSet("admin.mail", "admin mail")
+	} else if _, err := mail.ParseAddress(admin.Mail); err != nil {
+		problems.add("admin.mail", CodeInvalidFormat, "invalid admin mail")
 	}
 	if admin.Username == "" {
-		return getPropertyNotSetError("admin username")
+		problems.addPropertyNotSet("admin.username", "admin username")
 	}
 	if admin.Password == "" {
-		return getPropertyNotSetError("admin password")
+		problems.addPropertyNotSet("admin.password", "admin password")
 	}
 
-	return nil
+	return problems.Err()
 }
@@ -0,0 +1,194 @@
+package validation
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	certificateExpiryWarningDaysEnvVar  = "CERTIFICATE_EXPIRY_WARNING_DAYS"
+	defaultCertificateExpiryWarningDays = 30
+	minimumRSAKeySize                   = 2048
+)
+
+// insecureSignatureAlgorithms contains the signature algorithms which are not accepted by current clients.
+var insecureSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
+	x509.MD2WithRSA:    true,
+	x509.MD5WithRSA:    true,
+	x509.SHA1WithRSA:   true,
+	x509.DSAWithSHA1:   true,
+	x509.DSAWithSHA256: true,
+	x509.ECDSAWithSHA1: true,
+}
+
+// CertificateExpiryWarningWindow returns the duration before the expiry of an external certificate in which a warning
+// is reported. It is either defaultCertificateExpiryWarningDays or a non-negative number of days if set as EnvVar
+// CERTIFICATE_EXPIRY_WARNING_DAYS.
+func CertificateExpiryWarningWindow() time.Duration {
+	rawDays, ok := os.LookupEnv(certificateExpiryWarningDaysEnvVar)
+	if !ok {
+		return defaultCertificateExpiryWarningDays * 24 * time.Hour
+	}
+
+	days, err := strconv.Atoi(rawDays)
+	if err != nil || days < 0 {
+		logrus.Errorf("Invalid certificate expiry warning %s=%s (fallback to %d)", certificateExpiryWarningDaysEnvVar, rawDays, defaultCertificateExpiryWarningDays)
+		days = defaultCertificateExpiryWarningDays
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// validateCertificateChain checks that the chain is ordered from leaf to root, that all certificates are valid for at
+// least the warning window and use secure algorithms and that the leaf covers the FQDN. The chain is verified against
+// its self-signed root or the certificate authorities of the system.
+func validateCertificateChain(chain []*x509.Certificate, fqdn string, expiryWarningWindow time.Duration, problems *Problems) {
+	now := time.Now()
+	trustable := true
+
+	for i, cert := range chain {
+		if now.Before(cert.NotBefore) {
+			problems.add("naming.certificate", CodeExpired, "%d-th certificate in [certificate] property is not valid before %s", i, cert.NotBefore.Format(time.RFC3339))
+			trustable = false
+		} else if now.After(cert.NotAfter) {
+			problems.add("naming.certificate", CodeExpired, "%d-th certificate in [certificate] property expired at %s", i, cert.NotAfter.Format(time.RFC3339))
+			trustable = false
+		} else if now.Add(expiryWarningWindow).After(cert.NotAfter) {
+			problems.addWarning("naming.certificate", CodeExpired, "%d-th certificate in [certificate] property expires at %s", i, cert.NotAfter.Format(time.RFC3339))
+		}
+
+		validatePublicKey(cert.PublicKey, fmt.Sprintf("public key of %d-th certificate in [certificate] property", i), "naming.certificate", problems)
+		// the signature of a root is not checked by clients because they trust it directly
+		if !isSelfSigned(cert) && insecureSignatureAlgorithms[cert.SignatureAlgorithm] {
+			problems.add("naming.certificate", CodeInsecure, "%d-th certificate in [certificate] property uses insecure signature algorithm %s", i, cert.SignatureAlgorithm)
+			trustable = false
+		}
+
+		if i+1 < len(chain) {
+			err := cert.CheckSignatureFrom(chain[i+1])
+			var insecureErr x509.InsecureAlgorithmError
+			// an insecure signature algorithm is already reported
+			if err != nil && !errors.As(err, &insecureErr) {
+				problems.add("naming.certificate", CodeInvalidFormat, "%d-th certificate in [certificate] property is not issued by the %d-th certificate, the chain must be ordered from leaf to root", i, i+1)
+			}
+			trustable = trustable && err == nil
+		}
+	}
+
+	leaf := chain[0]
+	if fqdn != "" && fqdn != "<<ip>>" {
+		err := leaf.VerifyHostname(fqdn)
+		if err != nil {
+			problems.add("naming.certificate", CodeMismatch, "certificate is not valid for fqdn: %s", err.Error())
+		}
+	}
+
+	if trustable {
+		verifyCertificateChain(chain, problems)
+	}
+}
+
+func verifyCertificateChain(chain []*x509.Certificate, problems *Problems) {
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		if isSelfSigned(cert) {
+			roots.AddCert(cert)
+		} else {
+			intermediates.AddCert(cert)
+		}
+	}
+	if len(chain) == 1 && isSelfSigned(chain[0]) {
+		roots.AddCert(chain[0])
+	}
+
+	_, err = chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	switch {
+	case errors.As(err, &unknownAuthorityErr):
+		problems.addWarning("naming.certificate", CodeUntrusted, "certificate is not issued by a certificate authority trusted by the system, clients have to trust the issuer explicitly")
+	case err != nil:
+		problems.add("naming.certificate", CodeUntrusted, "failed to verify certificate chain: %s", err.Error())
+	}
+}
+
+// validateCertificateKey checks that the private key can be parsed, is secure and belongs to the leaf certificate.
+func validateCertificateKey(keyBlock *pem.Block, leaf *x509.Certificate, problems *Problems) {
+	key, err := parsePrivateKey(keyBlock.Bytes)
+	if err != nil {
+		problems.add("naming.certificateKey", CodeInvalidFormat, "failed to parse certificate key: %s", err.Error())
+		return
+	}
+
+	validatePublicKey(key.Public(), "certificate key", "naming.certificateKey", problems)
+
+	if leaf == nil {
+		return
+	}
+	publicKey, ok := leaf.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !publicKey.Equal(key.Public()) {
+		problems.add("naming.certificateKey", CodeMismatch, "certificate key does not match the 0-th certificate in [certificate] property")
+	}
+}
+
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("key is neither a PKCS #1, EC nor PKCS #8 private key: %w", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+
+	return signer, nil
+}
+
+func validatePublicKey(publicKey any, name string, path string, problems *Problems) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minimumRSAKeySize {
+			problems.add(path, CodeInsecure, "%s has insecure RSA key size %d, at least %d bits are required", name, key.N.BitLen(), minimumRSAKeySize)
+		}
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() && key.Curve != elliptic.P384() && key.Curve != elliptic.P521() {
+			problems.add(path, CodeInsecure, "%s uses unsupported elliptic curve %s", name, key.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+	default:
+		problems.add(path, CodeInsecure, "%s uses unsupported algorithm %T", name, publicKey)
+	}
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
@@ -0,0 +1,280 @@
+package validation
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/context"
+)
+
+const testFqdn = "ces.example.com"
+
+func TestCertificateExpiryWarningWindow(t *testing.T) {
+	t.Run("should return default", func(t *testing.T) {
+		assert.Equal(t, 30*24*time.Hour, CertificateExpiryWarningWindow())
+	})
+
+	t.Run("should return days of environment variable", func(t *testing.T) {
+		// given
+		t.Setenv("CERTIFICATE_EXPIRY_WARNING_DAYS", "7")
+
+		// when
+		actual := CertificateExpiryWarningWindow()
+
+		// then
+		assert.Equal(t, 7*24*time.Hour, actual)
+	})
+
+	t.Run("should return default for invalid environment variable", func(t *testing.T) {
+		// given
+		t.Setenv("CERTIFICATE_EXPIRY_WARNING_DAYS", "-1")
+
+		// when
+		actual := CertificateExpiryWarningWindow()
+
+		// then
+		assert.Equal(t, 30*24*time.Hour, actual)
+	})
+}
+
+func Test_namingValidator_ValidateNaming_certificate(t *testing.T) {
+	root, rootKey := issueTestCertificate(t, testCertificate{name: "Test Root", isCA: true}, nil, nil)
+	intermediate, intermediateKey := issueTestCertificate(t, testCertificate{name: "Test Intermediate", isCA: true}, root, rootKey)
+	leaf, leafKey := issueTestCertificate(t, testCertificate{name: testFqdn, dnsNames: []string{testFqdn}}, intermediate, intermediateKey)
+	sut := &namingValidator{certificateExpiryWarning: 30 * 24 * time.Hour}
+
+	t.Run("should accept ordered chain with matching key", func(t *testing.T) {
+		// given
+		naming := externalNaming(leafKey, leaf, intermediate, root)
+
+		// when
+		err := sut.ValidateNaming(naming)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should reject key of another certificate", func(t *testing.T) {
+		// given
+		naming := externalNaming(intermediateKey, leaf, intermediate, root)
+
+		// when
+		err := sut.ValidateNaming(naming)
+
+		// then
+		assert.Equal(t, Problems{{Path: "naming.certificateKey", Code: CodeMismatch, Message: "certificate key does not match the 0-th certificate in [certificate] property", Severity: SeverityError}}, err)
+	})
+
+	t.Run("should reject certificate not covering the fqdn", func(t *testing.T) {
+		// given
+		naming := externalNaming(leafKey, leaf, intermediate, root)
+		naming.Fqdn = "other.example.com"
+
+		// when
+		err := sut.ValidateNaming(naming)
+
+		// then
+		assert.Equal(t, Problems{{Path: "naming.certificate", Code: CodeMismatch, Message: "certificate is not valid for fqdn: x509: certificate is valid for ces.example.com, not other.example.com", Severity: SeverityError}}, err)
+	})
+
+	t.Run("should skip fqdn which is retrieved from the load balancer", func(t *testing.T) {
+		// given
+		naming := externalNaming(leafKey, leaf, intermediate, root)
+		naming.Fqdn = "<<ip>>"
+
+		// when
+		err := sut.ValidateNaming(naming)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should reject unordered chain", func(t *testing.T) {
+		// given
+		naming := externalNaming(leafKey, leaf, root, intermediate)
+
+		// when
+		err := sut.ValidateNaming(naming)
+
+		// then
+		var problems Problems
+		require.ErrorAs(t, err, &problems)
+		assert.Contains(t, problems, Problem{Path: "naming.certificate", Code: CodeInvalidFormat, Message: "0-th certificate in [certificate] property is not issued by the 1-th certificate, the chain must be ordered from leaf to root", Severity: SeverityError})
+	})
+
+	t.Run("should reject expired certificate", func(t *testing.T) {
+		// given
+		expired, expiredKey := issueTestCertificate(t, testCertificate{name: testFqdn, dnsNames: []string{testFqdn}, notAfter: time.Now().Add(-time.Hour)}, intermediate, intermediateKey)
+		naming := externalNaming(expiredKey, expired, intermediate, root)
+
+		// when
+		err := sut.ValidateNaming(naming)
+
+		// then
+		var problems Problems
+		require.ErrorAs(t, err, &problems)
+		require.Len(t, problems, 1)
+		assert.Equal(t, CodeExpired, problems[0].Code)
+		assert.Equal(t, SeverityError, problems[0].Severity)
+		assert.Contains(t, problems[0].Message, "0-th certificate in [certificate] property expired at")
+	})
+
+	t.Run("should warn about certificate expiring soon", func(t *testing.T) {
+		// given
+		expiring, expiringKey := issueTestCertificate(t, testCertificate{name: testFqdn, dnsNames: []string{testFqdn}, notAfter: time.Now().Add(7 * 24 * time.Hour)}, intermediate, intermediateKey)
+		naming := externalNaming(expiringKey, expiring, intermediate, root)
+
+		// when
+		err := sut.ValidateNaming(naming)
+
+		// then
+		var problems Problems
+		require.ErrorAs(t, err, &problems)
+		require.Len(t, problems, 1)
+		assert.Equal(t, CodeExpired, problems[0].Code)
+		assert.Equal(t, SeverityWarning, problems[0].Severity)
+		assert.Contains(t, problems[0].Message, "0-th certificate in [certificate] property expires at")
+	})
+
+	t.Run("should warn about chain without trusted root", func(t *testing.T) {
+		// given
+		naming := externalNaming(leafKey, leaf, intermediate)
+
+		// when
+		err := sut.ValidateNaming(naming)
+
+		// then
+		assert.Equal(t, Problems{{Path: "naming.certificate", Code: CodeUntrusted, Message: "certificate is not issued by a certificate authority trusted by the system, clients have to trust the issuer explicitly", Severity: SeverityWarning}}, err)
+	})
+
+	t.Run("should reject insecure keys", func(t *testing.T) {
+		// given
+		weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+		weak, _ := issueTestCertificate(t, testCertificate{name: testFqdn, dnsNames: []string{testFqdn}, key: weakKey}, intermediate, intermediateKey)
+		naming := externalNaming(weakKey, weak, intermediate, root)
+
+		// when
+		err = sut.ValidateNaming(naming)
+
+		// then
+		assert.Equal(t, Problems{
+			{Path: "naming.certificate", Code: CodeInsecure, Message: "public key of 0-th certificate in [certificate] property has insecure RSA key size 1024, at least 2048 bits are required", Severity: SeverityError},
+			{Path: "naming.certificateKey", Code: CodeInsecure, Message: "certificate key has insecure RSA key size 1024, at least 2048 bits are required", Severity: SeverityError},
+		}, err)
+	})
+
+	t.Run("should reject unsupported elliptic curve", func(t *testing.T) {
+		// given
+		weakKey, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+		require.NoError(t, err)
+		weak, _ := issueTestCertificate(t, testCertificate{name: testFqdn, dnsNames: []string{testFqdn}, key: weakKey}, intermediate, intermediateKey)
+		naming := externalNaming(weakKey, weak, intermediate, root)
+
+		// when
+		err = sut.ValidateNaming(naming)
+
+		// then
+		var problems Problems
+		require.ErrorAs(t, err, &problems)
+		assert.Contains(t, problems, Problem{Path: "naming.certificateKey", Code: CodeInsecure, Message: "certificate key uses unsupported elliptic curve P-224", Severity: SeverityError})
+	})
+
+	t.Run("should reject unparsable key", func(t *testing.T) {
+		// given
+		naming := externalNaming(leafKey, leaf, intermediate, root)
+		naming.CertificateKey = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("invalid")}))
+
+		// when
+		err := sut.ValidateNaming(naming)
+
+		// then
+		var problems Problems
+		require.ErrorAs(t, err, &problems)
+		require.Len(t, problems, 1)
+		assert.Equal(t, "naming.certificateKey", problems[0].Path)
+		assert.Equal(t, CodeInvalidFormat, problems[0].Code)
+	})
+}
+
+type testCertificate struct {
+	name     string
+	dnsNames []string
+	isCA     bool
+	notAfter time.Time
+	key      crypto.Signer
+}
+
+// issueTestCertificate creates a certificate which is signed by the parent or self-signed if parent is nil.
+func issueTestCertificate(t *testing.T, cert testCertificate, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	t.Helper()
+
+	key := cert.key
+	if key == nil {
+		var err error
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+	}
+	notAfter := cert.notAfter
+	if notAfter.IsZero() {
+		notAfter = time.Now().Add(365 * 24 * time.Hour)
+	}
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cert.name},
+		DNSNames:              cert.dnsNames,
+		NotBefore:             time.Now().Add(-24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  cert.isCA,
+		BasicConstraintsValid: true,
+	}
+	if cert.isCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return certificate, key
+}
+
+func externalNaming(key crypto.Signer, chain ...*x509.Certificate) context.Naming {
+	var certs strings.Builder
+	for _, cert := range chain {
+		certs.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+
+	return context.Naming{
+		Fqdn:            testFqdn,
+		Domain:          "example.com",
+		CertificateType: "external",
+		Certificate:     certs.String(),
+		CertificateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		RelayHost:       "relay",
+	}
+}
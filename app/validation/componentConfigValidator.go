@@ -0,0 +1,135 @@
+package validation
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	componentOpConfig "github.com/cloudogu/k8s-component-operator/pkg/config"
+	"sigs.k8s.io/yaml"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"github.com/cloudogu/k8s-ces-setup/v4/app/setup/component"
+)
+
+const latestChartVersion = "latest"
+
+type componentValidator struct {
+	tagResolver  chartTagResolver
+	helmRepoData *componentOpConfig.HelmRepositoryData
+	// tags caches the versions of the charts which are already resolved.
+	tags map[string][]string
+}
+
+// NewComponentConfigurationValidator creates a new validator for the components of the setup configuration. If the
+// tagResolver or the helmRepoData is nil, the charts and versions are not checked against the helm repository.
+func NewComponentConfigurationValidator(tagResolver chartTagResolver, helmRepoData *componentOpConfig.HelmRepositoryData) *componentValidator {
+	return &componentValidator{tagResolver: tagResolver, helmRepoData: helmRepoData}
+}
+
+// Validate checks the component operator charts and the components of the setup configuration and returns all
+// problems as Problems.
+func (cv *componentValidator) Validate(config *context.Config) error {
+	cv.tags = map[string][]string{}
+	problems := Problems{}
+
+	cv.validateChart(&problems, "component_operator_crd_chart", config.ComponentOperatorCrdChart)
+	cv.validateChart(&problems, "component_operator_chart", config.ComponentOperatorChart)
+
+	names := make([]string, 0, len(config.Components))
+	for name := range config.Components {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		cv.validateComponent(&problems, name, config.Components[name])
+	}
+
+	return problems.Err()
+}
+
+// validateChart checks a chart reference in the format "<helmRepositoryNamespace>/<chartName>:<version>".
+func (cv *componentValidator) validateChart(problems *Problems, path string, chart string) {
+	if chart == "" {
+		problems.addPropertyNotSet(path, path)
+		return
+	}
+
+	fullChartName, version, err := component.SplitChartString(chart)
+	if err != nil {
+		problems.add(path, CodeInvalidFormat, "%s", err.Error())
+		return
+	}
+
+	_, _, err = component.SplitHelmNamespaceFromChartString(fullChartName)
+	if err != nil {
+		problems.add(path, CodeInvalidFormat, "%s", err.Error())
+		return
+	}
+
+	cv.validateVersion(problems, path, path, fullChartName, version)
+}
+
+func (cv *componentValidator) validateComponent(problems *Problems, name string, attributes context.ComponentAttributes) {
+	path := "components." + name
+	valid := true
+
+	if attributes.HelmRepositoryNamespace == "" {
+		problems.addPropertyNotSet(path+".helmRepositoryNamespace", fmt.Sprintf("helmRepositoryNamespace for component %s", name))
+		valid = false
+	}
+	if attributes.Version == "" {
+		problems.addPropertyNotSet(path+".version", fmt.Sprintf("version for component %s", name))
+		valid = false
+	}
+
+	var values map[string]any
+	err := yaml.Unmarshal([]byte(attributes.ValuesYamlOverwrite), &values)
+	if err != nil {
+		problems.add(path+".valuesYamlOverwrite", CodeInvalidFormat, "valuesYamlOverwrite of component %s is no valid YAML: %s", name, err.Error())
+	}
+
+	if valid {
+		fullChartName := attributes.HelmRepositoryNamespace + "/" + name
+		cv.validateVersion(problems, path, path+".version", fullChartName, attributes.Version)
+	}
+}
+
+// validateVersion checks that the chart exists in the helm repository and has the given version. The version latest
+// only requires that the chart exists.
+func (cv *componentValidator) validateVersion(problems *Problems, chartPath string, versionPath string, fullChartName string, version string) {
+	if cv.tagResolver == nil || cv.helmRepoData == nil {
+		return
+	}
+
+	tags, err := cv.resolveTags(fullChartName)
+	if err != nil {
+		problems.add(chartPath, CodeUnavailable, "failed to get versions of chart %s from the helm repository: %s", fullChartName, err.Error())
+		return
+	}
+
+	if len(tags) == 0 {
+		problems.add(chartPath, CodeUnknownReference, "chart %s does not exist in the helm repository", fullChartName)
+		return
+	}
+
+	if version != latestChartVersion && !slices.Contains(tags, version) {
+		problems.add(versionPath, CodeUnknownReference, "version %s of chart %s does not exist in the helm repository", version, fullChartName)
+	}
+}
+
+func (cv *componentValidator) resolveTags(fullChartName string) ([]string, error) {
+	if tags, ok := cv.tags[fullChartName]; ok {
+		return tags, nil
+	}
+
+	// the registry client expects a reference without the scheme, like the helm client of the component operator
+	ref := strings.TrimPrefix(fmt.Sprintf("%s/%s", cv.helmRepoData.URL(), fullChartName), string(componentOpConfig.EndpointSchemaOCI)+"://")
+	tags, err := cv.tagResolver.Tags(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	cv.tags[fullChartName] = tags
+	return tags, nil
+}
@@ -0,0 +1,128 @@
+package validation
+
+import (
+	"testing"
+
+	componentOpConfig "github.com/cloudogu/k8s-component-operator/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/context"
+)
+
+var testHelmRepoData = &componentOpConfig.HelmRepositoryData{Endpoint: "registry.cloudogu.com", Schema: componentOpConfig.EndpointSchemaOCI}
+
+func validComponentConfig() *context.Config {
+	return &context.Config{
+		ComponentOperatorCrdChart: "k8s/k8s-component-operator-crd:1.2.0",
+		ComponentOperatorChart:    "k8s/k8s-component-operator:1.2.0",
+		Components: map[string]context.ComponentAttributes{
+			"k8s-longhorn": {Version: "latest", HelmRepositoryNamespace: "k8s", ValuesYamlOverwrite: "longhorn:\n  replicas: 2\n"},
+		},
+	}
+}
+
+func TestNewComponentConfigurationValidator(t *testing.T) {
+	t.Run("should return a valid object", func(t *testing.T) {
+		actual := NewComponentConfigurationValidator(nil, nil)
+		require.NotNil(t, actual)
+	})
+}
+
+func Test_componentValidator_Validate(t *testing.T) {
+	t.Run("should succeed", func(t *testing.T) {
+		// given
+		resolverMock := newMockChartTagResolver(t)
+		resolverMock.EXPECT().Tags("registry.cloudogu.com/k8s/k8s-component-operator-crd").Return([]string{"1.1.0", "1.2.0"}, nil)
+		resolverMock.EXPECT().Tags("registry.cloudogu.com/k8s/k8s-component-operator").Return([]string{"1.2.0"}, nil)
+		resolverMock.EXPECT().Tags("registry.cloudogu.com/k8s/k8s-longhorn").Return([]string{"1.5.1"}, nil)
+		sut := NewComponentConfigurationValidator(resolverMock, testHelmRepoData)
+
+		// when
+		err := sut.Validate(validComponentConfig())
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should only validate the format without helm repository", func(t *testing.T) {
+		// given
+		sut := NewComponentConfigurationValidator(nil, nil)
+
+		// when
+		err := sut.Validate(validComponentConfig())
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should report all invalid formats", func(t *testing.T) {
+		// given
+		config := &context.Config{
+			ComponentOperatorChart: "k8s-component-operator:1.2.0",
+			Components: map[string]context.ComponentAttributes{
+				"k8s-longhorn":     {ValuesYamlOverwrite: "longhorn: [\n"},
+				"k8s-cert-manager": {Version: "1.0.0", HelmRepositoryNamespace: "k8s"},
+			},
+		}
+		sut := NewComponentConfigurationValidator(nil, nil)
+
+		// when
+		err := sut.Validate(config)
+
+		// then
+		var problems Problems
+		require.ErrorAs(t, err, &problems)
+		require.Len(t, problems, 5)
+		assert.Equal(t, Problem{Path: "component_operator_crd_chart", Code: CodeRequired, Message: "no component_operator_crd_chart set", Severity: SeverityError}, problems[0])
+		assert.Equal(t, "component_operator_chart", problems[1].Path)
+		assert.Equal(t, CodeInvalidFormat, problems[1].Code)
+		assert.Contains(t, problems[1].Message, "Must be '<helmRepositoryNamespace>/<chartName>'")
+		assert.Equal(t, Problem{Path: "components.k8s-longhorn.helmRepositoryNamespace", Code: CodeRequired, Message: "no helmRepositoryNamespace for component k8s-longhorn set", Severity: SeverityError}, problems[2])
+		assert.Equal(t, Problem{Path: "components.k8s-longhorn.version", Code: CodeRequired, Message: "no version for component k8s-longhorn set", Severity: SeverityError}, problems[3])
+		assert.Equal(t, "components.k8s-longhorn.valuesYamlOverwrite", problems[4].Path)
+		assert.Equal(t, CodeInvalidFormat, problems[4].Code)
+		assert.Contains(t, problems[4].Message, "valuesYamlOverwrite of component k8s-longhorn is no valid YAML")
+	})
+
+	t.Run("should report unknown charts and versions", func(t *testing.T) {
+		// given
+		config := validComponentConfig()
+		config.ComponentOperatorChart = "k8s/k8s-component-operator:9.9.9"
+		config.Components["k8s-velero"] = context.ComponentAttributes{Version: "1.0.0", HelmRepositoryNamespace: "k8s"}
+		resolverMock := newMockChartTagResolver(t)
+		resolverMock.EXPECT().Tags("registry.cloudogu.com/k8s/k8s-component-operator-crd").Return([]string{"1.2.0"}, nil)
+		resolverMock.EXPECT().Tags("registry.cloudogu.com/k8s/k8s-component-operator").Return([]string{"1.2.0"}, nil)
+		resolverMock.EXPECT().Tags("registry.cloudogu.com/k8s/k8s-longhorn").Return(nil, assert.AnError)
+		resolverMock.EXPECT().Tags("registry.cloudogu.com/k8s/k8s-velero").Return([]string{}, nil)
+		sut := NewComponentConfigurationValidator(resolverMock, testHelmRepoData)
+
+		// when
+		err := sut.Validate(config)
+
+		// then
+		var problems Problems
+		require.ErrorAs(t, err, &problems)
+		assert.Equal(t, Problems{
+			{Path: "component_operator_chart", Code: CodeUnknownReference, Message: "version 9.9.9 of chart k8s/k8s-component-operator does not exist in the helm repository", Severity: SeverityError},
+			{Path: "components.k8s-longhorn", Code: CodeUnavailable, Message: "failed to get versions of chart k8s/k8s-longhorn from the helm repository: " + assert.AnError.Error(), Severity: SeverityError},
+			{Path: "components.k8s-velero", Code: CodeUnknownReference, Message: "chart k8s/k8s-velero does not exist in the helm repository", Severity: SeverityError},
+		}, problems)
+	})
+
+	t.Run("should resolve the versions of a chart only once", func(t *testing.T) {
+		// given
+		config := validComponentConfig()
+		config.ComponentOperatorCrdChart = "k8s/k8s-component-operator:1.2.0"
+		config.Components = map[string]context.ComponentAttributes{"k8s-component-operator": {Version: "1.1.0", HelmRepositoryNamespace: "k8s"}}
+		resolverMock := newMockChartTagResolver(t)
+		resolverMock.EXPECT().Tags("registry.cloudogu.com/k8s/k8s-component-operator").Return([]string{"1.1.0", "1.2.0"}, nil).Once()
+		sut := NewComponentConfigurationValidator(resolverMock, testHelmRepoData)
+
+		// when
+		err := sut.Validate(config)
+
+		// then
+		require.NoError(t, err)
+	})
+}
@@ -25,12 +25,10 @@ func NewDoguValidator(repository remoteDoguDescriptorRepository) *doguValidator
 	return &doguValidator{Repository: repository}
 }
 
-// ValidateDogus check whether the configured dogu has no invalid or unmet dependencies.
+// ValidateDogus check whether the configured dogu has no invalid or unmet dependencies and returns all found problems.
 func (dv *doguValidator) ValidateDogus(ctx ctx.Context, dogus context.Dogus) error {
-	doguList, err := dv.parseDoguStrToDoguList(ctx, dogus.Install)
-	if err != nil {
-		return err
-	}
+	var problems Problems
+	doguList, resolved := dv.parseDoguStrToDoguList(ctx, dogus.Install, &problems)
 
 	isDeafultDoguValid := false
 	for _, dogu := range dogus.Install {
@@ -40,31 +38,47 @@ func (dv *doguValidator) ValidateDogus(ctx ctx.Context, dogus context.Dogus) err
 	}
 
 	if !isDeafultDoguValid {
-		return fmt.Errorf("invalid value for default dogu [%s]", dogus.DefaultDogu)
+		problems.add("dogus.defaultDogu", CodeUnknownReference, "invalid value for default dogu [%s]", dogus.DefaultDogu)
+	}
+
+	// dependencies can only be checked reliably if all dogus are known
+	if !resolved {
+		return problems.Err()
 	}
 
-	for _, installDogu := range doguList {
-		err = dv.validateDoguDependencies(doguList, installDogu.GetDependenciesOfType("dogu"))
+	for i, installDogu := range doguList {
+		err := dv.validateDoguDependencies(doguList, installDogu.GetDependenciesOfType("dogu"))
 		if err != nil {
-			return fmt.Errorf("failed to validate dependencies for dogu %s: %w", installDogu.Name, err)
+			problems.add(installPath(i), CodeUnmetDependency, "failed to validate dependencies for dogu %s: %s", installDogu.Name, err.Error())
 		}
 	}
 
-	return nil
+	return problems.Err()
+}
+
+func installPath(index int) string {
+	return fmt.Sprintf("dogus.install[%d]", index)
 }
 
-func (dv *doguValidator) parseDoguStrToDoguList(ctx ctx.Context, dogus []string) ([]*core.Dogu, error) {
-	var doguList = make([]*core.Dogu, len(dogus))
+// parseDoguStrToDoguList returns the dogus of all given dogu strings which could be retrieved and whether all dogus
+// could be retrieved.
+func (dv *doguValidator) parseDoguStrToDoguList(ctx ctx.Context, dogus []string, problems *Problems) ([]*core.Dogu, bool) {
+	var doguList = make([]*core.Dogu, 0, len(dogus))
 	for i, doguStr := range dogus {
 		dogu, err := dv.getDoguFromVersionStr(ctx, doguStr)
 		if err != nil {
-			return nil, err
+			code := CodeUnknownReference
+			if cloudoguerrors.IsConnectionError(err) {
+				code = CodeUnavailable
+			}
+			problems.add(installPath(i), code, "%s", err.Error())
+			continue
 		}
 
-		doguList[i] = dogu
+		doguList = append(doguList, dogu)
 	}
 
-	return doguList, nil
+	return doguList, len(doguList) == len(dogus)
 }
 
 func (dv *doguValidator) validateDoguDependencies(dogus []*core.Dogu, dependencies []core.Dependency) error {
@@ -127,7 +141,6 @@ func (dv *doguValidator) getDoguFromVersionStr(ctx ctx.Context, doguStr string)
 	namespacedName, version, found := strings.Cut(doguStr, ":")
 	namespace, name, _ := strings.Cut(namespacedName, "/")
 	var dogu *core.Dogu
-	var err error
 
 	QualifiedName := cescommons.QualifiedName{
 		SimpleName: cescommons.SimpleName(name),
@@ -136,7 +149,7 @@ func (dv *doguValidator) getDoguFromVersionStr(ctx ctx.Context, doguStr string)
 	if found {
 		v, vErr := core.ParseVersion(version)
 		if vErr != nil {
-			return nil, fmt.Errorf("failed to parse dogu version %s: %w", version, err)
+			return nil, fmt.Errorf("failed to parse dogu version %s: %w", version, vErr)
 		}
 		QualifiedVersion := cescommons.QualifiedVersion{
 			Name:    QualifiedName,
@@ -390,3 +390,27 @@ func Test_doguValidator_ValidateDogus(t *testing.T) {
 		mock.AssertExpectationsForObjects(t, remoteDoguRepo)
 	})
 }
+
+func Test_doguValidator_ValidateDogus_allProblems(t *testing.T) {
+	t.Run("should report every unknown dogu and the default dogu without checking dependencies", func(t *testing.T) {
+		// given
+		dogus := context.Dogus{Install: []string{"official/ldap", "official/cas:1.asd"}, DefaultDogu: "redmine"}
+		remoteDoguRepo := newMockRemoteDoguDescriptorRepository(t)
+		remoteDoguRepo.EXPECT().GetLatest(mock.Anything, cescommons.QualifiedName{Namespace: "official", SimpleName: "ldap"}).Return(nil, assert.AnError)
+		doguValidator := NewDoguValidator(remoteDoguRepo)
+
+		// when
+		err := doguValidator.ValidateDogus(ctx.TODO(), dogus)
+
+		// then
+		var problems Problems
+		require.ErrorAs(t, err, &problems)
+		require.Len(t, problems, 3)
+		assert.Equal(t, "dogus.install[0]", problems[0].Path)
+		assert.Equal(t, CodeUnknownReference, problems[0].Code)
+		assert.Contains(t, problems[0].Message, "failed to get latest version of dogu [official/ldap]")
+		assert.Equal(t, "dogus.install[1]", problems[1].Path)
+		assert.Contains(t, problems[1].Message, "failed to parse dogu version 1.asd")
+		assert.Equal(t, Problem{Path: "dogus.defaultDogu", Code: CodeUnknownReference, Message: "invalid value for default dogu [redmine]", Severity: SeverityError}, problems[2])
+	})
+}
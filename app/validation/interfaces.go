@@ -34,3 +34,8 @@ type RegistryConfigEncryptedValidator interface {
 type remoteDoguDescriptorRepository interface {
 	cescommons.RemoteDoguDescriptorRepository
 }
+
+// chartTagResolver lists the versions of a helm chart in the helm repository.
+type chartTagResolver interface {
+	Tags(ref string) ([]string, error)
+}
@@ -7,49 +7,53 @@ import (
 	"net"
 	"net/mail"
 	"strings"
+	"time"
 
 	"github.com/cloudogu/k8s-ces-setup/v4/app/context"
 )
 
-type namingValidator struct{}
+type namingValidator struct {
+	// certificateExpiryWarning is the duration before the expiry of an external certificate in which a warning is
+	// reported.
+	certificateExpiryWarning time.Duration
+}
 
 // NewNamingValidator creates a new validator for the naming section of the setup configuration
 func NewNamingValidator() *namingValidator {
-	return &namingValidator{}
+	return &namingValidator{certificateExpiryWarning: CertificateExpiryWarningWindow()}
 }
 
-// ValidateNaming validates all properties of the naming section from a setup json
+// ValidateNaming validates all properties of the naming section from a setup json and returns all found problems
 // see: https://docs.cloudogu.com/docs/system-components/ces-setup/operations/setup-json_de/
 func (nv *namingValidator) ValidateNaming(naming context.Naming) error {
+	var problems Problems
+
 	if naming.Fqdn == "" {
-		return getPropertyNotSetError("fqdn")
+		problems.addPropertyNotSet("naming.fqdn", "fqdn")
 	}
 
 	if naming.Domain == "" {
-		return getPropertyNotSetError("domain")
+		problems.addPropertyNotSet("naming.domain", "domain")
 	}
 
 	certificateType := naming.CertificateType
 	if certificateType != "selfsigned" && certificateType != "external" {
-		return getInvalidOptionError("certificateType", "selfsigned", "external")
+		problems.addInvalidOption("naming.certificateType", "certificateType", "selfsigned", "external")
 	}
 
 	if certificateType == "external" {
-		err := validateCertificates(naming)
-		if err != nil {
-			return err
-		}
+		nv.validateCertificates(naming, &problems)
 	}
 
 	if naming.RelayHost == "" {
-		return getPropertyNotSetError("relayHost")
+		problems.addPropertyNotSet("naming.relayHost", "relayHost")
 	}
-	address := naming.MailAddress
 
+	address := naming.MailAddress
 	if address != "" {
 		_, err := mail.ParseAddress(address)
 		if err != nil {
-			return fmt.Errorf("failed to validate mail address: %w", err)
+			problems.add("naming.mailAddress", CodeInvalidFormat, "failed to validate mail address: %s", err.Error())
 		}
 	}
 
@@ -57,41 +61,58 @@ func (nv *namingValidator) ValidateNaming(naming context.Naming) error {
 		internalIP := naming.InternalIp
 		ip := net.ParseIP(internalIP)
 		if ip == nil {
-			return fmt.Errorf("failed to parse internal ip: %s", internalIP)
+			problems.add("naming.internalIp", CodeInvalidFormat, "failed to parse internal ip: %s", internalIP)
 		}
 	}
 
-	return nil
+	return problems.Err()
 }
 
-func validateCertificates(naming context.Naming) error {
+func (nv *namingValidator) validateCertificates(naming context.Naming, problems *Problems) {
 	cert := naming.Certificate
 	if cert == "" {
-		return getPropertyNotSetError("certificate")
+		problems.addPropertyNotSet("naming.certificate", "certificate")
 	}
 
+	var chain []*x509.Certificate
+	parsable := true
 	certs := SplitPemCertificates(cert)
 	for i, cert := range certs {
 		block, _ := pem.Decode([]byte(cert))
 		if block == nil {
-			return fmt.Errorf("failed to decode %d-th certificate in [certificate] property", i)
+			problems.add("naming.certificate", CodeInvalidFormat, "failed to decode %d-th certificate in [certificate] property", i)
+			parsable = false
+			continue
 		}
-		_, err := x509.ParseCertificate(block.Bytes)
+		parsed, err := x509.ParseCertificate(block.Bytes)
 		if err != nil {
-			return fmt.Errorf("failed to parse %d-th certificate in [certificate] property: %w", i, err)
+			problems.add("naming.certificate", CodeInvalidFormat, "failed to parse %d-th certificate in [certificate] property: %s", i, err.Error())
+			parsable = false
+			continue
 		}
+		chain = append(chain, parsed)
+	}
+
+	// the chain can only be checked if all certificates are known
+	var leaf *x509.Certificate
+	if parsable && len(chain) > 0 {
+		leaf = chain[0]
+		validateCertificateChain(chain, naming.Fqdn, nv.certificateExpiryWarning, problems)
 	}
 
 	key := naming.CertificateKey
 	if key == "" {
-		return getPropertyNotSetError("certificate key")
+		problems.addPropertyNotSet("naming.certificateKey", "certificate key")
+		return
 	}
 
 	keyBlock, _ := pem.Decode([]byte(key))
 	if keyBlock == nil {
-		return fmt.Errorf("failed to parse certificate key")
+		problems.add("naming.certificateKey", CodeInvalidFormat, "failed to parse certificate key")
+		return
 	}
-	return nil
+
+	validateCertificateKey(keyBlock, leaf, problems)
 }
 
 // SplitPemCertificates splits a certificate chain in pem format and returns all certificates of the chain as []string
@@ -76,7 +76,7 @@ func Test_namingValidator_ValidateNaming(t *testing.T) {
 
 	t.Run("successful naming validation with external certificate", func(t *testing.T) {
 		// given
-		naming := context.Naming{Fqdn: "cloudogu.com", Domain: "cloudogu.com", CertificateType: "external", RelayHost: "relay", MailAddress: "a@b.de", Certificate: cert, CertificateKey: key}
+		naming := context.Naming{Fqdn: "local.cloudogu.com", Domain: "cloudogu.com", CertificateType: "external", RelayHost: "relay", MailAddress: "a@b.de", Certificate: cert, CertificateKey: key}
 		validator := &namingValidator{}
 
 		// when
@@ -86,3 +86,22 @@ func Test_namingValidator_ValidateNaming(t *testing.T) {
 		require.NoError(t, result)
 	})
 }
+
+func Test_namingValidator_ValidateNaming_allProblems(t *testing.T) {
+	// given
+	naming := context.Naming{CertificateType: "external", MailAddress: "a@b@a", UseInternalIp: true, InternalIp: "1234.123"}
+	validator := &namingValidator{}
+
+	// when
+	err := validator.ValidateNaming(naming)
+
+	// then
+	var problems Problems
+	require.ErrorAs(t, err, &problems)
+	paths := make([]string, len(problems))
+	for i, problem := range problems {
+		paths[i] = problem.Path
+	}
+	assert.Equal(t, []string{"naming.fqdn", "naming.domain", "naming.certificate", "naming.certificateKey", "naming.relayHost", "naming.mailAddress", "naming.internalIp"}, paths)
+	assert.Equal(t, CodeInvalidFormat, problems[5].Code)
+}
@@ -0,0 +1,140 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Severity describes how serious a Problem is.
+type Severity string
+
+const (
+	// SeverityError marks a problem which prevents the setup.
+	SeverityError Severity = "error"
+	// SeverityWarning marks a problem which does not prevent the setup but probably leads to an unexpected result.
+	SeverityWarning Severity = "warning"
+)
+
+// ProblemCode classifies a Problem so that clients can react to it without parsing the message.
+type ProblemCode string
+
+const (
+	// CodeRequired is used for fields which must be set.
+	CodeRequired ProblemCode = "required"
+	// CodeInvalidOption is used for fields whose value is not one of the valid options.
+	CodeInvalidOption ProblemCode = "invalid_option"
+	// CodeInvalidFormat is used for fields whose value cannot be parsed, f. i. a mail address or a certificate.
+	CodeInvalidFormat ProblemCode = "invalid_format"
+	// CodeUnknownReference is used for fields which reference something that does not exist, f. i. a dogu which is
+	// not installed.
+	CodeUnknownReference ProblemCode = "unknown_reference"
+	// CodeUnmetDependency is used for dogus whose dependencies are not fulfilled by the other dogus to install.
+	CodeUnmetDependency ProblemCode = "unmet_dependency"
+	// CodeUnavailable is used for fields which could not be checked, f. i. because the dogu registry is not reachable.
+	CodeUnavailable ProblemCode = "unavailable"
+	// CodeAuthenticationFailed is used for credentials which are rejected, f. i. by the user backend.
+	CodeAuthenticationFailed ProblemCode = "authentication_failed"
+	// CodeNoResults is used for searches which do not find anything, f. i. a search filter of the user backend.
+	CodeNoResults ProblemCode = "no_results"
+	// CodeRejected is used for values which are rejected by an external service, f. i. a sender address by the relay
+	// host.
+	CodeRejected ProblemCode = "rejected"
+	// CodeMismatch is used for values which do not fit together, f. i. a certificate key which does not belong to the
+	// certificate.
+	CodeMismatch ProblemCode = "mismatch"
+	// CodeExpired is used for certificates which are expired, not valid yet or expire soon.
+	CodeExpired ProblemCode = "expired"
+	// CodeInsecure is used for certificates with a weak key or signature algorithm.
+	CodeInsecure ProblemCode = "insecure"
+	// CodeUntrusted is used for certificate chains which cannot be verified.
+	CodeUntrusted ProblemCode = "untrusted"
+)
+
+// Problem describes a single invalid field of the setup.json.
+type Problem struct {
+	// Path contains the JSON path of the field, f. i. "naming.certificate" or "dogus.install[2]".
+	Path string `json:"path"`
+	// Code classifies the problem.
+	Code ProblemCode `json:"code"`
+	// Message contains the human-readable description of the problem.
+	Message string `json:"message"`
+	// Severity describes whether the problem prevents the setup.
+	Severity Severity `json:"severity"`
+}
+
+// Problems contains all problems of a setup.json. It is returned as error by the validators so that callers can
+// either fail on the first invalid section or collect all problems with errors.As.
+type Problems []Problem
+
+// Error returns the messages of all problems.
+func (p Problems) Error() string {
+	messages := make([]string, len(p))
+	for i, problem := range p {
+		messages[i] = problem.Message
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Errors returns all problems with the severity error.
+func (p Problems) Errors() Problems {
+	return p.withSeverity(SeverityError)
+}
+
+// Warnings returns all problems with the severity warning.
+func (p Problems) Warnings() Problems {
+	return p.withSeverity(SeverityWarning)
+}
+
+func (p Problems) withSeverity(severity Severity) Problems {
+	var result Problems
+	for _, problem := range p {
+		if problem.Severity == severity {
+			result = append(result, problem)
+		}
+	}
+
+	return result
+}
+
+// Err returns the problems as error or nil if there are no problems.
+func (p Problems) Err() error {
+	if len(p) == 0 {
+		return nil
+	}
+
+	return p
+}
+
+func (p *Problems) add(path string, code ProblemCode, format string, args ...any) {
+	*p = append(*p, Problem{Path: path, Code: code, Message: fmt.Sprintf(format, args...), Severity: SeverityError})
+}
+
+func (p *Problems) addWarning(path string, code ProblemCode, format string, args ...any) {
+	*p = append(*p, Problem{Path: path, Code: code, Message: fmt.Sprintf(format, args...), Severity: SeverityWarning})
+}
+
+func (p *Problems) addPropertyNotSet(path string, property string) {
+	p.add(path, CodeRequired, "%s", getPropertyNotSetError(property).Error())
+}
+
+func (p *Problems) addInvalidOption(path string, property string, validOptions ...string) {
+	p.add(path, CodeInvalidOption, "%s", getInvalidOptionError(property, validOptions...).Error())
+}
+
+// appendError appends the problems of err. Other errors are appended as a problem of the given path which could not
+// be checked.
+func (p *Problems) appendError(path string, err error) {
+	if err == nil {
+		return
+	}
+
+	var problems Problems
+	if errors.As(err, &problems) {
+		*p = append(*p, problems...)
+		return
+	}
+
+	p.add(path, CodeUnavailable, "%s", err.Error())
+}
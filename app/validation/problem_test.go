@@ -0,0 +1,83 @@
+package validation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblems_Err(t *testing.T) {
+	t.Run("should return nil without problems", func(t *testing.T) {
+		// given
+		var sut Problems
+
+		// when
+		err := sut.Err()
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should return all problems as error", func(t *testing.T) {
+		// given
+		var sut Problems
+		sut.addPropertyNotSet("naming.fqdn", "fqdn")
+		sut.addInvalidOption("naming.certificateType", "certificateType", "selfsigned", "external")
+
+		// when
+		err := sut.Err()
+
+		// then
+		require.Error(t, err)
+		assert.Equal(t, "no fqdn set; invalid certificateType valid options are [selfsigned external]", err.Error())
+		assert.Equal(t, Problems{
+			{Path: "naming.fqdn", Code: CodeRequired, Message: "no fqdn set", Severity: SeverityError},
+			{Path: "naming.certificateType", Code: CodeInvalidOption, Message: "invalid certificateType valid options are [selfsigned external]", Severity: SeverityError},
+		}, err)
+	})
+}
+
+func TestProblems_appendError(t *testing.T) {
+	t.Run("should append the problems of wrapped problems", func(t *testing.T) {
+		// given
+		var sut Problems
+		problems := Problems{{Path: "admin.mail", Code: CodeRequired, Message: "no admin mail set", Severity: SeverityError}}
+
+		// when
+		sut.appendError("admin", fmt.Errorf("failed to validate admin: %w", problems))
+		sut.appendError("naming", nil)
+
+		// then
+		assert.Equal(t, problems, sut)
+	})
+
+	t.Run("should append other errors as problem of the path", func(t *testing.T) {
+		// given
+		var sut Problems
+
+		// when
+		sut.appendError("dogus", assert.AnError)
+
+		// then
+		assert.Equal(t, Problems{{Path: "dogus", Code: CodeUnavailable, Message: assert.AnError.Error(), Severity: SeverityError}}, sut)
+	})
+}
+
+func TestProblems_Errors(t *testing.T) {
+	t.Run("should split problems by severity", func(t *testing.T) {
+		// given
+		var sut Problems
+		sut.addPropertyNotSet("userBackend.host", "host")
+		sut.addWarning("userBackend.searchFilter", CodeNoResults, "no users found")
+
+		// when
+		errs := sut.Errors()
+		warnings := sut.Warnings()
+
+		// then
+		assert.Equal(t, Problems{{Path: "userBackend.host", Code: CodeRequired, Message: "no host set", Severity: SeverityError}}, errs)
+		assert.Equal(t, Problems{{Path: "userBackend.searchFilter", Code: CodeNoResults, Message: "no users found", Severity: SeverityWarning}}, warnings)
+	})
+}
@@ -2,8 +2,11 @@ package validation
 
 import (
 	"fmt"
-	"github.com/cloudogu/k8s-ces-setup/v4/app/context"
+	"maps"
+	"slices"
 	"strings"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/context"
 )
 
 type registryConfigEncryptedValidator struct {
@@ -16,7 +19,8 @@ func NewRegistryConfigEncryptedValidator() *registryConfigEncryptedValidator {
 
 // ValidateRegistryConfigEncrypted check whether the registryConfigEncrypted section has invalid dogu keys
 func (rcev *registryConfigEncryptedValidator) ValidateRegistryConfigEncrypted(config *context.SetupJsonConfiguration) error {
-	for key := range config.RegistryConfigEncrypted {
+	var problems Problems
+	for _, key := range slices.Sorted(maps.Keys(config.RegistryConfigEncrypted)) {
 		keyFound := false
 		for _, dogu := range config.Dogus.Install {
 			if strings.Contains(dogu, key) {
@@ -26,9 +30,9 @@ func (rcev *registryConfigEncryptedValidator) ValidateRegistryConfigEncrypted(co
 		}
 
 		if !keyFound {
-			return fmt.Errorf("key %s does not exist in dogu install list", key)
+			problems.add(fmt.Sprintf("registryConfigEncrypted.%s", key), CodeUnknownReference, "key %s does not exist in dogu install list", key)
 		}
 	}
 
-	return nil
+	return problems.Err()
 }
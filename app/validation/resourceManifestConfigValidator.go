@@ -0,0 +1,25 @@
+package validation
+
+import (
+	"errors"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+)
+
+type resourceManifestValidator struct{}
+
+// NewResourceManifestConfigurationValidator creates a new validator.
+func NewResourceManifestConfigurationValidator() *resourceManifestValidator {
+	return &resourceManifestValidator{}
+}
+
+// Validate checks resource manifest configurations for configuration errors.
+func (r *resourceManifestValidator) Validate(resourceManifestConfig []patch.ResourceManifest) error {
+	var errs []error
+
+	for _, resourceManifest := range resourceManifestConfig {
+		errs = append(errs, resourceManifest.Validate())
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,51 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/patch"
+)
+
+func TestNewResourceManifestConfigurationValidator(t *testing.T) {
+	t.Run("should return a valid object", func(t *testing.T) {
+		actual := NewResourceManifestConfigurationValidator()
+		require.NotNil(t, actual)
+	})
+}
+
+func Test_resourceManifestValidator_Validate(t *testing.T) {
+	t.Run("should accept valid manifests", func(t *testing.T) {
+		// given
+		manifests := []patch.ResourceManifest{
+			{Phase: patch.ComponentPhase, Manifest: "kind: ConfigMap"},
+			{Phase: patch.DoguPhase, URL: "https://example.com/manifest.yaml"},
+		}
+		sut := &resourceManifestValidator{}
+
+		// when
+		err := sut.Validate(manifests)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should report all invalid manifests", func(t *testing.T) {
+		// given
+		manifests := []patch.ResourceManifest{
+			{Phase: "boohoo", Manifest: "kind: ConfigMap"},
+			{Phase: patch.DoguPhase},
+		}
+		sut := &resourceManifestValidator{}
+
+		// when
+		err := sut.Validate(manifests)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "phase 'boohoo' does not exist")
+		assert.ErrorContains(t, err, "resource manifest must contain either a manifest or an url")
+	})
+}
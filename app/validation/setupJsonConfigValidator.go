@@ -2,7 +2,11 @@ package validation
 
 import (
 	ctx "context"
+	"errors"
 	"fmt"
+
+	"github.com/sirupsen/logrus"
+
 	cescommons "github.com/cloudogu/ces-commons-lib/dogu"
 	"github.com/cloudogu/k8s-ces-setup/v4/app/context"
 )
@@ -28,37 +32,64 @@ func NewSetupJsonConfigurationValidator(repository cescommons.RemoteDoguDescript
 	}
 }
 
-// Validate checks the section naming, user backend and user from the setup.json configuration
+// Validate checks the section naming, user backend and user from the setup.json configuration. Warnings are only logged.
 // see: https://docs.cloudogu.com/docs/system-components/ces-setup/operations/setup-json_de/
 func (v *validator) Validate(ctx ctx.Context, configuration *context.SetupJsonConfiguration) error {
 	dogus := configuration.Dogus
-	err := v.doguValidator.ValidateDogus(ctx, dogus)
+	err := withoutWarnings(v.doguValidator.ValidateDogus(ctx, dogus))
 	if err != nil {
 		return fmt.Errorf("failed to validate dogu section: %w", err)
 	}
 
 	naming := configuration.Naming
-	err = v.namingValidator.ValidateNaming(naming)
+	err = withoutWarnings(v.namingValidator.ValidateNaming(naming))
 	if err != nil {
 		return fmt.Errorf("failed to validate naming section: %w", err)
 	}
 
 	userBackend := configuration.UserBackend
-	err = v.userBackenValidator.ValidateUserBackend(userBackend)
+	err = withoutWarnings(v.userBackenValidator.ValidateUserBackend(userBackend))
 	if err != nil {
 		return fmt.Errorf("failed to validate user backend section: %w", err)
 	}
 
 	admin := configuration.Admin
-	err = v.adminValidator.ValidateAdmin(admin, userBackend.DsType)
+	err = withoutWarnings(v.adminValidator.ValidateAdmin(admin, userBackend.DsType))
 	if err != nil {
 		return fmt.Errorf("failed to validate admin user section: %w", err)
 	}
 
-	err = v.registryConfigEncryptedValidator.ValidateRegistryConfigEncrypted(configuration)
+	err = withoutWarnings(v.registryConfigEncryptedValidator.ValidateRegistryConfigEncrypted(configuration))
 	if err != nil {
 		return fmt.Errorf("failed to validate registry config encrypted section: %w", err)
 	}
 
 	return nil
 }
+
+// ValidateAll checks all sections of the setup.json configuration like Validate but does not stop at the first invalid
+// section. It returns the problems of all sections.
+func (v *validator) ValidateAll(ctx ctx.Context, configuration *context.SetupJsonConfiguration) Problems {
+	var problems Problems
+	problems.appendError("dogus", v.doguValidator.ValidateDogus(ctx, configuration.Dogus))
+	problems.appendError("naming", v.namingValidator.ValidateNaming(configuration.Naming))
+	problems.appendError("userBackend", v.userBackenValidator.ValidateUserBackend(configuration.UserBackend))
+	problems.appendError("admin", v.adminValidator.ValidateAdmin(configuration.Admin, configuration.UserBackend.DsType))
+	problems.appendError("registryConfigEncrypted", v.registryConfigEncryptedValidator.ValidateRegistryConfigEncrypted(configuration))
+
+	return problems
+}
+
+// withoutWarnings logs the warnings of the problems of err and returns only the problems which prevent the setup.
+func withoutWarnings(err error) error {
+	var problems Problems
+	if !errors.As(err, &problems) {
+		return err
+	}
+
+	for _, warning := range problems.Warnings() {
+		logrus.Warnf("%s: %s", warning.Path, warning.Message)
+	}
+
+	return problems.Errors().Err()
+}
@@ -66,6 +66,34 @@ func Test_validator_ValidateConfiguration(t *testing.T) {
 		mock.AssertExpectationsForObjects(t, remoteDoguRepo)
 	})
 
+	t.Run("should only log warnings", func(t *testing.T) {
+		// given
+		configuration := &context.SetupJsonConfiguration{}
+		doguValidatorMock := NewMockDoguValidator(t)
+		doguValidatorMock.EXPECT().ValidateDogus(mock.Anything, mock.Anything).Return(nil)
+		namingValidatorMock := NewMockNamingValidator(t)
+		namingValidatorMock.EXPECT().ValidateNaming(mock.Anything).Return(Problems{{Path: "naming.certificate", Code: CodeExpired, Message: "certificate expires soon", Severity: SeverityWarning}})
+		userBackendValidatorMock := NewMockUserBackendValidator(t)
+		userBackendValidatorMock.EXPECT().ValidateUserBackend(mock.Anything).Return(nil)
+		adminValidatorMock := NewMockAdminValidator(t)
+		adminValidatorMock.EXPECT().ValidateAdmin(mock.Anything, mock.Anything).Return(nil)
+		registryConfigEncryptedValidatorMock := NewMockRegistryConfigEncryptedValidator(t)
+		registryConfigEncryptedValidatorMock.EXPECT().ValidateRegistryConfigEncrypted(mock.Anything).Return(nil)
+		sut := &validator{
+			doguValidator:                    doguValidatorMock,
+			namingValidator:                  namingValidatorMock,
+			userBackenValidator:              userBackendValidatorMock,
+			adminValidator:                   adminValidatorMock,
+			registryConfigEncryptedValidator: registryConfigEncryptedValidatorMock,
+		}
+
+		// when
+		err := sut.Validate(ctx.TODO(), configuration)
+
+		// then
+		require.NoError(t, err)
+	})
+
 	t.Run("error during dogu validation", func(t *testing.T) {
 		// given
 		configuration := &context.SetupJsonConfiguration{Dogus: context.Dogus{Completed: true}}
@@ -186,3 +214,67 @@ func Test_validator_ValidateConfiguration(t *testing.T) {
 		mock.AssertExpectationsForObjects(t, remoteDoguRepo)
 	})
 }
+
+func Test_validator_ValidateAll(t *testing.T) {
+	t.Run("should collect the problems of all sections", func(t *testing.T) {
+		// given
+		configuration := &context.SetupJsonConfiguration{UserBackend: context.UserBackend{DsType: DsTypeEmbedded}}
+		namingProblems := Problems{{Path: "naming.fqdn", Code: CodeRequired, Message: "no fqdn set", Severity: SeverityError}}
+		adminProblems := Problems{{Path: "admin.mail", Code: CodeRequired, Message: "no admin mail set", Severity: SeverityError}}
+		doguValidatorMock := NewMockDoguValidator(t)
+		doguValidatorMock.EXPECT().ValidateDogus(mock.Anything, configuration.Dogus).Return(assert.AnError)
+		namingValidatorMock := NewMockNamingValidator(t)
+		namingValidatorMock.EXPECT().ValidateNaming(configuration.Naming).Return(namingProblems)
+		userBackendValidatorMock := NewMockUserBackendValidator(t)
+		userBackendValidatorMock.EXPECT().ValidateUserBackend(configuration.UserBackend).Return(nil)
+		adminValidatorMock := NewMockAdminValidator(t)
+		adminValidatorMock.EXPECT().ValidateAdmin(configuration.Admin, DsTypeEmbedded).Return(adminProblems)
+		registryConfigEncryptedValidatorMock := NewMockRegistryConfigEncryptedValidator(t)
+		registryConfigEncryptedValidatorMock.EXPECT().ValidateRegistryConfigEncrypted(configuration).Return(nil)
+		sut := &validator{
+			doguValidator:                    doguValidatorMock,
+			namingValidator:                  namingValidatorMock,
+			userBackenValidator:              userBackendValidatorMock,
+			adminValidator:                   adminValidatorMock,
+			registryConfigEncryptedValidator: registryConfigEncryptedValidatorMock,
+		}
+
+		// when
+		actual := sut.ValidateAll(ctx.TODO(), configuration)
+
+		// then
+		assert.Equal(t, Problems{
+			{Path: "dogus", Code: CodeUnavailable, Message: assert.AnError.Error(), Severity: SeverityError},
+			namingProblems[0],
+			adminProblems[0],
+		}, actual)
+	})
+
+	t.Run("should return no problems for a valid configuration", func(t *testing.T) {
+		// given
+		configuration := &context.SetupJsonConfiguration{}
+		doguValidatorMock := NewMockDoguValidator(t)
+		doguValidatorMock.EXPECT().ValidateDogus(mock.Anything, mock.Anything).Return(nil)
+		namingValidatorMock := NewMockNamingValidator(t)
+		namingValidatorMock.EXPECT().ValidateNaming(mock.Anything).Return(nil)
+		userBackendValidatorMock := NewMockUserBackendValidator(t)
+		userBackendValidatorMock.EXPECT().ValidateUserBackend(mock.Anything).Return(nil)
+		adminValidatorMock := NewMockAdminValidator(t)
+		adminValidatorMock.EXPECT().ValidateAdmin(mock.Anything, mock.Anything).Return(nil)
+		registryConfigEncryptedValidatorMock := NewMockRegistryConfigEncryptedValidator(t)
+		registryConfigEncryptedValidatorMock.EXPECT().ValidateRegistryConfigEncrypted(mock.Anything).Return(nil)
+		sut := &validator{
+			doguValidator:                    doguValidatorMock,
+			namingValidator:                  namingValidatorMock,
+			userBackenValidator:              userBackendValidatorMock,
+			adminValidator:                   adminValidatorMock,
+			registryConfigEncryptedValidator: registryConfigEncryptedValidatorMock,
+		}
+
+		// when
+		actual := sut.ValidateAll(ctx.TODO(), configuration)
+
+		// then
+		assert.Empty(t, actual)
+	})
+}
@@ -0,0 +1,182 @@
+package validation
+
+import (
+	ctx "context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/context"
+)
+
+const (
+	defaultSmtpTimeout = 10 * time.Second
+	defaultSmtpPort    = "25"
+	// defaultHelloName is sent with EHLO if the FQDN is not known yet, f. i. because it is retrieved from the load
+	// balancer later.
+	defaultHelloName = "localhost"
+	testMailSubject  = "Test mail of the Cloudogu EcoSystem setup"
+)
+
+type smtpRelayChecker struct {
+	timeout time.Duration
+	// rootCAs contains the certificate authorities which are trusted for STARTTLS. The certificate authorities of the
+	// system are used if it is nil.
+	rootCAs *x509.CertPool
+	// sendTestMail enables sending a test mail to the admin in addition to checking the sender address.
+	sendTestMail bool
+}
+
+// NewSmtpRelayChecker creates a new checker which connects to the relay host of the setup configuration. If
+// sendTestMail is true, a test mail is sent to the mail address of the admin.
+func NewSmtpRelayChecker(sendTestMail bool) *smtpRelayChecker {
+	return &smtpRelayChecker{timeout: defaultSmtpTimeout, sendTestMail: sendTestMail}
+}
+
+// CheckSmtpRelay connects to the relay host, greets it with EHLO, starts TLS if the relay host offers it and checks
+// that the configured mail address is accepted as sender. Relay hosts whose TLS cannot be established are checked
+// without TLS and reported as warning because postfix falls back to unencrypted connections as well.
+func (c *smtpRelayChecker) CheckSmtpRelay(ctx ctx.Context, configuration *context.SetupJsonConfiguration) Problems {
+	var problems Problems
+	naming := configuration.Naming
+	host, address := relayHostAddress(naming.RelayHost)
+
+	client, err := c.connect(ctx, host, address, helloName(naming.Fqdn), true)
+	if err != nil {
+		var tlsErr *startTLSError
+		if !errors.As(err, &tlsErr) {
+			problems.add("naming.relayHost", CodeUnavailable, "failed to connect to relay host %s: %s", address, err.Error())
+			return problems
+		}
+
+		problems.addWarning("naming.relayHost", CodeUnavailable, "failed to start TLS with relay host %s, mails are sent unencrypted: %s", address, tlsErr.err.Error())
+		client, err = c.connect(ctx, host, address, helloName(naming.Fqdn), false)
+		if err != nil {
+			problems.add("naming.relayHost", CodeUnavailable, "failed to connect to relay host %s: %s", address, err.Error())
+			return problems
+		}
+	}
+	defer func() { _ = client.Close() }()
+
+	sender := naming.MailAddress
+	if sender == "" {
+		if c.sendTestMail {
+			problems.addWarning("naming.mailAddress", CodeRequired, "no test mail sent because no mail address is set")
+		}
+		return problems
+	}
+
+	err = client.Mail(sender)
+	if err != nil {
+		problems.add("naming.mailAddress", CodeRejected, "relay host rejected sender %s: %s", sender, err.Error())
+		return problems
+	}
+
+	if c.sendTestMail {
+		c.send(client, sender, configuration.Admin.Mail, &problems)
+	} else {
+		// nothing is sent if only the sender is checked
+		err = client.Reset()
+		if err != nil {
+			problems.add("naming.relayHost", CodeUnavailable, "failed to reset mail transaction with relay host: %s", err.Error())
+			return problems
+		}
+	}
+
+	_ = client.Quit()
+	return problems
+}
+
+type startTLSError struct {
+	err error
+}
+
+func (e *startTLSError) Error() string {
+	return fmt.Sprintf("failed to start TLS: %s", e.err.Error())
+}
+
+func (c *smtpRelayChecker) connect(ctx ctx.Context, host string, address string, hello string, useTLS bool) (*smtp.Client, error) {
+	dialer := &net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(c.timeout))
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	err = client.Hello(hello)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("relay host rejected EHLO %s: %w", hello, err)
+	}
+
+	if ok, _ := client.Extension("STARTTLS"); ok && useTLS {
+		err = client.StartTLS(&tls.Config{ServerName: host, RootCAs: c.rootCAs})
+		if err != nil {
+			_ = client.Close()
+			return nil, &startTLSError{err: err}
+		}
+	}
+
+	return client, nil
+}
+
+func (c *smtpRelayChecker) send(client *smtp.Client, sender string, recipient string, problems *Problems) {
+	if recipient == "" {
+		problems.addWarning("admin.mail", CodeRequired, "no test mail sent because no admin mail is set")
+		return
+	}
+
+	err := client.Rcpt(recipient)
+	if err != nil {
+		problems.add("admin.mail", CodeRejected, "relay host rejected recipient %s: %s", recipient, err.Error())
+		return
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		problems.add("naming.relayHost", CodeRejected, "relay host rejected test mail: %s", err.Error())
+		return
+	}
+
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\n\r\n"+
+		"This mail was sent by the setup of the Cloudogu EcoSystem to check the relay host.\r\n",
+		sender, recipient, testMailSubject, time.Now().Format(time.RFC1123Z))
+	_, err = writer.Write([]byte(message))
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		problems.add("naming.relayHost", CodeRejected, "relay host rejected test mail: %s", err.Error())
+	}
+}
+
+// relayHostAddress returns the host and the address of a relay host in the postfix format "host", "host:port",
+// "[host]" or "[host]:port".
+func relayHostAddress(relayHost string) (string, string) {
+	host, port, err := net.SplitHostPort(relayHost)
+	if err != nil {
+		host, port = relayHost, defaultSmtpPort
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+
+	return host, net.JoinHostPort(host, port)
+}
+
+func helloName(fqdn string) string {
+	if fqdn == "" || fqdn == "<<ip>>" {
+		return defaultHelloName
+	}
+
+	return fqdn
+}
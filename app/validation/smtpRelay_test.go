@@ -0,0 +1,317 @@
+package validation
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+)
+
+const (
+	testSender    = "ces@example.com"
+	testRecipient = "admin@example.com"
+)
+
+func TestSmtpRelayChecker_CheckSmtpRelay(t *testing.T) {
+	serverTLS, rootCAs := createTestCertificate(t)
+
+	t.Run("should accept sender without sending a mail", func(t *testing.T) {
+		// given
+		server := newTestSmtpServer(t, nil)
+		sut := &smtpRelayChecker{timeout: 5 * time.Second}
+
+		// when
+		problems := sut.CheckSmtpRelay(context.TODO(), server.setupJson())
+
+		// then
+		assert.Empty(t, problems)
+		assert.Equal(t, []string{"EHLO ces.example.com", "MAIL FROM:<ces@example.com>", "RSET", "QUIT"}, server.recordedCommands())
+		assert.Empty(t, server.recordedMails())
+	})
+
+	t.Run("should send test mail to admin", func(t *testing.T) {
+		// given
+		server := newTestSmtpServer(t, nil)
+		sut := &smtpRelayChecker{timeout: 5 * time.Second, sendTestMail: true}
+
+		// when
+		problems := sut.CheckSmtpRelay(context.TODO(), server.setupJson())
+
+		// then
+		assert.Empty(t, problems)
+		mails := server.recordedMails()
+		require.Len(t, mails, 1)
+		assert.Contains(t, mails[0], "From: ces@example.com")
+		assert.Contains(t, mails[0], "To: admin@example.com")
+		assert.Contains(t, mails[0], "Subject: Test mail of the Cloudogu EcoSystem setup")
+	})
+
+	t.Run("should start TLS if relay host offers it", func(t *testing.T) {
+		// given
+		server := newTestSmtpServer(t, serverTLS)
+		sut := &smtpRelayChecker{timeout: 5 * time.Second, rootCAs: rootCAs}
+
+		// when
+		problems := sut.CheckSmtpRelay(context.TODO(), server.setupJson())
+
+		// then
+		assert.Empty(t, problems)
+		assert.Equal(t, []string{"EHLO ces.example.com", "STARTTLS", "EHLO ces.example.com", "MAIL FROM:<ces@example.com>", "RSET", "QUIT"}, server.recordedCommands())
+	})
+
+	t.Run("should warn and continue without TLS if certificate is not trusted", func(t *testing.T) {
+		// given
+		server := newTestSmtpServer(t, serverTLS)
+		sut := &smtpRelayChecker{timeout: 5 * time.Second}
+
+		// when
+		problems := sut.CheckSmtpRelay(context.TODO(), server.setupJson())
+
+		// then
+		require.Len(t, problems, 1)
+		assert.Equal(t, "naming.relayHost", problems[0].Path)
+		assert.Equal(t, SeverityWarning, problems[0].Severity)
+		assert.Contains(t, problems[0].Message, "mails are sent unencrypted")
+		assert.Contains(t, server.recordedCommands(), "MAIL FROM:<ces@example.com>")
+	})
+
+	t.Run("should use localhost as EHLO if FQDN is not known yet", func(t *testing.T) {
+		// given
+		server := newTestSmtpServer(t, nil)
+		sut := &smtpRelayChecker{timeout: 5 * time.Second}
+		setupJson := server.setupJson()
+		setupJson.Naming.Fqdn = "<<ip>>"
+
+		// when
+		problems := sut.CheckSmtpRelay(context.TODO(), setupJson)
+
+		// then
+		assert.Empty(t, problems)
+		assert.Equal(t, "EHLO localhost", server.recordedCommands()[0])
+	})
+
+	t.Run("should fail to connect to unreachable relay host", func(t *testing.T) {
+		// given
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		address := listener.Addr().String()
+		require.NoError(t, listener.Close())
+		sut := &smtpRelayChecker{timeout: 5 * time.Second}
+
+		// when
+		problems := sut.CheckSmtpRelay(context.TODO(), &appcontext.SetupJsonConfiguration{Naming: appcontext.Naming{RelayHost: address}})
+
+		// then
+		require.Len(t, problems, 1)
+		assert.Equal(t, "naming.relayHost", problems[0].Path)
+		assert.Equal(t, CodeUnavailable, problems[0].Code)
+		assert.Equal(t, SeverityError, problems[0].Severity)
+		assert.Contains(t, problems[0].Message, "failed to connect to relay host "+address)
+	})
+
+	t.Run("should report rejected sender", func(t *testing.T) {
+		// given
+		server := newTestSmtpServer(t, nil)
+		sut := &smtpRelayChecker{timeout: 5 * time.Second, sendTestMail: true}
+		setupJson := server.setupJson()
+		setupJson.Naming.MailAddress = "ces@example.org"
+
+		// when
+		problems := sut.CheckSmtpRelay(context.TODO(), setupJson)
+
+		// then
+		assert.Equal(t, Problems{{Path: "naming.mailAddress", Code: CodeRejected, Message: "relay host rejected sender ces@example.org: 550 \"sender not allowed\"", Severity: SeverityError}}, problems)
+		assert.Empty(t, server.recordedMails())
+	})
+
+	t.Run("should report rejected recipient", func(t *testing.T) {
+		// given
+		server := newTestSmtpServer(t, nil)
+		sut := &smtpRelayChecker{timeout: 5 * time.Second, sendTestMail: true}
+		setupJson := server.setupJson()
+		setupJson.Admin.Mail = "admin@example.org"
+
+		// when
+		problems := sut.CheckSmtpRelay(context.TODO(), setupJson)
+
+		// then
+		assert.Equal(t, Problems{{Path: "admin.mail", Code: CodeRejected, Message: "relay host rejected recipient admin@example.org: 550 \"relay not permitted\"", Severity: SeverityError}}, problems)
+		assert.Empty(t, server.recordedMails())
+	})
+
+	t.Run("should warn if test mail cannot be sent without mail address", func(t *testing.T) {
+		// given
+		server := newTestSmtpServer(t, nil)
+		sut := &smtpRelayChecker{timeout: 5 * time.Second, sendTestMail: true}
+		setupJson := server.setupJson()
+		setupJson.Naming.MailAddress = ""
+
+		// when
+		problems := sut.CheckSmtpRelay(context.TODO(), setupJson)
+
+		// then
+		assert.Equal(t, Problems{{Path: "naming.mailAddress", Code: CodeRequired, Message: "no test mail sent because no mail address is set", Severity: SeverityWarning}}, problems)
+	})
+}
+
+func Test_relayHostAddress(t *testing.T) {
+	tests := []struct {
+		relayHost       string
+		expectedHost    string
+		expectedAddress string
+	}{
+		{relayHost: "mail.example.com", expectedHost: "mail.example.com", expectedAddress: "mail.example.com:25"},
+		{relayHost: "mail.example.com:587", expectedHost: "mail.example.com", expectedAddress: "mail.example.com:587"},
+		{relayHost: "[mail.example.com]", expectedHost: "mail.example.com", expectedAddress: "mail.example.com:25"},
+		{relayHost: "[mail.example.com]:587", expectedHost: "mail.example.com", expectedAddress: "mail.example.com:587"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.relayHost, func(t *testing.T) {
+			// when
+			host, address := relayHostAddress(tt.relayHost)
+
+			// then
+			assert.Equal(t, tt.expectedHost, host)
+			assert.Equal(t, tt.expectedAddress, address)
+		})
+	}
+}
+
+// testSmtpServer is a minimal SMTP server which supports EHLO, STARTTLS and the delivery of mails.
+type testSmtpServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	wg        sync.WaitGroup
+	mutex     sync.Mutex
+	commands  []string
+	mails     []string
+}
+
+func newTestSmtpServer(t *testing.T, tlsConfig *tls.Config) *testSmtpServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &testSmtpServer{listener: listener, tlsConfig: tlsConfig}
+	server.wg.Add(1)
+	go server.serve()
+	t.Cleanup(func() {
+		_ = listener.Close()
+		server.wg.Wait()
+	})
+
+	return server
+}
+
+func (s *testSmtpServer) setupJson() *appcontext.SetupJsonConfiguration {
+	return &appcontext.SetupJsonConfiguration{
+		Naming: appcontext.Naming{Fqdn: "ces.example.com", RelayHost: s.listener.Addr().String(), MailAddress: testSender},
+		Admin:  appcontext.User{Mail: testRecipient},
+	}
+}
+
+func (s *testSmtpServer) recordedCommands() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string{}, s.commands...)
+}
+
+func (s *testSmtpServer) recordedMails() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string{}, s.mails...)
+}
+
+func (s *testSmtpServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *testSmtpServer) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	text := textproto.NewConn(conn)
+	_ = text.PrintfLine("220 smtp.test ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		s.record(line)
+
+		command, argument, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(command) {
+		case "EHLO":
+			if s.tlsConfig != nil {
+				_ = text.PrintfLine("250-smtp.test")
+				_ = text.PrintfLine("250 STARTTLS")
+			} else {
+				_ = text.PrintfLine("250 smtp.test")
+			}
+		case "STARTTLS":
+			_ = text.PrintfLine("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(conn)
+		case "MAIL":
+			if argument != "FROM:<"+testSender+">" {
+				_ = text.PrintfLine("550 sender not allowed")
+				continue
+			}
+			_ = text.PrintfLine("250 OK")
+		case "RCPT":
+			if argument != "TO:<"+testRecipient+">" {
+				_ = text.PrintfLine("550 relay not permitted")
+				continue
+			}
+			_ = text.PrintfLine("250 OK")
+		case "DATA":
+			_ = text.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			mail, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mutex.Lock()
+			s.mails = append(s.mails, string(mail))
+			s.mutex.Unlock()
+			_ = text.PrintfLine("250 OK")
+		case "RSET", "NOOP":
+			_ = text.PrintfLine("250 OK")
+		case "QUIT":
+			_ = text.PrintfLine("221 bye")
+			return
+		default:
+			_ = text.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+func (s *testSmtpServer) record(command string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.commands = append(s.commands, command)
+}
@@ -1,7 +1,6 @@
 package validation
 
 import (
-	"fmt"
 	"strconv"
 
 	"github.com/cloudogu/k8s-ces-setup/v4/app/context"
@@ -23,12 +22,15 @@ func NewUserBackendValidator() *userBackendValidator {
 	return &userBackendValidator{}
 }
 
-// ValidateUserBackend validates all properties of the user backend section from a setup json
+// ValidateUserBackend validates all properties of the user backend section from a setup json and returns all found
+// problems
 // see: https://docs.cloudogu.com/docs/system-components/ces-setup/operations/setup-json_de/
 func (ubv *userBackendValidator) ValidateUserBackend(backend context.UserBackend) error {
 	dsType := backend.DsType
 	if dsType != DsTypeEmbedded && dsType != DsTypeExternal {
-		return getInvalidOptionError("dsType", DsTypeEmbedded, DsTypeExternal)
+		var problems Problems
+		problems.addInvalidOption("userBackend.dsType", "dsType", DsTypeEmbedded, DsTypeExternal)
+		return problems.Err()
 	}
 
 	var result error
@@ -43,132 +45,123 @@ func (ubv *userBackendValidator) ValidateUserBackend(backend context.UserBackend
 }
 
 func (ubv *userBackendValidator) validateActiveDirectoryServer(backend context.UserBackend) error {
+	var problems Problems
 	if backend.AttributeID != "sAMAccountName" {
-		return getInvalidOptionError("attributeID", "sAMAccountName")
+		problems.addInvalidOption("userBackend.attributeID", "attributeID", "sAMAccountName")
 	}
 	if backend.AttributeFullname != "cn" {
-		return getInvalidOptionError("attributeFullName", "cn")
+		problems.addInvalidOption("userBackend.attributeFullname", "attributeFullName", "cn")
 	}
 	if backend.AttributeMail != "mail" {
-		return getInvalidOptionError("attributeMail", "mail")
+		problems.addInvalidOption("userBackend.attributeMail", "attributeMail", "mail")
 	}
 	if backend.AttributeGroup != "memberOf" {
-		return getInvalidOptionError("attributeGroup", "memberOf")
+		problems.addInvalidOption("userBackend.attributeGroup", "attributeGroup", "memberOf")
 	}
 	if backend.SearchFilter != searchFilter {
-		return getInvalidOptionError("searchFilter", searchFilter)
+		problems.addInvalidOption("userBackend.searchFilter", "searchFilter", searchFilter)
 	}
 
-	return nil
+	return problems.Err()
 }
 
 func (ubv *userBackendValidator) validateExternalBackend(backend context.UserBackend) error {
-	err := ubv.validateBackendLocation(backend)
-	if err != nil {
-		return err
-	}
-
-	err = ubv.validateBackendAuth(backend)
-	if err != nil {
-		return err
-	}
+	var problems Problems
+	problems.appendError("userBackend", ubv.validateBackendLocation(backend))
+	problems.appendError("userBackend", ubv.validateBackendAuth(backend))
+	problems.appendError("userBackend", ubv.validateBackendGroups(backend))
 
-	err = ubv.validateBackendGroups(backend)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return problems.Err()
 }
 
 func (ubv *userBackendValidator) validateBackendLocation(backend context.UserBackend) error {
+	var problems Problems
 	if backend.Server != "activeDirectory" && backend.Server != "custom" {
-		return getInvalidOptionError("server", "activeDirectory", "custom")
+		problems.addInvalidOption("userBackend.server", "server", "activeDirectory", "custom")
 	}
 	if backend.Server == "activeDirectory" {
-		err := ubv.validateActiveDirectoryServer(backend)
-		if err != nil {
-			return err
-		}
+		problems.appendError("userBackend", ubv.validateActiveDirectoryServer(backend))
 	}
 	if backend.BaseDN == "" {
-		return getPropertyNotSetError("baseDn")
+		problems.addPropertyNotSet("userBackend.baseDN", "baseDn")
 	}
 	if backend.ConnectionDN == "" {
-		return getPropertyNotSetError("connectionDn")
+		problems.addPropertyNotSet("userBackend.connectionDN", "connectionDn")
 	}
 	if backend.Host == "" {
-		return getPropertyNotSetError("host")
+		problems.addPropertyNotSet("userBackend.host", "host")
 	}
 	if backend.Port == "" {
-		return getPropertyNotSetError("port")
-	}
-	if _, err := strconv.Atoi(backend.Port); err != nil {
-		return fmt.Errorf("failed to validate property port: the given value is not a number")
+		problems.addPropertyNotSet("userBackend.port", "port")
+	} else if _, err := strconv.Atoi(backend.Port); err != nil {
+		problems.add("userBackend.port", CodeInvalidFormat, "failed to validate property port: the given value is not a number")
 	}
 	if backend.Encryption != "none" && backend.Encryption != "ssl" && backend.Encryption != "sslAny" && backend.Encryption != "startTLS" && backend.Encryption != "startTLSAny" {
-		return getInvalidOptionError("encryption", "none", "ssl", "sslAny", "startTLS", "startTLSAny")
+		problems.addInvalidOption("userBackend.encryption", "encryption", "none", "ssl", "sslAny", "startTLS", "startTLSAny")
 	}
 
-	return nil
+	return problems.Err()
 }
 
 func (ubv *userBackendValidator) validateBackendAuth(backend context.UserBackend) error {
+	var problems Problems
 	if backend.Password == "" {
-		return getPropertyNotSetError("password")
+		problems.addPropertyNotSet("userBackend.password", "password")
 	}
 	if backend.AttributeGivenName == "" {
-		return getPropertyNotSetError("attributeGivenName")
+		problems.addPropertyNotSet("userBackend.attributeGivenName", "attributeGivenName")
 	}
 	if backend.AttributeSurname == "" {
-		return getPropertyNotSetError("attributeSurName")
+		problems.addPropertyNotSet("userBackend.attributeSurname", "attributeSurName")
 	}
 
-	return nil
+	return problems.Err()
 }
 
 func (ubv *userBackendValidator) validateBackendGroups(backend context.UserBackend) error {
+	var problems Problems
 	if backend.GroupBaseDN == "" {
-		return getPropertyNotSetError("groupBaseDN")
+		problems.addPropertyNotSet("userBackend.groupBaseDN", "groupBaseDN")
 	}
 	if backend.GroupSearchFilter == "" {
-		return getPropertyNotSetError("groupSearchFilter")
+		problems.addPropertyNotSet("userBackend.groupSearchFilter", "groupSearchFilter")
 	}
 	if backend.GroupAttributeName == "" {
-		return getPropertyNotSetError("groupAttributeName")
+		problems.addPropertyNotSet("userBackend.groupAttributeName", "groupAttributeName")
 	}
 	if backend.GroupAttributeDescription == "" {
-		return getPropertyNotSetError("groupAttributeDescription")
+		problems.addPropertyNotSet("userBackend.groupAttributeDescription", "groupAttributeDescription")
 	}
 	if backend.GroupAttributeMember == "" {
-		return getPropertyNotSetError("groupAttributeMember")
+		problems.addPropertyNotSet("userBackend.groupAttributeMember", "groupAttributeMember")
 	}
 
-	return nil
+	return problems.Err()
 }
 
 func (ubv *userBackendValidator) validateEmbeddedBackend(backend context.UserBackend) error {
+	var problems Problems
 	if backend.AttributeID != "uid" {
-		return getInvalidOptionError("attributeID", "uid")
+		problems.addInvalidOption("userBackend.attributeID", "attributeID", "uid")
 	}
 	if backend.AttributeFullname != "cn" {
-		return getInvalidOptionError("attributeFullName", "cn")
+		problems.addInvalidOption("userBackend.attributeFullname", "attributeFullName", "cn")
 	}
 	if backend.AttributeMail != "mail" {
-		return getInvalidOptionError("attributeMail", "mail")
+		problems.addInvalidOption("userBackend.attributeMail", "attributeMail", "mail")
 	}
 	if backend.AttributeGroup != "memberOf" {
-		return getInvalidOptionError("attributeGroup", "memberOf")
+		problems.addInvalidOption("userBackend.attributeGroup", "attributeGroup", "memberOf")
 	}
 	if backend.SearchFilter != searchFilter {
-		return getInvalidOptionError("searchFilter", searchFilter)
+		problems.addInvalidOption("userBackend.searchFilter", "searchFilter", searchFilter)
 	}
 	if backend.Host != "ldap" {
-		return getInvalidOptionError("host", "ldap")
+		problems.addInvalidOption("userBackend.host", "host", "ldap")
 	}
 	if backend.Port != "389" {
-		return getInvalidOptionError("port", "389")
+		problems.addInvalidOption("userBackend.port", "port", "389")
 	}
 
-	return nil
+	return problems.Err()
 }
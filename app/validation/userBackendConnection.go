@@ -0,0 +1,117 @@
+package validation
+
+import (
+	ctx "context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/cloudogu/k8s-ces-setup/v4/app/context"
+)
+
+const defaultUserBackendTimeout = 10 * time.Second
+
+type userBackendConnectionChecker struct {
+	timeout time.Duration
+	// rootCAs contains the certificate authorities which are trusted for the encryptions ssl and startTLS. The
+	// certificate authorities of the system are used if it is nil.
+	rootCAs *x509.CertPool
+}
+
+// NewUserBackendConnectionChecker creates a new checker which connects to the external user backend of the setup
+// configuration.
+func NewUserBackendConnectionChecker() *userBackendConnectionChecker {
+	return &userBackendConnectionChecker{timeout: defaultUserBackendTimeout}
+}
+
+// CheckUserBackendConnection connects to an external user backend with its encryption, binds as the connection DN and
+// searches the users and groups. Searches without results are reported as warnings. Embedded user backends are not
+// checked because they are installed by the setup.
+func (c *userBackendConnectionChecker) CheckUserBackendConnection(ctx ctx.Context, backend context.UserBackend) Problems {
+	var problems Problems
+	if backend.DsType != DsTypeExternal {
+		return problems
+	}
+
+	conn, err := c.connect(ctx, backend)
+	if err != nil {
+		problems.add("userBackend.host", CodeUnavailable, "failed to connect to user backend %s: %s", net.JoinHostPort(backend.Host, backend.Port), err.Error())
+		return problems
+	}
+	defer conn.Close()
+
+	if backend.Encryption == "startTLS" || backend.Encryption == "startTLSAny" {
+		err = conn.StartTLS(c.tlsConfig(backend))
+		if err != nil {
+			problems.add("userBackend.encryption", CodeUnavailable, "failed to start TLS with user backend: %s", err.Error())
+			return problems
+		}
+	}
+
+	err = conn.Bind(backend.ConnectionDN, backend.Password)
+	if err != nil {
+		problems.add("userBackend.connectionDN", CodeAuthenticationFailed, "failed to bind to user backend as %s: %s", backend.ConnectionDN, err.Error())
+		return problems
+	}
+
+	c.search(conn, backend.BaseDN, backend.SearchFilter, "users", "userBackend.baseDN", "userBackend.searchFilter", &problems)
+	c.search(conn, backend.GroupBaseDN, backend.GroupSearchFilter, "groups", "userBackend.groupBaseDN", "userBackend.groupSearchFilter", &problems)
+
+	return problems
+}
+
+func (c *userBackendConnectionChecker) connect(ctx ctx.Context, backend context.UserBackend) (*ldap.Conn, error) {
+	scheme := "ldap"
+	if backend.Encryption == "ssl" || backend.Encryption == "sslAny" {
+		scheme = "ldaps"
+	}
+
+	dialer := &net.Dialer{Timeout: c.timeout}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+
+	address := fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(backend.Host, backend.Port))
+	conn, err := ldap.DialURL(address, ldap.DialWithDialer(dialer), ldap.DialWithTLSConfig(c.tlsConfig(backend)))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(c.timeout)
+
+	return conn, nil
+}
+
+func (c *userBackendConnectionChecker) tlsConfig(backend context.UserBackend) *tls.Config {
+	return &tls.Config{
+		ServerName: backend.Host,
+		RootCAs:    c.rootCAs,
+		// the encryptions with the suffix "Any" accept every certificate of the user backend
+		InsecureSkipVerify: backend.Encryption == "sslAny" || backend.Encryption == "startTLSAny",
+	}
+}
+
+func (c *userBackendConnectionChecker) search(conn *ldap.Conn, baseDN string, filter string, kind string, baseDNPath string, filterPath string, problems *Problems) {
+	request := ldap.NewSearchRequest(baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 1, int(c.timeout.Seconds()), false, filter, []string{"dn"}, nil)
+	result, err := conn.Search(request)
+	// the size limit only restricts the search to a single entry
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		err = nil
+	}
+
+	var ldapErr *ldap.Error
+	switch {
+	case errors.As(err, &ldapErr) && ldapErr.ResultCode == ldap.LDAPResultNoSuchObject:
+		problems.add(baseDNPath, CodeUnknownReference, "%s cannot be found because %s does not exist", kind, baseDN)
+	case errors.As(err, &ldapErr) && (ldapErr.ResultCode == ldap.LDAPResultFilterError || ldapErr.ResultCode == ldap.ErrorFilterCompile):
+		problems.add(filterPath, CodeInvalidFormat, "failed to search %s with invalid filter %s: %s", kind, filter, err.Error())
+	case err != nil:
+		problems.add(baseDNPath, CodeUnavailable, "failed to search %s with filter %s under %s: %s", kind, filter, baseDN, err.Error())
+	case len(result.Entries) == 0:
+		problems.addWarning(filterPath, CodeNoResults, "no %s found with filter %s under %s", kind, filter, baseDN)
+	}
+}
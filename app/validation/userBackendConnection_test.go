@@ -0,0 +1,387 @@
+package validation
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appcontext "github.com/cloudogu/k8s-ces-setup/v4/app/context"
+)
+
+const (
+	testConnectionDN = "cn=setup,dc=cloudogu,dc=com"
+	testPassword     = "secret"
+	testUserBaseDN   = "ou=People,dc=cloudogu,dc=com"
+	testGroupBaseDN  = "ou=Groups,dc=cloudogu,dc=com"
+	testUserFilter   = "(objectClass=person)"
+	testGroupFilter  = "(objectClass=group)"
+	startTLSOID      = "1.3.6.1.4.1.1466.20037"
+)
+
+func TestUserBackendConnectionChecker_CheckUserBackendConnection(t *testing.T) {
+	serverTLS, rootCAs := createTestCertificate(t)
+
+	tests := []struct {
+		name       string
+		encryption string
+		tls        bool
+		rootCAs    *x509.CertPool
+	}{
+		{name: "without encryption", encryption: "none"},
+		{name: "with ssl", encryption: "ssl", tls: true, rootCAs: rootCAs},
+		{name: "with sslAny", encryption: "sslAny", tls: true},
+		{name: "with startTLS", encryption: "startTLS", rootCAs: rootCAs},
+		{name: "with startTLSAny", encryption: "startTLSAny"},
+	}
+	for _, tt := range tests {
+		t.Run("should connect successfully "+tt.name, func(t *testing.T) {
+			// given
+			server := newTestLdapServer(t, serverTLS, tt.tls)
+			sut := &userBackendConnectionChecker{timeout: 5 * time.Second, rootCAs: tt.rootCAs}
+			backend := server.userBackend(tt.encryption)
+
+			// when
+			problems := sut.CheckUserBackendConnection(context.TODO(), backend)
+
+			// then
+			assert.Empty(t, problems)
+		})
+	}
+
+	t.Run("should skip embedded user backend", func(t *testing.T) {
+		// given
+		sut := NewUserBackendConnectionChecker()
+
+		// when
+		problems := sut.CheckUserBackendConnection(context.TODO(), appcontext.UserBackend{DsType: DsTypeEmbedded})
+
+		// then
+		assert.Empty(t, problems)
+	})
+
+	t.Run("should fail to connect to unreachable host", func(t *testing.T) {
+		// given
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		host, port, err := net.SplitHostPort(listener.Addr().String())
+		require.NoError(t, err)
+		require.NoError(t, listener.Close())
+		sut := &userBackendConnectionChecker{timeout: 5 * time.Second}
+
+		// when
+		problems := sut.CheckUserBackendConnection(context.TODO(), appcontext.UserBackend{DsType: DsTypeExternal, Host: host, Port: port, Encryption: "none"})
+
+		// then
+		require.Len(t, problems, 1)
+		assert.Equal(t, "userBackend.host", problems[0].Path)
+		assert.Equal(t, CodeUnavailable, problems[0].Code)
+		assert.Equal(t, SeverityError, problems[0].Severity)
+		assert.Contains(t, problems[0].Message, "failed to connect to user backend "+net.JoinHostPort(host, port))
+	})
+
+	t.Run("should fail to connect with ssl to untrusted certificate", func(t *testing.T) {
+		// given
+		server := newTestLdapServer(t, serverTLS, true)
+		sut := &userBackendConnectionChecker{timeout: 5 * time.Second}
+
+		// when
+		problems := sut.CheckUserBackendConnection(context.TODO(), server.userBackend("ssl"))
+
+		// then
+		require.Len(t, problems, 1)
+		assert.Equal(t, "userBackend.host", problems[0].Path)
+		assert.Contains(t, problems[0].Message, "certificate")
+	})
+
+	t.Run("should fail to start TLS with untrusted certificate", func(t *testing.T) {
+		// given
+		server := newTestLdapServer(t, serverTLS, false)
+		sut := &userBackendConnectionChecker{timeout: 5 * time.Second}
+
+		// when
+		problems := sut.CheckUserBackendConnection(context.TODO(), server.userBackend("startTLS"))
+
+		// then
+		require.Len(t, problems, 1)
+		assert.Equal(t, "userBackend.encryption", problems[0].Path)
+		assert.Equal(t, CodeUnavailable, problems[0].Code)
+		assert.Contains(t, problems[0].Message, "failed to start TLS with user backend")
+	})
+
+	t.Run("should fail to start TLS if the server does not support it", func(t *testing.T) {
+		// given
+		server := newTestLdapServer(t, nil, false)
+		sut := &userBackendConnectionChecker{timeout: 5 * time.Second}
+
+		// when
+		problems := sut.CheckUserBackendConnection(context.TODO(), server.userBackend("startTLSAny"))
+
+		// then
+		require.Len(t, problems, 1)
+		assert.Equal(t, "userBackend.encryption", problems[0].Path)
+	})
+
+	t.Run("should fail to bind with invalid credentials", func(t *testing.T) {
+		// given
+		server := newTestLdapServer(t, nil, false)
+		sut := &userBackendConnectionChecker{timeout: 5 * time.Second}
+		backend := server.userBackend("none")
+		backend.Password = "wrong"
+
+		// when
+		problems := sut.CheckUserBackendConnection(context.TODO(), backend)
+
+		// then
+		require.Len(t, problems, 1)
+		assert.Equal(t, "userBackend.connectionDN", problems[0].Path)
+		assert.Equal(t, CodeAuthenticationFailed, problems[0].Code)
+		assert.Contains(t, problems[0].Message, "failed to bind to user backend as "+testConnectionDN)
+		assert.Contains(t, problems[0].Message, "Invalid Credentials")
+	})
+
+	t.Run("should report missing base DNs", func(t *testing.T) {
+		// given
+		server := newTestLdapServer(t, nil, false)
+		sut := &userBackendConnectionChecker{timeout: 5 * time.Second}
+		backend := server.userBackend("none")
+		backend.BaseDN = "ou=Users,dc=cloudogu,dc=com"
+		backend.GroupBaseDN = "ou=Roles,dc=cloudogu,dc=com"
+
+		// when
+		problems := sut.CheckUserBackendConnection(context.TODO(), backend)
+
+		// then
+		assert.Equal(t, Problems{
+			{Path: "userBackend.baseDN", Code: CodeUnknownReference, Message: "users cannot be found because ou=Users,dc=cloudogu,dc=com does not exist", Severity: SeverityError},
+			{Path: "userBackend.groupBaseDN", Code: CodeUnknownReference, Message: "groups cannot be found because ou=Roles,dc=cloudogu,dc=com does not exist", Severity: SeverityError},
+		}, problems)
+	})
+
+	t.Run("should warn about searches without results", func(t *testing.T) {
+		// given
+		server := newTestLdapServer(t, nil, false)
+		sut := &userBackendConnectionChecker{timeout: 5 * time.Second}
+		backend := server.userBackend("none")
+		backend.SearchFilter = "(objectClass=inetOrgPerson)"
+		backend.GroupSearchFilter = "(objectClass=groupOfNames)"
+
+		// when
+		problems := sut.CheckUserBackendConnection(context.TODO(), backend)
+
+		// then
+		assert.Equal(t, Problems{
+			{Path: "userBackend.searchFilter", Code: CodeNoResults, Message: "no users found with filter (objectClass=inetOrgPerson) under " + testUserBaseDN, Severity: SeverityWarning},
+			{Path: "userBackend.groupSearchFilter", Code: CodeNoResults, Message: "no groups found with filter (objectClass=groupOfNames) under " + testGroupBaseDN, Severity: SeverityWarning},
+		}, problems)
+		assert.Empty(t, problems.Errors())
+	})
+
+	t.Run("should report invalid search filter", func(t *testing.T) {
+		// given
+		server := newTestLdapServer(t, nil, false)
+		sut := &userBackendConnectionChecker{timeout: 5 * time.Second}
+		backend := server.userBackend("none")
+		backend.SearchFilter = "objectClass=person"
+
+		// when
+		problems := sut.CheckUserBackendConnection(context.TODO(), backend)
+
+		// then
+		require.Len(t, problems, 1)
+		assert.Equal(t, "userBackend.searchFilter", problems[0].Path)
+		assert.Equal(t, CodeInvalidFormat, problems[0].Code)
+	})
+}
+
+// testLdapServer is a minimal LDAP server which supports simple binds, searches and StartTLS.
+type testLdapServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	// entries contains the distinguished names of the entries per base DN and search filter
+	entries map[string]map[string][]string
+	wg      sync.WaitGroup
+}
+
+func newTestLdapServer(t *testing.T, tlsConfig *tls.Config, useTLS bool) *testLdapServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if useTLS {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	server := &testLdapServer{
+		listener:  listener,
+		tlsConfig: tlsConfig,
+		entries: map[string]map[string][]string{
+			testUserBaseDN:  {testUserFilter: {"uid=tricia,ou=People,dc=cloudogu,dc=com"}},
+			testGroupBaseDN: {testGroupFilter: {"cn=admins,ou=Groups,dc=cloudogu,dc=com"}},
+		},
+	}
+
+	server.wg.Add(1)
+	go server.serve()
+	t.Cleanup(func() {
+		_ = listener.Close()
+		server.wg.Wait()
+	})
+
+	return server
+}
+
+func (s *testLdapServer) userBackend(encryption string) appcontext.UserBackend {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return appcontext.UserBackend{
+		DsType:            DsTypeExternal,
+		Host:              host,
+		Port:              port,
+		Encryption:        encryption,
+		ConnectionDN:      testConnectionDN,
+		Password:          testPassword,
+		BaseDN:            testUserBaseDN,
+		SearchFilter:      testUserFilter,
+		GroupBaseDN:       testGroupBaseDN,
+		GroupSearchFilter: testGroupFilter,
+	}
+}
+
+func (s *testLdapServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *testLdapServer) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	for {
+		request, err := ber.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		if len(request.Children) < 2 {
+			return
+		}
+
+		messageID := request.Children[0].Value.(int64)
+		operation := request.Children[1]
+		switch operation.Tag {
+		case ldap.ApplicationBindRequest:
+			name := operation.Children[1].Value.(string)
+			password := operation.Children[2].Data.String()
+			resultCode := ldap.LDAPResultSuccess
+			if name != testConnectionDN || password != testPassword {
+				resultCode = ldap.LDAPResultInvalidCredentials
+			}
+			writeResponse(conn, messageID, ldap.ApplicationBindResponse, resultCode)
+		case ldap.ApplicationSearchRequest:
+			s.search(conn, messageID, operation)
+		case ldap.ApplicationExtendedRequest:
+			if s.tlsConfig == nil || operation.Children[0].Data.String() != startTLSOID {
+				writeResponse(conn, messageID, ldap.ApplicationExtendedResponse, ldap.LDAPResultProtocolError)
+				continue
+			}
+			writeResponse(conn, messageID, ldap.ApplicationExtendedResponse, ldap.LDAPResultSuccess)
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn = tlsConn
+		default:
+			return
+		}
+	}
+}
+
+func (s *testLdapServer) search(conn net.Conn, messageID int64, operation *ber.Packet) {
+	baseDN := operation.Children[0].Value.(string)
+	filter, err := ldap.DecompileFilter(operation.Children[6])
+	if err != nil {
+		writeResponse(conn, messageID, ldap.ApplicationSearchResultDone, ldap.LDAPResultFilterError)
+		return
+	}
+
+	filters, ok := s.entries[baseDN]
+	if !ok {
+		writeResponse(conn, messageID, ldap.ApplicationSearchResultDone, ldap.LDAPResultNoSuchObject)
+		return
+	}
+
+	for _, dn := range filters[filter] {
+		entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+		entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, "Object Name"))
+		entry.AppendChild(ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes"))
+		writePacket(conn, messageID, entry)
+	}
+	writeResponse(conn, messageID, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess)
+}
+
+func writeResponse(conn io.Writer, messageID int64, application ber.Tag, resultCode int) {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, application, nil, "Response")
+	response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(resultCode), "Result Code"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	writePacket(conn, messageID, response)
+}
+
+func writePacket(conn io.Writer, messageID int64, operation *ber.Packet) {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "Message ID"))
+	packet.AppendChild(operation)
+	_, _ = conn.Write(packet.Bytes())
+}
+
+func createTestCertificate(t *testing.T) (*tls.Config, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ldap.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(certificate)
+
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}, rootCAs
+}
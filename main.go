@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -72,12 +73,22 @@ func createSetupRouter(setupContextBuilder *appcontext.SetupContextBuilder) (*gi
 
 	logrus.Debugf("Current Version: [%+v]", setupContext.AppVersion)
 
-	if setupContext.SetupJsonConfiguration.IsCompleted() {
+	interrupted, err := setup.IsSetupInterrupted(ctx, clientSet, setupContext.AppConfig.TargetNamespace)
+	if err != nil {
+		logrus.Warningf("failed to check for an interrupted setup: %s", err.Error())
+	}
+
+	if setupContext.SetupJsonConfiguration.IsCompleted() || interrupted {
 		go func() {
-			logrus.Info("Setup configuration is completed. Start setup...")
+			logrus.Info("Setup configuration is completed or a previous setup was interrupted. Start setup...")
 			starter, err := setup.NewStarter(ctx, clusterConfig, clientSet, setupContextBuilder)
 			if err != nil {
 				logrus.Error(err.Error())
+				return
+			}
+			if setup.IsDryRunEnabled() {
+				logSetupPlan(ctx, starter)
+				return
 			}
 			err = starter.StartSetup(ctx)
 			if err != nil {
@@ -89,6 +100,22 @@ func createSetupRouter(setupContextBuilder *appcontext.SetupContextBuilder) (*gi
 	return createRouter(ctx, clusterConfig, clientSet, setupContextBuilder), nil
 }
 
+func logSetupPlan(ctx context.Context, starter *setup.Starter) {
+	setupPlan, err := starter.PlanSetup(ctx)
+	if err != nil {
+		logrus.Error(err.Error())
+		return
+	}
+
+	rawPlan, err := json.MarshalIndent(setupPlan, "", "  ")
+	if err != nil {
+		logrus.Errorf("failed to marshal setup plan: %s", err.Error())
+		return
+	}
+
+	logrus.Infof("Dry-run is enabled. The setup would perform the following steps:\n%s", rawPlan)
+}
+
 func createRouter(ctx context.Context, clusterConfig *rest.Config, k8sClient kubernetes.Interface, setupContextBuilder *appcontext.SetupContextBuilder) *gin.Engine {
 	router := gin.New()
 	router.Use(ginlogrus.Logger(logrus.StandardLogger(), doNotLogEndpoints...), gin.Recovery())